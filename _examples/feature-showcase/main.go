@@ -28,6 +28,10 @@ func (t *devNullTransport) SendEvent(event *sentry.Event) {
 	fmt.Println("Faked Transport")
 }
 
+func (t *devNullTransport) SendEnvelope(envelope *sentry.Envelope) {
+	fmt.Println("Faked Transport")
+}
+
 func (t *devNullTransport) Flush(timeout time.Duration) bool {
 	return true
 }
@@ -0,0 +1,25 @@
+module github.com/getsentry/sentry-go/asynq
+
+go 1.21
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+	github.com/hibiken/asynq v0.24.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/redis/go-redis/v9 v9.0.3 // indirect
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	github.com/spf13/cast v1.3.1 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+	golang.org/x/time v0.3.0 // indirect
+	google.golang.org/protobuf v1.29.1 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
@@ -0,0 +1,189 @@
+// Package sentryasynq provides Sentry integration for task queues built with
+// github.com/hibiken/asynq.
+//
+// Add the middleware to the ServeMux of the server to process each task in a
+// "queue.task.asynq" transaction, tagged with the type and queue of the task:
+//
+//	mux := asynq.NewServeMux()
+//	mux.Use(sentryasynq.NewMiddleware(sentryasynq.Options{}))
+//
+// Create tasks with NewTask to continue the trace of the enqueuing code in
+// the transactions of the tasks. As asynq tasks have no headers, the trace
+// is propagated in the "_sentry" field of JSON object payloads, which is
+// ignored by the decoding of payloads into structs.
+//
+// Errors returned by handlers are reported to Sentry: as handled exceptions
+// while the task will be retried, and as unhandled exceptions once it
+// failed for the last time.
+package sentryasynq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/hibiken/asynq"
+)
+
+// The identifier of the asynq SDK.
+const sdkIdentifier = "sentry.go.asynq"
+
+// spanOperation is the operation of the transactions of tasks.
+const spanOperation = "queue.task.asynq"
+
+// spanOrigin is the origin of the transactions of tasks.
+const spanOrigin = "auto.queue.asynq"
+
+// mechanismType is the type of the mechanism of reported errors.
+const mechanismType = "asynq"
+
+// payloadField is the field of JSON payloads propagating the trace.
+const payloadField = "_sentry"
+
+// Options configure the middleware.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// in a handler. Otherwise, the panic is returned as an error, which
+	// asynq handles as it handles panics.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+type handler struct {
+	handler         asynq.Handler
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// NewMiddleware returns an asynq middleware instrumenting the processing of
+// tasks.
+func NewMiddleware(options Options) asynq.MiddlewareFunc {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return func(next asynq.Handler) asynq.Handler {
+		return &handler{
+			handler:         next,
+			repanic:         options.Repanic,
+			waitForDelivery: options.WaitForDelivery,
+			timeout:         timeout,
+		}
+	}
+}
+
+// NewTask returns a new task like asynq.NewTask, whose payload propagates
+// the trace of the span in ctx if it is a JSON object.
+func NewTask(ctx context.Context, typename string, payload []byte, opts ...asynq.Option) *asynq.Task {
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &fields); err == nil && fields != nil {
+			trace := map[string]string{sentry.SentryTraceHeader: span.ToSentryTrace()}
+			if baggage := span.ToBaggage(); baggage != "" {
+				trace[sentry.SentryBaggageHeader] = baggage
+			}
+			if field, err := json.Marshal(trace); err == nil {
+				fields[payloadField] = field
+				if p, err := json.Marshal(fields); err == nil {
+					payload = p
+				}
+			}
+		}
+	}
+	return asynq.NewTask(typename, payload, opts...)
+}
+
+// ProcessTask implements asynq.Handler.
+func (h *handler) ProcessTask(ctx context.Context, task *asynq.Task) (err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	queue, _ := asynq.GetQueueName(ctx)
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	taskContext := sentry.Context{
+		"type":        task.Type(),
+		"queue":       queue,
+		"retry_count": retryCount,
+		"max_retry":   maxRetry,
+	}
+	if id, ok := asynq.GetTaskID(ctx); ok {
+		taskContext["id"] = id
+	}
+	scope := hub.Scope()
+	scope.SetContext("asynq", taskContext)
+	scope.SetTag("asynq.task_type", task.Type())
+	scope.SetTag("asynq.queue", queue)
+
+	// Ignore the payloads that aren't JSON objects.
+	var payload struct {
+		Trace map[string]string `json:"_sentry"`
+	}
+	_ = json.Unmarshal(task.Payload(), &payload)
+
+	transaction := sentry.StartTransaction(sentry.SetHubOnContext(ctx, hub), task.Type(),
+		sentry.WithOpName(spanOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(payload.Trace[sentry.SentryTraceHeader], payload.Trace[sentry.SentryBaggageHeader]),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.Data = map[string]interface{}{
+		"messaging.system":                  "asynq",
+		"messaging.destination.name":        queue,
+		"messaging.message.retry.count":     retryCount,
+		"messaging.asynq.message.max_retry": maxRetry,
+	}
+	defer transaction.Finish()
+
+	ctx = transaction.Context()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Status = sentry.SpanStatusInternalError
+			eventID := hub.RecoverWithContext(ctx, r)
+			if eventID != nil && h.waitForDelivery {
+				hub.Flush(h.timeout)
+			}
+			if h.repanic {
+				panic(r)
+			}
+			err = fmt.Errorf("sentryasynq: panic: %v", r)
+		}
+	}()
+
+	err = h.handler.ProcessTask(ctx, task)
+	if err != nil {
+		transaction.SetError(err)
+		// Like asynq, consider the task to have failed for the last time if it
+		// ran out of retries or asked not to be retried.
+		final := retryCount >= maxRetry || errors.Is(err, asynq.SkipRetry)
+		scope.SetTag("asynq.retry", fmt.Sprint(!final))
+		handled := !final
+		if final {
+			scope.SetLevel(sentry.LevelError)
+		} else {
+			scope.SetLevel(sentry.LevelWarning)
+		}
+		hub.CaptureExceptionWithHint(err, &sentry.EventHint{
+			Context:           ctx,
+			OriginalException: err,
+			Mechanism:         &sentry.Mechanism{Type: mechanismType, Handled: &handled},
+		})
+	} else {
+		transaction.Status = sentry.SpanStatusOK
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package sentryasynq_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryasynq "github.com/getsentry/sentry-go/asynq"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hibiken/asynq"
+)
+
+// record returns a BeforeSend callback adding the events to events and
+// dropping them.
+func record(events *[]*sentry.Event) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func TestNewTaskAndProcess(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	producer := sentry.StartTransaction(ctx, "producer")
+	task := sentryasynq.NewTask(producer.Context(), "email:send", []byte(`{"to":"user@example.com"}`))
+	producer.Finish()
+
+	var payload struct {
+		To string `json:"to"`
+	}
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("user@example.com", payload.To); diff != "" {
+		t.Errorf("payload mismatch (-want +got):\n%s", diff)
+	}
+
+	var processed bool
+	handler := sentryasynq.NewMiddleware(sentryasynq.Options{})(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		processed = sentry.SpanFromContext(ctx) != nil
+		return nil
+	}))
+	if err := handler.ProcessTask(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if !processed {
+		t.Error("handler called without a span")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	enqueue, process := events[0], events[1]
+	if diff := cmp.Diff(enqueue.Contexts["trace"]["span_id"], process.Contexts["trace"]["parent_span_id"]); diff != "" {
+		t.Errorf("task transaction doesn't continue the trace (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("email:send", process.Transaction); diff != "" {
+		t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("email:send", process.Tags["asynq.task_type"]); diff != "" {
+		t.Errorf("task type tag mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusOK, process.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewTaskNonJSONPayload(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	transaction := sentry.StartTransaction(ctx, "producer")
+	defer transaction.Finish()
+	for _, payload := range []string{"", "raw", `["a"]`, "null"} {
+		task := sentryasynq.NewTask(transaction.Context(), "email:send", []byte(payload))
+		if diff := cmp.Diff(payload, string(task.Payload())); diff != "" {
+			t.Errorf("payload %q modified (-want +got):\n%s", payload, diff)
+		}
+	}
+}
+
+func TestProcessFinalFailure(t *testing.T) {
+	for _, taskErr := range []error{
+		errors.New("smtp unavailable"),
+		fmt.Errorf("invalid address: %w", asynq.SkipRetry),
+	} {
+		t.Run(taskErr.Error(), func(t *testing.T) {
+			var events []*sentry.Event
+			client, err := sentry.NewClient(sentry.ClientOptions{
+				EnableTracing:         true,
+				TracesSampleRate:      1.0,
+				BeforeSend:            record(&events),
+				BeforeSendTransaction: record(&events),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			hub := sentry.NewHub(client, sentry.NewScope())
+			ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+			handler := sentryasynq.NewMiddleware(sentryasynq.Options{})(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+				return taskErr
+			}))
+			if got := handler.ProcessTask(ctx, asynq.NewTask("email:send", nil)); got != taskErr {
+				t.Fatalf("got error %v, want %v", got, taskErr)
+			}
+
+			if len(events) != 2 {
+				t.Fatalf("got %d events, want 2", len(events))
+			}
+			event, transaction := events[0], events[1]
+			mechanism := event.Exception[len(event.Exception)-1].Mechanism
+			if mechanism == nil || mechanism.Handled == nil || *mechanism.Handled {
+				t.Errorf("final failure reported as handled: %+v", mechanism)
+			}
+			if diff := cmp.Diff(sentry.LevelError, event.Level); diff != "" {
+				t.Errorf("level mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff("false", event.Tags["asynq.retry"]); diff != "" {
+				t.Errorf("retry tag mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(sentry.SpanStatusInternalError, transaction.Contexts["trace"]["status"]); diff != "" {
+				t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcessPanic(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	handler := sentryasynq.NewMiddleware(sentryasynq.Options{})(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		panic("boom")
+	}))
+	if err := handler.ProcessTask(ctx, asynq.NewTask("email:send", nil)); err == nil {
+		t.Fatal("got nil error")
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("boom", events[0].Message); diff != "" {
+		t.Errorf("panic message mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,69 @@
+package sentry
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// NewFileAttachment returns an attachment streaming the contents of the file
+// at path when it is sent, instead of holding them in memory. The file is read
+// anew for every event the attachment is sent with, up to the size it has
+// when it is opened.
+func NewFileAttachment(path, contentType string) *Attachment {
+	return &Attachment{
+		Filename:    filepath.Base(path),
+		ContentType: contentType,
+		Open: func() (io.ReadCloser, int64, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, 0, err
+			}
+			info, err := f.Stat()
+			if err != nil {
+				f.Close()
+				return nil, 0, err
+			}
+			return f, info.Size(), nil
+		},
+	}
+}
+
+// limitAttachments drops attachments larger than maxSize bytes. The size of
+// streamed attachments is only known when they are opened, so their Open
+// function is wrapped to fail instead. A negative maxSize disables the limit.
+func limitAttachments(attachments []*Attachment, maxSize int64) []*Attachment {
+	if maxSize < 0 {
+		return attachments
+	}
+
+	limited := make([]*Attachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.Open == nil {
+			if int64(len(attachment.Payload)) > maxSize {
+				debugf(LevelWarning, "Dropping attachment %q: %d bytes exceed the maximum size of %d bytes.",
+					attachment.Filename, len(attachment.Payload), maxSize)
+				continue
+			}
+			limited = append(limited, attachment)
+			continue
+		}
+
+		open := attachment.Open
+		a := *attachment
+		a.Open = func() (io.ReadCloser, int64, error) {
+			r, size, err := open()
+			if err != nil {
+				return nil, 0, err
+			}
+			if size > maxSize {
+				r.Close()
+				return nil, 0, fmt.Errorf("%d bytes exceed the maximum size of %d bytes", size, maxSize)
+			}
+			return r, size, nil
+		}
+		limited = append(limited, &a)
+	}
+	return limited
+}
@@ -0,0 +1,128 @@
+package sentry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFileAttachment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heap.pprof")
+	if err := os.WriteFile(path, []byte("profile data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	event := NewEvent()
+	event.EventID = "b81c5be4d31e48959103a1f878a1efcb"
+	event.attachments = []*Attachment{NewFileAttachment(path, "application/octet-stream")}
+
+	dsn, _ := NewDsn("http://public@example.com/sentry/1")
+	request, err := getRequestFromEvent(event, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Body.Close()
+
+	assertEqual(t, request.ContentLength, int64(len(body)))
+	want := `{"type":"attachment","length":12,"filename":"heap.pprof","content_type":"application/octet-stream"}
+profile data
+`
+	if !strings.HasSuffix(string(body), want) {
+		t.Errorf("request body does not end with the attachment:\n%s", body)
+	}
+}
+
+func TestEnvelopeSkipsAttachmentsFailingToOpen(t *testing.T) {
+	event := NewEvent()
+	event.attachments = []*Attachment{
+		NewFileAttachment(filepath.Join(t.TempDir(), "missing.log"), "text/plain"),
+		{Filename: "inline.txt", Payload: []byte("inline")},
+	}
+
+	dsn, _ := NewDsn("http://public@example.com/sentry/1")
+	request, err := getRequestFromEvent(event, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(request.Body)
+	request.Body.Close()
+
+	if bytes.Contains(body, []byte("missing.log")) {
+		t.Errorf("request body contains the missing attachment:\n%s", body)
+	}
+	if !bytes.Contains(body, []byte(`"filename":"inline.txt"`)) {
+		t.Errorf("request body does not contain the inline attachment:\n%s", body)
+	}
+}
+
+func TestExactReader(t *testing.T) {
+	r := &exactReader{r: strings.NewReader("abcdef"), n: 3}
+	b, err := io.ReadAll(r)
+	assertEqual(t, string(b), "abc")
+	assertEqual(t, err, nil)
+
+	r = &exactReader{r: strings.NewReader("ab"), n: 3}
+	_, err = io.ReadAll(r)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestMaxAttachmentSize(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.options.MaxAttachmentSize = 4
+
+	path := filepath.Join(t.TempDir(), "large.log")
+	if err := os.WriteFile(path, []byte("too large"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	event := NewEvent()
+	event.attachments = []*Attachment{
+		{Filename: "small.txt", Payload: []byte("ok")},
+		{Filename: "large.txt", Payload: []byte("too large")},
+		NewFileAttachment(path, "text/plain"),
+	}
+	client.CaptureEvent(event, nil, scope)
+
+	attachments := transport.lastEvent.attachments
+	if len(attachments) != 2 {
+		t.Fatalf("len(attachments) = %d, want 2", len(attachments))
+	}
+	assertEqual(t, attachments[0].Filename, "small.txt")
+	if _, _, err := attachments[1].Open(); err == nil {
+		t.Error("opening an attachment exceeding the maximum size succeeded")
+	}
+}
+
+func TestEventAttachments(t *testing.T) {
+	client, _, transport := setupClientTest()
+	scope := NewScope()
+	scope.AddAttachment(&Attachment{Filename: "config.json", Payload: []byte("{}")})
+
+	event := client.EventFromMessage("message", LevelError)
+	event.AddAttachment(&Attachment{Filename: "goroutines.txt", Payload: []byte("goroutine 1 [running]:")})
+	client.CaptureEvent(event, &EventHint{
+		Attachments: []*Attachment{
+			{Filename: "request.json", ContentType: "application/json", Payload: []byte(`{"id":1}`)},
+		},
+	}, scope)
+
+	var filenames []string
+	for _, attachment := range transport.lastEvent.attachments {
+		filenames = append(filenames, attachment.Filename)
+	}
+	assertEqual(t, filenames, []string{"goroutines.txt", "config.json", "request.json"})
+
+	// Attachments of an event are not added to the scope.
+	client.CaptureMessage("another message", nil, scope)
+	assertEqual(t, len(transport.lastEvent.attachments), 1)
+}
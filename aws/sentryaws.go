@@ -0,0 +1,134 @@
+// Package sentryaws provides Sentry instrumentation for the AWS SDK for Go v2.
+//
+// Add the middleware to the configuration of the SDK to record a span for
+// each AWS API call made with a context containing a span, and a breadcrumb
+// for each failed call:
+//
+//	cfg, err := config.LoadDefaultConfig(ctx, config.WithAPIOptions(sentryaws.APIOptions()))
+//
+// SQSMessageAttributes and SNSMessageAttributes propagate the trace to the
+// consumers of SQS and SNS messages, which continue it with
+// StartSQSTransaction and StartSNSTransaction.
+package sentryaws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/getsentry/sentry-go"
+)
+
+// spanOperation is the operation of the spans recorded for API calls.
+const spanOperation = "aws.request"
+
+// spanOrigin is the origin of the spans recorded for API calls.
+const spanOrigin = "auto.aws"
+
+// middlewareID identifies the middleware in the stack of the SDK.
+const middlewareID = "SentryMiddleware"
+
+// APIOptions returns the API options adding the middleware to the stack of
+// the SDK. Use it with config.WithAPIOptions, or append it to the APIOptions
+// of the options of a service client.
+func APIOptions() []func(*middleware.Stack) error {
+	return []func(*middleware.Stack) error{AddMiddleware}
+}
+
+// AddMiddleware adds the middleware to stack. The middleware runs once per
+// API call, around all the attempts of the call.
+func AddMiddleware(stack *middleware.Stack) error {
+	// The service metadata is added to the context at the beginning of the
+	// initialize step.
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(middlewareID, handleInitialize), middleware.After)
+}
+
+func handleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (out middleware.InitializeOutput, metadata middleware.Metadata, err error) {
+	service := awsmiddleware.GetServiceID(ctx)
+	operation := awsmiddleware.GetOperationName(ctx)
+	region := awsmiddleware.GetRegion(ctx)
+
+	var span *sentry.Span
+	if parent := sentry.SpanFromContext(ctx); parent != nil {
+		span = parent.StartChild(spanOperation, sentry.WithSpanOrigin(spanOrigin))
+		span.Description = service + "." + operation
+		span.SetData("rpc.system", "aws-api")
+		span.SetData("rpc.service", service)
+		span.SetData("rpc.method", operation)
+		span.SetData("cloud.region", region)
+		ctx = span.Context()
+	}
+
+	out, metadata, err = next.HandleInitialize(ctx, in)
+
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(metadata)
+	statusCode := responseStatusCode(metadata, err)
+	var attempts int
+	if results, ok := retry.GetAttemptResults(metadata); ok {
+		attempts = len(results.Results)
+	}
+
+	if span != nil {
+		if requestID != "" {
+			span.SetData("aws.request_id", requestID)
+		}
+		if attempts > 1 {
+			span.Data["aws.retries"] = attempts - 1
+		}
+		if statusCode != 0 {
+			span.SetHTTPStatus(statusCode)
+		}
+		span.SetError(err)
+		if err == nil && statusCode == 0 {
+			span.Status = sentry.SpanStatusOK
+		}
+		span.Finish()
+	}
+
+	if err != nil {
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+		data := map[string]interface{}{
+			"service":   service,
+			"operation": operation,
+			"region":    region,
+		}
+		if requestID != "" {
+			data["request_id"] = requestID
+		}
+		if statusCode != 0 {
+			data["status_code"] = statusCode
+		}
+		if attempts > 1 {
+			data["retries"] = attempts - 1
+		}
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Type:     "error",
+			Category: "aws",
+			Message:  fmt.Sprintf("%s.%s failed: %v", service, operation, err),
+			Data:     data,
+			Level:    sentry.LevelError,
+		}, nil)
+	}
+
+	return out, metadata, err
+}
+
+// responseStatusCode returns the HTTP status code of the last response to an
+// API call, or 0 if there was none.
+func responseStatusCode(metadata middleware.Metadata, err error) int {
+	var responseError interface{ HTTPStatusCode() int }
+	if errors.As(err, &responseError) {
+		return responseError.HTTPStatusCode()
+	}
+	if response, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok && response != nil {
+		return response.StatusCode
+	}
+	return 0
+}
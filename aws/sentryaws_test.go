@@ -0,0 +1,116 @@
+package sentryaws_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/getsentry/sentry-go"
+	sentryaws "github.com/getsentry/sentry-go/aws"
+	"github.com/google/go-cmp/cmp"
+)
+
+// httpClient responds to all requests with the same response.
+type httpClient struct {
+	status int
+	body   string
+}
+
+func (c httpClient) Do(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: c.status,
+		Header: http.Header{
+			"Content-Type":     {"application/x-amz-json-1.0"},
+			"X-Amzn-Requestid": {"request-id"},
+		},
+		Body:    io.NopCloser(strings.NewReader(c.body)),
+		Request: r,
+	}, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	var events []*sentry.Event
+	record := func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		events = append(events, event)
+		return nil
+	}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record,
+		BeforeSendTransaction: record,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	newClient := func(httpClient httpClient) *sqs.Client {
+		return sqs.New(sqs.Options{
+			Region:           "eu-west-1",
+			Credentials:      aws.AnonymousCredentials{},
+			HTTPClient:       httpClient,
+			RetryMaxAttempts: 2,
+			APIOptions:       sentryaws.APIOptions(),
+		})
+	}
+
+	transaction := sentry.StartTransaction(ctx, "test")
+	_, err = newClient(httpClient{status: http.StatusOK, body: `{"QueueUrl":"https://sqs/queue"}`}).
+		GetQueueUrl(transaction.Context(), &sqs.GetQueueUrlInput{QueueName: aws.String("queue")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newClient(httpClient{status: http.StatusServiceUnavailable, body: `{"__type":"ServiceUnavailable","message":"try again"}`}).
+		GetQueueUrl(transaction.Context(), &sqs.GetQueueUrlInput{QueueName: aws.String("queue")})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	transaction.Finish()
+	hub.CaptureMessage("breadcrumbs")
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	spans := events[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for _, span := range spans {
+		if diff := cmp.Diff("SQS.GetQueueUrl", span.Description); diff != "" {
+			t.Errorf("span description mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff("eu-west-1", span.Data["cloud.region"]); diff != "" {
+			t.Errorf("span region mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if diff := cmp.Diff(sentry.SpanStatusOK, spans[0].Status); diff != "" {
+		t.Errorf("span status mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusUnavailable, spans[1].Status); diff != "" {
+		t.Errorf("span status mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(1, spans[1].Data["aws.retries"]); diff != "" {
+		t.Errorf("span retries mismatch (-want +got):\n%s", diff)
+	}
+
+	breadcrumbs := events[1].Breadcrumbs
+	if len(breadcrumbs) != 1 {
+		t.Fatalf("got %d breadcrumbs, want 1", len(breadcrumbs))
+	}
+	if diff := cmp.Diff(map[string]interface{}{
+		"service":     "SQS",
+		"operation":   "GetQueueUrl",
+		"region":      "eu-west-1",
+		"request_id":  "request-id",
+		"status_code": http.StatusServiceUnavailable,
+		"retries":     1,
+	}, breadcrumbs[0].Data); diff != "" {
+		t.Errorf("breadcrumb data mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,165 @@
+package sentryaws
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/getsentry/sentry-go"
+)
+
+// processOperation is the operation of the transactions processing messages.
+const processOperation = "queue.process"
+
+// queueOrigin is the origin of the transactions processing messages.
+const queueOrigin = "auto.queue.aws"
+
+// stringDataType is the data type of string message attributes.
+const stringDataType = "String"
+
+// SQSMessageAttributes adds the message attributes propagating the trace of
+// the span in ctx to attributes, which may be nil, and returns them. Use it
+// when sending SQS messages, keeping in mind that a message can have at most
+// 10 attributes.
+func SQSMessageAttributes(ctx context.Context, attributes map[string]sqstypes.MessageAttributeValue) map[string]sqstypes.MessageAttributeValue {
+	traceHeaders(ctx, func(key, value string) {
+		if attributes == nil {
+			attributes = make(map[string]sqstypes.MessageAttributeValue)
+		}
+		attributes[key] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String(stringDataType),
+			StringValue: aws.String(value),
+		}
+	})
+	return attributes
+}
+
+// SNSMessageAttributes is like SQSMessageAttributes, for SNS messages. The
+// attributes are delivered to SQS subscriptions along with the message, or in
+// the notification if raw message delivery is disabled.
+func SNSMessageAttributes(ctx context.Context, attributes map[string]snstypes.MessageAttributeValue) map[string]snstypes.MessageAttributeValue {
+	traceHeaders(ctx, func(key, value string) {
+		if attributes == nil {
+			attributes = make(map[string]snstypes.MessageAttributeValue)
+		}
+		attributes[key] = snstypes.MessageAttributeValue{
+			DataType:    aws.String(stringDataType),
+			StringValue: aws.String(value),
+		}
+	})
+	return attributes
+}
+
+// traceHeaders calls set with the headers propagating the trace of the span in
+// ctx, if any.
+func traceHeaders(ctx context.Context, set func(key, value string)) {
+	span := sentry.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	set(sentry.SentryTraceHeader, span.ToSentryTrace())
+	if baggage := span.ToBaggage(); baggage != "" {
+		set(sentry.SentryBaggageHeader, baggage)
+	}
+}
+
+// StartSQSTransaction starts a "queue.process" transaction named name, for
+// processing message, which continues the trace propagated in the message
+// attributes. When the message is an SNS notification delivered without raw
+// message delivery, the trace is read from the attributes of the
+// notification.
+//
+// Request the attributes when receiving messages:
+//
+//	output, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+//		QueueUrl:              queueURL,
+//		MessageAttributeNames: []string{"All"},
+//	})
+//	for _, message := range output.Messages {
+//		transaction := sentryaws.StartSQSTransaction(ctx, "orders", message)
+//		err := process(transaction.Context(), message)
+//		transaction.SetError(err)
+//		transaction.Finish()
+//	}
+func StartSQSTransaction(ctx context.Context, name string, message sqstypes.Message, options ...sentry.SpanOption) *sentry.Span {
+	trace := sqsAttribute(message.MessageAttributes, sentry.SentryTraceHeader)
+	baggage := sqsAttribute(message.MessageAttributes, sentry.SentryBaggageHeader)
+	if trace == "" && message.Body != nil {
+		if notification, ok := parseSNSNotification([]byte(*message.Body)); ok {
+			trace = notification.attribute(sentry.SentryTraceHeader)
+			baggage = notification.attribute(sentry.SentryBaggageHeader)
+		}
+	}
+
+	transaction := startProcessTransaction(ctx, name, "aws_sqs", trace, baggage, options)
+	if message.MessageId != nil {
+		transaction.SetData("messaging.message.id", *message.MessageId)
+	}
+	return transaction
+}
+
+// StartSNSTransaction starts a "queue.process" transaction named name, for
+// processing an SNS notification delivered to an HTTP endpoint or a Lambda
+// function, which continues the trace propagated in the attributes of the
+// notification. payload is the JSON representation of the notification.
+func StartSNSTransaction(ctx context.Context, name string, payload []byte, options ...sentry.SpanOption) *sentry.Span {
+	notification, _ := parseSNSNotification(payload)
+	transaction := startProcessTransaction(ctx, name, "aws_sns",
+		notification.attribute(sentry.SentryTraceHeader),
+		notification.attribute(sentry.SentryBaggageHeader),
+		options,
+	)
+	if notification.MessageID != "" {
+		transaction.SetData("messaging.message.id", notification.MessageID)
+	}
+	return transaction
+}
+
+func startProcessTransaction(ctx context.Context, name, system, trace, baggage string, options []sentry.SpanOption) *sentry.Span {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	ctx = sentry.SetHubOnContext(ctx, hub.Clone())
+
+	options = append([]sentry.SpanOption{
+		sentry.WithOpName(processOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(trace, baggage),
+		sentry.WithSpanOrigin(queueOrigin),
+	}, options...)
+	transaction := sentry.StartTransaction(ctx, name, options...)
+	transaction.SetData("messaging.system", system)
+	return transaction
+}
+
+func sqsAttribute(attributes map[string]sqstypes.MessageAttributeValue, key string) string {
+	if attribute, ok := attributes[key]; ok && attribute.StringValue != nil {
+		return *attribute.StringValue
+	}
+	return ""
+}
+
+// snsNotification is the JSON representation of an SNS notification.
+type snsNotification struct {
+	Type              string `json:"Type"`
+	MessageID         string `json:"MessageId"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+func parseSNSNotification(payload []byte) (snsNotification, bool) {
+	var notification snsNotification
+	if err := json.Unmarshal(payload, &notification); err != nil || notification.Type != "Notification" {
+		return snsNotification{}, false
+	}
+	return notification, true
+}
+
+func (n snsNotification) attribute(key string) string {
+	return n.MessageAttributes[key].Value
+}
@@ -0,0 +1,130 @@
+package sentryaws_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/getsentry/sentry-go"
+	sentryaws "github.com/getsentry/sentry-go/aws"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTraceContinuation(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+
+	if attributes := sentryaws.SQSMessageAttributes(ctx, nil); attributes != nil {
+		t.Errorf("got attributes %v without a span", attributes)
+	}
+
+	producer := sentry.StartTransaction(ctx, "producer")
+	sqsAttributes := sentryaws.SQSMessageAttributes(producer.Context(), map[string]sqstypes.MessageAttributeValue{
+		"key": {DataType: aws.String("String"), StringValue: aws.String("value")},
+	})
+	if len(sqsAttributes) != 3 {
+		t.Errorf("got %d SQS attributes, want 3", len(sqsAttributes))
+	}
+	snsAttributes := sentryaws.SNSMessageAttributes(producer.Context(), nil)
+	if diff := cmp.Diff(producer.ToSentryTrace(), *snsAttributes[sentry.SentryTraceHeader].StringValue); diff != "" {
+		t.Errorf("SNS sentry-trace attribute mismatch (-want +got):\n%s", diff)
+	}
+
+	notificationAttributes := make(map[string]interface{})
+	for key, attribute := range snsAttributes {
+		notificationAttributes[key] = map[string]string{"Type": *attribute.DataType, "Value": *attribute.StringValue}
+	}
+	notification, err := json.Marshal(map[string]interface{}{
+		"Type":              "Notification",
+		"MessageId":         "notification-id",
+		"Message":           "hello",
+		"MessageAttributes": notificationAttributes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		start  func() *sentry.Span
+		system string
+		id     string
+	}{
+		{
+			name: "SQS",
+			start: func() *sentry.Span {
+				return sentryaws.StartSQSTransaction(ctx, "SQS", sqstypes.Message{
+					MessageId:         aws.String("message-id"),
+					MessageAttributes: sqsAttributes,
+				})
+			},
+			system: "aws_sqs",
+			id:     "message-id",
+		},
+		{
+			name: "SNS to SQS",
+			start: func() *sentry.Span {
+				return sentryaws.StartSQSTransaction(ctx, "SNS to SQS", sqstypes.Message{
+					MessageId: aws.String("message-id"),
+					Body:      aws.String(string(notification)),
+				})
+			},
+			system: "aws_sqs",
+			id:     "message-id",
+		},
+		{
+			name: "SNS",
+			start: func() *sentry.Span {
+				return sentryaws.StartSNSTransaction(ctx, "SNS", notification)
+			},
+			system: "aws_sns",
+			id:     "notification-id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := tt.start()
+			transaction.Finish()
+
+			if diff := cmp.Diff(producer.TraceID, transaction.TraceID); diff != "" {
+				t.Errorf("trace ID mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(producer.SpanID, transaction.ParentSpanID); diff != "" {
+				t.Errorf("parent span ID mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff("queue.process", transaction.Op); diff != "" {
+				t.Errorf("operation mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(map[string]interface{}{
+				"messaging.system":     tt.system,
+				"messaging.message.id": tt.id,
+			}, transaction.Data); diff != "" {
+				t.Errorf("data mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	transaction := sentryaws.StartSQSTransaction(ctx, "new trace", sqstypes.Message{Body: aws.String("hello")})
+	if transaction.TraceID == producer.TraceID {
+		t.Error("got the trace of the producer for a message without attributes")
+	}
+	transaction.Finish()
+	producer.Finish()
+
+	if len(events) != 5 {
+		t.Errorf("got %d transactions, want 5", len(events))
+	}
+}
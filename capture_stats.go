@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/getsentry/sentry-go/internal/ratelimit"
+)
+
+// CaptureStats is a snapshot of the counters of the events captured by a
+// client.
+//
+// Stats are useful to monitor the SDK, for example on a dashboard, and to
+// assert on the events reported by a program in integration tests. See also
+// TransportStats, which describes the delivery of events to Sentry.
+type CaptureStats struct {
+	// Captured is the number of events handed over to the transport.
+	Captured uint64 `json:"captured"`
+	// Dropped counts the events discarded before reaching the transport, or
+	// refused by the transport, by reason.
+	Dropped map[DropReason]uint64 `json:"dropped"`
+	// LastEventID is the ID of the last event handed over to the transport.
+	LastEventID EventID `json:"last_event_id,omitempty"`
+}
+
+// captureStats accumulates the stats of a client. It is safe for concurrent
+// use.
+type captureStats struct {
+	mu          sync.Mutex
+	captured    uint64
+	dropped     map[DropReason]uint64
+	lastEventID EventID
+}
+
+func (s *captureStats) capture(eventID EventID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captured++
+	s.lastEventID = eventID
+}
+
+func (s *captureStats) drop(reason DropReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dropped == nil {
+		s.dropped = make(map[DropReason]uint64)
+	}
+	s.dropped[reason]++
+}
+
+func (s *captureStats) snapshot() CaptureStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dropped := make(map[DropReason]uint64, len(s.dropped))
+	for reason, n := range s.dropped {
+		dropped[reason] = n
+	}
+	return CaptureStats{
+		Captured:    s.captured,
+		Dropped:     dropped,
+		LastEventID: s.lastEventID,
+	}
+}
+
+// CaptureStats returns the counters of the events captured by the client.
+func (client *Client) CaptureStats() CaptureStats {
+	return client.stats.snapshot()
+}
+
+// discard counts an event of the given category discarded by the client for
+// reason, in the capture stats and in client reports.
+func (client *Client) discard(reason DropReason, category ratelimit.Category) {
+	client.stats.drop(reason)
+	client.reports.record(reason, category)
+}
+
+// recordSendResult counts an event handed over to the transport, which may
+// have refused it with err.
+func (client *Client) recordSendResult(event *Event, err error) {
+	switch {
+	case err == nil:
+		client.stats.capture(event.EventID)
+	case errors.Is(err, ErrQueueFull):
+		client.stats.drop(DropReasonQueueOverflow)
+	case errors.Is(err, ErrRateLimited):
+		client.stats.drop(DropReasonRateLimit)
+	case errors.Is(err, ErrSDKDisabled):
+		// Events are not counted when the SDK is disabled.
+	default:
+		// The event was accepted, but its delivery failed.
+		client.stats.capture(event.EventID)
+	}
+}
+
+// CaptureStats returns the counters of the events captured by the client bound
+// to the hub, which are shared by all hubs using the client. LastEventID is
+// the ID of the last event captured through this hub, as returned by
+// LastEventID.
+func (hub *Hub) CaptureStats() CaptureStats {
+	stats := CaptureStats{Dropped: map[DropReason]uint64{}}
+	if client := hub.Client(); client != nil {
+		stats = client.CaptureStats()
+	}
+	stats.LastEventID = hub.LastEventID()
+	return stats
+}
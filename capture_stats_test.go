@@ -0,0 +1,75 @@
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptureStats(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	client.options.BeforeSend = func(event *Event, hint *EventHint) *Event {
+		if event.Message == "drop" {
+			return nil
+		}
+		return event
+	}
+	client.options.ErrorRateLimitPerFingerprint = RateLimit{Rate: 0.001}
+
+	hub.CaptureMessage("drop")
+	hub.CaptureException(errors.New("boom"))
+	hub.CaptureException(errors.New("boom"))
+	eventID := hub.CaptureMessage("hello")
+	client.options.SampleRate = 0.000000000000001
+	hub.CaptureMessage("sampled")
+
+	stats := hub.CaptureStats()
+	assertEqual(t, stats.Captured, uint64(2))
+	assertEqual(t, stats.Dropped, map[DropReason]uint64{
+		DropReasonBeforeSend: 1,
+		DropReasonRateLimit:  1,
+		DropReasonSampleRate: 1,
+	})
+	assertEqual(t, stats.LastEventID, *eventID)
+	assertEqual(t, client.CaptureStats().LastEventID, *eventID)
+}
+
+func TestCaptureStatsQueueOverflow(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	transport := NewHTTPTransport()
+	transport.BufferSize = 1
+	client, err := NewClient(ClientOptions{
+		Dsn:       fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent uint64
+	for i := 0; i < 10; i++ {
+		if _, err = client.TryCaptureMessage("message", nil, NewScope()); err != nil {
+			break
+		}
+		sent++
+	}
+	assertEqual(t, err, ErrQueueFull)
+	stats := client.CaptureStats()
+	assertEqual(t, stats.Captured, sent)
+	assertEqual(t, stats.Dropped[DropReasonQueueOverflow], uint64(1))
+}
+
+func TestCaptureStatsWithoutClient(t *testing.T) {
+	hub := NewHub(nil, NewScope())
+	stats := hub.CaptureStats()
+	assertEqual(t, stats.Captured, uint64(0))
+	assertEqual(t, len(stats.Dropped), 0)
+}
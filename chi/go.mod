@@ -0,0 +1,16 @@
+module github.com/getsentry/sentry-go/chi
+
+go 1.23
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/go-chi/chi/v5 v5.3.2
+	github.com/google/go-cmp v0.6.0
+)
+
+require (
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
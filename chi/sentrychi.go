@@ -0,0 +1,132 @@
+// Package sentrychi provides Sentry integration for servers based on the chi
+// router.
+//
+// The middleware starts a transaction for each request, named after the route
+// pattern matched by chi, and reports panics:
+//
+//	r := chi.NewRouter()
+//	r.Use(sentrychi.New(sentrychi.Options{}))
+//	r.Get("/users/{id}", getUser)
+//
+// The hub of the request is available with sentry.GetHubFromContext.
+package sentrychi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// The identifier of the chi SDK.
+const sdkIdentifier = "sentry.go.chi"
+
+// spanOrigin is the origin of the transactions of requests.
+const spanOrigin = "auto.http.chi"
+
+type handler struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// Options configure the middleware.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a
+	// panic. Use it with chi's Recoverer middleware, registered before this
+	// one, to respond to the request.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// New returns a chi middleware providing integration with Sentry.
+func New(options Options) func(http.Handler) http.Handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	h := &handler{
+		repanic:         options.Repanic,
+		timeout:         timeout,
+		waitForDelivery: options.WaitForDelivery,
+	}
+	return h.handle
+}
+
+func (h *handler) handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+		transaction := sentry.StartTransaction(ctx,
+			r.Method+" "+r.URL.Path,
+			sentry.WithOpName("http.server"),
+			sentry.ContinueFromRequest(r),
+			sentry.WithTransactionSource(sentry.SourceURL),
+			sentry.WithSpanOrigin(spanOrigin),
+		)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer transaction.Finish()
+
+		r = r.WithContext(transaction.Context())
+		// chi routes requests once they went through the middlewares of the
+		// router, so the route pattern is only known once the handler
+		// returns.
+		defer func() {
+			if pattern := routePattern(r.Context()); pattern != "" {
+				transaction.Name = r.Method + " " + pattern
+				transaction.Source = sentry.SourceRoute
+				transaction.SetTag("http.route", pattern)
+			}
+		}()
+		hub.Scope().SetRequest(r)
+		defer h.recoverWithSentry(hub, r, transaction)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		transaction.Status = sentry.HTTPtoSpanStatus(status)
+	})
+}
+
+// routePattern returns the pattern of the route matched by chi, if any.
+func routePattern(ctx context.Context) string {
+	rctx := chi.RouteContext(ctx)
+	if rctx == nil {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
+
+func (h *handler) recoverWithSentry(hub *sentry.Hub, r *http.Request, transaction *sentry.Span) {
+	if err := recover(); err != nil {
+		transaction.Status = sentry.SpanStatusInternalError
+		eventID := hub.RecoverWithContext(
+			context.WithValue(r.Context(), sentry.RequestContextKey, r),
+			err,
+		)
+		if eventID != nil && h.waitForDelivery {
+			hub.Flush(h.timeout)
+		}
+		if h.repanic {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package sentrychi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrychi "github.com/getsentry/sentry-go/chi"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIntegration(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(sentrychi.New(sentrychi.Options{}))
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			sentry.GetHubFromContext(r.Context()).CaptureMessage("user " + chi.URLParam(r, "id"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+	r.Post("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("test")
+	})
+
+	tests := []struct {
+		method    string
+		path      string
+		wantName  string
+		wantRoute string
+		source    sentry.TransactionSource
+		status    sentry.SpanStatus
+	}{
+		{http.MethodGet, "/users/42", "GET /users/{id}", "/users/{id}", sentry.SourceRoute, sentry.SpanStatusOK},
+		{http.MethodPost, "/panic", "POST /panic", "/panic", sentry.SourceRoute, sentry.SpanStatusInternalError},
+		{http.MethodGet, "/missing/1", "GET /missing/1", "", sentry.SourceURL, sentry.SpanStatusNotFound},
+	}
+	for _, tt := range tests {
+		transactions = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+		if len(transactions) != 1 {
+			t.Fatalf("%s %s: got %d transactions, want 1", tt.method, tt.path, len(transactions))
+		}
+		transaction := transactions[0]
+		if diff := cmp.Diff(tt.wantName, transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.source, transaction.TransactionInfo.Source); diff != "" {
+			t.Errorf("transaction source mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantRoute, transaction.Tags["http.route"]); diff != "" {
+			t.Errorf("route tag mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.status, transaction.Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("user 42", events[0].Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, events[1].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.HasSuffix(events[1].Request.URL, "/panic") {
+		t.Errorf("panic request URL = %q, want /panic", events[1].Request.URL)
+	}
+}
@@ -3,18 +3,22 @@ package sentry
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/getsentry/sentry-go/internal/debug"
+	"github.com/getsentry/sentry-go/internal/ratelimit"
 )
 
 // The identifier of the SDK.
@@ -34,6 +38,29 @@ const maxErrorDepth = 10
 // would be rejected by Sentry.
 const defaultMaxSpans = 1000
 
+// defaultMaxSpanDescriptionLength is the default maximum length in bytes of
+// the description of spans.
+const defaultMaxSpanDescriptionLength = 2048
+
+// defaultMaxSpanDataSize is the default maximum size in bytes of the data of
+// spans, once serialized.
+const defaultMaxSpanDataSize = 16 * 1024
+
+// defaultEnvTagsPrefix is the default prefix of the environment variables set
+// as tags by Init.
+const defaultEnvTagsPrefix = "SENTRY_TAGS_"
+
+// defaultEnvContextsPrefix is the default prefix of the environment variables
+// set as contexts by Init.
+const defaultEnvContextsPrefix = "SENTRY_CONTEXT_"
+
+// defaultMaxAttachmentSize is the default maximum size of an attachment.
+const defaultMaxAttachmentSize = 20 * 1024 * 1024
+
+// defaultStartupFlushTimeout is the default maximum time to wait for an event
+// captured during the StartupSyncWindow to be sent.
+const defaultStartupFlushTimeout = 2 * time.Second
+
 // hostname is the host name reported by the kernel. It is precomputed once to
 // avoid syscalls when capturing events.
 //
@@ -77,8 +104,36 @@ type usageError struct {
 	error
 }
 
+// Errors returned by the TryCapture functions when an event is not sent.
+var (
+	// ErrSDKDisabled means that no client is bound to the hub, or that the
+	// client was initialized without a DSN.
+	ErrSDKDisabled = errors.New("sentry: SDK is disabled")
+	// ErrEventSampled means that the event was discarded because of the
+	// SampleRate client option.
+	ErrEventSampled = errors.New("sentry: event dropped by sampling")
+	// ErrEventDropped means that an event processor, BeforeSend,
+	// BeforeSendTransaction or a level mapper discarded the event.
+	ErrEventDropped = errors.New("sentry: event dropped by an event processor")
+	// ErrQueueFull means that the transport buffer was full.
+	ErrQueueFull = errors.New("sentry: transport queue is full")
+	// ErrRateLimited means that Sentry asked the SDK to back off.
+	ErrRateLimited = errors.New("sentry: rate limited")
+	// ErrEventRateLimited means that the event was discarded because of the
+	// ErrorRateLimit or ErrorRateLimitPerFingerprint client options.
+	ErrEventRateLimited = errors.New("sentry: event dropped by the client rate limit")
+)
+
+// eventSender is implemented by transports that report whether an event was
+// accepted for delivery.
+type eventSender interface {
+	sendEvent(event *Event) error
+}
+
 // Logger is an instance of log.Logger that is use to provide debug information about running Sentry Client
 // can be enabled by either using Logger.SetOutput directly or with Debug client option.
+// Set a DebugLogger with SetDebugLogger to receive the diagnostics along with
+// their level instead.
 var Logger = log.New(io.Discard, "[Sentry] ", log.LstdFlags)
 
 // EventProcessor is a function that processes an event.
@@ -133,9 +188,36 @@ type ClientOptions struct {
 	TracesSampleRate float64
 	// Used to customize the sampling of traces, overrides TracesSampleRate.
 	TracesSampler TracesSampler
+	// TracesSamplingRules set the sample rate of the transactions matching
+	// them, in place of TracesSampleRate. The first matching rule applies.
+	// Rules are ignored when TracesSampler is set, and for transactions
+	// continuing a trace whose sampling decision was already made.
+	TracesSamplingRules []TracesSamplingRule
+	// TracePropagationTargets is a list of regexp strings matched against the
+	// URLs of outgoing requests. Integrations only add the headers propagating
+	// the trace, such as sentry-trace and baggage, to the requests matching
+	// one of them, so that trace headers are not leaked to third-party APIs.
+	// By default, the trace is propagated to all requests. Set it to an empty,
+	// non-nil list to never propagate the trace.
+	TracePropagationTargets []string
+	// SpanStatusMapping customizes the statuses of spans derived from the
+	// errors, HTTP status codes and gRPC codes set on them.
+	SpanStatusMapping SpanStatusMapping
 	// The sample rate for profiling traces in the range [0.0, 1.0].
 	// This is relative to TracesSampleRate - it is a ratio of profiled traces out of all sampled traces.
 	ProfilesSampleRate float64
+	// ContinuousProfiling starts the continuous profiler on Init, which
+	// samples the stacks of all goroutines for as long as the program runs,
+	// independently of transactions. See StartProfiler.
+	ContinuousProfiling bool
+	// ProfilerSampleFrequency is the number of times per second that the
+	// continuous profiler samples goroutine stacks. Defaults to, and can't
+	// exceed, 101.
+	ProfilerSampleFrequency int
+	// ProfilerOverheadBudget is the maximum fraction of the time of one CPU
+	// that the continuous profiler spends sampling, for example 0.01 for 1%.
+	// Samples are skipped while sampling takes longer. Zero means no limit.
+	ProfilerOverheadBudget float64
 	// List of regexp strings that will be used to match against event's message
 	// and if applicable, caught errors type and value.
 	// If the match is found, then a whole event will be dropped.
@@ -143,6 +225,9 @@ type ClientOptions struct {
 	// If this flag is enabled, certain personally identifiable information (PII) is added by active integrations.
 	// By default, no such data is sent.
 	SendDefaultPII bool
+	// DataScrubber, if not nil, removes sensitive data from all events before
+	// BeforeSend and BeforeSendTransaction are called.
+	DataScrubber *DataScrubber
 	// BeforeSend is called before error events are sent to Sentry.
 	// Use it to mutate the event or return nil to discard the event.
 	BeforeSend func(event *Event, hint *EventHint) *Event
@@ -151,13 +236,36 @@ type ClientOptions struct {
 	BeforeSendTransaction func(event *Event, hint *EventHint) *Event
 	// Before breadcrumb add callback.
 	BeforeBreadcrumb func(breadcrumb *Breadcrumb, hint *BreadcrumbHint) *Breadcrumb
+	// BeforeEmitMetric is called before metrics are aggregated, with their
+	// tags, including the release and environment, and their timestamp set.
+	// Use it to mutate the metric or return nil to discard the metric.
+	BeforeEmitMetric func(metric *Metric) *Metric
 	// Integrations to be installed on the current Client, receives default
 	// integrations.
 	Integrations func([]Integration) []Integration
 	// io.Writer implementation that should be used with the Debug mode.
 	DebugWriter io.Writer
+	// DebugLogger, if set, receives the diagnostics of the client and of its
+	// spans and transport along with their level, instead of Logger or the
+	// DebugLogger set with SetDebugLogger, regardless of Debug. Diagnostics
+	// not tied to a client still go to the global logger.
+	DebugLogger DebugLogger
+	// Router, if set, is called for every event right before it is sent, and
+	// returns the DSN of the project the event is sent to. Returning nil sends
+	// the event to the project of Dsn. This allows sending events to several
+	// projects from a single client, for example depending on their tags.
+	//
+	// Routing is supported by HTTPTransport, HTTPSyncTransport and
+	// WriterTransport. Rate limits received from any project apply to all
+	// events of the client.
+	Router func(event *Event) *Dsn
 	// The transport to use. Defaults to HTTPTransport.
 	Transport Transport
+	// DryRun configures the SDK to use a DryRunTransport, which prints events
+	// to DebugWriter (or os.Stderr) instead of sending them to Sentry. Events
+	// are printed even if Debug is false and the DSN is empty. DryRun has no
+	// effect if Transport is set.
+	DryRun bool
 	// The server name to be reported.
 	ServerName string
 	// The release to be sent with events.
@@ -191,18 +299,95 @@ type ClientOptions struct {
 	// Maximum number of breadcrumbs
 	// when MaxBreadcrumbs is negative then ignore breadcrumbs.
 	MaxBreadcrumbs int
+	// Maximum number of breadcrumbs per category, such as "http" or "query".
+	// When a category reaches its limit, its oldest breadcrumb is evicted, so
+	// that chatty categories don't push out breadcrumbs of other categories.
+	// A limit of zero or less drops all breadcrumbs of the category. Categories
+	// without a limit are only bound by MaxBreadcrumbs.
+	MaxBreadcrumbsByCategory map[string]int
+	// Prefix of the environment variables that Init sets as tags on the
+	// initial scope, with the rest of the variable name, lowercased, as the
+	// tag key. For example, SENTRY_TAGS_TEAM=payments sets the tag
+	// "team=payments". Defaults to "SENTRY_TAGS_".
+	EnvTagsPrefix string
+	// Prefix of the environment variables that Init sets as contexts on the
+	// initial scope. The rest of the variable name, lowercased, is the context
+	// name followed by an underscore and the key. For example,
+	// SENTRY_CONTEXT_DEPLOY_CLUSTER=eu-1 sets the key "cluster" of the
+	// "deploy" context to "eu-1". Defaults to "SENTRY_CONTEXT_".
+	EnvContextsPrefix string
+	// DisableEnvScope disables setting tags and contexts from environment
+	// variables in Init.
+	DisableEnvScope bool
+	// DisableClientReports disables reporting the number of events discarded
+	// by the SDK, such as those dropped by sampling or by BeforeSend, to
+	// Sentry. Client reports are sent along with captured events, at most
+	// every 30 seconds, and when the client is flushed.
+	DisableClientReports bool
+	// ErrorRateLimit limits the rate of errors and messages captured with
+	// CaptureException, CaptureMessage and Recover. Events exceeding the limit
+	// are discarded before they are built, so that a tight error loop doesn't
+	// spend CPU building and serializing events. Not limited by default.
+	ErrorRateLimit RateLimit
+	// ErrorRateLimitPerFingerprint is like ErrorRateLimit, but limits each
+	// error, identified by its type and message, separately, so that a single
+	// noisy error doesn't prevent others from being reported.
+	ErrorRateLimitPerFingerprint RateLimit
+	// IDGenerator, if set, generates the IDs of events and check-ins instead
+	// of random UUIDs. Together with Clock, it allows tests to produce stable
+	// events.
+	IDGenerator func() EventID
+	// Clock, if set, is used instead of time.Now to timestamp events and
+	// breadcrumbs.
+	Clock func() time.Time
+	// AutoSessionTracking starts a release health session on Init, which
+	// lets Sentry compute the crash-free rate of the release. Call EndSession
+	// before the program exits. Requires Release to be set.
+	AutoSessionTracking bool
+	// StartupSyncWindow is the time after the creation of the client during
+	// which captured errors and messages are flushed before the capture
+	// returns, waiting at most StartupFlushTimeout. It prevents losing the
+	// events of crashes during the startup of the program, which may exit
+	// before the transport sends its queue. Disabled by default.
+	StartupSyncWindow time.Duration
+	// StartupFlushTimeout is the maximum time to wait for an event to be sent
+	// during the StartupSyncWindow. Defaults to 2 seconds.
+	StartupFlushTimeout time.Duration
 	// Maximum number of spans.
 	//
 	// See https://develop.sentry.dev/sdk/envelopes/#size-limits for size limits
 	// applied during event ingestion. Events that exceed these limits might get dropped.
+	//
+	// Spans started once a transaction has MaxSpans spans are dropped. They
+	// are counted in the "_meta" annotations of the transaction and in client
+	// reports.
 	MaxSpans int
+	// MaxSpanDescriptionLength is the maximum length in bytes of the
+	// description of spans. Longer descriptions are truncated when the
+	// transaction is sent. Defaults to 2048. A negative value disables the
+	// limit.
+	MaxSpanDescriptionLength int
+	// MaxSpanDataSize is the maximum size in bytes of the data of a span,
+	// serialized as JSON. When the transaction is sent, the largest values are
+	// removed from the data of spans exceeding it. Defaults to 16 KiB. A
+	// negative value disables the limit.
+	MaxSpanDataSize int
+	// MaxMetricTagValues is the maximum number of distinct values of each tag
+	// of a metric. Once a tag of a metric has reached it, the tag is removed
+	// from the emissions of the metric with new values, which protects against
+	// tags with unbounded values, like user IDs. Defaults to 1000. A negative
+	// value disables the limit.
+	MaxMetricTagValues int
+	// Maximum size of an attachment in bytes. Larger attachments are dropped.
+	// Defaults to 20 MiB.
+	MaxAttachmentSize int64
 	// An optional pointer to http.Client that will be used with a default
 	// HTTPTransport. Using your own client will make HTTPTransport, HTTPProxy,
-	// HTTPSProxy and CaCerts options ignored.
+	// HTTPSProxy, CaCerts, DialContext and UnixSocket options ignored.
 	HTTPClient *http.Client
 	// An optional pointer to http.Transport that will be used with a default
-	// HTTPTransport. Using your own transport will make HTTPProxy, HTTPSProxy
-	// and CaCerts options ignored.
+	// HTTPTransport. Using your own transport will make HTTPProxy, HTTPSProxy,
+	// CaCerts, DialContext and UnixSocket options ignored.
 	HTTPTransport http.RoundTripper
 	// An optional HTTP proxy to use.
 	// This will default to the HTTP_PROXY environment variable.
@@ -213,6 +398,22 @@ type ClientOptions struct {
 	HTTPSProxy string
 	// An optional set of SSL certificates to use.
 	CaCerts *x509.CertPool
+	// An optional function used to dial connections to Sentry, for example to
+	// use a custom network stack. Ignored if UnixSocket is set.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// An optional path to a unix domain socket. When set, all requests are
+	// sent through this socket instead of TCP, for example to reach a local
+	// Relay running as a sidecar. Proxies are not used in this case.
+	UnixSocket string
+	// Optional HTTP headers added to every request sent to Sentry by
+	// HTTPTransport and HTTPSyncTransport, for example to authenticate with an
+	// egress proxy. They take precedence over the headers set by the SDK.
+	HTTPHeaders http.Header
+	// HTTPHeadersFunc, if set, is called right before every request is sent to
+	// Sentry by HTTPTransport and HTTPSyncTransport. The headers it returns are
+	// added to the request after HTTPHeaders, which makes it suitable for
+	// short-lived tokens or request signatures.
+	HTTPHeadersFunc func(request *http.Request) http.Header
 	// MaxErrorDepth is the maximum number of errors reported in a chain of errors.
 	// This protects the SDK from an arbitrarily long chain of wrapped errors.
 	//
@@ -229,10 +430,18 @@ type Client struct {
 	mu              sync.RWMutex
 	options         ClientOptions
 	dsn             *Dsn
-	eventProcessors []EventProcessor
+	eventProcessors eventProcessors
 	integrations    []Integration
 	sdkIdentifier   string
 	sdkVersion      string
+	reports         clientReports
+	errorLimiter    errorRateLimiter
+	stats           captureStats
+	metrics         metricsAggregator
+	metricTagValues metricTagLimiter
+	created         time.Time
+	// tracePropagationTargets are the compiled TracePropagationTargets.
+	tracePropagationTargets []*regexp.Regexp
 	// Transport is read-only. Replacing the transport of an existing client is
 	// not supported, create a new client instead.
 	Transport Transport
@@ -288,6 +497,14 @@ func NewClient(options ClientOptions) (*Client, error) {
 		options.Environment = os.Getenv("SENTRY_ENVIRONMENT")
 	}
 
+	if options.EnvTagsPrefix == "" {
+		options.EnvTagsPrefix = defaultEnvTagsPrefix
+	}
+
+	if options.EnvContextsPrefix == "" {
+		options.EnvContextsPrefix = defaultEnvContextsPrefix
+	}
+
 	if options.MaxErrorDepth == 0 {
 		options.MaxErrorDepth = maxErrorDepth
 	}
@@ -296,6 +513,18 @@ func NewClient(options ClientOptions) (*Client, error) {
 		options.MaxSpans = defaultMaxSpans
 	}
 
+	if options.MaxSpanDescriptionLength == 0 {
+		options.MaxSpanDescriptionLength = defaultMaxSpanDescriptionLength
+	}
+
+	if options.MaxSpanDataSize == 0 {
+		options.MaxSpanDataSize = defaultMaxSpanDataSize
+	}
+
+	if options.MaxAttachmentSize == 0 {
+		options.MaxAttachmentSize = defaultMaxAttachmentSize
+	}
+
 	// SENTRYGODEBUG is a comma-separated list of key=value pairs (similar
 	// to GODEBUG). It is not a supported feature: recognized debug options
 	// may change any time.
@@ -328,12 +557,18 @@ func NewClient(options ClientOptions) (*Client, error) {
 		}
 	}
 
+	if options.StartupFlushTimeout <= 0 {
+		options.StartupFlushTimeout = defaultStartupFlushTimeout
+	}
+
 	client := Client{
 		options:       options,
 		dsn:           dsn,
 		sdkIdentifier: sdkIdentifier,
 		sdkVersion:    SDKVersion,
 	}
+	client.created = client.now()
+	client.tracePropagationTargets = transformStringsIntoRegexps(options.TracePropagationTargets)
 
 	client.setupTransport()
 	client.setupIntegrations()
@@ -346,7 +581,13 @@ func (client *Client) setupTransport() {
 	transport := opts.Transport
 
 	if transport == nil {
-		if opts.Dsn == "" {
+		if opts.DryRun {
+			output := opts.DebugWriter
+			if output == nil {
+				output = os.Stderr
+			}
+			transport = NewDryRunTransport(output)
+		} else if opts.Dsn == "" {
 			transport = new(noopTransport)
 		} else {
 			httpTransport := NewHTTPTransport()
@@ -369,6 +610,8 @@ func (client *Client) setupIntegrations() {
 	integrations := []Integration{
 		new(contextifyFramesIntegration),
 		new(environmentIntegration),
+		new(memoryIntegration),
+		new(kubernetesIntegration),
 		new(modulesIntegration),
 		new(ignoreErrorsIntegration),
 	}
@@ -379,12 +622,12 @@ func (client *Client) setupIntegrations() {
 
 	for _, integration := range integrations {
 		if client.integrationAlreadyInstalled(integration.Name()) {
-			Logger.Printf("Integration %s is already installed\n", integration.Name())
+			client.debugf(LevelWarning, "Integration %s is already installed\n", integration.Name())
 			continue
 		}
 		client.integrations = append(client.integrations, integration)
 		integration.SetupOnce(client)
-		Logger.Printf("Integration installed: %s\n", integration.Name())
+		client.debugf(LevelDebug, "Integration installed: %s\n", integration.Name())
 	}
 
 	sort.Slice(client.integrations, func(i, j int) bool {
@@ -400,7 +643,28 @@ func (client *Client) setupIntegrations() {
 // client is shared among multiple hubs, one per goroutine, such that adding an
 // event processor to the client affects all hubs that share the client.
 func (client *Client) AddEventProcessor(processor EventProcessor) {
-	client.eventProcessors = append(client.eventProcessors, processor)
+	client.eventProcessors = client.eventProcessors.add("", 0, processor)
+}
+
+// AddNamedEventProcessor adds an event processor identified by name to the
+// client, replacing any processor previously added with the same name. It must
+// not be called from concurrent goroutines.
+//
+// Processors run in ascending order of priority, and in order of registration
+// for equal priorities. Processors added with AddEventProcessor have priority
+// 0. Naming processors allows libraries to install them without clobbering
+// each other, and applications to remove or override them.
+func (client *Client) AddNamedEventProcessor(name string, priority int, processor EventProcessor) {
+	client.eventProcessors = client.eventProcessors.add(name, priority, processor)
+}
+
+// RemoveEventProcessor removes the event processor added with the given name,
+// and reports whether it was found. It must not be called from concurrent
+// goroutines.
+func (client *Client) RemoveEventProcessor(name string) bool {
+	var ok bool
+	client.eventProcessors, ok = client.eventProcessors.remove(name)
+	return ok
 }
 
 // Options return ClientOptions for the current Client.
@@ -409,18 +673,78 @@ func (client *Client) Options() ClientOptions {
 	return client.options
 }
 
+// ShouldPropagateTrace reports whether the trace should be propagated to the
+// outgoing request to url, according to TracePropagationTargets.
+func (client *Client) ShouldPropagateTrace(url string) bool {
+	if client.options.TracePropagationTargets == nil {
+		return true
+	}
+	for _, target := range client.tracePropagationTargets {
+		if target.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
 // CaptureMessage captures an arbitrary message.
 func (client *Client) CaptureMessage(message string, hint *EventHint, scope EventModifier) *EventID {
+	if !client.allowError(message) {
+		return nil
+	}
 	event := client.EventFromMessage(message, LevelInfo)
 	return client.CaptureEvent(event, hint, scope)
 }
 
+// TryCaptureMessage is like CaptureMessage, but also returns an error
+// describing why the event was not sent.
+func (client *Client) TryCaptureMessage(message string, hint *EventHint, scope EventModifier) (*EventID, error) {
+	if !client.allowError(message) {
+		return nil, ErrEventRateLimited
+	}
+	event := client.EventFromMessage(message, LevelInfo)
+	return client.TryCaptureEvent(event, hint, scope)
+}
+
 // CaptureException captures an error.
 func (client *Client) CaptureException(exception error, hint *EventHint, scope EventModifier) *EventID {
-	event := client.EventFromException(exception, LevelError)
+	level, err := client.levelForCapturedException(exception)
+	if err != nil {
+		return nil
+	}
+	event := client.EventFromException(exception, level)
+	event.setMechanism(newMechanism(true))
 	return client.CaptureEvent(event, hint, scope)
 }
 
+// TryCaptureException is like CaptureException, but also returns an error
+// describing why the event was not sent.
+func (client *Client) TryCaptureException(exception error, hint *EventHint, scope EventModifier) (*EventID, error) {
+	level, err := client.levelForCapturedException(exception)
+	if err != nil {
+		return nil, err
+	}
+	event := client.EventFromException(exception, level)
+	event.setMechanism(newMechanism(true))
+	return client.TryCaptureEvent(event, hint, scope)
+}
+
+// levelForCapturedException returns the level of the event of a captured
+// error, or an error if the event is discarded by the client error rate limit
+// or by a level mapper.
+func (client *Client) levelForCapturedException(exception error) (Level, error) {
+	if !client.allowError(errorKey(exception)) {
+		return "", ErrEventRateLimited
+	}
+	level, ok := levelForError(exception, LevelError)
+	if !ok {
+		client.debugf(LevelWarning, "Event dropped by a level mapper.")
+		client.discard(DropReasonEventProcessor, ratelimit.CategoryError)
+		return "", ErrEventDropped
+	}
+	return level, nil
+}
+
 // CaptureCheckIn captures a check in.
 func (client *Client) CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorConfig, scope EventModifier) *EventID {
 	event := client.EventFromCheckIn(checkIn, monitorConfig)
@@ -437,7 +761,24 @@ func (client *Client) CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorCon
 // the utility methods like CaptureException. The return value is the
 // event ID. In case Sentry is disabled or event was dropped, the return value will be nil.
 func (client *Client) CaptureEvent(event *Event, hint *EventHint, scope EventModifier) *EventID {
-	return client.processEvent(event, hint, scope)
+	eventID, _ := client.processEvent(event, hint, scope)
+	return eventID
+}
+
+// TryCaptureEvent is like CaptureEvent, but also returns an error describing
+// why the event was not sent, such as ErrSDKDisabled, ErrEventSampled,
+// ErrEventDropped, ErrQueueFull or ErrRateLimited. When using
+// HTTPSyncTransport, delivery errors are returned as well. The event ID is nil
+// whenever the error is not nil.
+//
+// Note that a nil error doesn't guarantee delivery, as asynchronous transports
+// send events in the background.
+func (client *Client) TryCaptureEvent(event *Event, hint *EventHint, scope EventModifier) (*EventID, error) {
+	eventID, err := client.processEvent(event, hint, scope)
+	if err != nil {
+		return nil, err
+	}
+	return eventID, nil
 }
 
 // Recover captures a panic.
@@ -469,6 +810,9 @@ func (client *Client) RecoverWithContext(
 	if err == nil {
 		return nil
 	}
+	if !client.allowError(errorKey(err)) {
+		return nil
+	}
 
 	if ctx != nil {
 		if hint == nil {
@@ -488,6 +832,8 @@ func (client *Client) RecoverWithContext(
 	default:
 		event = client.EventFromMessage(fmt.Sprintf("%#v", err), LevelFatal)
 	}
+	event.setMechanism(newMechanism(false))
+	event.sdkMetaData.unhandled = true
 	return client.CaptureEvent(event, hint, scope)
 }
 
@@ -503,6 +849,8 @@ func (client *Client) RecoverWithContext(
 // the network synchronously, configure it to use the HTTPSyncTransport in the
 // call to Init.
 func (client *Client) Flush(timeout time.Duration) bool {
+	client.flushMetrics(true)
+	client.sendClientReport(true)
 	return client.Transport.Flush(timeout)
 }
 
@@ -553,7 +901,7 @@ func (client *Client) EventFromCheckIn(checkIn *CheckIn, monitorConfig *MonitorC
 
 	var checkInID EventID
 	if checkIn.ID == "" {
-		checkInID = EventID(uuid())
+		checkInID = client.newEventID()
 	} else {
 		checkInID = checkIn.ID
 	}
@@ -591,22 +939,25 @@ func reverse(a []Exception) {
 	}
 }
 
-func (client *Client) processEvent(event *Event, hint *EventHint, scope EventModifier) *EventID {
+func (client *Client) processEvent(event *Event, hint *EventHint, scope EventModifier) (*EventID, error) {
 	if event == nil {
 		err := usageError{fmt.Errorf("%s called with nil event", callerFunctionName())}
-		return client.CaptureException(err, hint, scope)
+		return client.TryCaptureException(err, hint, scope)
 	}
 
 	// Transactions are sampled by options.TracesSampleRate or
 	// options.TracesSampler when they are started. All other events
-	// (errors, messages) are sampled here.
-	if event.Type != transactionType && !sample(client.options.SampleRate) {
-		Logger.Println("Event dropped due to SampleRate hit.")
-		return nil
+	// (errors, messages) are sampled here. User feedback is never sampled.
+	if event.Type != transactionType && event.Type != feedbackType && !sample(client.options.SampleRate) {
+		client.debugf(LevelWarning, "Event dropped due to SampleRate hit.")
+		client.discard(DropReasonSampleRate, categoryFor(event.Type))
+		return nil, ErrEventSampled
 	}
 
+	original := event
 	if event = client.prepareEvent(event, hint, scope); event == nil {
-		return nil
+		client.discard(DropReasonEventProcessor, categoryFor(original.Type))
+		return nil, ErrEventDropped
 	}
 
 	// Apply beforeSend* processors
@@ -616,30 +967,64 @@ func (client *Client) processEvent(event *Event, hint *EventHint, scope EventMod
 	if event.Type == transactionType && client.options.BeforeSendTransaction != nil {
 		// Transaction events
 		if event = client.options.BeforeSendTransaction(event, hint); event == nil {
-			Logger.Println("Transaction dropped due to BeforeSendTransaction callback.")
-			return nil
+			client.debugf(LevelWarning, "Transaction dropped due to BeforeSendTransaction callback.")
+			client.discard(DropReasonBeforeSend, categoryFor(original.Type))
+			return nil, ErrEventDropped
 		}
 	} else if event.Type != transactionType && client.options.BeforeSend != nil {
 		// All other events
 		if event = client.options.BeforeSend(event, hint); event == nil {
-			Logger.Println("Event dropped due to BeforeSend callback.")
-			return nil
+			client.debugf(LevelWarning, "Event dropped due to BeforeSend callback.")
+			client.discard(DropReasonBeforeSend, categoryFor(original.Type))
+			return nil, ErrEventDropped
 		}
 	}
 
-	client.Transport.SendEvent(event)
+	if client.options.Router != nil {
+		event.sdkMetaData.dsn = client.options.Router(event)
+	}
+
+	client.updateSession(scope, event)
+	client.sendClientReport(false)
 
-	return &event.EventID
+	// The event ID is returned along with transport errors, as CaptureEvent
+	// always returned it for events handed over to the transport.
+	if sender, ok := client.Transport.(eventSender); ok {
+		err := sender.sendEvent(event)
+		client.recordSendResult(event, err)
+		if err != nil {
+			return &event.EventID, err
+		}
+	} else {
+		client.Transport.SendEvent(event)
+		client.recordSendResult(event, nil)
+	}
+
+	client.flushDuringStartup(event)
+
+	return &event.EventID, nil
+}
+
+// flushDuringStartup waits for an error or message event to be sent if it was
+// captured during the StartupSyncWindow.
+func (client *Client) flushDuringStartup(event *Event) {
+	window := client.options.StartupSyncWindow
+	if window <= 0 || event.Type == transactionType || client.now().Sub(client.created) > window {
+		return
+	}
+	if !client.Transport.Flush(client.options.StartupFlushTimeout) {
+		client.debugf(LevelWarning, "Event captured during startup was not sent before the flush timeout.")
+	}
 }
 
 func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventModifier) *Event {
 	if event.EventID == "" {
 		// TODO set EventID when the event is created, same as in other SDKs. It's necessary for profileTransaction.ID.
-		event.EventID = EventID(uuid())
+		event.EventID = client.newEventID()
 	}
 
 	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now()
+		event.Timestamp = client.now()
 	}
 
 	if event.Level == "" {
@@ -677,6 +1062,29 @@ func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventMod
 		}},
 	}
 
+	// Errors captured with the context of a span are linked to its trace, and
+	// sent with the DynamicSamplingContext of its transaction.
+	if event.Type != transactionType && hint != nil && hint.Context != nil {
+		if span := SpanFromContext(hint.Context); span != nil {
+			if event.Contexts == nil {
+				event.Contexts = make(map[string]Context)
+			}
+			if _, ok := event.Contexts["trace"]; !ok {
+				event.Contexts["trace"] = span.traceContext().Map()
+			}
+			if !event.sdkMetaData.dsc.HasEntries() {
+				event.sdkMetaData.dsc = span.transactionDynamicSamplingContext()
+			}
+		}
+	}
+
+	if hint != nil && hint.Mechanism != nil {
+		event.setMechanism(hint.Mechanism)
+		if hint.Mechanism.Handled != nil {
+			event.sdkMetaData.unhandled = !*hint.Mechanism.Handled
+		}
+	}
+
 	if scope != nil {
 		event = scope.ApplyToEvent(event, hint)
 		if event == nil {
@@ -684,20 +1092,19 @@ func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventMod
 		}
 	}
 
-	for _, processor := range client.eventProcessors {
-		id := event.EventID
-		event = processor(event, hint)
-		if event == nil {
-			Logger.Printf("Event dropped by one of the Client EventProcessors: %s\n", id)
-			return nil
-		}
+	if hint != nil && len(hint.Attachments) > 0 {
+		event.attachments = append(event.attachments, hint.Attachments...)
+	}
+
+	if event = client.eventProcessors.apply(event, hint, "Client"); event == nil {
+		return nil
 	}
 
 	for _, processor := range globalEventProcessors {
 		id := event.EventID
 		event = processor(event, hint)
 		if event == nil {
-			Logger.Printf("Event dropped by one of the Global EventProcessors: %s\n", id)
+			client.debugf(LevelWarning, "Event dropped by one of the Global EventProcessors: %s\n", id)
 			return nil
 		}
 	}
@@ -706,9 +1113,34 @@ func (client *Client) prepareEvent(event *Event, hint *EventHint, scope EventMod
 		event.sdkMetaData.transactionProfile.UpdateFromEvent(event)
 	}
 
+	if client.options.DataScrubber != nil {
+		client.options.DataScrubber.Scrub(event)
+	}
+
+	if len(event.attachments) > 0 {
+		event.attachments = limitAttachments(event.attachments, client.options.MaxAttachmentSize)
+	}
+
 	return event
 }
 
+// newEventID returns a new event ID from the IDGenerator option, or a random
+// one.
+func (client *Client) newEventID() EventID {
+	if client.options.IDGenerator != nil {
+		return client.options.IDGenerator()
+	}
+	return EventID(uuid())
+}
+
+// now returns the current time according to the Clock option.
+func (client *Client) now() time.Time {
+	if client.options.Clock != nil {
+		return client.options.Clock()
+	}
+	return time.Now()
+}
+
 func (client *Client) listIntegrations() []string {
 	integrations := make([]string, len(client.integrations))
 	for i, integration := range client.integrations {
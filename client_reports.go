@@ -0,0 +1,111 @@
+package sentry
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go/internal/ratelimit"
+)
+
+// clientReportType is the type of a client report envelope item.
+const clientReportType = "client_report"
+
+// clientReportInterval is the minimum time between two client reports sent
+// along with captured events.
+const clientReportInterval = 30 * time.Second
+
+// clientReports counts the events discarded by the client, which are reported
+// to Sentry so that they show up in the usage stats of the project. It is safe
+// for concurrent use.
+type clientReports struct {
+	mu       sync.Mutex
+	counts   map[discardedEventKey]int64
+	lastSent time.Time
+}
+
+type discardedEventKey struct {
+	reason   DropReason
+	category string
+}
+
+type discardedEvent struct {
+	Reason   DropReason `json:"reason"`
+	Category string     `json:"category"`
+	Quantity int64      `json:"quantity"`
+}
+
+// record counts an event of the given category discarded for reason.
+//
+// The reasons are those of the client reports protocol.
+//
+// See https://develop.sentry.dev/sdk/client-reports/.
+func (r *clientReports) record(reason DropReason, category ratelimit.Category) {
+	r.recordN(reason, category, 1)
+}
+
+// recordN counts n events of the given category discarded for reason.
+func (r *clientReports) recordN(reason DropReason, category ratelimit.Category, n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[discardedEventKey]int64)
+	}
+	r.counts[discardedEventKey{reason: reason, category: string(category)}] += n
+}
+
+// take returns and resets the discarded events counted so far. Unless force is
+// true, it returns nothing if the last report was taken less than
+// clientReportInterval ago.
+func (r *clientReports) take(force bool) []discardedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.counts) == 0 || (!force && time.Since(r.lastSent) < clientReportInterval) {
+		return nil
+	}
+	discarded := make([]discardedEvent, 0, len(r.counts))
+	for k, n := range r.counts {
+		discarded = append(discarded, discardedEvent{Reason: k.reason, Category: k.category, Quantity: n})
+	}
+	r.counts = nil
+	r.lastSent = time.Now()
+	return discarded
+}
+
+// sendClientReport sends the discarded events counted since the last report,
+// if any. Unless force is true, reports are sent at most once every
+// clientReportInterval.
+func (client *Client) sendClientReport(force bool) {
+	if client.options.DisableClientReports || client.dsn == nil {
+		return
+	}
+	discarded := client.reports.take(force)
+	if len(discarded) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(struct {
+		Timestamp       time.Time        `json:"timestamp"`
+		DiscardedEvents []discardedEvent `json:"discarded_events"`
+	}{
+		Timestamp:       time.Now().UTC(),
+		DiscardedEvents: discarded,
+	})
+	if err != nil {
+		client.debugf(LevelError, "Client report couldn't be marshaled: %v", err)
+		return
+	}
+	envelope := NewEnvelope(EnvelopeHeader{
+		SentAt: time.Now(),
+		Dsn:    client.dsn.String(),
+		Sdk: map[string]string{
+			"name":    client.GetSDKIdentifier(),
+			"version": client.sdkVersion,
+		},
+	})
+	envelope.AddItem(&EnvelopeItem{
+		Type:    clientReportType,
+		Payload: payload,
+	})
+	client.Transport.SendEnvelope(envelope)
+}
@@ -0,0 +1,62 @@
+package sentry
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestClientReports(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.options.BeforeSend = func(event *Event, hint *EventHint) *Event {
+		if event.Message == "drop" {
+			return nil
+		}
+		return event
+	}
+
+	client.CaptureMessage("drop", nil, scope)
+	client.options.SampleRate = 0.000000000000001
+	client.CaptureMessage("sampled", nil, scope)
+	client.CaptureMessage("sampled", nil, scope)
+	client.Flush(time.Second)
+
+	if len(transport.envelopes) != 1 {
+		t.Fatalf("got %d envelopes, want 1", len(transport.envelopes))
+	}
+	item := transport.envelopes[0].Items[0]
+	assertEqual(t, item.Type, "client_report")
+	var report struct {
+		Timestamp       time.Time        `json:"timestamp"`
+		DiscardedEvents []discardedEvent `json:"discarded_events"`
+	}
+	if err := json.Unmarshal(item.Payload, &report); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(report.DiscardedEvents, func(i, j int) bool {
+		return report.DiscardedEvents[i].Reason < report.DiscardedEvents[j].Reason
+	})
+	assertEqual(t, report.DiscardedEvents, []discardedEvent{
+		{Reason: "before_send", Category: "error", Quantity: 1},
+		{Reason: "sample_rate", Category: "error", Quantity: 2},
+	})
+	if report.Timestamp.IsZero() {
+		t.Error("client report has no timestamp")
+	}
+
+	// Counts are reset once reported.
+	client.Flush(time.Second)
+	assertEqual(t, len(transport.envelopes), 1)
+}
+
+func TestClientReportsDisabled(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.options.DisableClientReports = true
+	client.options.SampleRate = 0.000000000000001
+
+	client.CaptureMessage("sampled", nil, scope)
+	client.Flush(time.Second)
+
+	assertEqual(t, len(transport.envelopes), 0)
+}
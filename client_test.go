@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -80,7 +82,7 @@ func TestCaptureMessageEmptyString(t *testing.T) {
 	}
 	got := transport.lastEvent
 	opts := cmp.Options{
-		cmpopts.IgnoreFields(Event{}, "sdkMetaData", "attachments"),
+		cmpopts.IgnoreFields(Event{}, "sdkMetaData", "attachments", "meta", "metricsSummary"),
 		cmp.Transformer("SimplifiedEvent", func(e *Event) *Event {
 			return &Event{
 				Exception: e.Exception,
@@ -140,6 +142,7 @@ func TestCaptureException(t *testing.T) {
 					Type:       "sentry.usageError",
 					Value:      "CaptureException called with nil error",
 					Stacktrace: &Stacktrace{Frames: []Frame{}},
+					Mechanism:  newMechanism(true),
 				},
 			},
 		},
@@ -151,6 +154,7 @@ func TestCaptureException(t *testing.T) {
 					Type:       "*errors.errorString",
 					Value:      "custom error",
 					Stacktrace: &Stacktrace{Frames: []Frame{}},
+					Mechanism:  newMechanism(true),
 				},
 			},
 		},
@@ -172,6 +176,7 @@ func TestCaptureException(t *testing.T) {
 					Type:       "*errors.withStack",
 					Value:      "wat",
 					Stacktrace: &Stacktrace{Frames: []Frame{}},
+					Mechanism:  newMechanism(true),
 				},
 			},
 		},
@@ -183,6 +188,7 @@ func TestCaptureException(t *testing.T) {
 					Type:       "*sentry.customErrWithCause",
 					Value:      "err",
 					Stacktrace: &Stacktrace{Frames: []Frame{}},
+					Mechanism:  newMechanism(true),
 				},
 			},
 		},
@@ -198,6 +204,7 @@ func TestCaptureException(t *testing.T) {
 					Type:       "*sentry.customErrWithCause",
 					Value:      "err",
 					Stacktrace: &Stacktrace{Frames: []Frame{}},
+					Mechanism:  newMechanism(true),
 				},
 			},
 		},
@@ -213,6 +220,7 @@ func TestCaptureException(t *testing.T) {
 					Type:       "sentry.wrappedError",
 					Value:      "wrapped: original",
 					Stacktrace: &Stacktrace{Frames: []Frame{}},
+					Mechanism:  newMechanism(true),
 				},
 			},
 		},
@@ -287,7 +295,7 @@ func TestCaptureEvent(t *testing.T) {
 		},
 	}
 	got := transport.lastEvent
-	opts := cmp.Options{cmpopts.IgnoreFields(Event{}, "Release", "sdkMetaData", "attachments")}
+	opts := cmp.Options{cmpopts.IgnoreFields(Event{}, "Release", "sdkMetaData", "attachments", "meta", "metricsSummary")}
 	if diff := cmp.Diff(want, got, opts); diff != "" {
 		t.Errorf("Event mismatch (-want +got):\n%s", diff)
 	}
@@ -310,12 +318,13 @@ func TestCaptureEventNil(t *testing.T) {
 				Type:       "sentry.usageError",
 				Value:      "CaptureEvent called with nil event",
 				Stacktrace: &Stacktrace{Frames: []Frame{}},
+				Mechanism:  newMechanism(true),
 			},
 		},
 	}
 	got := transport.lastEvent
 	opts := cmp.Options{
-		cmpopts.IgnoreFields(Event{}, "sdkMetaData", "attachments"),
+		cmpopts.IgnoreFields(Event{}, "sdkMetaData", "attachments", "meta", "metricsSummary"),
 		cmp.Transformer("SimplifiedEvent", func(e *Event) *Event {
 			return &Event{
 				Exception: e.Exception,
@@ -633,6 +642,7 @@ func TestRecover(t *testing.T) {
 						Type:       "*errors.errorString",
 						Value:      "panic error",
 						Stacktrace: &Stacktrace{Frames: []Frame{}},
+						Mechanism:  newMechanism(false),
 					},
 				},
 			},
@@ -654,7 +664,7 @@ func TestRecover(t *testing.T) {
 		}
 		got := events[0]
 		opts := cmp.Options{
-			cmpopts.IgnoreFields(Event{}, "sdkMetaData", "attachments"),
+			cmpopts.IgnoreFields(Event{}, "sdkMetaData", "attachments", "meta", "metricsSummary"),
 			cmp.Transformer("SimplifiedEvent", func(e *Event) *Event {
 				return &Event{
 					Message:   e.Message,
@@ -732,3 +742,205 @@ func TestClientSetsUpTransport(t *testing.T) {
 	client, _ = NewClient(ClientOptions{})
 	require.IsType(t, &noopTransport{}, client.Transport)
 }
+
+func TestRouter(t *testing.T) {
+	var defaultRequests, routedRequests int32
+	defaultServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&defaultRequests, 1)
+		assertEqual(t, r.URL.Path, "/api/1/envelope/")
+	}))
+	defer defaultServer.Close()
+	routedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&routedRequests, 1)
+		assertEqual(t, r.URL.Path, "/api/2/envelope/")
+	}))
+	defer routedServer.Close()
+
+	routedDsn, err := NewDsn(fmt.Sprintf("http://routed@%s/2", routedServer.Listener.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ClientOptions{
+		Dsn:       fmt.Sprintf("http://default@%s/1", defaultServer.Listener.Addr()),
+		Transport: NewHTTPSyncTransport(),
+		Router: func(event *Event) *Dsn {
+			if event.Tags["team"] == "payments" {
+				return routedDsn
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := NewScope()
+	client.CaptureMessage("default", nil, scope)
+	scope.SetTag("team", "payments")
+	client.CaptureMessage("routed", nil, scope)
+
+	assertEqual(t, atomic.LoadInt32(&defaultRequests), int32(1))
+	assertEqual(t, atomic.LoadInt32(&routedRequests), int32(1))
+}
+
+func TestTryCaptureErrors(t *testing.T) {
+	t.Run("NoClient", func(t *testing.T) {
+		hub := NewHub(nil, NewScope())
+		_, err := hub.TryCaptureException(errors.New("boom"))
+		assertEqual(t, err, ErrSDKDisabled)
+	})
+
+	t.Run("EmptyDSN", func(t *testing.T) {
+		client, err := NewClient(ClientOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		eventID, err := client.TryCaptureMessage("message", nil, NewScope())
+		assertEqual(t, err, ErrSDKDisabled)
+		if eventID != nil {
+			t.Errorf("eventID = %v, want nil", *eventID)
+		}
+	})
+
+	t.Run("BeforeSend", func(t *testing.T) {
+		client, scope, _ := setupClientTest()
+		client.options.BeforeSend = func(event *Event, hint *EventHint) *Event {
+			return nil
+		}
+		_, err := client.TryCaptureMessage("message", nil, scope)
+		assertEqual(t, err, ErrEventDropped)
+	})
+
+	t.Run("Sampled", func(t *testing.T) {
+		client, scope, _ := setupClientTest()
+		client.options.SampleRate = 0.000000001
+		_, err := client.TryCaptureMessage("message", nil, scope)
+		assertEqual(t, err, ErrEventSampled)
+	})
+
+	t.Run("Sent", func(t *testing.T) {
+		client, scope, transport := setupClientTest()
+		eventID, err := client.TryCaptureMessage("message", nil, scope)
+		assertEqual(t, err, nil)
+		assertEqual(t, *eventID, transport.lastEvent.EventID)
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		unblock := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+		}))
+		defer server.Close()
+		defer close(unblock)
+
+		transport := NewHTTPTransport()
+		transport.BufferSize = 1
+		client, err := NewClient(ClientOptions{
+			Dsn:       fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+			Transport: transport,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 10; i++ {
+			if _, err = client.TryCaptureMessage("message", nil, NewScope()); err != nil {
+				break
+			}
+		}
+		assertEqual(t, err, ErrQueueFull)
+	})
+
+	t.Run("SyncDeliveryError", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(ClientOptions{
+			Dsn:       fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+			Transport: NewHTTPSyncTransport(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.TryCaptureMessage("message", nil, NewScope())
+		assertEqual(t, err, ErrRateLimited)
+	})
+}
+
+func TestIDGeneratorAndClock(t *testing.T) {
+	var n int
+	clock := time.Date(2023, 9, 5, 10, 0, 0, 0, time.UTC)
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport: transport,
+		IDGenerator: func() EventID {
+			n++
+			return EventID(fmt.Sprintf("%032d", n))
+		},
+		Clock: func() time.Time { return clock },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(client, NewScope())
+
+	hub.AddBreadcrumb(&Breadcrumb{Message: "step"}, nil)
+	hub.CaptureMessage("message")
+	checkInID := hub.CaptureCheckIn(&CheckIn{MonitorSlug: "cron", Status: CheckInStatusOK}, nil)
+
+	events := transport.Events()
+	assertEqual(t, events[0].EventID, EventID("00000000000000000000000000000001"))
+	assertEqual(t, events[0].Timestamp, clock)
+	assertEqual(t, events[0].Breadcrumbs[0].Timestamp, clock)
+	assertEqual(t, *checkInID, EventID("00000000000000000000000000000002"))
+}
+
+func TestStartupSyncWindow(t *testing.T) {
+	now := time.Date(2023, 9, 5, 10, 0, 0, 0, time.UTC)
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport:         transport,
+		StartupSyncWindow: 5 * time.Second,
+		Clock:             func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.CaptureMessage("during startup", nil, nil)
+	client.CaptureEvent(&Event{Type: transactionType}, nil, nil)
+	assertEqual(t, transport.flushes, 1)
+
+	now = now.Add(10 * time.Second)
+	client.CaptureMessage("after startup", nil, nil)
+	assertEqual(t, transport.flushes, 1)
+}
+
+func TestShouldPropagateTrace(t *testing.T) {
+	tests := []struct {
+		targets []string
+		url     string
+		want    bool
+	}{
+		{nil, "https://api.example.com/users", true},
+		{[]string{}, "https://api.example.com/users", false},
+		{[]string{"example.com"}, "https://api.example.com/users", true},
+		{[]string{"example.com"}, "https://api.stripe.com/v1", false},
+		{[]string{`^https://internal\.`, "localhost"}, "https://internal.example.com", true},
+		{[]string{`^https://internal\.`, "localhost"}, "http://localhost:8080/", true},
+		{[]string{`^https://internal\.`}, "https://example.com/internal.", false},
+		// Invalid regexps are ignored.
+		{[]string{"(", "example"}, "https://example.com", true},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(ClientOptions{TracePropagationTargets: tt.targets})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.ShouldPropagateTrace(tt.url); got != tt.want {
+			t.Errorf("ShouldPropagateTrace(%q) with targets %q = %t, want %t", tt.url, tt.targets, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultCloudMetadataTimeout is the time CloudMetadataIntegration waits for
+// the instance metadata services to respond.
+const defaultCloudMetadataTimeout = 300 * time.Millisecond
+
+// Instance metadata endpoints, variables for testing.
+var (
+	awsMetadataURL   = "http://169.254.169.254"
+	gcpMetadataURL   = "http://metadata.google.internal"
+	azureMetadataURL = "http://169.254.169.254"
+)
+
+// CloudMetadataIntegration adds a "cloud" context with the provider, region,
+// availability zone, instance type and account of the virtual machine the
+// program runs on to all events.
+//
+// The metadata is queried once, when the integration is set up, from the
+// instance metadata services of Amazon EC2, Google Compute Engine and Azure.
+// Because this delays initialization by up to Timeout outside of those
+// environments, the integration is not enabled by default:
+//
+//	sentry.Init(sentry.ClientOptions{
+//		Integrations: func(integrations []sentry.Integration) []sentry.Integration {
+//			return append(integrations, &sentry.CloudMetadataIntegration{})
+//		},
+//	})
+type CloudMetadataIntegration struct {
+	// Timeout limits the time spent querying the metadata services. Defaults
+	// to 300 milliseconds.
+	Timeout time.Duration
+	// HTTPClient is the client used to query the metadata services. Defaults
+	// to a client that doesn't use proxies.
+	HTTPClient *http.Client
+
+	context Context
+}
+
+func (ci *CloudMetadataIntegration) Name() string {
+	return "CloudMetadata"
+}
+
+func (ci *CloudMetadataIntegration) SetupOnce(client *Client) {
+	timeout := ci.Timeout
+	if timeout <= 0 {
+		timeout = defaultCloudMetadataTimeout
+	}
+	httpClient := ci.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &http.Transport{Proxy: nil},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ci.context = fetchCloudContext(ctx, httpClient)
+	if ci.context == nil {
+		debugf(LevelDebug, "No cloud instance metadata available.")
+		return
+	}
+	client.AddEventProcessor(ci.processor)
+}
+
+func (ci *CloudMetadataIntegration) processor(event *Event, hint *EventHint) *Event {
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]Context, 1)
+	}
+	cloudContext, ok := event.Contexts["cloud"]
+	if !ok {
+		cloudContext = make(Context, len(ci.context))
+		event.Contexts["cloud"] = cloudContext
+	}
+	// Preserve existing data.
+	for k, v := range ci.context {
+		if _, ok := cloudContext[k]; !ok {
+			cloudContext[k] = v
+		}
+	}
+	return event
+}
+
+// fetchCloudContext queries the metadata services of all supported providers
+// concurrently, and returns the context built from the first one to respond,
+// or nil if none did before ctx is done.
+func fetchCloudContext(ctx context.Context, client *http.Client) Context {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fetchers := []func(context.Context, *http.Client) (Context, error){
+		fetchAWSContext,
+		fetchGCPContext,
+		fetchAzureContext,
+	}
+	results := make(chan Context, len(fetchers))
+	for _, fetch := range fetchers {
+		fetch := fetch
+		go func() {
+			c, err := fetch(ctx, client)
+			if err != nil {
+				c = nil
+			}
+			results <- c
+		}()
+	}
+	for range fetchers {
+		if c := <-results; c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+func fetchAWSContext(ctx context.Context, client *http.Client) (Context, error) {
+	// IMDSv2 requires a session token.
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, awsMetadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := doMetadataRequest(client, request)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err = http.NewRequestWithContext(ctx, http.MethodGet, awsMetadataURL+"/latest/dynamic/instance-identity/document", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token", string(token))
+	body, err := doMetadataRequest(client, request)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceType     string `json:"instanceType"`
+		InstanceID       string `json:"instanceId"`
+		AccountID        string `json:"accountId"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+	return cloudContext(map[string]string{
+		"provider":          "aws",
+		"region":            document.Region,
+		"availability_zone": document.AvailabilityZone,
+		"instance_type":     document.InstanceType,
+		"instance_id":       document.InstanceID,
+		"account_id":        document.AccountID,
+	}), nil
+}
+
+func fetchGCPContext(ctx context.Context, client *http.Client) (Context, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataURL+"/computeMetadata/v1/?recursive=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Metadata-Flavor", "Google")
+	body, err := doMetadataRequest(client, request)
+	if err != nil {
+		return nil, err
+	}
+	var metadata struct {
+		Instance struct {
+			ID          json.Number `json:"id"`
+			Zone        string      `json:"zone"`
+			MachineType string      `json:"machineType"`
+		} `json:"instance"`
+		Project struct {
+			ProjectID string `json:"projectId"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return nil, err
+	}
+	// Zone and machine type are resource paths, such as
+	// "projects/123/zones/us-central1-a".
+	zone := lastPathElement(metadata.Instance.Zone)
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+	return cloudContext(map[string]string{
+		"provider":          "gcp",
+		"region":            region,
+		"availability_zone": zone,
+		"instance_type":     lastPathElement(metadata.Instance.MachineType),
+		"instance_id":       metadata.Instance.ID.String(),
+		"account_id":        metadata.Project.ProjectID,
+	}), nil
+}
+
+func fetchAzureContext(ctx context.Context, client *http.Client) (Context, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, azureMetadataURL+"/metadata/instance/compute?api-version=2021-02-01", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Metadata", "true")
+	body, err := doMetadataRequest(client, request)
+	if err != nil {
+		return nil, err
+	}
+	var compute struct {
+		Location       string `json:"location"`
+		Zone           string `json:"zone"`
+		VMSize         string `json:"vmSize"`
+		VMID           string `json:"vmId"`
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if err := json.Unmarshal(body, &compute); err != nil {
+		return nil, err
+	}
+	return cloudContext(map[string]string{
+		"provider":          "azure",
+		"region":            compute.Location,
+		"availability_zone": compute.Zone,
+		"instance_type":     compute.VMSize,
+		"instance_id":       compute.VMID,
+		"account_id":        compute.SubscriptionID,
+	}), nil
+}
+
+// doMetadataRequest returns the body of a successful metadata request.
+func doMetadataRequest(client *http.Client, request *http.Request) ([]byte, error) {
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+}
+
+// cloudContext builds a context from the non-empty values.
+func cloudContext(values map[string]string) Context {
+	c := make(Context, len(values))
+	for k, v := range values {
+		if v != "" {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func lastPathElement(path string) string {
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
@@ -0,0 +1,160 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// setCloudMetadataURLs points the metadata endpoints to the given URLs for the
+// duration of the test.
+func setCloudMetadataURLs(t *testing.T, aws, gcp, azure string) {
+	t.Helper()
+	oldAWS, oldGCP, oldAzure := awsMetadataURL, gcpMetadataURL, azureMetadataURL
+	t.Cleanup(func() {
+		awsMetadataURL, gcpMetadataURL, azureMetadataURL = oldAWS, oldGCP, oldAzure
+	})
+	awsMetadataURL, gcpMetadataURL, azureMetadataURL = aws, gcp, azure
+}
+
+func TestCloudMetadataIntegrationAWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("token"))
+		case r.URL.Path == "/latest/dynamic/instance-identity/document" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+			_, _ = w.Write([]byte(`{
+				"accountId": "123456789012",
+				"availabilityZone": "eu-west-1a",
+				"instanceId": "i-0123456789abcdef0",
+				"instanceType": "m5.large",
+				"region": "eu-west-1"
+			}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	setCloudMetadataURLs(t, server.URL, "http://127.0.0.1:0", "http://127.0.0.1:0")
+
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport: transport,
+		Integrations: func(integrations []Integration) []Integration {
+			return append(integrations, &CloudMetadataIntegration{Timeout: time.Second})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(client, NewScope())
+	hub.Scope().SetContext("cloud", Context{"region": "custom"})
+	hub.CaptureMessage("test event")
+
+	want := Context{
+		"provider":          "aws",
+		"region":            "custom",
+		"availability_zone": "eu-west-1a",
+		"instance_type":     "m5.large",
+		"instance_id":       "i-0123456789abcdef0",
+		"account_id":        "123456789012",
+	}
+	if diff := cmp.Diff(want, transport.lastEvent.Contexts["cloud"]); diff != "" {
+		t.Errorf("cloud context mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCloudMetadataIntegrationGCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"instance": {
+				"id": 4520031799277581759,
+				"machineType": "projects/123/machineTypes/e2-medium",
+				"zone": "projects/123/zones/us-central1-a"
+			},
+			"project": {"projectId": "my-project"}
+		}`))
+	}))
+	defer server.Close()
+	setCloudMetadataURLs(t, "http://127.0.0.1:0", server.URL, "http://127.0.0.1:0")
+
+	want := Context{
+		"provider":          "gcp",
+		"region":            "us-central1",
+		"availability_zone": "us-central1-a",
+		"instance_type":     "e2-medium",
+		"instance_id":       "4520031799277581759",
+		"account_id":        "my-project",
+	}
+	if diff := cmp.Diff(want, fetchCloudContext(context.Background(), http.DefaultClient)); diff != "" {
+		t.Errorf("cloud context mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCloudMetadataIntegrationAzure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"location": "westeurope",
+			"subscriptionId": "8d10da13-8125-4ba9-a717-bf7490507b3d",
+			"vmId": "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+			"vmSize": "Standard_D2s_v3",
+			"zone": "1"
+		}`))
+	}))
+	defer server.Close()
+	setCloudMetadataURLs(t, "http://127.0.0.1:0", "http://127.0.0.1:0", server.URL)
+
+	want := Context{
+		"provider":          "azure",
+		"region":            "westeurope",
+		"availability_zone": "1",
+		"instance_type":     "Standard_D2s_v3",
+		"instance_id":       "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+		"account_id":        "8d10da13-8125-4ba9-a717-bf7490507b3d",
+	}
+	if diff := cmp.Diff(want, fetchCloudContext(context.Background(), http.DefaultClient)); diff != "" {
+		t.Errorf("cloud context mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCloudMetadataIntegrationTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+	setCloudMetadataURLs(t, server.URL, server.URL, server.URL)
+
+	transport := &TransportMock{}
+	start := time.Now()
+	client, err := NewClient(ClientOptions{
+		Transport: transport,
+		Integrations: func(integrations []Integration) []Integration {
+			return append(integrations, &CloudMetadataIntegration{Timeout: 50 * time.Millisecond})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("setup took %s, want it bounded by the timeout", elapsed)
+	}
+	NewHub(client, NewScope()).CaptureMessage("test event")
+
+	if _, ok := transport.lastEvent.Contexts["cloud"]; ok {
+		t.Error("cloud context should not be set when no metadata service responds")
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/getsentry/sentry-go/cobra
+
+go 1.21
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
@@ -0,0 +1,197 @@
+// Package sentrycobra provides Sentry integration for command-line programs
+// built with github.com/spf13/cobra.
+//
+// Execute the root command with Execute, and exit with the code it returns:
+//
+//	func main() {
+//		sentry.Init(sentry.ClientOptions{})
+//		os.Exit(sentrycobra.Execute(rootCmd, sentrycobra.Options{}))
+//	}
+//
+// Commands run in a "cli.command" transaction named after the path of the
+// command, with the hub in the context of the command. Events are tagged with
+// the command path and include the flags set on the command line, with the
+// values of sensitive flags redacted. Errors returned by the run functions of
+// commands and panics are reported to Sentry, and events are flushed before
+// Execute returns.
+package sentrycobra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// The identifier of the cobra SDK.
+const sdkIdentifier = "sentry.go.cobra"
+
+// spanOperation is the operation of the transactions of commands.
+const spanOperation = "cli.command"
+
+// spanOrigin is the origin of the transactions of commands.
+const spanOrigin = "auto.cli.cobra"
+
+// filteredValue replaces the values of sensitive flags.
+const filteredValue = "[Filtered]"
+
+// Options configure Execute.
+type Options struct {
+	// DenyFlags are the names of the flags whose values are redacted. A flag
+	// matches if its name contains one of DenyFlags, ignoring case, dashes and
+	// underscores. Defaults to sentry.DefaultDenyKeys.
+	DenyFlags []string
+	// Timeout for the delivery of events before Execute returns. Defaults to
+	// 2s.
+	Timeout time.Duration
+}
+
+// Execute executes root with a clone of the current hub, and returns the exit
+// code of the program: 0 if the command succeeded, the code returned by the
+// ExitCode method of the error of the command if it has one, such as
+// *exec.ExitError, and 1 otherwise.
+//
+// Panics are reported to Sentry, and Execute panics again once events are
+// flushed.
+func Execute(root *cobra.Command, options Options) int {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	denyFlags := options.DenyFlags
+	if denyFlags == nil {
+		denyFlags = sentry.DefaultDenyKeys
+	}
+
+	ctx := root.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	instrument(root, hub, denyFlags)
+
+	defer func() {
+		if r := recover(); r != nil {
+			hub.RecoverWithContext(ctx, r)
+			hub.Flush(timeout)
+			panic(r)
+		}
+	}()
+	err := root.ExecuteContext(ctx)
+	hub.Flush(timeout)
+	return exitCode(err)
+}
+
+// instrument wraps the run functions of cmd and of its subcommands to run
+// them in a transaction, tag the scope of hub with the command, and report
+// the errors they return.
+func instrument(cmd *cobra.Command, hub *sentry.Hub, denyFlags []string) {
+	for _, sub := range cmd.Commands() {
+		instrument(sub, hub, denyFlags)
+	}
+
+	run := cmd.RunE
+	if run == nil && cmd.Run != nil {
+		r := cmd.Run
+		run = func(cmd *cobra.Command, args []string) error {
+			r(cmd, args)
+			return nil
+		}
+	}
+	if run == nil {
+		return
+	}
+	cmd.Run = nil
+	cmd.RunE = func(cmd *cobra.Command, args []string) (err error) {
+		path := cmd.CommandPath()
+		scope := hub.Scope()
+		scope.SetTag("cobra.command", path)
+		scope.SetContext("cobra", sentry.Context{
+			"command": path,
+			"args":    len(args),
+			"flags":   flags(cmd, denyFlags),
+		})
+
+		transaction := sentry.StartTransaction(cmd.Context(), path,
+			sentry.WithOpName(spanOperation),
+			sentry.WithTransactionSource(sentry.SourceTask),
+			sentry.WithSpanOrigin(spanOrigin),
+		)
+		defer func() {
+			if r := recover(); r != nil {
+				transaction.Status = sentry.SpanStatusInternalError
+				transaction.Finish()
+				panic(r)
+			}
+			if err != nil {
+				transaction.SetError(err)
+			} else {
+				transaction.Status = sentry.SpanStatusOK
+			}
+			transaction.Finish()
+		}()
+		cmd.SetContext(transaction.Context())
+
+		if err = run(cmd, args); err != nil {
+			hub.CaptureException(err)
+		}
+		return err
+	}
+}
+
+// flags returns the flags of cmd set on the command line, with the values of
+// the flags matching denyFlags redacted.
+func flags(cmd *cobra.Command, denyFlags []string) map[string]string {
+	values := make(map[string]string)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if denied(f.Name, denyFlags) {
+			values[f.Name] = filteredValue
+		} else {
+			values[f.Name] = f.Value.String()
+		}
+	})
+	return values
+}
+
+// denied reports whether name contains one of denyFlags, ignoring case,
+// dashes and underscores.
+func denied(name string, denyFlags []string) bool {
+	name = normalizeFlagName(name)
+	for _, deny := range denyFlags {
+		if strings.Contains(name, normalizeFlagName(deny)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeFlagName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "-", "")
+	return strings.ReplaceAll(name, "_", "")
+}
+
+// exitCode returns the exit code of a program whose command returned err.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitCoder interface{ ExitCode() int }
+	if errors.As(err, &exitCoder) {
+		if code := exitCoder.ExitCode(); code > 0 {
+			return code
+		}
+	}
+	return 1
+}
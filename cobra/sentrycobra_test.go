@@ -0,0 +1,134 @@
+package sentrycobra_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrycobra "github.com/getsentry/sentry-go/cobra"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+type exitError struct{ code int }
+
+func (e exitError) Error() string { return "exit" }
+func (e exitError) ExitCode() int { return e.code }
+
+func TestExecute(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "app", SilenceErrors: true, SilenceUsage: true}
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		deploy := &cobra.Command{
+			Use: "deploy",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if sentry.GetHubFromContext(cmd.Context()) == nil {
+					t.Error("no hub in the context of the command")
+				}
+				switch env, _ := cmd.Flags().GetString("env"); env {
+				case "fail":
+					return errors.New("deployment failed")
+				case "exit":
+					return exitError{3}
+				}
+				return nil
+			},
+		}
+		deploy.Flags().String("env", "staging", "")
+		deploy.Flags().String("api-token", "", "")
+		root.AddCommand(deploy)
+		root.AddCommand(&cobra.Command{
+			Use: "crash",
+			Run: func(cmd *cobra.Command, args []string) {
+				panic("test")
+			},
+		})
+		return root
+	}
+
+	tests := []struct {
+		args       []string
+		wantCode   int
+		wantStatus sentry.SpanStatus
+		wantEvents int
+	}{
+		{[]string{"deploy", "--env", "production"}, 0, sentry.SpanStatusOK, 0},
+		{[]string{"deploy", "--env", "fail", "--api-token", "s3cr3t"}, 1, sentry.SpanStatusInternalError, 1},
+		{[]string{"deploy", "--env", "exit"}, 3, sentry.SpanStatusInternalError, 1},
+	}
+	for _, tt := range tests {
+		events, transactions = nil, nil
+		root := newRoot()
+		root.SetArgs(tt.args)
+		if code := sentrycobra.Execute(root, sentrycobra.Options{}); code != tt.wantCode {
+			t.Errorf("%v: got exit code %d, want %d", tt.args, code, tt.wantCode)
+		}
+		if len(transactions) != 1 {
+			t.Fatalf("%v: got %d transactions, want 1", tt.args, len(transactions))
+		}
+		if diff := cmp.Diff("app deploy", transactions[0].Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantStatus, transactions[0].Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+		if len(events) != tt.wantEvents {
+			t.Fatalf("%v: got %d events, want %d", tt.args, len(events), tt.wantEvents)
+		}
+	}
+
+	events = nil
+	root := newRoot()
+	root.SetArgs([]string{"deploy", "--env", "fail", "--api-token", "s3cr3t"})
+	sentrycobra.Execute(root, sentrycobra.Options{})
+	event := events[0]
+	if diff := cmp.Diff("app deploy", event.Tags["cobra.command"]); diff != "" {
+		t.Errorf("command tag mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{
+		"env":       "fail",
+		"api-token": "[Filtered]",
+	}, event.Contexts["cobra"]["flags"]); diff != "" {
+		t.Errorf("flags mismatch (-want +got):\n%s", diff)
+	}
+
+	events = nil
+	root = newRoot()
+	root.SetArgs([]string{"crash"})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		sentrycobra.Execute(root, sentrycobra.Options{})
+	}()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, events[0].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("app crash", events[0].Tags["cobra.command"]); diff != "" {
+		t.Errorf("command tag mismatch (-want +got):\n%s", diff)
+	}
+}
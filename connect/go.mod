@@ -0,0 +1,17 @@
+module github.com/getsentry/sentry-go/connect
+
+go 1.21
+
+require (
+	connectrpc.com/connect v1.16.2
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
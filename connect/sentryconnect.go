@@ -0,0 +1,265 @@
+// Package sentryconnect provides Sentry instrumentation for Connect clients
+// and handlers built with connectrpc.com/connect.
+//
+// Register the interceptor with both the handlers and the clients:
+//
+//	interceptor := sentryconnect.NewInterceptor(sentryconnect.Options{})
+//	mux.Handle(greetv1connect.NewGreetServiceHandler(greeter, connect.WithInterceptors(interceptor)))
+//	client := greetv1connect.NewGreetServiceClient(http.DefaultClient, url, connect.WithInterceptors(interceptor))
+//
+// Handlers run in "rpc.server" transactions named after the procedure, which
+// continue the trace propagated by clients in the sentry-trace and baggage
+// headers. Clients record "rpc.client" spans when the context of calls
+// contains a span. The status of transactions and spans is derived from the
+// code of the connect.Error returned by calls.
+package sentryconnect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/getsentry/sentry-go"
+)
+
+// The identifier of the Connect SDK.
+const sdkIdentifier = "sentry.go.connect"
+
+const (
+	// serverOperation is the operation of the transactions of handlers.
+	serverOperation = "rpc.server"
+	// clientOperation is the operation of the spans of client calls.
+	clientOperation = "rpc.client"
+)
+
+// spanOrigin is the origin of the transactions and spans of calls.
+const spanOrigin = "auto.rpc.connect"
+
+// Options configure the interceptor.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a
+	// panic in a handler. Use it with the connect.WithRecover handler
+	// option. Otherwise, the panic is returned to the client as an internal
+	// error.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// Interceptor is a connect.Interceptor instrumenting unary and streaming
+// calls, of both clients and handlers.
+type Interceptor struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+var _ connect.Interceptor = (*Interceptor)(nil)
+
+// NewInterceptor returns an Interceptor.
+func NewInterceptor(options Options) *Interceptor {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &Interceptor{
+		repanic:         options.Repanic,
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (res connect.AnyResponse, err error) {
+		if req.Spec().IsClient {
+			span := startClientSpan(ctx, req.Spec(), req.Peer(), req.Header())
+			if span == nil {
+				return next(ctx, req)
+			}
+			defer span.Finish()
+			res, err = next(span.Context(), req)
+			setStatus(span, err)
+			return res, err
+		}
+
+		ctx, transaction := i.startTransaction(ctx, req.Spec(), req.Header())
+		defer func() {
+			setStatus(transaction, err)
+			transaction.Finish()
+		}()
+		defer i.recoverWithSentry(ctx, &err)
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		span := startClientSpan(ctx, spec, conn.Peer(), conn.RequestHeader())
+		if span == nil {
+			return conn
+		}
+		return &streamingClientConn{StreamingClientConn: conn, span: span}
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) (err error) {
+		ctx, transaction := i.startTransaction(ctx, conn.Spec(), conn.RequestHeader())
+		defer func() {
+			setStatus(transaction, err)
+			transaction.Finish()
+		}()
+		defer i.recoverWithSentry(ctx, &err)
+		return next(ctx, conn)
+	}
+}
+
+// startTransaction starts the transaction of a call to a handler, continuing
+// the trace of its headers, with a clone of the hub of ctx or of the current
+// hub.
+func (i *Interceptor) startTransaction(ctx context.Context, spec connect.Spec, header http.Header) (context.Context, *sentry.Span) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	service, method := splitProcedure(spec.Procedure)
+	hub.Scope().SetContext("rpc", sentry.Context{
+		"system":    "connect_rpc",
+		"service":   service,
+		"method":    method,
+		"procedure": spec.Procedure,
+		"stream":    streamType(spec.StreamType),
+	})
+
+	transaction := sentry.StartTransaction(sentry.SetHubOnContext(ctx, hub), spec.Procedure,
+		sentry.WithOpName(serverOperation),
+		sentry.WithTransactionSource(sentry.SourceRoute),
+		sentry.ContinueFromRequest(&http.Request{Header: header}),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	setData(transaction, spec)
+	return transaction.Context(), transaction
+}
+
+// recoverWithSentry reports panics of handlers and sets *err to an internal
+// error, marking the transaction as such, before repanicking if configured.
+func (i *Interceptor) recoverWithSentry(ctx context.Context, err *error) {
+	if r := recover(); r != nil {
+		hub := sentry.GetHubFromContext(ctx)
+		eventID := hub.RecoverWithContext(ctx, r)
+		if eventID != nil && i.waitForDelivery {
+			hub.Flush(i.timeout)
+		}
+		*err = connect.NewError(connect.CodeInternal, fmt.Errorf("panic: %v", r))
+		if i.repanic {
+			panic(r)
+		}
+	}
+}
+
+// startClientSpan starts the span of a call of a client, and propagates its
+// trace in the headers of the call, if ctx contains a span.
+func startClientSpan(ctx context.Context, spec connect.Spec, peer connect.Peer, header http.Header) *sentry.Span {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+	span := parent.StartChild(clientOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.Description = spec.Procedure
+	setData(span, spec)
+	if peer.Addr != "" {
+		span.SetData("server.address", peer.Addr)
+	}
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	// The targets of the trace propagation are matched against the address
+	// of the server followed by the procedure, as the URL of calls isn't
+	// available to interceptors.
+	if client := hub.Client(); client == nil ||
+		client.ShouldPropagateTrace(peer.Addr+spec.Procedure) {
+		header.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+		if baggage := span.ToBaggage(); baggage != "" {
+			header.Set(sentry.SentryBaggageHeader, baggage)
+		}
+	}
+	return span
+}
+
+// streamingClientConn finishes the span of a streaming call once its response
+// is closed.
+type streamingClientConn struct {
+	connect.StreamingClientConn
+	span *sentry.Span
+}
+
+func (c *streamingClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	setStatus(c.span, err)
+	c.span.Finish()
+	return err
+}
+
+// setData sets the RPC data of a span.
+func setData(span *sentry.Span, spec connect.Spec) {
+	service, method := splitProcedure(spec.Procedure)
+	span.SetData("rpc.system", "connect_rpc")
+	span.SetData("rpc.service", service)
+	span.SetData("rpc.method", method)
+}
+
+// setStatus sets the status of a span from the code of the error of a call.
+func setStatus(span *sentry.Span, err error) {
+	if err == nil {
+		span.Status = sentry.SpanStatusOK
+		return
+	}
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		span.SetGRPCCode(uint32(connectErr.Code()))
+		return
+	}
+	span.SetError(err)
+}
+
+// splitProcedure splits a procedure, such as "/acme.foo.v1.FooService/Bar",
+// into its service and method.
+func splitProcedure(procedure string) (service, method string) {
+	procedure = strings.TrimPrefix(procedure, "/")
+	if i := strings.LastIndex(procedure, "/"); i >= 0 {
+		return procedure[:i], procedure[i+1:]
+	}
+	return procedure, ""
+}
+
+// streamType returns the name of a stream type.
+func streamType(t connect.StreamType) string {
+	switch t {
+	case connect.StreamTypeUnary:
+		return "unary"
+	case connect.StreamTypeClient:
+		return "client"
+	case connect.StreamTypeServer:
+		return "server"
+	default:
+		return "bidi"
+	}
+}
@@ -0,0 +1,152 @@
+package sentryconnect_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/getsentry/sentry-go"
+	sentryconnect "github.com/getsentry/sentry-go/connect"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const procedure = "/test.v1.GreetService/Greet"
+
+func TestInterceptor(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interceptor := sentryconnect.NewInterceptor(sentryconnect.Options{})
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			switch req.Msg.Value {
+			case "panic":
+				panic("test")
+			case "":
+				return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("empty name"))
+			}
+			return connect.NewResponse(wrapperspb.String("hello " + req.Msg.Value)), nil
+		},
+		connect.WithInterceptors(interceptor),
+	))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		srv.Client(), srv.URL+procedure, connect.WithInterceptors(interceptor),
+	)
+
+	tests := []struct {
+		name       string
+		wantStatus sentry.SpanStatus
+		wantCode   connect.Code
+	}{
+		{"alice", sentry.SpanStatusOK, 0},
+		{"", sentry.SpanStatusInvalidArgument, connect.CodeInvalidArgument},
+		{"panic", sentry.SpanStatusInternalError, connect.CodeInternal},
+	}
+	for _, tt := range tests {
+		transactions = nil
+		parent := sentry.StartTransaction(context.Background(), "client")
+		_, err := client.CallUnary(parent.Context(), connect.NewRequest(wrapperspb.String(tt.name)))
+		parent.Finish()
+
+		if code := connect.CodeOf(err); err != nil && code != tt.wantCode || err == nil && tt.wantCode != 0 {
+			t.Errorf("%q: got error %v, want code %v", tt.name, err, tt.wantCode)
+		}
+		if len(transactions) != 2 {
+			t.Fatalf("%q: got %d transactions, want 2", tt.name, len(transactions))
+		}
+		server, clientTransaction := transactions[0], transactions[1]
+		if diff := cmp.Diff(procedure, server.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantStatus, server.Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff("rpc.server", server.Contexts["trace"]["op"]); diff != "" {
+			t.Errorf("transaction operation mismatch (-want +got):\n%s", diff)
+		}
+
+		if len(clientTransaction.Spans) != 1 {
+			t.Fatalf("%q: got %d client spans, want 1", tt.name, len(clientTransaction.Spans))
+		}
+		span := clientTransaction.Spans[0]
+		if diff := cmp.Diff(tt.wantStatus, span.Status); diff != "" {
+			t.Errorf("client span status mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(span.TraceID, server.Contexts["trace"]["trace_id"]); diff != "" {
+			t.Errorf("trace ID mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(span.SpanID, server.Contexts["trace"]["parent_span_id"]); diff != "" {
+			t.Errorf("parent span ID mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, events[0].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("test.v1.GreetService", events[0].Contexts["rpc"]["service"]); diff != "" {
+		t.Errorf("rpc context mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInterceptorRepanic(t *testing.T) {
+	var transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interceptor := sentryconnect.NewInterceptor(sentryconnect.Options{Repanic: true})
+	unary := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		panic("test")
+	})
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("got no panic, want a repanic")
+			}
+		}()
+		_, _ = unary(context.Background(), connect.NewRequest(wrapperspb.String("panic")))
+	}()
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transactions[0].Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,111 @@
+package sentry
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// DeviceContext describes the device that caused an event, using the keys
+// recognized by Sentry.
+//
+// See https://develop.sentry.dev/sdk/event-payloads/contexts/#device-context.
+type DeviceContext struct {
+	Name               string     `json:"name,omitempty"`
+	Family             string     `json:"family,omitempty"`
+	Model              string     `json:"model,omitempty"`
+	ModelID            string     `json:"model_id,omitempty"`
+	Arch               string     `json:"arch,omitempty"`
+	Manufacturer       string     `json:"manufacturer,omitempty"`
+	Brand              string     `json:"brand,omitempty"`
+	Simulator          *bool      `json:"simulator,omitempty"`
+	MemorySize         int64      `json:"memory_size,omitempty"`
+	FreeMemory         int64      `json:"free_memory,omitempty"`
+	UsableMemory       int64      `json:"usable_memory,omitempty"`
+	StorageSize        int64      `json:"storage_size,omitempty"`
+	FreeStorage        int64      `json:"free_storage,omitempty"`
+	BootTime           *time.Time `json:"boot_time,omitempty"`
+	Timezone           string     `json:"timezone,omitempty"`
+	ProcessorCount     int        `json:"processor_count,omitempty"`
+	CPUDescription     string     `json:"cpu_description,omitempty"`
+	ProcessorFrequency float64    `json:"processor_frequency,omitempty"`
+}
+
+// OSContext describes the operating system on which an event was created,
+// using the keys recognized by Sentry.
+//
+// See https://develop.sentry.dev/sdk/event-payloads/contexts/#os-context.
+type OSContext struct {
+	Name           string `json:"name,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Build          string `json:"build,omitempty"`
+	KernelVersion  string `json:"kernel_version,omitempty"`
+	Rooted         *bool  `json:"rooted,omitempty"`
+	RawDescription string `json:"raw_description,omitempty"`
+}
+
+// RuntimeContext describes the runtime in which an event was created, using the
+// keys recognized by Sentry.
+//
+// See https://develop.sentry.dev/sdk/event-payloads/contexts/#runtime-context.
+type RuntimeContext struct {
+	Name           string `json:"name,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Build          string `json:"build,omitempty"`
+	RawDescription string `json:"raw_description,omitempty"`
+}
+
+// AppContext describes the application in which an event was created, using
+// the keys recognized by Sentry.
+//
+// See https://develop.sentry.dev/sdk/event-payloads/contexts/#app-context.
+type AppContext struct {
+	AppStartTime  *time.Time `json:"app_start_time,omitempty"`
+	DeviceAppHash string     `json:"device_app_hash,omitempty"`
+	BuildType     string     `json:"build_type,omitempty"`
+	AppIdentifier string     `json:"app_identifier,omitempty"`
+	AppName       string     `json:"app_name,omitempty"`
+	AppVersion    string     `json:"app_version,omitempty"`
+	AppBuild      string     `json:"app_build,omitempty"`
+	AppMemory     int64      `json:"app_memory,omitempty"`
+	InForeground  *bool      `json:"in_foreground,omitempty"`
+}
+
+// SetDeviceContext sets the "device" context of the current scope.
+func (scope *Scope) SetDeviceContext(device DeviceContext) {
+	scope.SetContext("device", toContext(device))
+}
+
+// SetOSContext sets the "os" context of the current scope.
+func (scope *Scope) SetOSContext(os OSContext) {
+	scope.SetContext("os", toContext(os))
+}
+
+// SetRuntimeContext sets the "runtime" context of the current scope.
+func (scope *Scope) SetRuntimeContext(runtime RuntimeContext) {
+	scope.SetContext("runtime", toContext(runtime))
+}
+
+// SetAppContext sets the "app" context of the current scope.
+func (scope *Scope) SetAppContext(app AppContext) {
+	scope.SetContext("app", toContext(app))
+}
+
+// toContext converts a typed context to its generic form, keeping the JSON
+// keys and omitting empty fields. Numbers are kept as json.Number, which
+// serializes to the original value.
+func toContext(v interface{}) Context {
+	b, err := json.Marshal(v)
+	if err != nil {
+		debugf(LevelError, "Could not encode context %T: %v", v, err)
+		return Context{}
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var c Context
+	if err := dec.Decode(&c); err != nil {
+		debugf(LevelError, "Could not decode context %T: %v", v, err)
+		return Context{}
+	}
+	return c
+}
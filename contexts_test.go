@@ -0,0 +1,51 @@
+package sentry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestScopeSetTypedContexts(t *testing.T) {
+	scope := NewScope()
+	simulator := false
+	bootTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	scope.SetDeviceContext(DeviceContext{
+		Model:          "m5.large",
+		Arch:           "amd64",
+		Simulator:      &simulator,
+		MemorySize:     8589934592,
+		BootTime:       &bootTime,
+		ProcessorCount: 2,
+	})
+	scope.SetOSContext(OSContext{Name: "linux", KernelVersion: "6.1.0"})
+	scope.SetRuntimeContext(RuntimeContext{Name: "go", Version: "go1.21.0"})
+	scope.SetAppContext(AppContext{AppName: "api", AppVersion: "1.2.3"})
+
+	got, err := json.Marshal(scope.contexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{` +
+		`"app":{"app_name":"api","app_version":"1.2.3"},` +
+		`"device":{"arch":"amd64","boot_time":"2023-01-02T03:04:05Z","memory_size":8589934592,"model":"m5.large","processor_count":2,"simulator":false},` +
+		`"os":{"kernel_version":"6.1.0","name":"linux"},` +
+		`"runtime":{"name":"go","version":"go1.21.0"}` +
+		`}`
+	assertEqual(t, string(got), want)
+}
+
+func TestTypedContextsMergeWithEnvironment(t *testing.T) {
+	scope := NewScope()
+	scope.SetDeviceContext(DeviceContext{Model: "m5.large"})
+
+	event := scope.ApplyToEvent(NewEvent(), nil)
+	event = new(environmentIntegration).processor(event, nil)
+
+	device := event.Contexts["device"]
+	assertEqual(t, device["model"], "m5.large")
+	if _, ok := device["arch"]; !ok {
+		t.Error("the environment integration should fill in missing device keys")
+	}
+}
@@ -0,0 +1,228 @@
+package sentry
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// profileChunkType is the type of a continuous profiling envelope item.
+const profileChunkType = "profile_chunk"
+
+// profileChunkDuration is the duration of the chunks sent by the continuous
+// profiler. It must be shorter than the buffer of the profile recorder.
+const profileChunkDuration = 10 * time.Second
+
+type profileChunkSample struct {
+	Timestamp float64 `json:"timestamp"`
+	StackID   int     `json:"stack_id"`
+	ThreadID  string  `json:"thread_id"`
+}
+
+type profileChunkTrace struct {
+	Frames         []*Frame                          `json:"frames"`
+	Samples        []profileChunkSample              `json:"samples"`
+	Stacks         []profileStack                    `json:"stacks"`
+	ThreadMetadata map[uint64]*profileThreadMetadata `json:"thread_metadata"`
+}
+
+// profileChunk is a continuous profiling chunk. Chunks of the same profiler
+// session share their profiler ID.
+//
+// See https://develop.sentry.dev/sdk/telemetry/profiles/.
+type profileChunk struct {
+	ChunkID     EventID           `json:"chunk_id"`
+	ProfilerID  EventID           `json:"profiler_id"`
+	ClientSDK   SdkInfo           `json:"client_sdk"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Platform    string            `json:"platform"`
+	Version     string            `json:"version"`
+	Profile     profileChunkTrace `json:"profile"`
+}
+
+// continuousProfiler samples the stacks of all goroutines until stopped, and
+// sends them to Sentry in chunks.
+type continuousProfiler struct {
+	client   *Client
+	id       EventID
+	interval time.Duration
+	budget   float64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+var (
+	continuousProfilerMu     sync.Mutex
+	activeContinuousProfiler *continuousProfiler
+)
+
+// StartProfiler starts the continuous profiler, which samples the stacks of
+// all goroutines at ClientOptions.ProfilerSampleFrequency and sends them to
+// Sentry in chunks, using the client of the current hub. Transactions
+// finished while the profiler runs are linked to its profiles. It does
+// nothing if the profiler is already running.
+func StartProfiler() {
+	client := CurrentHub().Client()
+	if client == nil || client.dsn == nil {
+		debugf(LevelWarning, "Profiler not started: no client or DSN.")
+		return
+	}
+
+	continuousProfilerMu.Lock()
+	defer continuousProfilerMu.Unlock()
+
+	if activeContinuousProfiler != nil {
+		return
+	}
+
+	frequency := client.options.ProfilerSampleFrequency
+	if frequency <= 0 || frequency > profilerSamplingRateHz {
+		frequency = profilerSamplingRateHz
+	}
+	budget := client.options.ProfilerOverheadBudget
+	if budget < 0 || budget >= 1 {
+		budget = 0
+	}
+
+	p := &continuousProfiler{
+		client:   client,
+		id:       client.newEventID(),
+		interval: time.Second / time.Duration(frequency),
+		budget:   budget,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	activeContinuousProfiler = p
+	go p.run()
+}
+
+// StopProfiler stops the continuous profiler and sends its last chunk.
+func StopProfiler() {
+	continuousProfilerMu.Lock()
+	p := activeContinuousProfiler
+	activeContinuousProfiler = nil
+	continuousProfilerMu.Unlock()
+
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+}
+
+// continuousProfilerID returns the ID of the running continuous profiler, if
+// any.
+func continuousProfilerID() EventID {
+	continuousProfilerMu.Lock()
+	defer continuousProfilerMu.Unlock()
+
+	if activeContinuousProfiler == nil {
+		return ""
+	}
+	return activeContinuousProfiler.id
+}
+
+func (p *continuousProfiler) run() {
+	defer close(p.done)
+	// We shouldn't panic but let's be super safe.
+	defer func() {
+		if err := recover(); err != nil {
+			debugf(LevelError, "Continuous profiler panic in run(): %v\n", err)
+		}
+	}()
+
+	onProfilerStart()
+	recorder := newProfiler(time.Now())
+	nextSample := p.sample(recorder)
+
+	ticker := profilerTickerFactory(p.interval)
+	defer ticker.Stop()
+	tickerChannel := ticker.TickSource()
+
+	for {
+		select {
+		case now := <-tickerChannel:
+			if now.Sub(recorder.startTime) >= profileChunkDuration {
+				p.sendChunk(recorder, now)
+				recorder = newProfiler(now)
+			}
+			// Samples are skipped while the time spent sampling exceeds the
+			// overhead budget.
+			if !now.Before(nextSample) {
+				nextSample = p.sample(recorder)
+			}
+			ticker.Ticked()
+		case <-p.stop:
+			p.sendChunk(recorder, time.Now())
+			return
+		}
+	}
+}
+
+// sample collects a sample and returns the earliest time of the next one
+// within the overhead budget.
+func (p *continuousProfiler) sample(recorder *profileRecorder) time.Time {
+	start := time.Now()
+	recorder.onTick()
+	if p.budget == 0 {
+		return time.Time{}
+	}
+	return start.Add(time.Duration(float64(time.Since(start)) / p.budget))
+}
+
+// sendChunk sends the samples collected by recorder until end.
+func (p *continuousProfiler) sendChunk(recorder *profileRecorder, end time.Time) {
+	result := recorder.GetSlice(recorder.startTime, end)
+	if result == nil || result.trace == nil {
+		return
+	}
+
+	start := float64(recorder.startTime.UnixNano()) / float64(time.Second)
+	samples := make([]profileChunkSample, len(result.trace.Samples))
+	for i, sample := range result.trace.Samples {
+		samples[i] = profileChunkSample{
+			Timestamp: start + float64(sample.ElapsedSinceStartNS)/float64(time.Second),
+			StackID:   sample.StackID,
+			ThreadID:  strconv.FormatUint(sample.ThreadID, 10),
+		}
+	}
+
+	options := p.client.Options()
+	payload, err := json.Marshal(profileChunk{
+		ChunkID:    p.client.newEventID(),
+		ProfilerID: p.id,
+		ClientSDK: SdkInfo{
+			Name:    p.client.GetSDKIdentifier(),
+			Version: p.client.sdkVersion,
+		},
+		Environment: options.Environment,
+		Release:     options.Release,
+		Platform:    "go",
+		Version:     "2",
+		Profile: profileChunkTrace{
+			Frames:         result.trace.Frames,
+			Samples:        samples,
+			Stacks:         result.trace.Stacks,
+			ThreadMetadata: result.trace.ThreadMetadata,
+		},
+	})
+	if err != nil {
+		debugf(LevelError, "Profile chunk couldn't be marshaled: %v", err)
+		return
+	}
+	envelope := NewEnvelope(EnvelopeHeader{
+		SentAt: time.Now(),
+		Dsn:    p.client.dsn.String(),
+		Sdk: map[string]string{
+			"name":    p.client.GetSDKIdentifier(),
+			"version": p.client.sdkVersion,
+		},
+	})
+	envelope.AddItem(&EnvelopeItem{
+		Type:    profileChunkType,
+		Payload: payload,
+	})
+	p.client.Transport.SendEnvelope(envelope)
+}
@@ -0,0 +1,78 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContinuousProfiler(t *testing.T) {
+	var require = require.New(t)
+	ticker := setupProfilerTestTicker(io.Discard)
+	defer restoreProfilerTicker()
+
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:              "http://whatever@example.com/1337",
+		Transport:        transport,
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Release:          "rel",
+	})
+	require.NoError(err)
+	currentHub.BindClient(client)
+	defer currentHub.stackTop().SetClient(nil)
+
+	start := time.Now()
+	StartProfiler()
+	defer StopProfiler()
+	id := continuousProfilerID()
+	require.NotEmpty(id)
+
+	transaction := StartSpan(context.Background(), "op", WithTransactionName("transaction"))
+	require.True(ticker.Tick())
+	require.True(ticker.Tick())
+	transaction.Finish()
+	StopProfiler()
+	require.Empty(continuousProfilerID())
+
+	events := transport.Events()
+	require.Len(events, 1)
+	require.Equal(Context{"profiler_id": id}, events[0].Contexts["profile"])
+
+	require.Len(transport.envelopes, 1)
+	item := transport.envelopes[0].Items[0]
+	require.Equal(profileChunkType, item.Type)
+	var chunk profileChunk
+	require.NoError(json.Unmarshal(item.Payload, &chunk))
+	require.Equal(id, chunk.ProfilerID)
+	require.NotEmpty(chunk.ChunkID)
+	require.Equal("rel", chunk.Release)
+	require.Equal("go", chunk.Platform)
+	require.Equal("2", chunk.Version)
+	require.NotEmpty(chunk.Profile.Samples)
+	require.NotEmpty(chunk.Profile.Frames)
+	for _, sample := range chunk.Profile.Samples {
+		require.GreaterOrEqual(sample.Timestamp, float64(start.UnixNano())/float64(time.Second))
+		require.LessOrEqual(sample.Timestamp, float64(time.Now().UnixNano())/float64(time.Second))
+		require.NotEmpty(sample.ThreadID)
+		require.Less(sample.StackID, len(chunk.Profile.Stacks))
+	}
+}
+
+func TestContinuousProfilerOverheadBudget(t *testing.T) {
+	recorder := newProfiler(time.Now())
+
+	p := &continuousProfiler{}
+	require.True(t, p.sample(recorder).IsZero())
+
+	p.budget = 0.01
+	start := time.Now()
+	next := p.sample(recorder)
+	// The next sample is 100 times the sampling time away.
+	require.Greater(t, next.Sub(start), time.Since(start))
+}
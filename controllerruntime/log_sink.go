@@ -0,0 +1,132 @@
+package sentrycontrollerruntime
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-logr/logr"
+)
+
+// logSink reports the errors logged with logr to Sentry.
+type logSink struct {
+	sink    logr.LogSink
+	name    string
+	values  []interface{}
+	limiter *errorLimiter
+}
+
+// NewLogSink returns a logr.LogSink reporting the errors logged to the current
+// hub, and writing all logs to sink, if not nil. Identical errors, with the
+// same logger name, message and error, are reported once per ErrorInterval.
+// The errors returned by reconcilers wrapped with WrapReconciler, already
+// reported, are not reported again. Set it as the logger of controller-runtime
+// to report the errors of its components:
+//
+//	ctrl.SetLogger(logr.New(sentrycontrollerruntime.NewLogSink(zap.New().GetSink(), sentrycontrollerruntime.Options{})))
+func NewLogSink(sink logr.LogSink, options Options) logr.LogSink {
+	return &logSink{
+		sink:    sink,
+		limiter: newErrorLimiter(options.ErrorInterval),
+	}
+}
+
+func (s *logSink) Init(info logr.RuntimeInfo) {
+	if s.sink != nil {
+		// Skip the frame of this sink.
+		info.CallDepth++
+		s.sink.Init(info)
+	}
+}
+
+func (s *logSink) Enabled(level int) bool {
+	if s.sink != nil {
+		return s.sink.Enabled(level)
+	}
+	return level == 0
+}
+
+func (s *logSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if s.sink != nil {
+		s.sink.Info(level, msg, keysAndValues...)
+	}
+}
+
+func (s *logSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if s.sink != nil {
+		s.sink.Error(err, msg, keysAndValues...)
+	}
+
+	log := sentry.Context{"message": msg}
+	if s.name != "" {
+		log["logger"] = s.name
+	}
+	values := append(append([]interface{}{}, s.values...), keysAndValues...)
+	for i := 0; i+1 < len(values); i += 2 {
+		log[fmt.Sprint(values[i])] = logValue(values[i+1])
+	}
+
+	// The errors returned by wrapped reconcilers are already reported.
+	var reconcileErr *reconcileError
+	if errors.As(err, &reconcileErr) {
+		return
+	}
+	// The values are not part of the key, since they may differ for every
+	// log of an identical error, like the reconcileID of controller-runtime.
+	key := s.name + ": " + msg
+	if err != nil {
+		key += ": " + err.Error()
+	}
+	if !s.limiter.allow(key) {
+		return
+	}
+
+	hub := sentry.CurrentHub()
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetContext("log", log)
+		if s.name != "" {
+			scope.SetTag("logger", s.name)
+		}
+		if err != nil {
+			hub.CaptureException(err)
+		} else {
+			scope.SetLevel(sentry.LevelError)
+			hub.CaptureMessage(msg)
+		}
+	})
+}
+
+func (s *logSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	c := *s
+	c.values = append(append([]interface{}{}, s.values...), keysAndValues...)
+	if s.sink != nil {
+		c.sink = s.sink.WithValues(keysAndValues...)
+	}
+	return &c
+}
+
+func (s *logSink) WithName(name string) logr.LogSink {
+	c := *s
+	if c.name != "" {
+		c.name += "." + name
+	} else {
+		c.name = name
+	}
+	if s.sink != nil {
+		c.sink = s.sink.WithName(name)
+	}
+	return &c
+}
+
+// logValue returns the value of a key-value pair logged with logr, as a
+// string unless it is a basic type.
+func logValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
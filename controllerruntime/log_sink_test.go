@@ -0,0 +1,74 @@
+package sentrycontrollerruntime_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrycontrollerruntime "github.com/getsentry/sentry-go/controllerruntime"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestLogSink(t *testing.T) {
+	var events []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	sink := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{}).GetSink()
+	logger := logr.New(sentrycontrollerruntime.NewLogSink(sink, sentrycontrollerruntime.Options{})).
+		WithName("controller").
+		WithValues("controller", "deployment")
+
+	logger.Info("Starting workers", "count", 2)
+	logger.Error(errors.New("conflict"), "Reconciler error", "namespace", "default", "name", "web", "reconcileID", "a1")
+	logger.Error(errors.New("conflict"), "Reconciler error", "namespace", "default", "name", "web", "reconcileID", "b2")
+	logger.Error(nil, "Watch failed")
+	// The errors of wrapped reconcilers are reported by the wrapper.
+	r := sentrycontrollerruntime.WrapReconciler(schema.GroupVersionKind{Kind: "Deployment"}, reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, errors.New("image not found")
+	}), sentrycontrollerruntime.Options{})
+	_, err = r.Reconcile(context.Background(), reconcile.Request{})
+	events = events[:len(events)-1]
+	logger.Error(err, "Reconciler error", "reconcileID", "c3")
+
+	if len(lines) != 5 {
+		t.Errorf("got %d lines written to the wrapped sink, want 5", len(lines))
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("conflict", events[0].Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.Context{
+		"logger":      "controller",
+		"message":     "Reconciler error",
+		"controller":  "deployment",
+		"namespace":   "default",
+		"name":        "web",
+		"reconcileID": "a1",
+	}, events[0].Contexts["log"]); diff != "" {
+		t.Errorf("log context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("Watch failed", events[1].Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelError, events[1].Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+}
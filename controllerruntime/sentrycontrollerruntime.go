@@ -0,0 +1,203 @@
+// Package sentrycontrollerruntime provides Sentry integration for Kubernetes
+// operators built with sigs.k8s.io/controller-runtime.
+//
+// Wrap the reconcilers of controllers to run each reconciliation in a
+// "k8s.reconcile" transaction and report the errors it returns, tagged with
+// the group, version and kind of the reconciled object, and its namespace
+// and name:
+//
+//	err := ctrl.NewControllerManagedBy(mgr).
+//		For(&appsv1.Deployment{}).
+//		Complete(sentrycontrollerruntime.WrapReconciler(
+//			appsv1.SchemeGroupVersion.WithKind("Deployment"),
+//			reconciler,
+//			sentrycontrollerruntime.Options{},
+//		))
+//
+// Reconciliations failing repeatedly with the same error are reported once
+// per ErrorInterval, so that requeued reconciliations don't flood Sentry. The
+// errors returned by the wrapped reconcilers wrap the errors of reconcilers,
+// which errors.Is and errors.As see through.
+//
+// NewLogSink reports the errors logged with logr, for example by the
+// components of controller-runtime, in addition to writing them to another
+// sink.
+package sentrycontrollerruntime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The identifier of the controller-runtime SDK.
+const sdkIdentifier = "sentry.go.controllerruntime"
+
+// spanOperation is the operation of the transactions of reconciliations.
+const spanOperation = "k8s.reconcile"
+
+// spanOrigin is the origin of the transactions of reconciliations.
+const spanOrigin = "auto.k8s.controllerruntime"
+
+// defaultErrorInterval is the default minimum interval between reports of an
+// identical error.
+const defaultErrorInterval = time.Minute
+
+// Options configure the reconciler wrapper and the log sink.
+type Options struct {
+	// ErrorInterval is the minimum interval between two reports of an
+	// identical error, for the same object. Defaults to 1 minute. Set it to
+	// a negative duration to report all errors.
+	ErrorInterval time.Duration
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before panicking again for controller-runtime to handle the
+	// panic.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+type reconciler struct {
+	reconciler      reconcile.Reconciler
+	gvk             schema.GroupVersionKind
+	limiter         *errorLimiter
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// WrapReconciler returns a reconcile.Reconciler instrumenting r, which
+// reconciles objects of kind gvk.
+func WrapReconciler(gvk schema.GroupVersionKind, r reconcile.Reconciler, options Options) reconcile.Reconciler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &reconciler{
+		reconciler:      r,
+		gvk:             gvk,
+		limiter:         newErrorLimiter(options.ErrorInterval),
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+// Reconcile implements reconcile.Reconciler.
+func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	tags := map[string]string{
+		"k8s.group":     r.gvk.Group,
+		"k8s.version":   r.gvk.Version,
+		"k8s.kind":      r.gvk.Kind,
+		"k8s.namespace": req.Namespace,
+		"k8s.name":      req.Name,
+	}
+	for key, value := range tags {
+		if value == "" {
+			delete(tags, key)
+		}
+	}
+	hub.Scope().SetTags(tags)
+
+	transaction := sentry.StartTransaction(sentry.SetHubOnContext(ctx, hub), "Reconcile "+r.gvk.Kind,
+		sentry.WithOpName(spanOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.SetTag("k8s.kind", r.gvk.Kind)
+	defer func() {
+		if err != nil {
+			transaction.SetError(err)
+		} else if transaction.Status == sentry.SpanStatusUndefined {
+			transaction.Status = sentry.SpanStatusOK
+		}
+		transaction.Finish()
+	}()
+
+	ctx = transaction.Context()
+	defer r.recoverWithSentry(ctx, hub, transaction)
+	result, err = r.reconciler.Reconcile(ctx, req)
+	if err != nil {
+		if r.limiter.allow(r.gvk.String() + "/" + req.String() + ": " + err.Error()) {
+			hub.CaptureException(err)
+		}
+		err = &reconcileError{err}
+	}
+	return result, err
+}
+
+// reconcileError marks the errors of reconciliations already handled by the
+// reconciler wrapper, which controller-runtime logs again as "Reconciler
+// error", so that the log sink doesn't report them twice.
+type reconcileError struct {
+	error
+}
+
+func (e *reconcileError) Unwrap() error {
+	return e.error
+}
+
+// recoverWithSentry reports panics of reconciliations and panics again, for
+// controller-runtime to handle the panic.
+func (r *reconciler) recoverWithSentry(ctx context.Context, hub *sentry.Hub, transaction *sentry.Span) {
+	if v := recover(); v != nil {
+		transaction.Status = sentry.SpanStatusInternalError
+		eventID := hub.RecoverWithContext(ctx, v)
+		if eventID != nil && r.waitForDelivery {
+			hub.Flush(r.timeout)
+		}
+		panic(v)
+	}
+}
+
+// errorLimiter limits the reports of identical errors.
+type errorLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	reported map[string]time.Time
+}
+
+func newErrorLimiter(interval time.Duration) *errorLimiter {
+	if interval == 0 {
+		interval = defaultErrorInterval
+	}
+	return &errorLimiter{
+		interval: interval,
+		reported: make(map[string]time.Time),
+	}
+}
+
+// allow reports whether the error identified by key can be reported, and
+// records its report.
+func (l *errorLimiter) allow(key string) bool {
+	if l.interval < 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := l.reported[key]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+	// Forget the errors that can be reported again, so that the errors of
+	// deleted objects don't accumulate.
+	for k, last := range l.reported {
+		if now.Sub(last) >= l.interval {
+			delete(l.reported, k)
+		}
+	}
+	l.reported[key] = now
+	return true
+}
@@ -0,0 +1,99 @@
+package sentrycontrollerruntime_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrycontrollerruntime "github.com/getsentry/sentry-go/controllerruntime"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestWrapReconciler(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	r := sentrycontrollerruntime.WrapReconciler(gvk, reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		switch req.Name {
+		case "broken", "other":
+			return reconcile.Result{}, errors.New("image not found")
+		case "panic":
+			panic("test")
+		}
+		return reconcile.Result{}, nil
+	}), sentrycontrollerruntime.Options{})
+
+	reconcileObject := func(name string) {
+		_, _ = r.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: name},
+		})
+	}
+	reconcileObject("web")
+	// The errors of the same object are reported once per interval.
+	reconcileObject("broken")
+	reconcileObject("broken")
+	reconcileObject("other")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		reconcileObject("panic")
+	}()
+
+	var statuses []sentry.SpanStatus
+	for _, transaction := range transactions {
+		if diff := cmp.Diff("Reconcile Deployment", transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		statuses = append(statuses, transaction.Contexts["trace"]["status"].(sentry.SpanStatus))
+	}
+	if diff := cmp.Diff([]sentry.SpanStatus{
+		sentry.SpanStatusOK,
+		sentry.SpanStatusInternalError,
+		sentry.SpanStatusInternalError,
+		sentry.SpanStatusInternalError,
+		sentry.SpanStatusInternalError,
+	}, statuses); diff != "" {
+		t.Errorf("transaction statuses mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if diff := cmp.Diff(map[string]string{
+		"k8s.group":     "apps",
+		"k8s.version":   "v1",
+		"k8s.kind":      "Deployment",
+		"k8s.namespace": "default",
+		"k8s.name":      "broken",
+	}, events[0].Tags); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("other", events[1].Tags["k8s.name"]); diff != "" {
+		t.Errorf("name tag mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, events[2].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,101 @@
+package sentry
+
+import "sync"
+
+// DebugLogger receives the diagnostics of the SDK, such as the reasons events
+// are dropped, along with their level: LevelDebug for information, LevelWarning
+// for data that is dropped and LevelError for failures. Implement it to route
+// the diagnostics to a structured logger and filter them by level.
+type DebugLogger interface {
+	Logf(level Level, format string, args ...interface{})
+}
+
+// DebugLoggerFunc is a function implementing DebugLogger.
+type DebugLoggerFunc func(level Level, format string, args ...interface{})
+
+// Logf calls f.
+func (f DebugLoggerFunc) Logf(level Level, format string, args ...interface{}) {
+	f(level, format, args...)
+}
+
+// stdDebugLogger is the default DebugLogger, writing the diagnostics of all
+// levels to Logger.
+type stdDebugLogger struct{}
+
+func (stdDebugLogger) Logf(level Level, format string, args ...interface{}) {
+	Logger.Printf(format, args...)
+}
+
+var (
+	debugLoggerMu sync.RWMutex
+	debugLogger   DebugLogger = stdDebugLogger{}
+)
+
+// SetDebugLogger sets the DebugLogger receiving the diagnostics of the SDK,
+// instead of Logger. Pass nil to restore Logger. Like Logger, it is shared by
+// all clients, except the clients with a DebugLogger in their ClientOptions.
+func SetDebugLogger(logger DebugLogger) {
+	if logger == nil {
+		logger = stdDebugLogger{}
+	}
+	debugLoggerMu.Lock()
+	defer debugLoggerMu.Unlock()
+	debugLogger = logger
+}
+
+// debugf sends a diagnostic to the DebugLogger set with SetDebugLogger.
+func debugf(level Level, format string, args ...interface{}) {
+	debugLoggerMu.RLock()
+	logger := debugLogger
+	debugLoggerMu.RUnlock()
+	logger.Logf(level, format, args...)
+}
+
+// debugfTo sends a diagnostic to logger, or to the DebugLogger set with
+// SetDebugLogger if logger is nil.
+func debugfTo(logger DebugLogger, level Level, format string, args ...interface{}) {
+	if logger == nil {
+		debugf(level, format, args...)
+		return
+	}
+	logger.Logf(level, format, args...)
+}
+
+// debugf sends a diagnostic to the DebugLogger of the client, if any.
+func (client *Client) debugf(level Level, format string, args ...interface{}) {
+	var logger DebugLogger
+	if client != nil {
+		logger = client.options.DebugLogger
+	}
+	debugfTo(logger, level, format, args...)
+}
+
+// debugf sends a diagnostic to the DebugLogger of the client of the span, if
+// any.
+func (s *Span) debugf(level Level, format string, args ...interface{}) {
+	debugfTo(s.clientOptions().DebugLogger, level, format, args...)
+}
+
+// debugf sends a diagnostic to the DebugLogger of the client of the
+// transport, if any.
+func (t *HTTPTransport) debugf(level Level, format string, args ...interface{}) {
+	debugfTo(t.debugLogger, level, format, args...)
+}
+
+// debugf sends a diagnostic to the DebugLogger of the client of the
+// transport, if any.
+func (t *HTTPSyncTransport) debugf(level Level, format string, args ...interface{}) {
+	debugfTo(t.debugLogger, level, format, args...)
+}
+
+// debugf sends a diagnostic to the DebugLogger of the client of the
+// transport, if any.
+func (t *DryRunTransport) debugf(level Level, format string, args ...interface{}) {
+	debugfTo(t.debugLogger, level, format, args...)
+}
+
+// debugf sends a diagnostic to the DebugLogger of the client of the
+// transport, if any.
+func (t *WriterTransport) debugf(level Level, format string, args ...interface{}) {
+	debugfTo(t.debugLogger, level, format, args...)
+}
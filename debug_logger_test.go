@@ -0,0 +1,70 @@
+package sentry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDebugLogger(t *testing.T) {
+	type log struct {
+		level   Level
+		message string
+	}
+	var logs []log
+	client, err := NewClient(ClientOptions{
+		DebugLogger: DebugLoggerFunc(func(level Level, format string, args ...interface{}) {
+			logs = append(logs, log{level, fmt.Sprintf(format, args...)})
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.debugf(LevelWarning, "Event dropped due to %s.", "tests")
+	assertEqual(t, logs[len(logs)-1], log{LevelWarning, "Event dropped due to tests."})
+
+	// Other clients and the diagnostics not tied to a client still go to
+	// Logger.
+	other, err := NewClient(ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := len(logs)
+	var buf bytes.Buffer
+	Logger.SetOutput(&buf)
+	defer Logger.SetOutput(io.Discard)
+	other.debugf(LevelDebug, "Buffer flushed successfully.")
+	debugf(LevelDebug, "Buffer flushed successfully.")
+	assertEqual(t, len(logs), count)
+	if !strings.Contains(buf.String(), "[Sentry] ") || strings.Count(buf.String(), "Buffer flushed successfully.\n") != 2 {
+		t.Errorf("Logger output = %q", buf.String())
+	}
+
+	SetDebugLogger(DebugLoggerFunc(func(level Level, format string, args ...interface{}) {}))
+	defer SetDebugLogger(nil)
+	client.debugf(LevelError, "Sending failed.")
+	assertEqual(t, logs[len(logs)-1], log{LevelError, "Sending failed."})
+}
+
+func TestDebugLoggerTransports(t *testing.T) {
+	var messages []string
+	logger := DebugLoggerFunc(func(level Level, format string, args ...interface{}) {
+		messages = append(messages, fmt.Sprintf(format, args...))
+	})
+
+	NewDryRunTransport(nil).Configure(ClientOptions{DebugLogger: logger})
+	NewWriterTransport(io.Discard).Configure(ClientOptions{Dsn: "invalid", DebugLogger: logger})
+
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2: %q", len(messages), messages)
+	}
+	if !strings.Contains(messages[0], "DryRunTransport") {
+		t.Errorf("got message %q, want the DryRunTransport one", messages[0])
+	}
+	if !strings.Contains(messages[1], "invalid") {
+		t.Errorf("got message %q, want the invalid DSN one", messages[1])
+	}
+}
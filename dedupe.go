@@ -0,0 +1,115 @@
+package sentry
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDedupeWindow is the default time during which DedupeIntegration
+	// drops repeated events.
+	defaultDedupeWindow = time.Minute
+	// defaultDedupeFrames is the default number of stack frames compared by
+	// DedupeIntegration.
+	defaultDedupeFrames = 5
+)
+
+// DedupeIntegration drops error events that repeat an event sent less than
+// Window ago, protecting the quota of the project during error storms. Two
+// events are considered the same when they have the same message, exception
+// types and values, and innermost stack frames.
+//
+// Dropped events are reported to Sentry in client reports, unless
+// ClientOptions.DisableClientReports is set. The integration is not enabled by
+// default:
+//
+//	sentry.Init(sentry.ClientOptions{
+//		Integrations: func(integrations []sentry.Integration) []sentry.Integration {
+//			return append(integrations, &sentry.DedupeIntegration{Window: time.Minute})
+//		},
+//	})
+type DedupeIntegration struct {
+	// Window is the time during which repeated events are dropped, starting
+	// from the first one. Defaults to one minute.
+	Window time.Duration
+	// Frames is the number of innermost stack frames compared. Defaults to 5.
+	Frames int
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func (di *DedupeIntegration) Name() string {
+	return "Dedupe"
+}
+
+func (di *DedupeIntegration) SetupOnce(client *Client) {
+	if di.Window <= 0 {
+		di.Window = defaultDedupeWindow
+	}
+	if di.Frames <= 0 {
+		di.Frames = defaultDedupeFrames
+	}
+	di.seen = make(map[string]time.Time)
+	client.AddEventProcessor(di.processor)
+}
+
+func (di *DedupeIntegration) processor(event *Event, hint *EventHint) *Event {
+	if event.Type != "" {
+		// Only error events are deduplicated.
+		return event
+	}
+
+	key := di.key(event)
+	now := time.Now()
+
+	di.mu.Lock()
+	defer di.mu.Unlock()
+
+	if now.Sub(di.lastPrune) > di.Window {
+		for k, first := range di.seen {
+			if now.Sub(first) > di.Window {
+				delete(di.seen, k)
+			}
+		}
+		di.lastPrune = now
+	}
+
+	if first, ok := di.seen[key]; ok && now.Sub(first) <= di.Window {
+		debugf(LevelWarning, "Event [%s] dropped as a duplicate of an event sent at %s.", event.EventID, first.Format(time.RFC3339))
+		return nil
+	}
+	di.seen[key] = now
+	return event
+}
+
+// key identifies the events considered the same.
+func (di *DedupeIntegration) key(event *Event) string {
+	var b strings.Builder
+	b.WriteString(event.Message)
+	for _, exception := range event.Exception {
+		b.WriteByte('\n')
+		b.WriteString(exception.Type)
+		b.WriteByte(':')
+		b.WriteString(exception.Value)
+		if exception.Stacktrace == nil {
+			continue
+		}
+		frames := exception.Stacktrace.Frames
+		if len(frames) > di.Frames {
+			frames = frames[len(frames)-di.Frames:]
+		}
+		for _, frame := range frames {
+			b.WriteByte('\n')
+			b.WriteString(frame.Module)
+			b.WriteByte('.')
+			b.WriteString(frame.Function)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Lineno))
+		}
+	}
+	return b.String()
+}
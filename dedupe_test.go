@@ -0,0 +1,107 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDedupeIntegration(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:       "http://whatever@example.com/1337",
+		Transport: transport,
+		Integrations: func(integrations []Integration) []Integration {
+			return append(integrations, &DedupeIntegration{})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(client, NewScope())
+
+	for i := 0; i < 3; i++ {
+		hub.CaptureException(errors.New("connection refused"))
+	}
+	hub.CaptureException(errors.New("timeout"))
+	hub.CaptureMessage("hello")
+	hub.CaptureMessage("hello")
+
+	events := transport.Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	assertEqual(t, events[0].Exception[0].Value, "connection refused")
+	assertEqual(t, events[1].Exception[0].Value, "timeout")
+	assertEqual(t, events[2].Message, "hello")
+
+	client.Flush(time.Second)
+	var dropped int64
+	for _, envelope := range transport.envelopes {
+		assertEqual(t, envelope.Items[0].Type, "client_report")
+		var report struct {
+			DiscardedEvents []discardedEvent `json:"discarded_events"`
+		}
+		if err := json.Unmarshal(envelope.Items[0].Payload, &report); err != nil {
+			t.Fatal(err)
+		}
+		for _, discarded := range report.DiscardedEvents {
+			assertEqual(t, discarded.Reason, DropReasonEventProcessor)
+			assertEqual(t, discarded.Category, "error")
+			dropped += discarded.Quantity
+		}
+	}
+	assertEqual(t, dropped, int64(3))
+}
+
+func TestDedupeIntegrationWindow(t *testing.T) {
+	integration := &DedupeIntegration{Window: time.Hour}
+	integration.SetupOnce(&Client{})
+
+	event := &Event{Message: "hello"}
+	if integration.processor(event, nil) == nil {
+		t.Fatal("the first event should not be dropped")
+	}
+	if integration.processor(event, nil) != nil {
+		t.Fatal("a repeated event should be dropped")
+	}
+
+	// Simulate the window passing.
+	integration.seen[integration.key(event)] = time.Now().Add(-2 * time.Hour)
+	if integration.processor(event, nil) == nil {
+		t.Error("an event repeated after the window should not be dropped")
+	}
+
+	transaction := &Event{Type: transactionType, Transaction: "GET /"}
+	integration.processor(transaction, nil)
+	if integration.processor(transaction, nil) == nil {
+		t.Error("transactions should not be deduplicated")
+	}
+}
+
+func TestDedupeIntegrationComparesFrames(t *testing.T) {
+	integration := &DedupeIntegration{Frames: 1}
+	integration.SetupOnce(&Client{})
+
+	newEvent := func(function string) *Event {
+		return &Event{Exception: []Exception{{
+			Type:  "*errors.errorString",
+			Value: "failed",
+			Stacktrace: &Stacktrace{Frames: []Frame{
+				{Function: "main", Lineno: 1},
+				{Function: function, Lineno: 10},
+			}},
+		}}}
+	}
+
+	if integration.processor(newEvent("a"), nil) == nil {
+		t.Fatal("the first event should not be dropped")
+	}
+	if integration.processor(newEvent("b"), nil) == nil {
+		t.Error("events with different frames should not be dropped")
+	}
+	if integration.processor(newEvent("a"), nil) != nil {
+		t.Error("events with the same frames should be dropped")
+	}
+}
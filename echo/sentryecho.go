@@ -2,6 +2,8 @@ package sentryecho
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -12,7 +14,13 @@ import (
 // The identifier of the Echo SDK.
 const sdkIdentifier = "sentry.go.echo"
 
-const valuesKey = "sentry"
+const (
+	valuesKey      = "sentry"
+	transactionKey = "sentry_transaction"
+)
+
+// spanOrigin is the origin of the transactions of requests.
+const spanOrigin = "auto.http.echo"
 
 type handler struct {
 	repanic         bool
@@ -23,6 +31,8 @@ type handler struct {
 type Options struct {
 	// Repanic configures whether Sentry should repanic after recovery, in most cases it should be set to true,
 	// as echo includes it's own Recover middleware what handles http responses.
+	// Otherwise, the panic is passed to the HTTPErrorHandler of echo, which
+	// responds with an internal server error.
 	Repanic bool
 	// WaitForDelivery configures whether you want to block the request before moving forward with the response.
 	// Because Echo's Recover handler doesn't restart the application,
@@ -47,36 +57,89 @@ func New(options Options) echo.MiddlewareFunc {
 }
 
 func (h *handler) handle(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		hub := sentry.GetHubFromContext(ctx.Request().Context())
+	return func(ctx echo.Context) (err error) {
+		r := ctx.Request()
+		hub := sentry.GetHubFromContext(r.Context())
 		if hub == nil {
 			hub = sentry.CurrentHub().Clone()
 		}
 
 		hub.Client().SetSDKIdentifier(sdkIdentifier)
 
-		hub.Scope().SetRequest(ctx.Request())
+		transactionName := r.URL.Path
+		transactionSource := sentry.SourceURL
+		if path := ctx.Path(); path != "" {
+			transactionName = path
+			transactionSource = sentry.SourceRoute
+		}
+
+		transaction := sentry.StartTransaction(
+			sentry.SetHubOnContext(r.Context(), hub),
+			fmt.Sprintf("%s %s", r.Method, transactionName),
+			sentry.WithOpName("http.server"),
+			sentry.ContinueFromRequest(r),
+			sentry.WithTransactionSource(transactionSource),
+			sentry.WithSpanOrigin(spanOrigin),
+		)
+		defer func() {
+			transaction.Status = sentry.HTTPtoSpanStatus(responseStatus(ctx, err))
+			transaction.Finish()
+		}()
+
+		r = r.WithContext(transaction.Context())
+		ctx.SetRequest(r)
+		hub.Scope().SetRequest(r)
 		ctx.Set(valuesKey, hub)
-		defer h.recoverWithSentry(hub, ctx.Request())
+		ctx.Set(transactionKey, transaction)
+		defer h.recoverWithSentry(hub, ctx, &err)
 		return next(ctx)
 	}
 }
 
-func (h *handler) recoverWithSentry(hub *sentry.Hub, r *http.Request) {
-	if err := recover(); err != nil {
+// recoverWithSentry reports panics and sets *err to the recovered panic, so
+// that the transaction is marked as an internal error. It then either
+// repanics or lets *err be passed to the HTTPErrorHandler of echo.
+func (h *handler) recoverWithSentry(hub *sentry.Hub, ctx echo.Context, err *error) {
+	if r := recover(); r != nil {
+		request := ctx.Request()
 		eventID := hub.RecoverWithContext(
-			context.WithValue(r.Context(), sentry.RequestContextKey, r),
-			err,
+			context.WithValue(request.Context(), sentry.RequestContextKey, request),
+			r,
 		)
 		if eventID != nil && h.waitForDelivery {
 			hub.Flush(h.timeout)
 		}
+		if e, ok := r.(error); ok {
+			*err = fmt.Errorf("sentryecho: panic: %w", e)
+		} else {
+			*err = fmt.Errorf("sentryecho: panic: %v", r)
+		}
 		if h.repanic {
-			panic(err)
+			panic(r)
 		}
 	}
 }
 
+// responseStatus returns the status code of the response to a request handled
+// with err. The HTTPErrorHandler of echo only responds to errors once the
+// middlewares returned, so the status code is derived from err in that case.
+func responseStatus(ctx echo.Context, err error) int {
+	if err == nil {
+		if status := ctx.Response().Status; status != 0 {
+			return status
+		}
+		return http.StatusOK
+	}
+	if ctx.Response().Committed {
+		return ctx.Response().Status
+	}
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.Code
+	}
+	return http.StatusInternalServerError
+}
+
 // GetHubFromContext retrieves attached *sentry.Hub instance from echo.Context.
 func GetHubFromContext(ctx echo.Context) *sentry.Hub {
 	if hub, ok := ctx.Get(valuesKey).(*sentry.Hub); ok {
@@ -84,3 +147,17 @@ func GetHubFromContext(ctx echo.Context) *sentry.Hub {
 	}
 	return nil
 }
+
+// SetHubOnContext attaches *sentry.Hub instance to echo.Context.
+func SetHubOnContext(ctx echo.Context, hub *sentry.Hub) {
+	ctx.Set(valuesKey, hub)
+}
+
+// GetSpanFromContext retrieves the transaction of the request, started by
+// the middleware, from echo.Context.
+func GetSpanFromContext(ctx echo.Context) *sentry.Span {
+	if span, ok := ctx.Get(transactionKey).(*sentry.Span); ok {
+		return span
+	}
+	return nil
+}
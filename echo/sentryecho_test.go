@@ -0,0 +1,159 @@
+package sentryecho_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryecho "github.com/getsentry/sentry-go/echo"
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+)
+
+func TestIntegration(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handledErrors []error
+	app := echo.New()
+	app.HTTPErrorHandler = func(err error, c echo.Context) {
+		handledErrors = append(handledErrors, err)
+		app.DefaultHTTPErrorHandler(err, c)
+	}
+	app.Use(sentryecho.New(sentryecho.Options{}))
+	app.GET("/users/:id", func(c echo.Context) error {
+		if sentryecho.GetSpanFromContext(c) != sentry.SpanFromContext(c.Request().Context()) {
+			t.Error("transaction of echo.Context differs from the span of the request")
+		}
+		sentryecho.GetHubFromContext(c).CaptureMessage("user " + c.Param("id"))
+		return c.NoContent(http.StatusNoContent)
+	})
+	app.GET("/forbidden", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusForbidden)
+	})
+	app.POST("/panic", func(c echo.Context) error {
+		panic("test")
+	})
+
+	tests := []struct {
+		method     string
+		path       string
+		wantName   string
+		wantSource sentry.TransactionSource
+		wantStatus sentry.SpanStatus
+		wantCode   int
+	}{
+		{http.MethodGet, "/users/42", "GET /users/:id", sentry.SourceRoute, sentry.SpanStatusOK, http.StatusNoContent},
+		{http.MethodGet, "/forbidden", "GET /forbidden", sentry.SourceRoute, sentry.SpanStatusPermissionDenied, http.StatusForbidden},
+		{http.MethodPost, "/panic", "POST /panic", sentry.SourceRoute, sentry.SpanStatusInternalError, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		transactions = nil
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s %s: got status code %d, want %d", tt.method, tt.path, w.Code, tt.wantCode)
+		}
+		if len(transactions) != 1 {
+			t.Fatalf("%s %s: got %d transactions, want 1", tt.method, tt.path, len(transactions))
+		}
+		transaction := transactions[0]
+		if diff := cmp.Diff(tt.wantName, transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantSource, transaction.TransactionInfo.Source); diff != "" {
+			t.Errorf("transaction source mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantStatus, transaction.Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("user 42", events[0].Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, events[1].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+
+	// The forbidden and panic requests are passed to the error handler.
+	if len(handledErrors) != 2 {
+		t.Fatalf("got %d handled errors, want 2", len(handledErrors))
+	}
+	var httpError *echo.HTTPError
+	if !errors.As(handledErrors[0], &httpError) || httpError.Code != http.StatusForbidden {
+		t.Errorf("got handled error %v, want forbidden", handledErrors[0])
+	}
+	if diff := cmp.Diff("sentryecho: panic: test", handledErrors[1].Error()); diff != "" {
+		t.Errorf("panic error mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIntegrationRepanic(t *testing.T) {
+	var transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := echo.New()
+	// Respond to the repanicked requests, as the Recover middleware of echo.
+	app.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			defer func() {
+				if r := recover(); r != nil {
+					c.Error(fmt.Errorf("%v", r))
+				}
+			}()
+			return next(c)
+		}
+	})
+	app.Use(sentryecho.New(sentryecho.Options{Repanic: true}))
+	app.POST("/panic", func(c echo.Context) error {
+		panic("test")
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status code %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transactions[0].Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/getsentry/sentry-go/ent
+
+go 1.24
+
+require (
+	entgo.io/ent v0.14.6
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+)
+
+require (
+	github.com/google/uuid v1.3.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
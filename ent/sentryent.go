@@ -0,0 +1,266 @@
+// Package sentryent provides Sentry instrumentation for ent.
+//
+// Wrap the driver of a client to record a "db.query" span for each statement
+// run with a context containing a span, with the SQL stripped of its literal
+// values as description, and the entity type and operation of the query or
+// mutation running the statement as data:
+//
+//	drv, err := sql.Open(dialect.Postgres, dsn)
+//	client := ent.NewClient(ent.Driver(sentryent.NewDriver(drv, sentryent.Options{})))
+//	client.Use(sentryent.Hook())
+//
+// The queries of the generated code are identified without further setup,
+// while mutations are identified by the hook.
+package sentryent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect"
+	"github.com/getsentry/sentry-go"
+	sentrysql "github.com/getsentry/sentry-go/sql"
+)
+
+// spanOperation is the operation of the spans recorded for statements.
+const spanOperation = "db.query"
+
+// spanOrigin is the origin of the spans recorded for statements.
+const spanOrigin = "auto.db.ent"
+
+// Options configure the instrumentation of a driver.
+type Options struct {
+	// DatabaseName is the name of the database being accessed. It is recorded
+	// as the "db.name" span data.
+	DatabaseName string
+	// CaptureErrors configures whether errors returned by the driver are
+	// reported to Sentry, in addition to setting the status of the span.
+	// Errors are reported to the hub of the statement context, or to the
+	// current hub. Context cancellations are never reported.
+	CaptureErrors bool
+}
+
+// Driver is a dialect.Driver instrumenting the statements run by a driver
+// and by its transactions.
+type Driver struct {
+	dialect.Driver
+	options Options
+}
+
+// NewDriver returns a Driver instrumenting drv.
+func NewDriver(drv dialect.Driver, options Options) *Driver {
+	return &Driver{Driver: drv, options: options}
+}
+
+// Exec implements dialect.ExecQuerier.
+func (d *Driver) Exec(ctx context.Context, query string, args, v any) error {
+	return d.instrument(ctx, query, v, func() error {
+		return d.Driver.Exec(ctx, query, args, v)
+	})
+}
+
+// Query implements dialect.ExecQuerier.
+func (d *Driver) Query(ctx context.Context, query string, args, v any) error {
+	return d.instrument(ctx, query, nil, func() error {
+		return d.Driver.Query(ctx, query, args, v)
+	})
+}
+
+// ExecContext calls the ExecContext method of the wrapped driver, used by the
+// ExecContext method of generated clients, if it is supported.
+func (d *Driver) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	drv, ok := d.Driver.(interface {
+		ExecContext(context.Context, string, ...any) (sql.Result, error)
+	})
+	if !ok {
+		return nil, errors.New("sentryent: driver does not support ExecContext")
+	}
+	var result sql.Result
+	err := d.instrument(ctx, query, &result, func() (err error) {
+		result, err = drv.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryContext calls the QueryContext method of the wrapped driver, used by
+// the QueryContext method of generated clients, if it is supported.
+func (d *Driver) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	drv, ok := d.Driver.(interface {
+		QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	})
+	if !ok {
+		return nil, errors.New("sentryent: driver does not support QueryContext")
+	}
+	var rows *sql.Rows
+	err := d.instrument(ctx, query, nil, func() (err error) {
+		rows, err = drv.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// Tx starts a transaction instrumenting its statements.
+func (d *Driver) Tx(ctx context.Context) (dialect.Tx, error) {
+	tx, err := d.Driver.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, driver: d}, nil
+}
+
+// BeginTx calls the BeginTx method of the wrapped driver, used by the BeginTx
+// method of generated clients, if it is supported, and instruments the
+// statements of the transaction.
+func (d *Driver) BeginTx(ctx context.Context, opts *sql.TxOptions) (dialect.Tx, error) {
+	drv, ok := d.Driver.(interface {
+		BeginTx(context.Context, *sql.TxOptions) (dialect.Tx, error)
+	})
+	if !ok {
+		return nil, errors.New("sentryent: driver does not support BeginTx")
+	}
+	tx, err := drv.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, driver: d}, nil
+}
+
+// Tx is a dialect.Tx instrumenting the statements run in a transaction.
+type Tx struct {
+	dialect.Tx
+	driver *Driver
+}
+
+// Exec implements dialect.ExecQuerier.
+func (tx *Tx) Exec(ctx context.Context, query string, args, v any) error {
+	return tx.driver.instrument(ctx, query, v, func() error {
+		return tx.Tx.Exec(ctx, query, args, v)
+	})
+}
+
+// Query implements dialect.ExecQuerier.
+func (tx *Tx) Query(ctx context.Context, query string, args, v any) error {
+	return tx.driver.instrument(ctx, query, nil, func() error {
+		return tx.Tx.Query(ctx, query, args, v)
+	})
+}
+
+// instrument runs a statement in a "db.query" child span of the span in ctx,
+// if any, and reports the error it returns. result is the *sql.Result the
+// statement stores its result in, if any.
+func (d *Driver) instrument(ctx context.Context, query string, result any, run func() error) error {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		err := run()
+		d.captureError(ctx, query, err)
+		return err
+	}
+
+	span := parent.StartChild(spanOperation, sentry.WithSpanOrigin(spanOrigin))
+	defer span.Finish()
+	span.Description = sentrysql.SanitizeQuery(query)
+	if system := databaseSystem(d.Dialect()); system != "" {
+		span.SetData("db.system", system)
+	}
+	if d.options.DatabaseName != "" {
+		span.SetData("db.name", d.options.DatabaseName)
+	}
+	if entType, operation := entOperation(ctx); entType != "" {
+		span.SetData("ent.type", entType)
+		span.SetData("ent.operation", operation)
+	}
+
+	err := run()
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.Status = sentry.SpanStatusOK
+		if r, ok := result.(*sql.Result); ok && *r != nil {
+			if n, err := (*r).RowsAffected(); err == nil {
+				if span.Data == nil {
+					span.Data = make(map[string]interface{})
+				}
+				span.Data["db.rows_affected"] = n
+			}
+		}
+	}
+	d.captureError(ctx, query, err)
+	return err
+}
+
+// captureError reports a driver error to Sentry, if enabled.
+func (d *Driver) captureError(ctx context.Context, query string, err error) {
+	if !d.options.CaptureErrors || err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		database := sentry.Context{
+			"query":  sentrysql.SanitizeQuery(query),
+			"system": databaseSystem(d.Dialect()),
+			"name":   d.options.DatabaseName,
+		}
+		if entType, operation := entOperation(ctx); entType != "" {
+			database["ent_type"] = entType
+			database["ent_operation"] = operation
+		}
+		scope.SetContext("database", database)
+		hub.CaptureException(err)
+	})
+}
+
+// mutationKey is the context key of the mutation set by Hook.
+type mutationKey struct{}
+
+// mutation identifies the mutation running statements.
+type mutation struct {
+	entType   string
+	operation string
+}
+
+// Hook returns a hook identifying the entity type and operation of mutations
+// in the spans of their statements. Register it on the client with Use.
+func Hook() ent.Hook {
+	return func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			ctx = context.WithValue(ctx, mutationKey{}, mutation{
+				entType:   m.Type(),
+				operation: strings.TrimPrefix(m.Op().String(), "Op"),
+			})
+			return next.Mutate(ctx, m)
+		})
+	}
+}
+
+// entOperation returns the entity type and operation of the query or mutation
+// in ctx, if any.
+func entOperation(ctx context.Context) (entType, operation string) {
+	if m, ok := ctx.Value(mutationKey{}).(mutation); ok {
+		return m.entType, m.operation
+	}
+	if q := ent.QueryFromContext(ctx); q != nil {
+		return q.Type, q.Op
+	}
+	return "", ""
+}
+
+// databaseSystem returns the "db.system" of an ent dialect.
+func databaseSystem(name string) string {
+	switch name {
+	case dialect.Postgres:
+		return "postgresql"
+	case dialect.SQLite:
+		return "sqlite"
+	default:
+		return name
+	}
+}
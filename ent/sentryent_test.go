@@ -0,0 +1,158 @@
+package sentryent_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect"
+	"github.com/getsentry/sentry-go"
+	sentryent "github.com/getsentry/sentry-go/ent"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeDriver is a dialect.Driver whose statements affect one row, or fail
+// when they are "FAIL".
+type fakeDriver struct{}
+
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (d fakeDriver) Exec(ctx context.Context, query string, args, v any) error {
+	if query == "FAIL" {
+		return errors.New("syntax error")
+	}
+	if r, ok := v.(*sql.Result); ok {
+		*r = fakeResult{}
+	}
+	return nil
+}
+
+func (d fakeDriver) Query(ctx context.Context, query string, args, v any) error {
+	if query == "FAIL" {
+		return errors.New("syntax error")
+	}
+	return nil
+}
+
+func (d fakeDriver) Tx(ctx context.Context) (dialect.Tx, error) { return dialect.NopTx(d), nil }
+func (d fakeDriver) Close() error                               { return nil }
+func (d fakeDriver) Dialect() string                            { return dialect.Postgres }
+
+// fakeMutation is an ent.Mutation of which only Type and Op are implemented.
+type fakeMutation struct {
+	ent.Mutation
+}
+
+func (fakeMutation) Type() string { return "User" }
+func (fakeMutation) Op() ent.Op   { return ent.OpUpdateOne }
+
+func TestDriver(t *testing.T) {
+	var events, transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	drv := sentryent.NewDriver(fakeDriver{}, sentryent.Options{
+		DatabaseName:  "app",
+		CaptureErrors: true,
+	})
+
+	transaction := sentry.StartTransaction(ctx, "test")
+	ctx = transaction.Context()
+
+	queryCtx := ent.NewQueryContext(ctx, &ent.QueryContext{Type: "User", Op: ent.OpQueryAll})
+	if err := drv.Query(queryCtx, `SELECT * FROM "users" WHERE "name" = 'alice'`, []any{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err = sentryent.Hook()(ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+		tx, err := drv.Tx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var result sql.Result
+		return nil, tx.Exec(ctx, `UPDATE "users" SET "name" = $1 WHERE "id" = $2`, []any{"bob", 1}, &result)
+	})).Mutate(ctx, fakeMutation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := drv.Exec(ctx, "FAIL", []any{}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	transaction.Finish()
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	type span struct {
+		Description string
+		Status      sentry.SpanStatus
+		Data        map[string]interface{}
+	}
+	var got []span
+	for _, s := range transactions[0].Spans {
+		got = append(got, span{s.Description, s.Status, s.Data})
+	}
+	want := []span{
+		{
+			Description: `SELECT * FROM "users" WHERE "name" = ?`,
+			Status:      sentry.SpanStatusOK,
+			Data: map[string]interface{}{
+				"db.system":     "postgresql",
+				"db.name":       "app",
+				"ent.type":      "User",
+				"ent.operation": "All",
+			},
+		},
+		{
+			Description: `UPDATE "users" SET "name" = $1 WHERE "id" = $2`,
+			Status:      sentry.SpanStatusOK,
+			Data: map[string]interface{}{
+				"db.system":        "postgresql",
+				"db.name":          "app",
+				"ent.type":         "User",
+				"ent.operation":    "UpdateOne",
+				"db.rows_affected": int64(1),
+			},
+		},
+		{
+			Description: "FAIL",
+			Status:      sentry.SpanStatusInternalError,
+			Data: map[string]interface{}{
+				"db.system": "postgresql",
+				"db.name":   "app",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(sentry.Context{
+		"query":  "FAIL",
+		"system": "postgresql",
+		"name":   "app",
+	}, events[0].Contexts["database"]); diff != "" {
+		t.Errorf("database context mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,225 @@
+package sentry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/getsentry/sentry-go/internal/ratelimit"
+)
+
+// An Envelope is the data format used to deliver events, transactions,
+// attachments and other payloads to Sentry.
+//
+// Most programs never need to build envelopes by hand, because the Client
+// creates them from captured events. Constructing an Envelope and passing it to
+// Transport.SendEnvelope is useful for advanced use cases, such as sending item
+// types that the SDK does not model yet.
+//
+// See https://develop.sentry.dev/sdk/envelopes/.
+type Envelope struct {
+	Header EnvelopeHeader
+	Items  []*EnvelopeItem
+}
+
+// EnvelopeHeader holds the headers of an Envelope.
+type EnvelopeHeader struct {
+	EventID EventID           `json:"event_id,omitempty"`
+	SentAt  time.Time         `json:"sent_at"`
+	Dsn     string            `json:"dsn,omitempty"`
+	Sdk     map[string]string `json:"sdk,omitempty"`
+	Trace   map[string]string `json:"trace,omitempty"`
+}
+
+// EnvelopeItem is a single item of an Envelope.
+//
+// The length of the item is always computed from the payload when the envelope
+// is serialized.
+type EnvelopeItem struct {
+	// Type is the item type, for example "event", "transaction" or
+	// "attachment".
+	Type string
+	// Filename is only used by attachment items.
+	Filename string
+	// ContentType is only used by attachment items.
+	ContentType string
+	// Payload is the raw item payload. JSON payloads must be compact, that is,
+	// they must not contain newlines.
+	Payload []byte
+
+	// open, if not nil, streams the payload instead of Payload. It is used by
+	// attachments created with an Open function.
+	open func() (io.ReadCloser, int64, error)
+}
+
+// NewEnvelope returns a new Envelope with the given header and no items.
+func NewEnvelope(header EnvelopeHeader) *Envelope {
+	return &Envelope{
+		Header: header,
+	}
+}
+
+// AddItem appends an item to the envelope.
+func (e *Envelope) AddItem(item *EnvelopeItem) {
+	e.Items = append(e.Items, item)
+}
+
+// Serialize returns the envelope encoded in the Sentry envelope format, ready
+// to be sent to Sentry.
+func (e *Envelope) Serialize() ([]byte, error) {
+	var b bytes.Buffer
+	if err := e.encode(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (e *Envelope) encode(w io.Writer) error {
+	r, _, err := e.reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// streaming reports whether any item of the envelope has a streamed payload.
+func (e *Envelope) streaming() bool {
+	for _, item := range e.Items {
+		if item.open != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// reader returns a reader producing the serialized envelope, along with its
+// total length. Streamed payloads are opened immediately, and are closed when
+// the returned reader is closed. Items whose payload cannot be opened are
+// skipped.
+func (e *Envelope) reader() (io.ReadCloser, int64, error) {
+	r := &envelopeReader{}
+	add := func(b []byte) {
+		r.parts = append(r.parts, bytes.NewReader(b))
+		r.length += int64(len(b))
+	}
+
+	// Envelope header
+	header, err := json.Marshal(e.Header)
+	if err != nil {
+		return nil, 0, err
+	}
+	add(append(header, '\n'))
+
+	for _, item := range e.Items {
+		var payload io.Reader = bytes.NewReader(item.Payload)
+		length := int64(len(item.Payload))
+		if item.open != nil {
+			rc, size, err := item.open()
+			if err != nil {
+				debugf(LevelWarning, "Skipping envelope item %q: %v", item.Filename, err)
+				continue
+			}
+			r.closers = append(r.closers, rc)
+			payload = &exactReader{r: rc, n: size}
+			length = size
+		}
+
+		// Item header
+		itemHeader, err := json.Marshal(struct {
+			Type        string `json:"type"`
+			Length      int64  `json:"length"`
+			Filename    string `json:"filename,omitempty"`
+			ContentType string `json:"content_type,omitempty"`
+		}{
+			Type:        item.Type,
+			Length:      length,
+			Filename:    item.Filename,
+			ContentType: item.ContentType,
+		})
+		if err != nil {
+			r.Close()
+			return nil, 0, err
+		}
+		add(append(itemHeader, '\n'))
+
+		// Item payload
+		r.parts = append(r.parts, payload)
+		r.length += length
+
+		// "Envelopes should be terminated with a trailing newline."
+		//
+		// [1]: https://develop.sentry.dev/sdk/envelopes/#envelopes
+		add([]byte("\n"))
+	}
+
+	r.Reader = io.MultiReader(r.parts...)
+	return r, r.length, nil
+}
+
+// envelopeReader reads the parts of a serialized envelope in sequence.
+type envelopeReader struct {
+	io.Reader
+	parts   []io.Reader
+	closers []io.Closer
+	length  int64
+}
+
+func (r *envelopeReader) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	r.closers = nil
+	return err
+}
+
+// exactReader reads exactly n bytes from r. It fails with io.ErrUnexpectedEOF if
+// r ends early, since the item length was already written to the envelope.
+type exactReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *exactReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	n, err := r.r.Read(p)
+	r.n -= int64(n)
+	if err == io.EOF && r.n > 0 {
+		err = io.ErrUnexpectedEOF
+	} else if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// category returns the rate limit category of the envelope, derived from the
+// type of its first item.
+func (e *Envelope) category() ratelimit.Category {
+	if len(e.Items) == 0 {
+		return ratelimit.CategoryAll
+	}
+	return categoryFor(e.Items[0].Type)
+}
+
+// sdkInfo returns the name and version of the SDK that created the envelope,
+// falling back to the current SDK if the envelope header doesn't carry them.
+func (e *Envelope) sdkInfo() (name, version string) {
+	name, version = e.Header.Sdk["name"], e.Header.Sdk["version"]
+	if name == "" {
+		name = sdkIdentifier
+	}
+	if version == "" {
+		version = SDKVersion
+	}
+	return name, version
+}
@@ -0,0 +1,132 @@
+package sentry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go/internal/testutils"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnvelopeSerialize(t *testing.T) {
+	envelope := NewEnvelope(EnvelopeHeader{
+		EventID: "b81c5be4d31e48959103a1f878a1efcb",
+		SentAt:  time.Unix(0, 0).UTC(),
+	})
+	envelope.AddItem(&EnvelopeItem{
+		Type:    "client_report",
+		Payload: []byte(`{"discarded_events":[]}`),
+	})
+	envelope.AddItem(&EnvelopeItem{
+		Type:        "attachment",
+		Filename:    "report.txt",
+		ContentType: "text/plain",
+		Payload:     []byte("hello"),
+	})
+
+	b, err := envelope.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	want := `{"event_id":"b81c5be4d31e48959103a1f878a1efcb","sent_at":"1970-01-01T00:00:00Z"}
+{"type":"client_report","length":23}
+{"discarded_events":[]}
+{"type":"attachment","length":5,"filename":"report.txt","content_type":"text/plain"}
+hello
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Envelope mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnvelopeSerializeWithoutItems(t *testing.T) {
+	envelope := NewEnvelope(EnvelopeHeader{
+		SentAt: time.Unix(0, 0).UTC(),
+		Sdk: map[string]string{
+			"name":    "sentry.go",
+			"version": "0.0.1",
+		},
+	})
+
+	b, err := envelope.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	want := `{"sent_at":"1970-01-01T00:00:00Z","sdk":{"name":"sentry.go","version":"0.0.1"}}
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Envelope mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetRequestFromEnvelope(t *testing.T) {
+	dsn, err := NewDsn("https://key@host/path/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := NewEnvelope(EnvelopeHeader{})
+	envelope.AddItem(&EnvelopeItem{Type: "custom", Payload: []byte("{}")})
+
+	req, err := getRequestFromEnvelope(envelope, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, req.URL.String(), "https://host/path/api/42/envelope/")
+	assertEqual(t, req.UserAgent(), "sentry.go/"+SDKVersion)
+	assertEqual(t, req.Header.Get("Content-Type"), "application/x-sentry-envelope")
+}
+
+func TestTransportSendEnvelope(t *testing.T) {
+	transports := map[string]func() Transport{
+		"HTTPTransport":     func() Transport { return NewHTTPTransport() },
+		"HTTPSyncTransport": func() Transport { return NewHTTPSyncTransport() },
+	}
+
+	for name, newTransport := range transports {
+		newTransport := newTransport
+		t.Run(name, func(t *testing.T) {
+			received := make(chan string, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				received <- string(b)
+			}))
+			defer srv.Close()
+
+			tr := newTransport()
+			tr.Configure(ClientOptions{
+				Dsn: strings.Replace(srv.URL, "//", "//pubkey@", 1) + "/1",
+			})
+
+			envelope := NewEnvelope(EnvelopeHeader{SentAt: time.Unix(0, 0).UTC()})
+			envelope.AddItem(&EnvelopeItem{Type: "custom", Payload: []byte(`{"foo":"bar"}`)})
+			tr.SendEnvelope(envelope)
+
+			if !tr.Flush(testutils.FlushTimeout()) {
+				t.Fatal("Flush timed out")
+			}
+
+			select {
+			case got := <-received:
+				want := `{"sent_at":"1970-01-01T00:00:00Z"}
+{"type":"custom","length":13}
+{"foo":"bar"}
+`
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("Envelope mismatch (-want +got):\n%s", diff)
+				}
+			case <-time.After(testutils.FlushTimeout()):
+				t.Fatal("envelope not received")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package sentry
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go/internal/ratelimit"
+)
+
+// maxErrorRateLimitKeys bounds the number of error fingerprints tracked by the
+// per-fingerprint rate limit.
+const maxErrorRateLimitKeys = 1000
+
+// RateLimit configures a token bucket rate limiter, which allows bursts of up
+// to Burst events, refilled at Rate events per second. The zero value doesn't
+// limit anything.
+type RateLimit struct {
+	// Rate is the number of events allowed per second on average.
+	Rate float64
+	// Burst is the number of events allowed at once. Defaults to Rate, rounded
+	// up, and at least 1.
+	Burst int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.Rate > 0
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// tokenBucket is the state of a RateLimit. The zero value is a full bucket.
+type tokenBucket struct {
+	used float64
+	last time.Time
+}
+
+// refill returns the number of tokens used after refilling the bucket at now.
+func (b *tokenBucket) refill(limit RateLimit, now time.Time) float64 {
+	if !b.last.IsZero() {
+		b.used = math.Max(0, b.used-now.Sub(b.last).Seconds()*limit.Rate)
+	}
+	b.last = now
+	return b.used
+}
+
+// available reports whether a token is available in the bucket at now.
+func (b *tokenBucket) available(limit RateLimit, now time.Time) bool {
+	return b.refill(limit, now)+1 <= limit.burst()
+}
+
+// errorRateLimiter applies the ErrorRateLimit and ErrorRateLimitPerFingerprint
+// client options. It is safe for concurrent use.
+type errorRateLimiter struct {
+	mu     sync.Mutex
+	global tokenBucket
+	keys   map[string]*tokenBucket
+}
+
+// allow reports whether an error identified by key may be captured.
+func (l *errorRateLimiter) allow(global, perKey RateLimit, key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var bucket *tokenBucket
+	if perKey.enabled() {
+		if l.keys == nil {
+			l.keys = make(map[string]*tokenBucket)
+		}
+		var ok bool
+		bucket, ok = l.keys[key]
+		if !ok {
+			if len(l.keys) >= maxErrorRateLimitKeys {
+				l.prune(perKey, now)
+			}
+			bucket = &tokenBucket{}
+			l.keys[key] = bucket
+		}
+		if !bucket.available(perKey, now) {
+			return false
+		}
+	}
+	if global.enabled() && !l.global.available(global, now) {
+		return false
+	}
+	// Tokens are only taken once both buckets allow the error, for errors
+	// dropped by one of them not to use the tokens of the other.
+	if bucket != nil {
+		bucket.used++
+	}
+	if global.enabled() {
+		l.global.used++
+	}
+	return true
+}
+
+// prune forgets the fingerprints whose bucket is full again, or all of them if
+// none is.
+func (l *errorRateLimiter) prune(limit RateLimit, now time.Time) {
+	for key, bucket := range l.keys {
+		if bucket.refill(limit, now) == 0 {
+			delete(l.keys, key)
+		}
+	}
+	if len(l.keys) >= maxErrorRateLimitKeys {
+		l.keys = make(map[string]*tokenBucket)
+	}
+}
+
+// allowError reports whether an error event identified by key may be built
+// and captured under the ErrorRateLimit and ErrorRateLimitPerFingerprint
+// client options. Discarded events are counted in client reports.
+func (client *Client) allowError(key string) bool {
+	global, perKey := client.options.ErrorRateLimit, client.options.ErrorRateLimitPerFingerprint
+	if !global.enabled() && !perKey.enabled() {
+		return true
+	}
+	if client.errorLimiter.allow(global, perKey, key, client.now()) {
+		return true
+	}
+	client.debugf(LevelWarning, "Event dropped due to the client error rate limit.")
+	client.discard(DropReasonRateLimit, ratelimit.CategoryError)
+	return false
+}
+
+// errorKey identifies errors with the same type and message.
+func errorKey(err interface{}) string {
+	if err, ok := err.(error); ok {
+		return fmt.Sprintf("%T: %s", err, err.Error())
+	}
+	return fmt.Sprintf("%T: %v", err, err)
+}
@@ -0,0 +1,78 @@
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorRateLimiter(t *testing.T) {
+	var limiter errorRateLimiter
+	global := RateLimit{Rate: 2, Burst: 3}
+	now := time.Now()
+
+	var allowed int
+	for i := 0; i < 10; i++ {
+		if limiter.allow(global, RateLimit{}, "key", now) {
+			allowed++
+		}
+	}
+	assertEqual(t, allowed, 3)
+
+	// Two tokens are refilled per second.
+	now = now.Add(time.Second)
+	assertEqual(t, limiter.allow(global, RateLimit{}, "key", now), true)
+	assertEqual(t, limiter.allow(global, RateLimit{}, "key", now), true)
+	assertEqual(t, limiter.allow(global, RateLimit{}, "key", now), false)
+}
+
+func TestErrorRateLimiterPerKey(t *testing.T) {
+	var limiter errorRateLimiter
+	perKey := RateLimit{Rate: 1}
+	now := time.Now()
+
+	assertEqual(t, limiter.allow(RateLimit{}, perKey, "a", now), true)
+	assertEqual(t, limiter.allow(RateLimit{}, perKey, "a", now), false)
+	assertEqual(t, limiter.allow(RateLimit{}, perKey, "b", now), true)
+
+	for i := 0; i < maxErrorRateLimitKeys+10; i++ {
+		limiter.allow(RateLimit{}, perKey, fmt.Sprint(i), now)
+	}
+	if len(limiter.keys) > maxErrorRateLimitKeys {
+		t.Errorf("tracking %d keys, want at most %d", len(limiter.keys), maxErrorRateLimitKeys)
+	}
+}
+
+func TestErrorRateLimiterGlobalAndPerKey(t *testing.T) {
+	var limiter errorRateLimiter
+	global := RateLimit{Rate: 1}
+	perKey := RateLimit{Rate: 0.1}
+	now := time.Now()
+
+	assertEqual(t, limiter.allow(global, perKey, "a", now), true)
+	// Errors dropped by the global limit don't use the tokens of their key.
+	assertEqual(t, limiter.allow(global, perKey, "b", now), false)
+	now = now.Add(time.Second)
+	assertEqual(t, limiter.allow(global, perKey, "b", now), true)
+}
+
+func TestErrorRateLimitOption(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.options.ErrorRateLimitPerFingerprint = RateLimit{Rate: 0.001}
+	// Keep the counts of discarded events rather than sending them.
+	client.options.DisableClientReports = true
+
+	for i := 0; i < 5; i++ {
+		client.CaptureException(errors.New("connection refused"), nil, scope)
+	}
+	client.CaptureException(errors.New("timeout"), nil, scope)
+	client.CaptureMessage("hello", nil, scope)
+	_, err := client.TryCaptureMessage("hello", nil, scope)
+
+	assertEqual(t, len(transport.Events()), 3)
+	if !errors.Is(err, ErrEventRateLimited) {
+		t.Errorf("TryCaptureMessage() error = %v, want ErrEventRateLimited", err)
+	}
+	assertEqual(t, client.reports.counts[discardedEventKey{reason: "ratelimit_backoff", category: "error"}], int64(5))
+}
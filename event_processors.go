@@ -0,0 +1,71 @@
+package sentry
+
+// namedEventProcessor is an event processor registered on a Client or Scope.
+// Anonymous processors have an empty name and cannot be removed.
+type namedEventProcessor struct {
+	name      string
+	priority  int
+	processor EventProcessor
+}
+
+// eventProcessors is a list of event processors sorted by ascending priority,
+// processors with equal priority being kept in order of registration.
+//
+// The list is copy-on-write: add and remove return a new slice and never modify
+// the receiver, so that it can be shared between a scope and its clones.
+type eventProcessors []namedEventProcessor
+
+// add returns a new list with processor inserted according to its priority.
+// A processor with the same non-empty name is replaced.
+func (p eventProcessors) add(name string, priority int, processor EventProcessor) eventProcessors {
+	if name != "" {
+		p, _ = p.remove(name)
+	}
+
+	i := len(p)
+	for i > 0 && p[i-1].priority > priority {
+		i--
+	}
+
+	res := make(eventProcessors, 0, len(p)+1)
+	res = append(res, p[:i]...)
+	res = append(res, namedEventProcessor{
+		name:      name,
+		priority:  priority,
+		processor: processor,
+	})
+	res = append(res, p[i:]...)
+	return res
+}
+
+// remove returns a new list without the processor with the given name, and
+// reports whether it was found.
+func (p eventProcessors) remove(name string) (eventProcessors, bool) {
+	for i, np := range p {
+		if name != "" && np.name == name {
+			res := make(eventProcessors, 0, len(p)-1)
+			res = append(res, p[:i]...)
+			res = append(res, p[i+1:]...)
+			return res, true
+		}
+	}
+	return p, false
+}
+
+// apply runs the processors in order, stopping as soon as one of them drops
+// the event. source names the owner of the processors in debug logs.
+func (p eventProcessors) apply(event *Event, hint *EventHint, source string) *Event {
+	for _, np := range p {
+		id := event.EventID
+		event = np.processor(event, hint)
+		if event == nil {
+			if np.name != "" {
+				debugf(LevelWarning, "Event dropped by the %s EventProcessor %q: %s\n", source, np.name, id)
+			} else {
+				debugf(LevelWarning, "Event dropped by one of the %s EventProcessors: %s\n", source, id)
+			}
+			return nil
+		}
+	}
+	return event
+}
@@ -0,0 +1,72 @@
+package sentry
+
+import (
+	"testing"
+)
+
+func appendTag(value string) EventProcessor {
+	return func(event *Event, hint *EventHint) *Event {
+		event.Tags["order"] += value
+		return event
+	}
+}
+
+func TestEventProcessorsOrder(t *testing.T) {
+	scope := NewScope()
+	scope.AddEventProcessor(appendTag("a"))
+	scope.AddNamedEventProcessor("late", 10, appendTag("d"))
+	scope.AddNamedEventProcessor("early", -10, appendTag("b"))
+	scope.AddEventProcessor(appendTag("c"))
+
+	event := scope.ApplyToEvent(&Event{Tags: map[string]string{}}, nil)
+	assertEqual(t, event.Tags["order"], "bacd")
+}
+
+func TestEventProcessorsReplaceAndRemove(t *testing.T) {
+	scope := NewScope()
+	scope.AddNamedEventProcessor("lib", 0, appendTag("a"))
+	scope.AddNamedEventProcessor("lib", 0, appendTag("b"))
+	scope.AddNamedEventProcessor("other", 0, appendTag("c"))
+
+	event := scope.ApplyToEvent(&Event{Tags: map[string]string{}}, nil)
+	assertEqual(t, event.Tags["order"], "bc")
+
+	assertEqual(t, scope.RemoveEventProcessor("lib"), true)
+	assertEqual(t, scope.RemoveEventProcessor("lib"), false)
+	assertEqual(t, scope.RemoveEventProcessor(""), false)
+
+	event = scope.ApplyToEvent(&Event{Tags: map[string]string{}}, nil)
+	assertEqual(t, event.Tags["order"], "c")
+}
+
+func TestEventProcessorsCloneIsolation(t *testing.T) {
+	scope := NewScope()
+	scope.AddNamedEventProcessor("lib", 0, appendTag("a"))
+
+	clone := scope.Clone()
+	clone.RemoveEventProcessor("lib")
+	clone.AddNamedEventProcessor("app", 0, appendTag("b"))
+
+	event := scope.ApplyToEvent(&Event{Tags: map[string]string{}}, nil)
+	assertEqual(t, event.Tags["order"], "a")
+	event = clone.ApplyToEvent(&Event{Tags: map[string]string{}}, nil)
+	assertEqual(t, event.Tags["order"], "b")
+}
+
+func TestClientRemoveEventProcessor(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.AddNamedEventProcessor("drop", 0, func(event *Event, hint *EventHint) *Event {
+		return nil
+	})
+
+	client.CaptureMessage("dropped", nil, scope)
+	if transport.lastEvent != nil {
+		t.Fatal("event should be dropped")
+	}
+
+	assertEqual(t, client.RemoveEventProcessor("drop"), true)
+	client.CaptureMessage("sent", nil, scope)
+	if transport.lastEvent == nil {
+		t.Fatal("event should be sent")
+	}
+}
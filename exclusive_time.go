@@ -0,0 +1,72 @@
+package sentry
+
+import (
+	"sort"
+	"time"
+)
+
+// setExclusiveTimes sets the exclusive time of a transaction and of its
+// finished spans, which is the part of their duration not covered by any of
+// their children. Children may overlap when they run concurrently, and the
+// time they spend outside of their parent is ignored.
+func setExclusiveTimes(transaction *Span, spans []*Span) {
+	children := make(map[SpanID][]*Span, len(spans))
+	for _, span := range spans {
+		children[span.ParentSpanID] = append(children[span.ParentSpanID], span)
+	}
+
+	// The caller holds the lock of the transaction.
+	transaction.exclusiveTime = exclusiveTime(transaction, children[transaction.SpanID])
+	for _, span := range spans {
+		t := exclusiveTime(span, children[span.SpanID])
+		span.mu.Lock()
+		span.exclusiveTime = t
+		span.mu.Unlock()
+	}
+}
+
+// exclusiveTime returns the duration of span not covered by children.
+func exclusiveTime(span *Span, children []*Span) time.Duration {
+	start, end := span.StartTime, span.EndTime
+	if !end.After(start) {
+		return 0
+	}
+
+	type interval struct{ start, end time.Time }
+	intervals := make([]interval, 0, len(children))
+	for _, child := range children {
+		i := interval{child.StartTime, child.EndTime}
+		if i.start.Before(start) {
+			i.start = start
+		}
+		if i.end.After(end) {
+			i.end = end
+		}
+		if i.end.After(i.start) {
+			intervals = append(intervals, i)
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+
+	var covered time.Duration
+	var current interval
+	for _, i := range intervals {
+		if i.start.After(current.end) {
+			covered += current.end.Sub(current.start)
+			current = i
+		} else if i.end.After(current.end) {
+			current.end = i.end
+		}
+	}
+	covered += current.end.Sub(current.start)
+
+	return end.Sub(start) - covered
+}
+
+// milliseconds converts a duration to fractional milliseconds, the unit of
+// the exclusive time of spans.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
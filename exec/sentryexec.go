@@ -0,0 +1,246 @@
+// Package sentryexec provides Sentry integration for the commands run with
+// os/exec, for CLIs and job runners shelling out to other programs.
+//
+// Run a command with Run instead of exec.Cmd.Run:
+//
+//	cmd := exec.CommandContext(ctx, "pg_dump", "--format=custom", database)
+//	cmd.Stdout = file
+//	if err := sentryexec.Run(ctx, cmd, sentryexec.Options{}); err != nil {
+//		return err
+//	}
+//
+// The lines the command writes to stderr are added as breadcrumbs to the hub
+// of ctx, or to the current hub, and are still written to the Stderr of the
+// command, if any. When the command fails to start or exits with a non-zero
+// status, an event is captured with the "command" context: the directory, exit
+// code and duration of the command, and its arguments if the SendDefaultPII
+// client option is set, as they may contain secrets. The trailing lines of its
+// stderr, and of its stdout with CaptureStdout, are attached to the event as
+// "output.txt". The command runs in a "subprocess" span when ctx has a span.
+package sentryexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The identifier of the exec SDK.
+const sdkIdentifier = "sentry.go.exec"
+
+// spanOperation is the operation of the spans of commands.
+const spanOperation = "subprocess"
+
+// spanOrigin is the origin of the spans of commands.
+const spanOrigin = "auto.subprocess.exec"
+
+// breadcrumbCategory is the category of the breadcrumbs of stderr lines.
+const breadcrumbCategory = "subprocess"
+
+// defaultTrailingLines is the default number of trailing lines of output
+// attached to events.
+const defaultTrailingLines = 50
+
+// maxLineLength is the length lines of output are truncated to, in bytes.
+const maxLineLength = 1024
+
+// Options configure Run.
+type Options struct {
+	// TrailingLines is the number of trailing lines of the output of
+	// commands attached to events. Defaults to 50.
+	TrailingLines int
+	// CaptureStdout configures whether the stdout of commands is attached to
+	// events along with their stderr. Leave it unset for commands writing
+	// binary data to stdout.
+	CaptureStdout bool
+	// DisableBreadcrumbs disables the breadcrumbs of stderr lines.
+	DisableBreadcrumbs bool
+}
+
+// Run runs cmd, which must not be started, reporting its stderr lines as
+// breadcrumbs and its failure as an event. It returns the error of cmd.Run.
+func Run(ctx context.Context, cmd *exec.Cmd, options Options) error {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	trailingLines := options.TrailingLines
+	if trailingLines <= 0 {
+		trailingLines = defaultTrailingLines
+	}
+	output := sentry.NewLogBuffer(trailingLines)
+	sendDefaultPII := hub.Client() != nil && hub.Client().Options().SendDefaultPII
+
+	stdout := &lineWriter{onLine: output.Add}
+	stderr := &lineWriter{onLine: func(line string) {
+		output.Add(line)
+		if !options.DisableBreadcrumbs {
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Type:      "default",
+				Category:  breadcrumbCategory,
+				Message:   line,
+				Data:      map[string]interface{}{"command": name(cmd)},
+				Level:     sentry.LevelInfo,
+				Timestamp: time.Now(),
+			}, nil)
+		}
+	}}
+	if options.CaptureStdout {
+		cmd.Stdout = tee(cmd.Stdout, stdout)
+	}
+	cmd.Stderr = tee(cmd.Stderr, stderr)
+
+	var span *sentry.Span
+	if sentry.SpanFromContext(ctx) != nil {
+		span = sentry.StartSpan(ctx, spanOperation, sentry.WithSpanOrigin(spanOrigin))
+		span.Description = name(cmd)
+		if sendDefaultPII {
+			span.Description = strings.Join(cmd.Args, " ")
+		}
+		ctx = span.Context()
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+	stdout.Flush()
+	stderr.Flush()
+	if span != nil {
+		if cmd.ProcessState != nil {
+			span.SetData("process.exit.code", strconv.Itoa(cmd.ProcessState.ExitCode()))
+		}
+		span.SetError(err)
+		span.Finish()
+	}
+	if err == nil {
+		return nil
+	}
+
+	client := hub.Client()
+	if client == nil {
+		return err
+	}
+	client.SetSDKIdentifier(sdkIdentifier)
+
+	command := sentry.Context{
+		"duration": duration.Seconds(),
+	}
+	if sendDefaultPII {
+		command["args"] = cmd.Args
+	}
+	if cmd.Dir != "" {
+		command["dir"] = cmd.Dir
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		command["exit_code"] = exitErr.ExitCode()
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = fmt.Sprintf("Command %s failed: %v", name(cmd), err)
+	event.SetException(err, client.Options().MaxErrorDepth)
+	event.Tags["command"] = name(cmd)
+	event.Contexts["command"] = command
+	hint := &sentry.EventHint{Context: ctx, OriginalException: err}
+	if lines := output.Lines(); len(lines) > 0 {
+		hint.Attachments = []*sentry.Attachment{{
+			Filename:    "output.txt",
+			ContentType: "text/plain",
+			Payload:     []byte(strings.Join(lines, "\n") + "\n"),
+		}}
+	}
+	hub.CaptureEventWithHint(event, hint)
+	return err
+}
+
+// name returns the name of the program of cmd.
+func name(cmd *exec.Cmd) string {
+	if len(cmd.Args) > 0 {
+		return filepath.Base(cmd.Args[0])
+	}
+	return filepath.Base(cmd.Path)
+}
+
+// tee returns a writer writing to w, if not nil, and to lines.
+func tee(w io.Writer, lines *lineWriter) io.Writer {
+	if w == nil {
+		return lines
+	}
+	return io.MultiWriter(w, lines)
+}
+
+// lineWriter calls onLine with each non-empty line written to it, truncated
+// to maxLineLength bytes.
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	onLine func(line string)
+	// skip is true when the rest of the current line is dropped, after
+	// reaching maxLineLength.
+	skip bool
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		chunk := p
+		if i >= 0 {
+			chunk = p[:i]
+		}
+		if !w.skip {
+			free := maxLineLength - len(w.buf)
+			if free > len(chunk) {
+				free = len(chunk)
+			}
+			w.buf = append(w.buf, chunk[:free]...)
+			if free < len(chunk) {
+				w.line(w.buf)
+				w.buf = w.buf[:0]
+				w.skip = true
+			}
+		}
+		if i < 0 {
+			break
+		}
+		if !w.skip {
+			w.line(w.buf)
+		}
+		w.buf = w.buf[:0]
+		w.skip = false
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Flush calls onLine with the last line, if it doesn't end with a newline.
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.skip {
+		w.line(w.buf)
+	}
+	w.buf = nil
+	w.skip = false
+}
+
+func (w *lineWriter) line(b []byte) {
+	if line := strings.TrimRight(string(b), "\r"); strings.TrimSpace(line) != "" {
+		w.onLine(line)
+	}
+}
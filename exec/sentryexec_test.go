@@ -0,0 +1,164 @@
+package sentryexec_test
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryexec "github.com/getsentry/sentry-go/exec"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	var events []*sentry.Event
+	var hints []*sentry.EventHint
+	var transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		SendDefaultPII:   true,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			hints = append(hints, hint)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	if err := sentryexec.Run(ctx, exec.Command("sh", "-c", "echo ok >&2"), sentryexec.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := sentry.StartTransaction(ctx, "backup")
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", "echo dumping; echo 'disk full' >&2; printf 'aborting' >&2; exit 3")
+	cmd.Stderr = &stderr
+	err = sentryexec.Run(transaction.Context(), cmd, sentryexec.Options{TrailingLines: 3, CaptureStdout: true})
+	transaction.Finish()
+	if err == nil {
+		t.Fatal("got no error, want exit status 3")
+	}
+
+	if diff := cmp.Diff("disk full\naborting", stderr.String()); diff != "" {
+		t.Errorf("stderr mismatch (-want +got):\n%s", diff)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff("Command sh failed: exit status 3", event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("sh", event.Tags["command"]); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+	command := event.Contexts["command"]
+	if diff := cmp.Diff(3, command["exit_code"]); diff != "" {
+		t.Errorf("exit code mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(cmd.Args, command["args"]); diff != "" {
+		t.Errorf("args mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := command["duration"].(float64); !ok {
+		t.Errorf("got duration %v, want seconds", command["duration"])
+	}
+	// The order of stdout and stderr lines is not deterministic.
+	output := strings.Split(strings.TrimSuffix(string(hints[0].Attachments[0].Payload), "\n"), "\n")
+	sort.Strings(output)
+	if diff := cmp.Diff([]string{"aborting", "disk full", "dumping"}, output); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	var breadcrumbs []string
+	for _, b := range event.Breadcrumbs {
+		breadcrumbs = append(breadcrumbs, b.Message)
+	}
+	if diff := cmp.Diff([]string{"ok", "disk full", "aborting"}, breadcrumbs); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(transaction.TraceID, event.Contexts["trace"]["trace_id"]); diff != "" {
+		t.Errorf("event isn't linked to the trace (-want +got):\n%s", diff)
+	}
+
+	if len(transactions) != 1 || len(transactions[0].Spans) != 1 {
+		t.Fatalf("got %d transactions, want 1 with 1 span", len(transactions))
+	}
+	span := transactions[0].Spans[0]
+	if diff := cmp.Diff("subprocess", span.Op); diff != "" {
+		t.Errorf("op mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("3", span.Data["process.exit.code"]); diff != "" {
+		t.Errorf("exit code mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(strings.Join(cmd.Args, " "), span.Description); diff != "" {
+		t.Errorf("description mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunDefaults(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	var events []*sentry.Event
+	var hints []*sentry.EventHint
+	var transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			hints = append(hints, hint)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+
+	transaction := sentry.StartTransaction(ctx, "backup")
+	var stdout bytes.Buffer
+	cmd := exec.Command("sh", "-c", "echo binary; head -c 5000 /dev/zero | tr '\\0' x >&2; echo >&2; echo done >&2; exit 1", "--password=secret")
+	cmd.Stdout = &stdout
+	err = sentryexec.Run(transaction.Context(), cmd, sentryexec.Options{})
+	transaction.Finish()
+	if err == nil {
+		t.Fatal("got no error, want exit status 1")
+	}
+
+	if diff := cmp.Diff("binary\n", stdout.String()); diff != "" {
+		t.Errorf("stdout mismatch (-want +got):\n%s", diff)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if args, ok := events[0].Contexts["command"]["args"]; ok {
+		t.Errorf("got args %v, want none without SendDefaultPII", args)
+	}
+	// Stdout isn't captured, and long lines are truncated.
+	want := strings.Repeat("x", 1024) + "\ndone\n"
+	if diff := cmp.Diff(want, string(hints[0].Attachments[0].Payload)); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("sh", transactions[0].Spans[0].Description); diff != "" {
+		t.Errorf("description mismatch (-want +got):\n%s", diff)
+	}
+}
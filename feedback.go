@@ -0,0 +1,107 @@
+package sentry
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UserFeedback is a description, provided by a user, of what happened when an
+// event was captured. It is sent as a "user_report" envelope item, which is
+// supported by all versions of Sentry.
+//
+// See https://develop.sentry.dev/sdk/envelopes/#user-feedback.
+type UserFeedback struct {
+	// EventID is the ID of the event the feedback is about, as returned by
+	// CaptureException and similar functions.
+	EventID  EventID `json:"event_id"`
+	Name     string  `json:"name,omitempty"`
+	Email    string  `json:"email,omitempty"`
+	Comments string  `json:"comments"`
+}
+
+// Feedback is feedback provided by a user, optionally about an event. Unlike
+// UserFeedback, it is sent as a "feedback" event, which supports feedback not
+// associated with any error and is processed like other events, with scope
+// data and event processors applied.
+//
+// See https://develop.sentry.dev/sdk/data-model/envelope-items/#user-feedback.
+type Feedback struct {
+	Message      string
+	ContactEmail string
+	Name         string
+	// URL is the address of the page the feedback was given on, if any.
+	URL string
+	// AssociatedEventID is the ID of the event the feedback is about, if any.
+	AssociatedEventID EventID
+}
+
+// CaptureUserFeedback sends feedback about an event to Sentry.
+func (client *Client) CaptureUserFeedback(feedback UserFeedback) {
+	if feedback.EventID == "" {
+		client.debugf(LevelWarning, "User feedback dropped: it has no event ID.")
+		return
+	}
+	if client.dsn == nil {
+		return
+	}
+
+	payload, err := json.Marshal(feedback)
+	if err != nil {
+		client.debugf(LevelWarning, "User feedback dropped: %v", err)
+		return
+	}
+	envelope := NewEnvelope(EnvelopeHeader{
+		EventID: feedback.EventID,
+		SentAt:  time.Now(),
+		Dsn:     client.dsn.String(),
+		Sdk: map[string]string{
+			"name":    client.GetSDKIdentifier(),
+			"version": client.sdkVersion,
+		},
+	})
+	envelope.AddItem(&EnvelopeItem{
+		Type:    userReportType,
+		Payload: payload,
+	})
+	client.Transport.SendEnvelope(envelope)
+}
+
+// CaptureFeedback captures feedback from a user. The return value is the ID of
+// the feedback event, or nil if it was dropped.
+func (client *Client) CaptureFeedback(feedback *Feedback, hint *EventHint, scope EventModifier) *EventID {
+	event := client.EventFromFeedback(feedback)
+	if event == nil {
+		return nil
+	}
+	return client.CaptureEvent(event, hint, scope)
+}
+
+// EventFromFeedback creates a new Sentry event from the given feedback.
+func (client *Client) EventFromFeedback(feedback *Feedback) *Event {
+	if feedback == nil {
+		return nil
+	}
+
+	event := NewEvent()
+	event.Type = feedbackType
+	event.Level = LevelInfo
+
+	feedbackContext := Context{
+		"message": feedback.Message,
+	}
+	if feedback.ContactEmail != "" {
+		feedbackContext["contact_email"] = feedback.ContactEmail
+	}
+	if feedback.Name != "" {
+		feedbackContext["name"] = feedback.Name
+	}
+	if feedback.URL != "" {
+		feedbackContext["url"] = feedback.URL
+	}
+	if feedback.AssociatedEventID != "" {
+		feedbackContext["associated_event_id"] = feedback.AssociatedEventID
+	}
+	event.Contexts["feedback"] = feedbackContext
+
+	return event
+}
@@ -0,0 +1,107 @@
+package sentry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCaptureUserFeedback(t *testing.T) {
+	client, _, transport := setupClientTest()
+
+	client.CaptureUserFeedback(UserFeedback{
+		EventID:  "d7c7ab9f7d1e4a6c8a2e9f4b1c3d5e7f",
+		Name:     "Jane",
+		Email:    "jane@example.com",
+		Comments: "It broke when I clicked save.",
+	})
+	client.CaptureUserFeedback(UserFeedback{Comments: "no event ID"})
+
+	if len(transport.envelopes) != 1 {
+		t.Fatalf("got %d envelopes, want 1", len(transport.envelopes))
+	}
+	envelope := transport.envelopes[0]
+	assertEqual(t, envelope.Header.EventID, EventID("d7c7ab9f7d1e4a6c8a2e9f4b1c3d5e7f"))
+	assertEqual(t, envelope.Header.Dsn, client.dsn.String())
+	if len(envelope.Items) != 1 {
+		t.Fatalf("got %d envelope items, want 1", len(envelope.Items))
+	}
+	assertEqual(t, envelope.Items[0].Type, "user_report")
+
+	var got map[string]string
+	if err := json.Unmarshal(envelope.Items[0].Payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"event_id": "d7c7ab9f7d1e4a6c8a2e9f4b1c3d5e7f",
+		"name":     "Jane",
+		"email":    "jane@example.com",
+		"comments": "It broke when I clicked save.",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("user report mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCaptureFeedback(t *testing.T) {
+	client, _, transport := setupClientTest()
+	client.options.SampleRate = 0.000000000000001
+	scope := NewScope()
+	scope.SetTag("team", "support")
+
+	eventID := client.CaptureFeedback(&Feedback{
+		Message:           "It broke when I clicked save.",
+		ContactEmail:      "jane@example.com",
+		AssociatedEventID: "d7c7ab9f7d1e4a6c8a2e9f4b1c3d5e7f",
+	}, nil, scope)
+
+	if eventID == nil {
+		t.Fatal("feedback should not be sampled")
+	}
+	event := transport.lastEvent
+	assertEqual(t, event.Type, "feedback")
+	assertEqual(t, event.Tags["team"], "support")
+	want := Context{
+		"message":             "It broke when I clicked save.",
+		"contact_email":       "jane@example.com",
+		"associated_event_id": EventID("d7c7ab9f7d1e4a6c8a2e9f4b1c3d5e7f"),
+	}
+	if diff := cmp.Diff(want, event.Contexts["feedback"]); diff != "" {
+		t.Errorf("feedback context mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := event.Contexts["memory"]; ok {
+		t.Error("feedback should not have a memory context")
+	}
+
+	envelope, err := envelopeFromEvent(event, client.dsn, event.Timestamp, []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, envelope.Items[0].Type, "feedback")
+}
+
+func TestCaptureFeedbackNil(t *testing.T) {
+	client, scope, transport := setupClientTest()
+
+	if eventID := client.CaptureFeedback(nil, nil, scope); eventID != nil {
+		t.Errorf("CaptureFeedback(nil) = %v, want nil", *eventID)
+	}
+	if events := transport.Events(); len(events) != 0 {
+		t.Errorf("got %d events, want none", len(events))
+	}
+}
+
+func TestFeedbackMarshalJSON(t *testing.T) {
+	client, _, _ := setupClientTest()
+	event := client.EventFromFeedback(&Feedback{Message: "hello"})
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"type":"feedback"`) {
+		t.Errorf("serialized feedback event has no feedback type: %s", b)
+	}
+}
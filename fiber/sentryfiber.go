@@ -0,0 +1,195 @@
+// Package sentryfiber provides Sentry integration for fiber applications.
+//
+// The middleware reports panics and starts a transaction for each request,
+// named after the matched route:
+//
+//	app := fiber.New()
+//	app.Use(sentryfiber.New(sentryfiber.Options{}))
+//	app.Get("/users/:id", getUser)
+//
+// The hub of the request is available with GetHubFromContext, and with
+// sentry.GetHubFromContext on the user context of the request.
+package sentryfiber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+)
+
+// The identifier of the fiber SDK.
+const sdkIdentifier = "sentry.go.fiber"
+
+const (
+	valuesKey      = "sentry"
+	transactionKey = "sentry_transaction"
+)
+
+// spanOrigin is the origin of the transactions of requests.
+const spanOrigin = "auto.http.fiber"
+
+type handler struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// Options configure the middleware.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a
+	// panic. Use it with fiber's recover middleware, registered before this
+	// one, to respond to the request. Otherwise, the panic is passed to the
+	// ErrorHandler of the application, which responds with an internal
+	// server error.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// New returns a fiber middleware providing integration with Sentry.
+func New(options Options) fiber.Handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	h := &handler{
+		repanic:         options.Repanic,
+		timeout:         timeout,
+		waitForDelivery: options.WaitForDelivery,
+	}
+	return h.handle
+}
+
+func (h *handler) handle(c *fiber.Ctx) (err error) {
+	// fasthttp, on which fiber is based, doesn't use the standard
+	// net/http.Request, so the hub can't come from the request.
+	hub := sentry.CurrentHub().Clone()
+
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	r := convert(c)
+	transaction := sentry.StartTransaction(
+		sentry.SetHubOnContext(c.UserContext(), hub),
+		fmt.Sprintf("%s %s", r.Method, r.URL.Path),
+		sentry.WithOpName("http.server"),
+		sentry.ContinueFromRequest(r),
+		sentry.WithTransactionSource(sentry.SourceURL),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	defer func() {
+		status := responseStatus(c, err)
+		// fiber routes requests through the handlers one after the other,
+		// so the route of the request is only known once they returned.
+		if status != http.StatusNotFound {
+			if route := c.Route(); route != nil && route.Path != "" {
+				transaction.Name = fmt.Sprintf("%s %s", r.Method, route.Path)
+				transaction.Source = sentry.SourceRoute
+			}
+		}
+		transaction.Status = sentry.HTTPtoSpanStatus(status)
+		transaction.Finish()
+	}()
+
+	r = r.WithContext(transaction.Context())
+	c.SetUserContext(transaction.Context())
+	scope := hub.Scope()
+	scope.SetRequest(r)
+	// The body of the request is reused by fasthttp once it is handled.
+	scope.SetRequestBody(append([]byte(nil), c.Body()...))
+	c.Locals(valuesKey, hub)
+	c.Locals(transactionKey, transaction)
+	defer h.recoverWithSentry(hub, r, &err)
+	return c.Next()
+}
+
+// recoverWithSentry reports panics and sets *err to the recovered panic, so
+// that the transaction is marked as an internal error. It then either
+// repanics or lets *err be passed to the ErrorHandler of the application.
+func (h *handler) recoverWithSentry(hub *sentry.Hub, r *http.Request, err *error) {
+	if v := recover(); v != nil {
+		eventID := hub.RecoverWithContext(
+			context.WithValue(r.Context(), sentry.RequestContextKey, r),
+			v,
+		)
+		if eventID != nil && h.waitForDelivery {
+			hub.Flush(h.timeout)
+		}
+		if e, ok := v.(error); ok {
+			*err = fmt.Errorf("sentryfiber: panic: %w", e)
+		} else {
+			*err = fmt.Errorf("sentryfiber: panic: %v", v)
+		}
+		if h.repanic {
+			panic(v)
+		}
+	}
+}
+
+// responseStatus returns the status code of the response to a request handled
+// with err. The ErrorHandler of the application only responds to errors once
+// the handlers returned, so the status code is derived from err in that case.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		return fiberError.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// GetHubFromContext retrieves attached *sentry.Hub instance from fiber.Ctx.
+func GetHubFromContext(c *fiber.Ctx) *sentry.Hub {
+	if hub, ok := c.Locals(valuesKey).(*sentry.Hub); ok {
+		return hub
+	}
+	return nil
+}
+
+// GetSpanFromContext retrieves the transaction of the request, started by
+// the middleware, from fiber.Ctx.
+func GetSpanFromContext(c *fiber.Ctx) *sentry.Span {
+	if span, ok := c.Locals(transactionKey).(*sentry.Span); ok {
+		return span
+	}
+	return nil
+}
+
+// convert returns an *http.Request with the method, URL, headers, cookies
+// and remote address of the request of c. Its body is left empty, the body of
+// the request is set on the scope separately.
+func convert(c *fiber.Ctx) *http.Request {
+	ctx := c.Context()
+	r := new(http.Request)
+
+	r.Method = c.Method()
+	uri := ctx.URI()
+	r.URL = &url.URL{
+		Scheme:   string(uri.Scheme()),
+		Host:     string(uri.Host()),
+		Path:     string(uri.Path()),
+		RawQuery: string(uri.QueryString()),
+	}
+
+	r.Header = make(http.Header)
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		r.Header.Add(string(key), string(value))
+	})
+	r.Host = string(ctx.Host())
+	r.ContentLength = int64(ctx.Request.Header.ContentLength())
+	r.RemoteAddr = ctx.RemoteAddr().String()
+
+	return r.WithContext(c.UserContext())
+}
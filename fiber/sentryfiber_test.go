@@ -0,0 +1,158 @@
+package sentryfiber_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryfiber "github.com/getsentry/sentry-go/fiber"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIntegration(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Use(sentryfiber.New(sentryfiber.Options{}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		if sentryfiber.GetSpanFromContext(c) != sentry.SpanFromContext(c.UserContext()) {
+			t.Error("transaction of fiber.Ctx differs from the span of the user context")
+		}
+		sentryfiber.GetHubFromContext(c).CaptureMessage("user " + c.Params("id"))
+		return c.SendStatus(http.StatusNoContent)
+	})
+	app.Get("/forbidden", func(c *fiber.Ctx) error {
+		return fiber.ErrForbidden
+	})
+	app.Post("/panic", func(c *fiber.Ctx) error {
+		panic("test")
+	})
+
+	tests := []struct {
+		method     string
+		path       string
+		body       string
+		wantName   string
+		wantSource sentry.TransactionSource
+		wantStatus sentry.SpanStatus
+		wantCode   int
+	}{
+		{http.MethodGet, "/users/42?q=1", "", "GET /users/:id", sentry.SourceRoute, sentry.SpanStatusOK, http.StatusNoContent},
+		{http.MethodGet, "/forbidden", "", "GET /forbidden", sentry.SourceRoute, sentry.SpanStatusPermissionDenied, http.StatusForbidden},
+		{http.MethodPost, "/panic", `{"a":1}`, "POST /panic", sentry.SourceRoute, sentry.SpanStatusInternalError, http.StatusInternalServerError},
+		{http.MethodGet, "/missing", "", "GET /missing", sentry.SourceURL, sentry.SpanStatusNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		transactions = nil
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("User-Agent", "sentry-test")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.wantCode {
+			t.Errorf("%s %s: got status code %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+		}
+		if len(transactions) != 1 {
+			t.Fatalf("%s %s: got %d transactions, want 1", tt.method, tt.path, len(transactions))
+		}
+		transaction := transactions[0]
+		if diff := cmp.Diff(tt.wantName, transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantSource, transaction.TransactionInfo.Source); diff != "" {
+			t.Errorf("transaction source mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantStatus, transaction.Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("user 42", events[0].Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	wantRequest := sentry.Request{
+		URL:         "http://example.com/users/42",
+		Method:      http.MethodGet,
+		QueryString: "q=1",
+		Headers: map[string]string{
+			"Content-Length": "0",
+			"Host":           "example.com",
+			"User-Agent":     "sentry-test",
+		},
+	}
+	if diff := cmp.Diff(wantRequest, *events[0].Request); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, events[1].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(`{"a":1}`, events[1].Request.Data); diff != "" {
+		t.Errorf("panic request body mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIntegrationRepanic(t *testing.T) {
+	var transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(sentryfiber.New(sentryfiber.Options{Repanic: true}))
+	app.Post("/panic", func(c *fiber.Ctx) error {
+		panic("test")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/panic", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("got status code %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transactions[0].Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
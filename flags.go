@@ -0,0 +1,53 @@
+package sentry
+
+// maxFeatureFlags is the number of feature flag evaluations kept by a scope.
+const maxFeatureFlags = 100
+
+// flagsContextKey is the key of the context holding the feature flags
+// evaluated before an error was captured.
+const flagsContextKey = "flags"
+
+// FeatureFlag is the result of the evaluation of a boolean feature flag.
+//
+// The feature flags evaluated in a scope are attached to the errors captured in
+// that scope, so that Sentry can show which flags were enabled when an error
+// occurred.
+type FeatureFlag struct {
+	Flag   string `json:"flag"`
+	Result bool   `json:"result"`
+}
+
+// AddFeatureFlag records the result of the evaluation of a feature flag. Only
+// the last evaluation of a flag is kept, and at most the 100 most recently
+// evaluated flags.
+//
+// Integrations with feature flag libraries typically call AddFeatureFlag on the
+// scope of the hub bound to the context of a request, so that flags evaluated
+// while handling the request are attached to the errors it captures:
+//
+//	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+//		hub.Scope().AddFeatureFlag("new-checkout", enabled)
+//	}
+func (scope *Scope) AddFeatureFlag(flag string, result bool) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	flags := make([]FeatureFlag, 0, len(scope.flags)+1)
+	for _, f := range scope.flags {
+		if f.Flag != flag {
+			flags = append(flags, f)
+		}
+	}
+	flags = append(flags, FeatureFlag{Flag: flag, Result: result})
+	if len(flags) > maxFeatureFlags {
+		flags = flags[len(flags)-maxFeatureFlags:]
+	}
+	scope.flags = flags
+}
+
+// flagsContext returns the context listing the given feature flag evaluations.
+func flagsContext(flags []FeatureFlag) Context {
+	values := make([]FeatureFlag, len(flags))
+	copy(values, flags)
+	return Context{"values": values}
+}
@@ -0,0 +1,53 @@
+package sentry
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScopeAddFeatureFlag(t *testing.T) {
+	scope := NewScope()
+	scope.AddFeatureFlag("a", true)
+	scope.AddFeatureFlag("b", false)
+	scope.AddFeatureFlag("a", false)
+
+	assertEqual(t, scope.flags, []FeatureFlag{
+		{Flag: "b", Result: false},
+		{Flag: "a", Result: false},
+	})
+}
+
+func TestScopeAddFeatureFlagLimit(t *testing.T) {
+	scope := NewScope()
+	for i := 0; i < maxFeatureFlags+10; i++ {
+		scope.AddFeatureFlag(fmt.Sprintf("flag-%d", i), true)
+	}
+
+	assertEqual(t, len(scope.flags), maxFeatureFlags)
+	assertEqual(t, scope.flags[0].Flag, "flag-10")
+}
+
+func TestScopeAddFeatureFlagDoesNotAffectClone(t *testing.T) {
+	scope := NewScope()
+	scope.AddFeatureFlag("a", true)
+	clone := scope.Clone()
+	clone.AddFeatureFlag("a", false)
+	clone.AddFeatureFlag("b", true)
+
+	assertEqual(t, scope.flags, []FeatureFlag{{Flag: "a", Result: true}})
+}
+
+func TestApplyToEventFeatureFlags(t *testing.T) {
+	scope := NewScope()
+	scope.AddFeatureFlag("a", true)
+
+	event := scope.ApplyToEvent(NewEvent(), nil)
+	assertEqual(t, event.Contexts["flags"], Context{"values": []FeatureFlag{{Flag: "a", Result: true}}})
+
+	transaction := NewEvent()
+	transaction.Type = transactionType
+	transaction = scope.ApplyToEvent(transaction, nil)
+	if _, ok := transaction.Contexts["flags"]; ok {
+		t.Error("feature flags should not be attached to transactions")
+	}
+}
@@ -0,0 +1,281 @@
+// Package sentrygcp provides Sentry integration for Google Cloud Functions
+// and Cloud Run services.
+//
+// Wrap the HTTP handlers of services and functions, and the event functions:
+//
+//	func init() {
+//		sentry.Init(sentry.ClientOptions{})
+//		functions.HTTP("Greet", sentrygcp.WrapHTTP(greet, sentrygcp.Options{}))
+//	}
+//
+// Each invocation runs with a clone of the current hub, whose scope has the
+// "cloud" and "gcp" contexts of the execution: the service, revision and
+// function from the environment, and the execution ID from the request
+// headers. Panics are reported to Sentry, and events are flushed before the
+// invocation returns, within the function timeout, as the instance may be
+// throttled afterwards.
+package sentrygcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The identifier of the GCP SDK.
+const sdkIdentifier = "sentry.go.gcp"
+
+// spanOperation is the operation of the transactions of invocations.
+const spanOperation = "function.gcp"
+
+// spanOrigin is the origin of the transactions of invocations.
+const spanOrigin = "auto.function.gcp"
+
+// flushMargin is the time left to the function to return after flushing.
+const flushMargin = 100 * time.Millisecond
+
+// Headers identifying executions.
+const (
+	executionIDHeader = "Function-Execution-Id"
+	cloudTraceHeader  = "X-Cloud-Trace-Context"
+)
+
+// defaultName is the name of transactions when the environment doesn't name
+// the function or service.
+const defaultName = "function"
+
+// Options configure the wrappers.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a
+	// panic. Otherwise, HTTP handlers respond with an internal server error,
+	// and event functions return an error.
+	Repanic bool
+	// Timeout for the delivery of the events of an invocation, when it
+	// returns. Defaults to 2s, and is capped to the time left before the
+	// function timeout.
+	Timeout time.Duration
+	// FunctionTimeout is the maximum duration of invocations. Defaults to
+	// the FUNCTION_TIMEOUT_SEC environment variable, if set.
+	FunctionTimeout time.Duration
+}
+
+type handler struct {
+	repanic         bool
+	timeout         time.Duration
+	functionTimeout time.Duration
+	environment     sentry.Context
+	name            string
+}
+
+func newHandler(options Options) *handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	functionTimeout := options.FunctionTimeout
+	if functionTimeout == 0 {
+		if seconds, err := strconv.Atoi(os.Getenv("FUNCTION_TIMEOUT_SEC")); err == nil {
+			functionTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+	h := &handler{
+		repanic:         options.Repanic,
+		timeout:         timeout,
+		functionTimeout: functionTimeout,
+		environment:     sentry.Context{},
+	}
+	// Cloud Run and second generation functions set the K_ variables, first
+	// generation functions the FUNCTION_ ones.
+	for key, env := range map[string]string{
+		"service":       "K_SERVICE",
+		"revision":      "K_REVISION",
+		"configuration": "K_CONFIGURATION",
+		"function":      "FUNCTION_TARGET",
+		"job":           "CLOUD_RUN_JOB",
+		"job_execution": "CLOUD_RUN_EXECUTION",
+	} {
+		if value := os.Getenv(env); value != "" {
+			h.environment[key] = value
+		}
+	}
+	if name := os.Getenv("FUNCTION_NAME"); name != "" {
+		h.environment["function"] = name
+	}
+	h.name = defaultName
+	for _, key := range []string{"function", "service", "job"} {
+		if name, ok := h.environment[key].(string); ok {
+			h.name = name
+			break
+		}
+	}
+	return h
+}
+
+// WrapHTTP returns an http.HandlerFunc instrumenting handler, for HTTP
+// functions and Cloud Run services. Requests run in transactions continuing
+// the trace of their headers.
+func WrapHTTP(handler http.HandlerFunc, options Options) http.HandlerFunc {
+	h := newHandler(options)
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, hub, finish := h.start(r.Context(), executionID(r.Header),
+			sentry.ContinueFromRequest(r),
+		)
+		r = r.WithContext(ctx)
+		hub.Scope().SetRequest(r)
+
+		var status int
+		defer func() {
+			if v := recover(); v != nil {
+				h.reportPanic(ctx, hub, v, finish)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			finish(sentry.HTTPtoSpanStatus(status))
+		}()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		handler(sw, r)
+		status = sw.status
+	}
+}
+
+// WrapEventFunction returns an event function instrumenting function, for
+// background and CloudEvent functions. Errors returned by function are
+// reported to Sentry.
+func WrapEventFunction[E any](function func(context.Context, E) error, options Options) func(context.Context, E) error {
+	h := newHandler(options)
+	return func(ctx context.Context, event E) (err error) {
+		ctx, hub, finish := h.start(ctx, "")
+		defer func() {
+			if v := recover(); v != nil {
+				h.reportPanic(ctx, hub, v, finish)
+				err = errPanic
+				return
+			}
+			finish(sentry.SpanStatusFromError(err))
+		}()
+		if err = function(ctx, event); err != nil {
+			hub.CaptureException(err)
+		}
+		return err
+	}
+}
+
+// start starts an invocation with a clone of the hub of ctx, or of the
+// current hub, and returns the context of its transaction and a function
+// finishing the invocation with a status.
+func (h *handler) start(ctx context.Context, executionID string, options ...sentry.SpanOption) (context.Context, *sentry.Hub, func(sentry.SpanStatus)) {
+	start := time.Now()
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	scope := hub.Scope()
+	cloud := sentry.Context{"provider": "gcp"}
+	if region := os.Getenv("FUNCTION_REGION"); region != "" {
+		cloud["region"] = region
+	}
+	if project := projectID(); project != "" {
+		cloud["account_id"] = project
+	}
+	scope.SetContext("cloud", cloud)
+	gcp := make(sentry.Context, len(h.environment)+1)
+	for key, value := range h.environment {
+		gcp[key] = value
+	}
+	if executionID != "" {
+		gcp["execution_id"] = executionID
+		scope.SetTag("gcp.execution_id", executionID)
+	}
+	scope.SetContext("gcp", gcp)
+
+	options = append(options,
+		sentry.WithOpName(spanOperation),
+		sentry.WithTransactionSource(sentry.SourceComponent),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction := sentry.StartTransaction(sentry.SetHubOnContext(ctx, hub), h.name, options...)
+
+	return transaction.Context(), hub, func(status sentry.SpanStatus) {
+		transaction.Status = status
+		transaction.Finish()
+		hub.Flush(h.flushTimeout(start))
+	}
+}
+
+// reportPanic reports a panic and finishes the invocation, then panics again
+// if configured to.
+func (h *handler) reportPanic(ctx context.Context, hub *sentry.Hub, v interface{}, finish func(sentry.SpanStatus)) {
+	hub.RecoverWithContext(ctx, v)
+	finish(sentry.SpanStatusInternalError)
+	if h.repanic {
+		panic(v)
+	}
+}
+
+// flushTimeout returns the timeout of the delivery of events of an invocation
+// started at start, capped to the time left before the function timeout.
+func (h *handler) flushTimeout(start time.Time) time.Duration {
+	timeout := h.timeout
+	if h.functionTimeout > 0 {
+		if left := time.Until(start.Add(h.functionTimeout)) - flushMargin; left < timeout {
+			timeout = left
+		}
+	}
+	if timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// executionID returns the execution ID of a request, set by Cloud Functions,
+// or the trace ID set by Cloud Run.
+func executionID(header http.Header) string {
+	if id := header.Get(executionIDHeader); id != "" {
+		return id
+	}
+	// The header has the form "TRACE_ID/SPAN_ID;o=TRACE_TRUE".
+	trace := header.Get(cloudTraceHeader)
+	if i := strings.IndexByte(trace, '/'); i >= 0 {
+		trace = trace[:i]
+	}
+	return trace
+}
+
+// projectID returns the ID of the project of the function, if set in the
+// environment.
+func projectID() string {
+	for _, env := range []string{"GOOGLE_CLOUD_PROJECT", "GCP_PROJECT"} {
+		if project := os.Getenv(env); project != "" {
+			return project
+		}
+	}
+	return ""
+}
+
+// errPanic is returned by event functions that panicked.
+var errPanic = errors.New("sentrygcp: function panicked")
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
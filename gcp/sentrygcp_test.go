@@ -0,0 +1,146 @@
+package sentrygcp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrygcp "github.com/getsentry/sentry-go/gcp"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initSentry(t *testing.T) (events, transactions *[]*sentry.Event) {
+	events, transactions = new([]*sentry.Event), new([]*sentry.Event)
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			*events = append(*events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			*transactions = append(*transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events, transactions
+}
+
+func TestWrapHTTP(t *testing.T) {
+	t.Setenv("K_SERVICE", "greeter")
+	t.Setenv("K_REVISION", "greeter-00042-abc")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "acme")
+	events, transactions := initSentry(t)
+
+	handler := sentrygcp.WrapHTTP(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("test")
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			sentry.GetHubFromContext(r.Context()).CaptureMessage("hello")
+		}
+	}, sentrygcp.Options{})
+
+	tests := []struct {
+		path       string
+		wantCode   int
+		wantStatus sentry.SpanStatus
+	}{
+		{"/", http.StatusOK, sentry.SpanStatusOK},
+		{"/missing", http.StatusNotFound, sentry.SpanStatusNotFound},
+		{"/panic", http.StatusInternalServerError, sentry.SpanStatusInternalError},
+	}
+	for _, tt := range tests {
+		*transactions = nil
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+		r.Header.Set("sentry-trace", "d49d9bf66f13450b81f65bc51cf49c03-1cc4b26ab9094ef0-1")
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: got status code %d, want %d", tt.path, w.Code, tt.wantCode)
+		}
+		if len(*transactions) != 1 {
+			t.Fatalf("%s: got %d transactions, want 1", tt.path, len(*transactions))
+		}
+		transaction := (*transactions)[0]
+		if diff := cmp.Diff("greeter", transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantStatus, transaction.Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(
+			"d49d9bf66f13450b81f65bc51cf49c03",
+			transaction.Contexts["trace"]["trace_id"].(sentry.TraceID).String(),
+		); diff != "" {
+			t.Errorf("trace ID mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2", len(*events))
+	}
+	event := (*events)[0]
+	if diff := cmp.Diff(sentry.Context{
+		"service":      "greeter",
+		"revision":     "greeter-00042-abc",
+		"execution_id": "105445aa7843bc8bf206b12000100000",
+	}, event.Contexts["gcp"]); diff != "" {
+		t.Errorf("gcp context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.Context{
+		"provider":   "gcp",
+		"account_id": "acme",
+	}, event.Contexts["cloud"]); diff != "" {
+		t.Errorf("cloud context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, (*events)[1].Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrapEventFunction(t *testing.T) {
+	t.Setenv("FUNCTION_TARGET", "OnMessage")
+	events, transactions := initSentry(t)
+
+	function := sentrygcp.WrapEventFunction(func(ctx context.Context, message string) error {
+		if message == "" {
+			return errors.New("empty message")
+		}
+		return nil
+	}, sentrygcp.Options{})
+
+	if err := function(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := function(context.Background(), ""); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var statuses []sentry.SpanStatus
+	for _, transaction := range *transactions {
+		if diff := cmp.Diff("OnMessage", transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		statuses = append(statuses, transaction.Contexts["trace"]["status"].(sentry.SpanStatus))
+	}
+	if diff := cmp.Diff([]sentry.SpanStatus{sentry.SpanStatusOK, sentry.SpanStatusInternalError}, statuses); diff != "" {
+		t.Errorf("transaction statuses mismatch (-want +got):\n%s", diff)
+	}
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+	if diff := cmp.Diff("empty message", (*events)[0].Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,10 +19,14 @@ const sdkIdentifier = "sentry.go.gin"
 
 const valuesKey = "sentry"
 
+// spanOrigin is the origin of the transactions of requests.
+const spanOrigin = "auto.http.gin"
+
 type handler struct {
 	repanic         bool
 	waitForDelivery bool
 	timeout         time.Duration
+	statusLevels    map[int]sentry.Level
 }
 
 type Options struct {
@@ -34,6 +39,14 @@ type Options struct {
 	WaitForDelivery bool
 	// Timeout for the event delivery requests.
 	Timeout time.Duration
+	// StatusLevels configures the responses reported to Sentry as events, by
+	// status class: the keys are the first digit of status codes, such as 5
+	// for 5xx responses, and the values the level of the events. The events
+	// report the last of the errors of gin.Context, if any, and include all of
+	// them in the "gin" context. For example, to report server errors:
+	//
+	//	StatusLevels: map[int]sentry.Level{5: sentry.LevelError}
+	StatusLevels map[int]sentry.Level
 }
 
 // New returns a function that satisfies gin.HandlerFunc interface
@@ -47,6 +60,7 @@ func New(options Options) gin.HandlerFunc {
 		repanic:         options.Repanic,
 		timeout:         timeout,
 		waitForDelivery: options.WaitForDelivery,
+		statusLevels:    options.StatusLevels,
 	}).handle
 }
 
@@ -75,6 +89,7 @@ func (h *handler) handle(c *gin.Context) {
 		sentry.WithOpName("http.server"),
 		sentry.ContinueFromRequest(c.Request),
 		sentry.WithTransactionSource(transactionSource),
+		sentry.WithSpanOrigin(spanOrigin),
 	}
 
 	transaction := sentry.StartTransaction(ctx,
@@ -91,6 +106,39 @@ func (h *handler) handle(c *gin.Context) {
 	c.Set(valuesKey, hub)
 	defer h.recoverWithSentry(hub, c.Request)
 	c.Next()
+	h.captureStatus(hub, c)
+}
+
+// captureStatus reports the response to the request of c as an event, if its
+// status class has a level in the options.
+func (h *handler) captureStatus(hub *sentry.Hub, c *gin.Context) {
+	status := c.Writer.Status()
+	level, ok := h.statusLevels[status/100]
+	if !ok {
+		return
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(level)
+		scope.SetTag("http.status_code", strconv.Itoa(status))
+		if err := c.Errors.Last(); err != nil {
+			scope.SetContext("gin", sentry.Context{
+				"errors": c.Errors.Errors(),
+			})
+			hub.CaptureException(err.Err)
+			return
+		}
+		hub.CaptureMessage(fmt.Sprintf("%s %s: %d %s",
+			c.Request.Method, requestPath(c), status, http.StatusText(status)))
+	})
+}
+
+// requestPath returns the route of the request of c, or its path if it didn't
+// match a route.
+func requestPath(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
+	}
+	return c.Request.URL.Path
 }
 
 func (h *handler) recoverWithSentry(hub *sentry.Hub, r *http.Request) {
@@ -1,6 +1,7 @@
 package sentrygin_test
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -363,7 +364,7 @@ func TestIntegration(t *testing.T) {
 			sentry.Event{},
 			"Contexts", "EventID", "Extra", "Platform", "Modules",
 			"Release", "Sdk", "ServerName", "Tags", "Timestamp",
-			"sdkMetaData", "attachments",
+			"sdkMetaData", "attachments", "meta", "metricsSummary",
 		),
 		cmpopts.IgnoreFields(
 			sentry.Request{},
@@ -387,7 +388,7 @@ func TestIntegration(t *testing.T) {
 			sentry.Event{},
 			"Contexts", "EventID", "Platform", "Modules",
 			"Release", "Sdk", "ServerName", "Timestamp",
-			"sdkMetaData", "StartTime", "Spans", "attachments",
+			"sdkMetaData", "StartTime", "Spans", "attachments", "meta", "metricsSummary",
 		),
 		cmpopts.IgnoreFields(
 			sentry.Request{},
@@ -402,3 +403,88 @@ func TestIntegration(t *testing.T) {
 		t.Fatalf("Transaction status codes mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestStatusLevels(t *testing.T) {
+	var events, transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		hub := sentry.NewHub(client, sentry.NewScope())
+		c.Request = c.Request.WithContext(sentry.SetHubOnContext(c.Request.Context(), hub))
+	})
+	router.Use(sentrygin.New(sentrygin.Options{
+		StatusLevels: map[int]sentry.Level{
+			4: sentry.LevelWarning,
+			5: sentry.LevelError,
+		},
+	}))
+	router.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/unauthorized", func(c *gin.Context) {
+		c.Status(http.StatusUnauthorized)
+	})
+	router.GET("/error", func(c *gin.Context) {
+		_ = c.Error(errors.New("connection refused"))
+		_ = c.Error(errors.New("upstream unavailable"))
+		c.Status(http.StatusBadGateway)
+	})
+
+	for _, path := range []string{"/ok", "/unauthorized", "/error"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var statuses []sentry.SpanStatus
+	for _, transaction := range transactions {
+		statuses = append(statuses, transaction.Contexts["trace"]["status"].(sentry.SpanStatus))
+	}
+	wantStatuses := []sentry.SpanStatus{
+		sentry.SpanStatusOK,
+		sentry.SpanStatusUnauthenticated,
+		sentry.SpanStatusInternalError,
+	}
+	if diff := cmp.Diff(wantStatuses, statuses); diff != "" {
+		t.Errorf("transaction statuses mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("GET /unauthorized: 401 Unauthorized", events[0].Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelWarning, events[0].Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("401", events[0].Tags["http.status_code"]); diff != "" {
+		t.Errorf("status code tag mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(sentry.LevelError, events[1].Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("upstream unavailable", events[1].Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(
+		[]string{"connection refused", "upstream unavailable"},
+		events[1].Contexts["gin"]["errors"],
+	); diff != "" {
+		t.Errorf("errors context mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,204 @@
+// Package sentrygorm provides Sentry instrumentation for GORM.
+//
+// Register the plugin to record a "db.query" span for each statement run
+// with a context containing a span, with the SQL of the statement as
+// description and the number of rows affected:
+//
+//	db, err := gorm.Open(postgres.Open(dsn))
+//	err = db.Use(sentrygorm.New(sentrygorm.Options{
+//		SlowQueryThreshold: time.Second,
+//	}))
+//	db.WithContext(transaction.Context()).First(&user, id)
+package sentrygorm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentrysql "github.com/getsentry/sentry-go/sql"
+	"gorm.io/gorm"
+)
+
+// spanOperation is the operation of the spans recorded for statements.
+const spanOperation = "db.query"
+
+// spanOrigin is the origin of the spans recorded for statements.
+const spanOrigin = "auto.db.gorm"
+
+// instanceKey is the key of the state of the instrumentation in the instance
+// settings of a statement.
+const instanceKey = "sentry:statement"
+
+// Options configure the plugin.
+type Options struct {
+	// SlowQueryThreshold is the duration from which statements are considered
+	// slow. Slow statements are recorded as breadcrumbs on the hub of the
+	// statement context, or on the current hub. Zero disables the detection
+	// of slow statements.
+	SlowQueryThreshold time.Duration
+	// CaptureSlowQueries configures whether slow statements are reported to
+	// Sentry as warning events, in addition to being recorded as
+	// breadcrumbs.
+	CaptureSlowQueries bool
+}
+
+// Plugin is a GORM plugin instrumenting the create, query, update, delete,
+// row and raw callbacks.
+type Plugin struct {
+	options Options
+}
+
+// New returns a new Plugin. Register it with gorm.DB.Use.
+func New(options Options) *Plugin {
+	return &Plugin{options: options}
+}
+
+// Name implements gorm.Plugin.
+func (p *Plugin) Name() string {
+	return "sentry"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	// registerer is implemented by the callbacks of GORM, whose type is
+	// unexported.
+	type registerer interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+	callbacks := db.Callback()
+	for _, c := range []struct {
+		operation     string
+		before, after registerer
+	}{
+		{"create", callbacks.Create().Before("gorm:create"), callbacks.Create().After("gorm:create")},
+		{"query", callbacks.Query().Before("gorm:query"), callbacks.Query().After("gorm:query")},
+		{"update", callbacks.Update().Before("gorm:update"), callbacks.Update().After("gorm:update")},
+		{"delete", callbacks.Delete().Before("gorm:delete"), callbacks.Delete().After("gorm:delete")},
+		{"row", callbacks.Row().Before("gorm:row"), callbacks.Row().After("gorm:row")},
+		{"raw", callbacks.Raw().Before("gorm:raw"), callbacks.Raw().After("gorm:raw")},
+	} {
+		if err := c.before.Register("sentry:before_"+c.operation, p.before(c.operation)); err != nil {
+			return err
+		}
+		if err := c.after.Register("sentry:after_"+c.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statement is the state of the instrumentation of a statement, kept between
+// the before and after callbacks.
+type statement struct {
+	span  *sentry.Span
+	start time.Time
+}
+
+func (p *Plugin) before(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		s := &statement{start: time.Now()}
+		if parent := sentry.SpanFromContext(db.Statement.Context); parent != nil {
+			s.span = parent.StartChild(spanOperation, sentry.WithSpanOrigin(spanOrigin))
+			s.span.SetData("db.operation", operation)
+			if system := databaseSystem(db); system != "" {
+				s.span.SetData("db.system", system)
+			}
+		}
+		db.InstanceSet(instanceKey, s)
+	}
+}
+
+func (p *Plugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(instanceKey)
+	if !ok {
+		return
+	}
+	s, ok := v.(*statement)
+	if !ok {
+		return
+	}
+	duration := time.Since(s.start)
+	query := sentrysql.SanitizeQuery(db.Statement.SQL.String())
+	table := db.Statement.Table
+
+	if s.span != nil {
+		s.span.Description = query
+		if table != "" {
+			s.span.SetData("db.sql.table", table)
+		}
+		if s.span.Data == nil {
+			s.span.Data = make(map[string]interface{})
+		}
+		s.span.Data["db.rows_affected"] = db.RowsAffected
+		switch {
+		case db.Error == nil:
+			s.span.Status = sentry.SpanStatusOK
+		case errors.Is(db.Error, gorm.ErrRecordNotFound):
+			s.span.Status = sentry.SpanStatusNotFound
+		default:
+			s.span.SetError(db.Error)
+		}
+		s.span.Finish()
+	}
+
+	if p.options.SlowQueryThreshold > 0 && duration >= p.options.SlowQueryThreshold {
+		p.slowQuery(db, query, table, duration)
+	}
+}
+
+// slowQuery records a slow statement as a breadcrumb, and reports it if
+// enabled.
+func (p *Plugin) slowQuery(db *gorm.DB, query, table string, duration time.Duration) {
+	hub := sentry.GetHubFromContext(db.Statement.Context)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	data := map[string]interface{}{
+		"duration_ms":   duration.Milliseconds(),
+		"rows_affected": db.RowsAffected,
+	}
+	if table != "" {
+		data["table"] = table
+	}
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Type:     "query",
+		Category: "db.query",
+		Message:  query,
+		Data:     data,
+		Level:    sentry.LevelWarning,
+	}, nil)
+
+	if !p.options.CaptureSlowQueries {
+		return
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelWarning)
+		scope.SetContext("database", sentry.Context{
+			"query":         query,
+			"system":        databaseSystem(db),
+			"table":         table,
+			"duration_ms":   duration.Milliseconds(),
+			"rows_affected": db.RowsAffected,
+		})
+		// Group the events of a statement together, whatever its duration.
+		scope.SetFingerprint([]string{"gorm-slow-query", query})
+		hub.CaptureMessage(fmt.Sprintf("Slow query: %s", query))
+	})
+}
+
+// databaseSystem returns the "db.system" of the dialector of db.
+func databaseSystem(db *gorm.DB) string {
+	if db.Dialector == nil {
+		return ""
+	}
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		return "postgresql"
+	case "sqlserver":
+		return "mssql"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,163 @@
+package sentrygorm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentrygorm "github.com/getsentry/sentry-go/gorm"
+	"github.com/glebarez/sqlite"
+	"github.com/google/go-cmp/cmp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type User struct {
+	ID   uint
+	Name string
+}
+
+func openDB(t *testing.T, options sentrygorm.Options) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(sentrygorm.New(options)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPlugin(t *testing.T) {
+	var transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	db := openDB(t, sentrygorm.Options{})
+
+	transaction := sentry.StartTransaction(ctx, "test")
+	tx := db.WithContext(transaction.Context())
+	if err := tx.Create(&User{Name: "alice"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Model(&User{}).Where("name = ?", "alice").Update("name", "bob").Error; err != nil {
+		t.Fatal(err)
+	}
+	var user User
+	if err := tx.Where("name = ?", "carol").First(&user).Error; err == nil {
+		t.Fatal("expected an error")
+	}
+	transaction.Finish()
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	type span struct {
+		Description string
+		Status      sentry.SpanStatus
+		Data        map[string]interface{}
+	}
+	var got []span
+	for _, s := range transactions[0].Spans {
+		got = append(got, span{s.Description, s.Status, s.Data})
+	}
+	want := []span{
+		{
+			Description: "INSERT INTO `users` (`name`) VALUES (?) RETURNING `id`",
+			Status:      sentry.SpanStatusOK,
+			Data: map[string]interface{}{
+				"db.system":        "sqlite",
+				"db.operation":     "create",
+				"db.sql.table":     "users",
+				"db.rows_affected": int64(1),
+			},
+		},
+		{
+			Description: "UPDATE `users` SET `name`=? WHERE name = ?",
+			Status:      sentry.SpanStatusOK,
+			Data: map[string]interface{}{
+				"db.system":        "sqlite",
+				"db.operation":     "update",
+				"db.sql.table":     "users",
+				"db.rows_affected": int64(1),
+			},
+		},
+		{
+			Description: "SELECT * FROM `users` WHERE name = ? ORDER BY `users`.`id` LIMIT ?",
+			Status:      sentry.SpanStatusNotFound,
+			Data: map[string]interface{}{
+				"db.system":        "sqlite",
+				"db.operation":     "query",
+				"db.sql.table":     "users",
+				"db.rows_affected": int64(0),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPluginSlowQueries(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	db := openDB(t, sentrygorm.Options{
+		SlowQueryThreshold: time.Nanosecond,
+		CaptureSlowQueries: true,
+	})
+
+	var users []User
+	if err := db.WithContext(ctx).Where("name = ?", "alice").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	query := "SELECT * FROM `users` WHERE name = ?"
+	if diff := cmp.Diff("Slow query: "+query, event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelWarning, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"gorm-slow-query", query}, event.Fingerprint); diff != "" {
+		t.Errorf("fingerprint mismatch (-want +got):\n%s", diff)
+	}
+	if len(event.Breadcrumbs) != 1 {
+		t.Fatalf("got %d breadcrumbs, want 1", len(event.Breadcrumbs))
+	}
+	breadcrumb := event.Breadcrumbs[0]
+	if diff := cmp.Diff(query, breadcrumb.Message); diff != "" {
+		t.Errorf("breadcrumb message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("users", breadcrumb.Data["table"]); diff != "" {
+		t.Errorf("breadcrumb table mismatch (-want +got):\n%s", diff)
+	}
+}
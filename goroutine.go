@@ -0,0 +1,74 @@
+package sentry
+
+import (
+	"context"
+	"time"
+)
+
+// defaultGoTimeout is the default time GoWithOptions waits for the delivery of
+// panic events.
+const defaultGoTimeout = 2 * time.Second
+
+// GoOptions configure how GoWithOptions handles panics.
+type GoOptions struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// and reporting it, crashing the program as an unhandled panic would. By
+	// default the goroutine ends after the panic is reported, and the program
+	// keeps running.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to wait until the
+	// panic event has been reported to Sentry before repanicking or ending the
+	// goroutine. It is implied by Repanic, since the program exits right after.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery or Repanic is true.
+	Timeout time.Duration
+}
+
+// Go runs f in a new goroutine, with a context carrying a clone of the hub of
+// ctx, or of the current hub if ctx has none. Scope changes made by f are
+// isolated to the goroutine, while the data set on the scope before Go was
+// called is kept. A panic in f is captured and ends the goroutine without
+// crashing the program.
+//
+//	sentry.Go(ctx, func(ctx context.Context) {
+//		sentry.HubFromContext(ctx).Scope().SetTag("job", "refresh-cache")
+//		refreshCache(ctx)
+//	})
+func Go(ctx context.Context, f func(ctx context.Context)) {
+	GoWithOptions(ctx, GoOptions{}, f)
+}
+
+// GoWithOptions is like Go, with control over how panics are handled.
+func GoWithOptions(ctx context.Context, options GoOptions, f func(ctx context.Context)) {
+	hub := hubFromContext(ctx).Clone()
+	ctx = SetHubOnContext(ctx, hub)
+	go func() {
+		defer recoverGoroutine(ctx, hub, options)
+		f(ctx)
+	}()
+}
+
+func recoverGoroutine(ctx context.Context, hub *Hub, options GoOptions) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	reportGoroutinePanic(ctx, hub, err, options)
+}
+
+// reportGoroutinePanic reports a recovered panic, then panics again if
+// configured to.
+func reportGoroutinePanic(ctx context.Context, hub *Hub, err interface{}, options GoOptions) {
+	eventID := hub.RecoverWithContext(ctx, err)
+	if eventID != nil && (options.WaitForDelivery || options.Repanic) {
+		timeout := options.Timeout
+		if timeout == 0 {
+			timeout = defaultGoTimeout
+		}
+		hub.Flush(timeout)
+	}
+	if options.Repanic {
+		panic(err)
+	}
+}
@@ -0,0 +1,54 @@
+package sentry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGo(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+	scope.SetTag("request", "parent")
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	Go(ctx, func(ctx context.Context) {
+		goroutineHub := GetHubFromContext(ctx)
+		if goroutineHub == hub {
+			t.Error("the goroutine should use a clone of the hub")
+		}
+		goroutineHub.Scope().SetTag("job", "refresh")
+		panic("boom")
+	})
+
+	// The panic is reported asynchronously.
+	deadline := time.Now().Add(time.Second)
+	for len(transport.Events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	assertEqual(t, events[0].Message, "boom")
+	assertEqual(t, events[0].Tags, map[string]string{"request": "parent", "job": "refresh"})
+	if _, ok := scope.tags["job"]; ok {
+		t.Error("scope changes made by the goroutine should not leak to the parent")
+	}
+}
+
+func TestGoWithOptionsRepanic(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recover() = %v, want the original panic", r)
+		}
+		assertEqual(t, len(transport.Events()), 1)
+	}()
+	func() {
+		defer recoverGoroutine(context.Background(), hub, GoOptions{Repanic: true})
+		panic("boom")
+	}()
+}
@@ -0,0 +1,24 @@
+module github.com/getsentry/sentry-go/gqlgen
+
+go 1.26
+
+require (
+	github.com/99designs/gqlgen v0.17.95
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+	github.com/vektah/gqlparser/v2 v2.5.37
+)
+
+require (
+	github.com/agnivade/levenshtein v1.2.1 // indirect
+	github.com/coder/websocket v1.8.15 // indirect
+	github.com/go-viper/mapstructure/v2 v2.5.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/sosodev/duration v1.4.0 // indirect
+	golang.org/x/sync v0.22.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.41.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
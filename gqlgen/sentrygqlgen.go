@@ -0,0 +1,208 @@
+// Package sentrygqlgen provides Sentry instrumentation for GraphQL servers
+// built with github.com/99designs/gqlgen.
+//
+// Add the tracer to the server as an extension:
+//
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
+//	srv.Use(sentrygqlgen.New(sentrygqlgen.Options{}))
+//
+// Each operation is recorded in a "graphql.query", "graphql.mutation" or
+// "graphql.subscription" transaction, or in a child span when the request
+// context already has a span, for example when the server is wrapped with
+// sentryhttp. The resolvers are recorded in "graphql.resolve" child spans, and
+// the errors they return are reported to Sentry along with the operation
+// name and its variables.
+package sentrygqlgen
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/getsentry/sentry-go"
+)
+
+// resolveOperation is the operation of the spans recorded for resolvers.
+const resolveOperation = "graphql.resolve"
+
+// spanOrigin is the origin of spans.
+const spanOrigin = "auto.graphql.gqlgen"
+
+// Options configure a Tracer.
+type Options struct {
+	// MaxDepth limits the spans of resolvers to fields nested at most MaxDepth
+	// levels deep, top-level fields being 1 level deep. Zero records the
+	// resolvers of all fields. Resolver errors are reported at any depth.
+	MaxDepth int
+	// DataScrubber sanitizes the variables of the operations reported along
+	// with resolver errors. Defaults to a DataScrubber removing the values of
+	// sentry.DefaultDenyKeys.
+	DataScrubber *sentry.DataScrubber
+}
+
+// Tracer is a gqlgen extension recording operations and resolvers in spans,
+// and reporting resolver errors to Sentry.
+type Tracer struct {
+	maxDepth int
+	scrubber *sentry.DataScrubber
+}
+
+var (
+	_ graphql.HandlerExtension    = (*Tracer)(nil)
+	_ graphql.ResponseInterceptor = (*Tracer)(nil)
+	_ graphql.FieldInterceptor    = (*Tracer)(nil)
+)
+
+// New returns a new Tracer.
+func New(options Options) *Tracer {
+	scrubber := options.DataScrubber
+	if scrubber == nil {
+		scrubber = &sentry.DataScrubber{}
+	}
+	return &Tracer{
+		maxDepth: options.MaxDepth,
+		scrubber: scrubber,
+	}
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (t *Tracer) ExtensionName() string {
+	return "SentryTracer"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (t *Tracer) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor, recording the
+// computation of each response in a span. Subscriptions have a span per
+// event.
+func (t *Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	opCtx := graphql.GetOperationContext(ctx)
+	operationType := "query"
+	if opCtx.Operation != nil {
+		operationType = string(opCtx.Operation.Operation)
+	}
+	name := operationType
+	if opCtx.OperationName != "" {
+		name += " " + opCtx.OperationName
+	}
+
+	var span *sentry.Span
+	if parent := sentry.SpanFromContext(ctx); parent != nil {
+		span = parent.StartChild("graphql."+operationType, sentry.WithSpanOrigin(spanOrigin))
+		span.Description = name
+	} else {
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+		ctx = sentry.SetHubOnContext(ctx, hub.Clone())
+		span = sentry.StartTransaction(ctx, name,
+			sentry.WithOpName("graphql."+operationType),
+			sentry.WithTransactionSource(sentry.SourceComponent),
+			sentry.WithSpanOrigin(spanOrigin),
+		)
+	}
+	span.SetData("graphql.operation.type", operationType)
+	if opCtx.OperationName != "" {
+		span.SetData("graphql.operation.name", opCtx.OperationName)
+	}
+
+	response := next(span.Context())
+	if response == nil {
+		// The subscription ended without another event, because it was
+		// cancelled or completed.
+		if ctx.Err() != nil {
+			span.Status = sentry.SpanStatusCanceled
+		} else {
+			span.Status = sentry.SpanStatusOK
+		}
+		span.Finish()
+		return nil
+	}
+	if len(response.Errors) > 0 {
+		span.SetError(response.Errors)
+	} else {
+		span.Status = sentry.SpanStatusOK
+	}
+	span.Finish()
+	return response
+}
+
+// InterceptField implements graphql.FieldInterceptor, recording resolvers in
+// spans and reporting the errors they return.
+func (t *Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	var span *sentry.Span
+	if parent := sentry.SpanFromContext(ctx); parent != nil && (t.maxDepth == 0 || fieldDepth(fc) <= t.maxDepth) {
+		span = parent.StartChild(resolveOperation, sentry.WithSpanOrigin(spanOrigin))
+		span.Description = fc.Object + "." + fc.Field.Name
+		span.SetData("graphql.field.path", fc.Path().String())
+		ctx = span.Context()
+	}
+
+	res, err := next(ctx)
+	if span != nil {
+		if err != nil {
+			span.SetError(err)
+		} else {
+			span.Status = sentry.SpanStatusOK
+		}
+		span.Finish()
+	}
+	if err != nil {
+		t.captureError(ctx, fc, err)
+	}
+	return res, err
+}
+
+// captureError reports a resolver error to the hub of ctx, or to the current
+// hub.
+func (t *Tracer) captureError(ctx context.Context, fc *graphql.FieldContext, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	graphqlContext := sentry.Context{
+		"path": fc.Path().String(),
+	}
+	if opCtx.Operation != nil {
+		graphqlContext["operation_type"] = string(opCtx.Operation.Operation)
+	}
+	if opCtx.OperationName != "" {
+		graphqlContext["operation_name"] = opCtx.OperationName
+	}
+	if len(opCtx.Variables) > 0 {
+		// DataScrubber works on events, scrub the variables as extra data.
+		event := &sentry.Event{Extra: opCtx.Variables}
+		t.scrubber.Scrub(event)
+		graphqlContext["variables"] = event.Extra
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetContext("graphql", graphqlContext)
+		hub.CaptureException(err)
+	})
+}
+
+// fieldDepth returns the nesting level of a field, ignoring list indices.
+func fieldDepth(fc *graphql.FieldContext) int {
+	depth := 0
+	for ; fc != nil; fc = fc.Parent {
+		if fc.Index == nil && fc.Field.Field != nil {
+			depth++
+		}
+	}
+	return depth
+}
@@ -0,0 +1,200 @@
+package sentrygqlgen_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/getsentry/sentry-go"
+	sentrygqlgen "github.com/getsentry/sentry-go/gqlgen"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var schema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		user(id: ID!, token: String): User
+	}
+	type User {
+		name: String!
+		friends: [User!]!
+	}
+`})
+
+// newExecutableSchema returns a schema resolving user and user.friends, the
+// latter failing, in the same way as generated code.
+func newExecutableSchema() graphql.ExecutableSchema {
+	return &graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return schema },
+		ExecFunc: func(context.Context) graphql.ResponseHandler {
+			ran := false
+			return func(ctx context.Context) *graphql.Response {
+				if ran {
+					return nil
+				}
+				ran = true
+				opCtx := graphql.GetOperationContext(ctx)
+				user := &graphql.FieldContext{
+					Object:     "Query",
+					Field:      graphql.CollectedField{Field: &ast.Field{Name: "user", Alias: "user"}},
+					IsResolver: true,
+				}
+				userCtx := graphql.WithFieldContext(ctx, user)
+				_, _ = opCtx.ResolverMiddleware(userCtx, func(context.Context) (interface{}, error) {
+					return "user", nil
+				})
+				friends := &graphql.FieldContext{
+					Parent:     user,
+					Object:     "User",
+					Field:      graphql.CollectedField{Field: &ast.Field{Name: "friends", Alias: "friends"}},
+					IsResolver: true,
+				}
+				_, _ = opCtx.ResolverMiddleware(graphql.WithFieldContext(userCtx, friends), func(context.Context) (interface{}, error) {
+					return nil, errors.New("friends unavailable")
+				})
+				return &graphql.Response{Data: []byte(`{"user":null}`)}
+			}
+		},
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  sentrygqlgen.Options
+		resolved []string
+	}{
+		{
+			name:     "all resolvers",
+			resolved: []string{"Query.user", "User.friends"},
+		},
+		{
+			name:     "max depth",
+			options:  sentrygqlgen.Options{MaxDepth: 1},
+			resolved: []string{"Query.user"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errors, transactions []*sentry.Event
+			client, err := sentry.NewClient(sentry.ClientOptions{
+				EnableTracing:    true,
+				TracesSampleRate: 1.0,
+				BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+					errors = append(errors, event)
+					return nil
+				},
+				BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+					transactions = append(transactions, event)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			hub := sentry.NewHub(client, sentry.NewScope())
+
+			srv := handler.New(newExecutableSchema())
+			srv.AddTransport(transport.POST{})
+			srv.Use(sentrygqlgen.New(tt.options))
+
+			body := `{
+				"operationName": "GetUser",
+				"query": "query GetUser($id: ID!, $token: String) { user(id: $id, token: $token) { name friends { name } } }",
+				"variables": {"id": "1", "token": "secret"}
+			}`
+			r := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d: %s", w.Code, w.Body)
+			}
+
+			if len(transactions) != 1 {
+				t.Fatalf("got %d transactions, want 1", len(transactions))
+			}
+			transaction := transactions[0]
+			if diff := cmp.Diff("query GetUser", transaction.Transaction); diff != "" {
+				t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff("graphql.query", transaction.Contexts["trace"]["op"]); diff != "" {
+				t.Errorf("transaction operation mismatch (-want +got):\n%s", diff)
+			}
+			var resolved []string
+			for _, span := range transaction.Spans {
+				if span.Op == "graphql.resolve" {
+					resolved = append(resolved, span.Description)
+				}
+			}
+			if diff := cmp.Diff(tt.resolved, resolved); diff != "" {
+				t.Errorf("resolver spans mismatch (-want +got):\n%s", diff)
+			}
+
+			if len(errors) != 1 {
+				t.Fatalf("got %d errors, want 1", len(errors))
+			}
+			if diff := cmp.Diff(sentry.Context{
+				"operation_name": "GetUser",
+				"operation_type": "query",
+				"path":           "user.friends",
+				"variables": map[string]interface{}{
+					"id":    "1",
+					"token": "[Filtered]",
+				},
+			}, errors[0].Contexts["graphql"]); diff != "" {
+				t.Errorf("graphql context mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTracerSubscriptionEnd(t *testing.T) {
+	var transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := sentrygqlgen.New(sentrygqlgen.Options{})
+	opCtx := &graphql.OperationContext{
+		OperationName: "OnMessage",
+		Operation:     &ast.OperationDefinition{Operation: ast.Subscription},
+	}
+
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+	ctx = graphql.WithOperationContext(ctx, opCtx)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, ctx := range []context.Context{ctx, cancelled} {
+		response := tracer.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+			return nil
+		})
+		if response != nil {
+			t.Fatalf("got response %v, want nil", response)
+		}
+	}
+
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+	for i, want := range []sentry.SpanStatus{sentry.SpanStatusOK, sentry.SpanStatusCanceled} {
+		if diff := cmp.Diff(want, transactions[i].Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction %d: status mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
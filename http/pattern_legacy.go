@@ -0,0 +1,11 @@
+//go:build !go1.23
+
+package sentryhttp
+
+import "net/http"
+
+// requestPattern returns the empty string, as the pattern of the route that
+// matched a request is only available since Go 1.23.
+func requestPattern(r *http.Request) string {
+	return ""
+}
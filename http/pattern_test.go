@@ -0,0 +1,54 @@
+//go:build go1.23
+
+// Use the routing of http.ServeMux of Go 1.22, which go.mod doesn't enable.
+//
+//go:debug httpmuxgo121=0
+
+package sentryhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryhttp "github.com/getsentry/sentry-go/http"
+)
+
+func TestTransactionNameFromServeMuxPattern(t *testing.T) {
+	transactions := make(chan *sentry.Event, 2)
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions <- event
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := sentryhttp.New(sentryhttp.Options{}).Handle(mux)
+
+	tests := []struct {
+		path       string
+		wantName   string
+		wantSource sentry.TransactionSource
+	}{
+		{"/users/42", "GET /users/{id}", sentry.SourceRoute},
+		{"/unknown", "GET /unknown", sentry.SourceURL},
+	}
+	for _, tt := range tests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		event := <-transactions
+		if event.Transaction != tt.wantName {
+			t.Errorf("transaction name = %q, want %q", event.Transaction, tt.wantName)
+		}
+		if event.TransactionInfo.Source != tt.wantSource {
+			t.Errorf("transaction source = %q, want %q", event.TransactionInfo.Source, tt.wantSource)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package sentryhttp
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// defaultMaxRequestBodySize is the default maximum size of captured request
+// bodies.
+const defaultMaxRequestBodySize = 10 * 1024
+
+// defaultRequestBodyContentTypes are the default media types of captured
+// request bodies.
+var defaultRequestBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+}
+
+// requestBody captures the body of a request as it is read by the handler.
+type requestBody struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *requestBody) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.overflow {
+		return len(p), nil
+	}
+	if b.buf.Len()+len(p) > b.limit {
+		// Partial bodies are not sent, see Scope.ApplyToEvent.
+		b.overflow = true
+		b.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// bytes returns a copy of the captured body, or nil if it exceeded the limit.
+func (b *requestBody) bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.overflow {
+		return nil
+	}
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// setRequestWithBody sets r on the scope of hub and starts capturing its body,
+// if its content type is allowed, with an event processor attaching the body
+// to the error events captured with hub.
+//
+// The scope buffers the body of the requests it is given, for all events, so
+// it is given a copy of r without body instead.
+func (h *Handler) setRequestWithBody(hub *sentry.Hub, r *http.Request) {
+	scopeRequest := r.WithContext(r.Context())
+	scopeRequest.Body = nil
+	hub.Scope().SetRequest(scopeRequest)
+
+	if r.Body == nil || r.Body == http.NoBody ||
+		r.ContentLength > int64(h.maxRequestBodySize) ||
+		!matchContentType(r.Header.Get("Content-Type"), h.requestBodyContentTypes) {
+		return
+	}
+	body := &requestBody{limit: h.maxRequestBodySize}
+	r.Body = readCloser{
+		Reader: io.TeeReader(r.Body, body),
+		Closer: r.Body,
+	}
+
+	redact := h.redactRequestBody
+	hub.Scope().AddEventProcessor(func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		if event.Type == "transaction" || event.Request == nil ||
+			(event.Level != sentry.LevelError && event.Level != sentry.LevelFatal) {
+			return event
+		}
+		data := body.bytes()
+		if len(data) > 0 && redact != nil {
+			data = redact(r, data)
+		}
+		if len(data) > 0 {
+			event.Request.Data = string(data)
+		}
+		return event
+	})
+}
+
+// matchContentType reports whether the media type of contentType is one of
+// types, in which "type/*" matches all subtypes.
+func matchContentType(contentType string, types []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// readCloser combines an io.Reader and an io.Closer to implement io.ReadCloser.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
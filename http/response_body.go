@@ -0,0 +1,181 @@
+package sentryhttp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// defaultMaxResponseBodySize is the default maximum size of captured response
+// bodies.
+const defaultMaxResponseBodySize = 4 * 1024
+
+// responseWriter captures the status and the beginning of the body of server
+// error responses.
+type responseWriter struct {
+	http.ResponseWriter
+
+	mu     sync.Mutex
+	status int
+	size   int
+	body   []byte
+	limit  int
+	// pending are the error events captured while the handler runs, held
+	// until it returns to attach the response written after them.
+	pending []pendingEvent
+	done    bool
+}
+
+// pendingEvent is an error event held until the handler returns.
+type pendingEvent struct {
+	event *sentry.Event
+	hint  *sentry.EventHint
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	if w.status == 0 {
+		w.status = status
+	}
+	w.mu.Unlock()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= http.StatusInternalServerError {
+		w.size += len(p)
+		if n := w.limit - len(w.body); n > 0 {
+			if n > len(p) {
+				n = len(p)
+			}
+			w.body = append(w.body, p[:n]...)
+		}
+	}
+	w.mu.Unlock()
+	return w.ResponseWriter.Write(p)
+}
+
+// ReadFrom implements io.ReaderFrom, for the wrapped http.ResponseWriter to
+// send files efficiently. The bodies of server error responses are copied
+// with Write instead, to capture them.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.mu.Lock()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	capture := w.status >= http.StatusInternalServerError
+	w.mu.Unlock()
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok && !capture {
+		return rf.ReadFrom(r)
+	}
+	// Hide the ReadFrom method of w from io.Copy.
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// Hijack implements http.Hijacker, if the wrapped http.ResponseWriter does.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("sentryhttp: the response writer doesn't implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Flush implements http.Flusher, if the wrapped http.ResponseWriter does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// context returns the "response" context of a server error response, or nil
+// if the response isn't a server error.
+func (w *responseWriter) context() sentry.Context {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.contextLocked()
+}
+
+// contextLocked is context, for callers holding w.mu.
+func (w *responseWriter) contextLocked() sentry.Context {
+	if w.status < http.StatusInternalServerError {
+		return nil
+	}
+	return sentry.Context{
+		"status_code": w.status,
+		"body_size":   w.size,
+		"data":        string(w.body),
+	}
+}
+
+// wrapResponseWriter returns a wrapper of w capturing the body of server
+// error responses, with an event processor of the scope of hub attaching it to
+// the error events captured with hub. The error events captured while the
+// response may still become a server error are held until sendPending is
+// called.
+func (h *Handler) wrapResponseWriter(hub *sentry.Hub, w http.ResponseWriter) *responseWriter {
+	rw := &responseWriter{ResponseWriter: w, limit: h.maxResponseBodySize}
+	hub.Scope().AddEventProcessor(func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		if event.Type == "transaction" ||
+			(event.Level != sentry.LevelError && event.Level != sentry.LevelFatal) {
+			return event
+		}
+		rw.mu.Lock()
+		defer rw.mu.Unlock()
+		if !rw.done && (rw.status == 0 || rw.status >= http.StatusInternalServerError) {
+			rw.pending = append(rw.pending, pendingEvent{event: event, hint: hint})
+			return nil
+		}
+		setResponse(event, rw.contextLocked())
+		return event
+	})
+	return rw
+}
+
+// sendPending sends the error events held while the handler ran, with the
+// response it wrote, and lets the next ones through. Call it once the handler
+// returned.
+func (w *responseWriter) sendPending(hub *sentry.Hub) {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.done = true
+	response := w.contextLocked()
+	w.mu.Unlock()
+
+	client := hub.Client()
+	if client == nil {
+		return
+	}
+	for _, p := range pending {
+		setResponse(p.event, response)
+		// The scope was already applied to the events.
+		client.CaptureEvent(p.event, p.hint, nil)
+	}
+}
+
+// setResponse sets the "response" context of event, if response isn't nil.
+func setResponse(event *sentry.Event, response sentry.Context) {
+	if response == nil {
+		return
+	}
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]sentry.Context)
+	}
+	event.Contexts["response"] = response
+}
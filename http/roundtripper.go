@@ -0,0 +1,216 @@
+package sentryhttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// RoundTripper is an http.RoundTripper that instruments outgoing requests.
+//
+// When the context of a request contains a span, RoundTripper starts an
+// "http.client" child span for the request, with child spans for the DNS
+// lookup, the connection, the TLS handshake and the time to first byte, and
+// propagates the trace to the server in the request headers, if the URL of the
+// request matches the TracePropagationTargets of the client. In all cases, it
+// adds a breadcrumb for the request to the hub of the request context, or to
+// the current hub.
+//
+//	client := &http.Client{
+//		Transport: sentryhttp.NewRoundTripper(nil),
+//	}
+type RoundTripper struct {
+	base http.RoundTripper
+}
+
+// NewRoundTripper returns a RoundTripper that instruments the requests sent
+// with base. If base is nil, http.DefaultTransport is used.
+func NewRoundTripper(base http.RoundTripper) *RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RoundTripper{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RoundTripper) RoundTrip(r *http.Request) (response *http.Response, err error) {
+	ctx := r.Context()
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	url := *r.URL
+	url.RawQuery = ""
+	url.Fragment = ""
+	url.User = nil
+
+	var span *sentry.Span
+	if parent := sentry.SpanFromContext(ctx); parent != nil {
+		span = parent.StartChild("http.client", sentry.WithSpanOrigin(clientOrigin))
+		span.Description = r.Method + " " + url.String()
+		span.SetData("http.request.method", r.Method)
+		span.SetData("url", url.String())
+		if r.URL.RawQuery != "" {
+			span.SetData("http.query", r.URL.RawQuery)
+		}
+		defer span.Finish()
+
+		trace, done := newClientTrace(span)
+		defer func() { done(err) }()
+
+		// RoundTrippers must not modify the request.
+		r = r.Clone(httptrace.WithClientTrace(span.Context(), trace))
+		if client := hub.Client(); client == nil || client.ShouldPropagateTrace(r.URL.String()) {
+			propagateTrace(r, span)
+		}
+	}
+
+	response, err = t.base.RoundTrip(r)
+
+	breadcrumb := &sentry.Breadcrumb{
+		Type:     "http",
+		Category: "http",
+		Data: map[string]interface{}{
+			"url":    url.String(),
+			"method": r.Method,
+		},
+	}
+	hint := &sentry.BreadcrumbHint{"request": r}
+	if err != nil {
+		breadcrumb.Level = sentry.LevelError
+		breadcrumb.Data["reason"] = err.Error()
+		if span != nil {
+			span.SetError(err)
+		}
+	} else {
+		breadcrumb.Data["status_code"] = response.StatusCode
+		(*hint)["response"] = response
+		if span != nil {
+			span.SetHTTPStatus(response.StatusCode)
+		}
+	}
+	hub.AddBreadcrumb(breadcrumb, hint)
+
+	return response, err
+}
+
+// propagateTrace sets the headers propagating the trace of span to the server
+// on r.
+func propagateTrace(r *http.Request, span *sentry.Span) {
+	r.Header.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+	if baggage := span.ToBaggage(); baggage != "" {
+		r.Header.Set(sentry.SentryBaggageHeader, mergeBaggage(r.Header.Values(sentry.SentryBaggageHeader), baggage))
+	}
+	if r.Header.Get(sentry.TraceparentHeader) == "" {
+		r.Header.Set(sentry.TraceparentHeader, span.ToTraceparent())
+		if tracestate := span.ToTracestate(); tracestate != "" {
+			r.Header.Set(sentry.TracestateHeader, tracestate)
+		}
+	}
+}
+
+// mergeBaggage returns the members of the existing baggage headers, except
+// for the "sentry-" ones which would conflict with the trace of the request,
+// followed by the members of baggage.
+func mergeBaggage(existing []string, baggage string) string {
+	var members []string
+	for _, header := range existing {
+		for _, member := range strings.Split(header, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" || strings.HasPrefix(member, "sentry-") {
+				continue
+			}
+			members = append(members, member)
+		}
+	}
+	return strings.Join(append(members, baggage), ",")
+}
+
+// newClientTrace returns hooks starting child spans of span for the phases of
+// a request, and a function finishing the child spans still in progress once
+// the request is done. Hooks may be called concurrently, for example when
+// dialing several addresses.
+func newClientTrace(span *sentry.Span) (*httptrace.ClientTrace, func(err error)) {
+	var mu sync.Mutex
+	var dns, handshake, ttfb *sentry.Span
+	connects := make(map[string]*sentry.Span)
+
+	start := func(op, description string) *sentry.Span {
+		child := span.StartChild(op, sentry.WithSpanOrigin(clientOrigin))
+		child.Description = description
+		return child
+	}
+	finish := func(child *sentry.Span, err error) {
+		if child == nil {
+			return
+		}
+		child.SetError(err)
+		child.Finish()
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dns = start("http.client.dns", info.Host)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			finish(dns, info.Err)
+			dns = nil
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			connects[network+" "+addr] = start("http.client.connect", network+" "+addr)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			key := network + " " + addr
+			finish(connects[key], err)
+			delete(connects, key)
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			handshake = start("http.client.tls", "")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			finish(handshake, err)
+			handshake = nil
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			if info.Err == nil {
+				ttfb = start("http.client.ttfb", "")
+			}
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			finish(ttfb, nil)
+			ttfb = nil
+		},
+	}
+	done := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, child := range connects {
+			finish(child, err)
+		}
+		finish(dns, err)
+		finish(handshake, err)
+		finish(ttfb, err)
+	}
+	return trace, done
+}
@@ -14,12 +14,27 @@ import (
 // The identifier of the Gin SDK.
 const sdkIdentifier = "sentry.go.http"
 
+// Origins of the spans of servers and clients.
+const (
+	serverOrigin = "auto.http.server"
+	clientOrigin = "auto.http.client"
+)
+
 // A Handler is an HTTP middleware factory that provides integration with
 // Sentry.
 type Handler struct {
 	repanic         bool
 	waitForDelivery bool
 	timeout         time.Duration
+	queueTime       bool
+
+	captureRequestBody      bool
+	maxRequestBodySize      int
+	requestBodyContentTypes []string
+	redactRequestBody       func(r *http.Request, body []byte) []byte
+
+	captureResponseBody bool
+	maxResponseBodySize int
 }
 
 // Options configure a Handler.
@@ -47,6 +62,44 @@ type Options struct {
 	// If the timeout is reached, the current goroutine is no longer blocked
 	// waiting, but the delivery is not canceled.
 	Timeout time.Duration
+	// QueueTime configures whether to record the time requests spent queued
+	// in a load balancer or proxy, as read from the X-Request-Start or
+	// X-Queue-Start header, at the front of transactions. Only enable it when
+	// the header is set by a trusted proxy. See sentry.RecordRequestQueueTime.
+	QueueTime bool
+	// CaptureRequestBody configures whether to attach the bodies of requests
+	// to the error events captured while handling them, in place of the
+	// default behavior of the scope, which attaches bodies of up to 10KB to
+	// all events. Bodies are never attached to transactions, nor to events
+	// with a level lower than error.
+	CaptureRequestBody bool
+	// MaxRequestBodySize is the maximum size of captured request bodies, in
+	// bytes. Larger bodies are not captured, rather than truncated. Defaults
+	// to 10KB. Only relevant when CaptureRequestBody is true.
+	MaxRequestBodySize int
+	// RequestBodyContentTypes are the media types of the captured request
+	// bodies, in which "type/*" matches all subtypes. Defaults to
+	// application/json and application/x-www-form-urlencoded. Only relevant
+	// when CaptureRequestBody is true.
+	RequestBodyContentTypes []string
+	// RedactRequestBody, if not nil, is called with the captured body of a
+	// request and returns the body attached to events, for example with
+	// sensitive fields removed. Returning nil drops the body. Only relevant
+	// when CaptureRequestBody is true.
+	RedactRequestBody func(r *http.Request, body []byte) []byte
+	// CaptureResponseBody configures whether to attach the status and the
+	// beginning of the bodies of server error (5xx) responses, in the
+	// "response" context, to transactions and error events.
+	//
+	// The error events captured with the hub of the request while the
+	// handler runs, for example with CaptureException followed by
+	// http.Error, are held until it returns, unless the response already
+	// has a status below 500.
+	CaptureResponseBody bool
+	// MaxResponseBodySize is the maximum size of captured response bodies, in
+	// bytes. Larger bodies are truncated. Defaults to 4KB. Only relevant when
+	// CaptureResponseBody is true.
+	MaxResponseBodySize int
 }
 
 // New returns a new Handler. Use the Handle and HandleFunc methods to wrap
@@ -56,10 +109,29 @@ func New(options Options) *Handler {
 	if timeout == 0 {
 		timeout = 2 * time.Second
 	}
+	maxRequestBodySize := options.MaxRequestBodySize
+	if maxRequestBodySize <= 0 {
+		maxRequestBodySize = defaultMaxRequestBodySize
+	}
+	requestBodyContentTypes := options.RequestBodyContentTypes
+	if requestBodyContentTypes == nil {
+		requestBodyContentTypes = defaultRequestBodyContentTypes
+	}
+	maxResponseBodySize := options.MaxResponseBodySize
+	if maxResponseBodySize <= 0 {
+		maxResponseBodySize = defaultMaxResponseBodySize
+	}
 	return &Handler{
-		repanic:         options.Repanic,
-		timeout:         timeout,
-		waitForDelivery: options.WaitForDelivery,
+		repanic:                 options.Repanic,
+		timeout:                 timeout,
+		waitForDelivery:         options.WaitForDelivery,
+		queueTime:               options.QueueTime,
+		captureRequestBody:      options.CaptureRequestBody,
+		maxRequestBodySize:      maxRequestBodySize,
+		requestBodyContentTypes: requestBodyContentTypes,
+		redactRequestBody:       options.RedactRequestBody,
+		captureResponseBody:     options.CaptureResponseBody,
+		maxResponseBodySize:     maxResponseBodySize,
 	}
 }
 
@@ -88,6 +160,11 @@ func (h *Handler) handle(handler http.Handler) http.HandlerFunc {
 		if hub == nil {
 			hub = sentry.CurrentHub().Clone()
 			ctx = sentry.SetHubOnContext(ctx, hub)
+		} else if h.captureRequestBody || h.captureResponseBody {
+			// The bodies are captured by event processors of the scope, which
+			// must not outlive the request.
+			hub = hub.Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
 		}
 
 		hub.Client().SetSDKIdentifier(sdkIdentifier)
@@ -96,20 +173,52 @@ func (h *Handler) handle(handler http.Handler) http.HandlerFunc {
 			sentry.WithOpName("http.server"),
 			sentry.ContinueFromRequest(r),
 			sentry.WithTransactionSource(sentry.SourceURL),
+			sentry.WithSpanOrigin(serverOrigin),
 		}
 		// We don't mind getting an existing transaction back so we don't need to
 		// check if it is.
+		existing := sentry.SpanFromContext(ctx)
 		transaction := sentry.StartTransaction(ctx,
 			fmt.Sprintf("%s %s", r.Method, r.URL.Path),
 			options...,
 		)
 		defer transaction.Finish()
+		if h.queueTime && existing == nil {
+			sentry.RecordRequestQueueTime(transaction, r)
+		}
 		// TODO(tracing): if the next handler.ServeHTTP panics, store
 		// information on the transaction accordingly (status, tag,
 		// level?, ...).
 		r = r.WithContext(transaction.Context())
-		hub.Scope().SetRequest(r)
+		// http.ServeMux sets the pattern of the matched route on the request
+		// it serves, which is only known once the handler returns.
+		defer func() {
+			if pattern := requestPattern(r); pattern != "" {
+				transaction.Name = sentry.TransactionNameFromPattern(r.Method, pattern)
+				transaction.Source = sentry.SourceRoute
+			}
+		}()
+		if h.captureRequestBody {
+			h.setRequestWithBody(hub, r)
+		} else {
+			hub.Scope().SetRequest(r)
+		}
+		var rw *responseWriter
+		if h.captureResponseBody {
+			rw = h.wrapResponseWriter(hub, w)
+			defer func() {
+				if response := rw.context(); response != nil {
+					transaction.SetContext("response", response)
+				}
+			}()
+			w = rw
+		}
 		defer h.recoverWithSentry(hub, r)
+		if rw != nil {
+			// Sent before recovering, for panics to be sent right away with
+			// the response.
+			defer rw.sendPending(hub)
+		}
 		// TODO(tracing): use custom response writer to intercept
 		// response. Use HTTP status to add tag to transaction; set span
 		// status.
@@ -1,8 +1,11 @@
 package sentryhttp_test
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -211,7 +214,7 @@ func TestIntegration(t *testing.T) {
 			sentry.Event{},
 			"Contexts", "EventID", "Extra", "Platform", "Modules",
 			"Release", "Sdk", "ServerName", "Tags", "Timestamp",
-			"sdkMetaData", "attachments",
+			"sdkMetaData", "attachments", "meta", "metricsSummary",
 		),
 		cmpopts.IgnoreFields(
 			sentry.Request{},
@@ -222,3 +225,386 @@ func TestIntegration(t *testing.T) {
 		t.Fatalf("Events mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestRoundTripper(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	transactions := make(chan *sentry.Event, 1)
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions <- event
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	transaction := sentry.StartTransaction(ctx, "test")
+
+	req, err := http.NewRequestWithContext(transaction.Context(), http.MethodGet, srv.URL+"/users?id=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(sentry.SentryBaggageHeader, "other=1,sentry-trace_id=stale")
+	c := &http.Client{Transport: sentryhttp.NewRoundTripper(nil), Timeout: time.Second}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	transaction.Finish()
+
+	got := <-headers
+	for _, header := range []string{sentry.SentryTraceHeader, sentry.SentryBaggageHeader, sentry.TraceparentHeader} {
+		if got.Get(header) == "" {
+			t.Errorf("missing %s header", header)
+		}
+	}
+	// The existing sentry- members are replaced by the ones of the trace.
+	if baggage := got.Get(sentry.SentryBaggageHeader); !strings.HasPrefix(baggage, "other=1,sentry-") || strings.Contains(baggage, "stale") {
+		t.Errorf("got baggage %q, want other=1 followed by the members of the trace", baggage)
+	}
+
+	event := <-transactions
+	ops := make(map[string]*sentry.Span)
+	for _, span := range event.Spans {
+		ops[span.Op] = span
+	}
+	span := ops["http.client"]
+	if span == nil {
+		t.Fatalf("missing http.client span in %v", event.Spans)
+	}
+	if diff := cmp.Diff("GET "+srv.URL+"/users", span.Description); diff != "" {
+		t.Errorf("span description mismatch (-want +got):\n%s", diff)
+	}
+	if span.Status != sentry.SpanStatusNotFound {
+		t.Errorf("span status = %v, want %v", span.Status, sentry.SpanStatusNotFound)
+	}
+	if diff := cmp.Diff("id=1", span.Data["http.query"]); diff != "" {
+		t.Errorf("span query mismatch (-want +got):\n%s", diff)
+	}
+	if got.Get(sentry.SentryTraceHeader) != span.ToSentryTrace() {
+		t.Errorf("sentry-trace header %q doesn't match span %q", got.Get(sentry.SentryTraceHeader), span.ToSentryTrace())
+	}
+	for _, op := range []string{"http.client.connect", "http.client.ttfb"} {
+		child := ops[op]
+		if child == nil {
+			t.Errorf("missing %s span", op)
+			continue
+		}
+		if child.ParentSpanID != span.SpanID {
+			t.Errorf("%s span isn't a child of the http.client span", op)
+		}
+	}
+
+	var breadcrumbs []*sentry.Breadcrumb
+	hub.Scope().AddEventProcessor(func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		breadcrumbs = event.Breadcrumbs
+		return event
+	})
+	hub.CaptureMessage("breadcrumbs")
+	if len(breadcrumbs) != 1 {
+		t.Fatalf("got %d breadcrumbs, want 1", len(breadcrumbs))
+	}
+	if diff := cmp.Diff(map[string]interface{}{
+		"url":         srv.URL + "/users",
+		"method":      http.MethodGet,
+		"status_code": http.StatusNotFound,
+	}, breadcrumbs[0].Data); diff != "" {
+		t.Errorf("breadcrumb data mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRoundTripperTracePropagationTargets(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:           true,
+		TracesSampleRate:        1.0,
+		TracePropagationTargets: []string{"api.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	transaction := sentry.StartTransaction(ctx, "test")
+	defer transaction.Finish()
+
+	req, err := http.NewRequestWithContext(transaction.Context(), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Transport: sentryhttp.NewRoundTripper(nil), Timeout: time.Second}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	got := <-headers
+	for _, header := range []string{sentry.SentryTraceHeader, sentry.SentryBaggageHeader, sentry.TraceparentHeader} {
+		if got.Get(header) != "" {
+			t.Errorf("unexpected %s header sent to %s", header, srv.URL)
+		}
+	}
+}
+
+func TestCaptureRequestBody(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentryHandler := sentryhttp.New(sentryhttp.Options{
+		CaptureRequestBody: true,
+		MaxRequestBodySize: 32,
+		RedactRequestBody: func(r *http.Request, body []byte) []byte {
+			return []byte(strings.ReplaceAll(string(body), "hunter2", "[Filtered]"))
+		},
+	})
+	handler := sentryHandler.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub := sentry.GetHubFromContext(r.Context())
+		if _, err := io.ReadAll(r.Body); err != nil {
+			t.Error(err)
+		}
+		hub.CaptureMessage("message")
+		hub.CaptureException(fmt.Errorf("error"))
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"JSON", "application/json; charset=utf-8", `{"password":"hunter2"}`, `{"password":"[Filtered]"}`},
+		{"Form", "application/x-www-form-urlencoded", "password=hunter2", "password=[Filtered]"},
+		{"ContentType", "text/plain", "hunter2", ""},
+		{"TooLarge", "application/json", `{"data":"` + strings.Repeat("x", 32) + `"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			hub := sentry.NewHub(client, sentry.NewScope())
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+			handler(httptest.NewRecorder(), r)
+
+			if len(events) != 3 {
+				t.Fatalf("got %d events, want 3", len(events))
+			}
+			// Bodies are only attached to error events.
+			for i, want := range []string{"", tt.want, ""} {
+				if diff := cmp.Diff(want, events[i].Request.Data); diff != "" {
+					t.Errorf("event %d: request data mismatch (-want +got):\n%s", i, diff)
+				}
+			}
+			// The hub of the request context isn't modified.
+			hub.CaptureException(fmt.Errorf("error"))
+			if diff := cmp.Diff((*sentry.Request)(nil), events[3].Request); diff != "" {
+				t.Errorf("request mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCaptureResponseBody(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentryHandler := sentryhttp.New(sentryhttp.Options{
+		CaptureResponseBody: true,
+		MaxResponseBodySize: 16,
+	})
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   sentry.Context
+	}{
+		{"ServerError", http.StatusInternalServerError, `{"code":"E42"}`, sentry.Context{
+			"status_code": http.StatusInternalServerError,
+			"body_size":   14,
+			"data":        `{"code":"E42"}`,
+		}},
+		{"Truncated", http.StatusBadGateway, `{"code":"E42","detail":"upstream"}`, sentry.Context{
+			"status_code": http.StatusBadGateway,
+			"body_size":   34,
+			"data":        `{"code":"E42","d`,
+		}},
+		{"ClientError", http.StatusNotFound, `{"code":"E42"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			handler := sentryHandler.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+				sentry.GetHubFromContext(r.Context()).CaptureException(fmt.Errorf("charge failed"))
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			hub := sentry.NewHub(client, sentry.NewScope())
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if diff := cmp.Diff(tt.body, w.Body.String()); diff != "" {
+				t.Errorf("response body mismatch (-want +got):\n%s", diff)
+			}
+			if len(events) != 2 {
+				t.Fatalf("got %d events, want 2", len(events))
+			}
+			// The error is captured before the response is written, and sent
+			// with it once the handler returns.
+			if diff := cmp.Diff(tt.want, events[0].Contexts["response"]); diff != "" {
+				t.Errorf("error: response context mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, events[1].Contexts["response"]); diff != "" {
+				t.Errorf("transaction: response context mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCaptureResponseBodyPanic(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := sentryhttp.New(sentryhttp.Options{CaptureResponseBody: true}).HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+		panic("test")
+	})
+	hub := sentry.NewHub(client, sentry.NewScope())
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+	handler(httptest.NewRecorder(), r)
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	want := sentry.Context{"status_code": 500, "body_size": 5, "data": "oops\n"}
+	if diff := cmp.Diff(want, events[0].Contexts["response"]); diff != "" {
+		t.Errorf("response context mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	readFrom bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func (w *hijackRecorder) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom = true
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestCaptureResponseBodyWriterInterfaces(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := sentryhttp.New(sentryhttp.Options{CaptureResponseBody: true}).HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		if _, err := w.(io.ReaderFrom).ReadFrom(strings.NewReader("body")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	for _, tt := range []struct {
+		path     string
+		hijacked bool
+		readFrom bool
+		want     sentry.Context
+	}{
+		{"/ws", true, false, nil},
+		{"/ok", false, true, nil},
+		// Server error bodies are copied through Write, to capture them.
+		{"/error", false, false, sentry.Context{"status_code": 500, "body_size": 4, "data": "body"}},
+	} {
+		events = nil
+		hub := sentry.NewHub(client, sentry.NewScope())
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+		w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+		handler(w, r)
+
+		if w.hijacked != tt.hijacked || w.readFrom != tt.readFrom {
+			t.Errorf("%s: hijacked = %v, read from = %v, want %v, %v", tt.path, w.hijacked, w.readFrom, tt.hijacked, tt.readFrom)
+		}
+		if len(events) != 1 {
+			t.Fatalf("%s: got %d events, want 1", tt.path, len(events))
+		}
+		if diff := cmp.Diff(tt.want, events[0].Contexts["response"]); diff != "" {
+			t.Errorf("%s: response context mismatch (-want +got):\n%s", tt.path, diff)
+		}
+	}
+}
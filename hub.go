@@ -217,11 +217,18 @@ func (hub *Hub) ConfigureScope(f func(scope *Scope)) {
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
 func (hub *Hub) CaptureEvent(event *Event) *EventID {
+	return hub.CaptureEventWithHint(event, nil)
+}
+
+// CaptureEventWithHint is like CaptureEvent, passing hint to the event
+// processors and BeforeSend, for integrations capturing events with the
+// context or the original error they come from.
+func (hub *Hub) CaptureEventWithHint(event *Event, hint *EventHint) *EventID {
 	client, scope := hub.Client(), hub.Scope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	eventID := client.CaptureEvent(event, nil, scope)
+	eventID := client.CaptureEvent(event, hint, scope)
 
 	if event.Type != transactionType && eventID != nil {
 		hub.mu.Lock()
@@ -231,6 +238,24 @@ func (hub *Hub) CaptureEvent(event *Event) *EventID {
 	return eventID
 }
 
+// TryCaptureEvent is like CaptureEvent, but also returns an error describing
+// why the event was not sent, such as ErrSDKDisabled, ErrEventSampled or
+// ErrQueueFull. The event ID is nil whenever the error is not nil.
+func (hub *Hub) TryCaptureEvent(event *Event) (*EventID, error) {
+	client, scope := hub.Client(), hub.Scope()
+	if client == nil || scope == nil {
+		return nil, ErrSDKDisabled
+	}
+	eventID, err := client.TryCaptureEvent(event, nil, scope)
+
+	if event != nil && event.Type != transactionType && eventID != nil {
+		hub.mu.Lock()
+		hub.lastEventID = *eventID
+		hub.mu.Unlock()
+	}
+	return eventID, err
+}
+
 // CaptureMessage calls the method of a same name on currently bound Client instance
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
@@ -249,15 +274,40 @@ func (hub *Hub) CaptureMessage(message string) *EventID {
 	return eventID
 }
 
+// TryCaptureMessage is like CaptureMessage, but also returns an error
+// describing why the event was not sent. The event ID is nil whenever the
+// error is not nil.
+func (hub *Hub) TryCaptureMessage(message string) (*EventID, error) {
+	client, scope := hub.Client(), hub.Scope()
+	if client == nil || scope == nil {
+		return nil, ErrSDKDisabled
+	}
+	eventID, err := client.TryCaptureMessage(message, nil, scope)
+
+	if eventID != nil {
+		hub.mu.Lock()
+		hub.lastEventID = *eventID
+		hub.mu.Unlock()
+	}
+	return eventID, err
+}
+
 // CaptureException calls the method of a same name on currently bound Client instance
 // passing it a top-level Scope.
 // Returns EventID if successfully, or nil if there's no Scope or Client available.
 func (hub *Hub) CaptureException(exception error) *EventID {
+	return hub.CaptureExceptionWithHint(exception, &EventHint{OriginalException: exception})
+}
+
+// CaptureExceptionWithHint is like CaptureException, passing hint to the event
+// processors and BeforeSend, for integrations setting the mechanism of
+// exceptions or the context they come from.
+func (hub *Hub) CaptureExceptionWithHint(exception error, hint *EventHint) *EventID {
 	client, scope := hub.Client(), hub.Scope()
 	if client == nil || scope == nil {
 		return nil
 	}
-	eventID := client.CaptureException(exception, &EventHint{OriginalException: exception}, scope)
+	eventID := client.CaptureException(exception, hint, scope)
 
 	if eventID != nil {
 		hub.mu.Lock()
@@ -267,6 +317,25 @@ func (hub *Hub) CaptureException(exception error) *EventID {
 	return eventID
 }
 
+// TryCaptureException is like CaptureException, but also returns an error
+// describing why the event was not sent, so that callers can fall back to
+// other means of reporting the exception. The event ID is nil whenever the
+// error is not nil.
+func (hub *Hub) TryCaptureException(exception error) (*EventID, error) {
+	client, scope := hub.Client(), hub.Scope()
+	if client == nil || scope == nil {
+		return nil, ErrSDKDisabled
+	}
+	eventID, err := client.TryCaptureException(exception, &EventHint{OriginalException: exception}, scope)
+
+	if eventID != nil {
+		hub.mu.Lock()
+		hub.lastEventID = *eventID
+		hub.mu.Unlock()
+	}
+	return eventID, err
+}
+
 // CaptureCheckIn calls the method of the same name on currently bound Client instance
 // passing it a top-level Scope.
 // Returns CheckInID if the check-in was captured successfully, or nil otherwise.
@@ -279,6 +348,63 @@ func (hub *Hub) CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorConfig) *
 	return client.CaptureCheckIn(checkIn, monitorConfig, scope)
 }
 
+// CaptureUserFeedback calls the method of the same name on currently bound
+// Client instance.
+func (hub *Hub) CaptureUserFeedback(feedback UserFeedback) {
+	client := hub.Client()
+	if client == nil {
+		return
+	}
+
+	client.CaptureUserFeedback(feedback)
+}
+
+// CaptureFeedback calls the method of the same name on currently bound Client
+// instance passing it a top-level Scope.
+// Returns EventID if the feedback was captured successfully, or nil otherwise.
+func (hub *Hub) CaptureFeedback(feedback *Feedback) *EventID {
+	client, scope := hub.Client(), hub.Scope()
+	if client == nil {
+		return nil
+	}
+
+	return client.CaptureFeedback(feedback, nil, scope)
+}
+
+// Monitor runs job and reports its execution to the monitor identified by
+// monitorSlug: an in-progress check-in is captured before job starts, followed
+// by an OK or error check-in with the duration of the job once it returns. A
+// panicking job is reported as failed, and the panic is propagated.
+//
+// If monitorConfig is not nil, the monitor is created or updated with it.
+// Monitor returns the error returned by job.
+func (hub *Hub) Monitor(monitorSlug string, monitorConfig *MonitorConfig, job func() error) error {
+	checkInID := hub.CaptureCheckIn(&CheckIn{
+		MonitorSlug: monitorSlug,
+		Status:      CheckInStatusInProgress,
+	}, monitorConfig)
+
+	start := time.Now()
+	status := CheckInStatusError
+	defer func() {
+		checkIn := &CheckIn{
+			MonitorSlug: monitorSlug,
+			Status:      status,
+			Duration:    time.Since(start),
+		}
+		if checkInID != nil {
+			checkIn.ID = *checkInID
+		}
+		hub.CaptureCheckIn(checkIn, monitorConfig)
+	}()
+
+	err := job()
+	if err == nil {
+		status = CheckInStatusOK
+	}
+	return err
+}
+
 // AddBreadcrumb records a new breadcrumb.
 //
 // The total number of breadcrumbs that can be recorded are limited by the
@@ -297,12 +423,16 @@ func (hub *Hub) AddBreadcrumb(breadcrumb *Breadcrumb, hint *BreadcrumbHint) {
 		return
 	}
 
+	if breadcrumb.Timestamp.IsZero() {
+		breadcrumb.Timestamp = client.now()
+	}
+
 	if client.options.BeforeBreadcrumb != nil {
 		if hint == nil {
 			hint = &BreadcrumbHint{}
 		}
 		if breadcrumb = client.options.BeforeBreadcrumb(breadcrumb, hint); breadcrumb == nil {
-			Logger.Println("breadcrumb dropped due to BeforeBreadcrumb callback.")
+			debugf(LevelWarning, "breadcrumb dropped due to BeforeBreadcrumb callback.")
 			return
 		}
 	}
@@ -313,6 +443,10 @@ func (hub *Hub) AddBreadcrumb(breadcrumb *Breadcrumb, hint *BreadcrumbHint) {
 		max = maxBreadcrumbs
 	}
 
+	if categoryMax, ok := client.options.MaxBreadcrumbsByCategory[breadcrumb.Category]; ok {
+		hub.Scope().addBreadcrumbWithCategoryLimit(breadcrumb, max, categoryMax)
+		return
+	}
 	hub.Scope().AddBreadcrumb(breadcrumb, max)
 }
 
@@ -393,3 +527,37 @@ func hubFromContext(ctx context.Context) *Hub {
 func SetHubOnContext(ctx context.Context, hub *Hub) context.Context {
 	return context.WithValue(ctx, HubContextKey, hub)
 }
+
+// NewContext returns a copy of ctx carrying hub. If hub is nil, a clone of the
+// hub found in ctx, or of the current hub, is used instead, so that changes
+// made to its scope are isolated to the returned context.
+func NewContext(ctx context.Context, hub *Hub) context.Context {
+	if hub == nil {
+		hub = hubFromContext(ctx).Clone()
+	}
+	return SetHubOnContext(ctx, hub)
+}
+
+// HubFromContext returns the hub carried by ctx. If ctx has no hub, it returns
+// a clone of the current hub, rather than the current hub itself, so that
+// request-scoped changes never leak into the global scope. Such changes are
+// not visible through ctx; use NewContext to propagate a hub.
+func HubFromContext(ctx context.Context) *Hub {
+	if hub := GetHubFromContext(ctx); hub != nil {
+		return hub
+	}
+	return CurrentHub().Clone()
+}
+
+// WithScopeContext returns a copy of ctx carrying a clone of its hub, whose
+// scope is configured by f. Events captured through the returned context
+// include the changes made by f, while ctx and its hub are left untouched.
+//
+//	ctx = sentry.WithScopeContext(r.Context(), func(scope *sentry.Scope) {
+//		scope.SetTag("tenant", tenantID)
+//	})
+func WithScopeContext(ctx context.Context, f func(scope *Scope)) context.Context {
+	hub := hubFromContext(ctx).Clone()
+	hub.ConfigureScope(f)
+	return SetHubOnContext(ctx, hub)
+}
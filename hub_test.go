@@ -2,6 +2,7 @@ package sentry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -190,6 +191,12 @@ func TestLastEventIDUpdatesAfterCaptures(t *testing.T) {
 
 	eventID := hub.CaptureEvent(&Event{Message: "wat"})
 	assertEqual(t, *eventID, hub.LastEventID())
+
+	hintEventID := hub.CaptureEventWithHint(&Event{Message: "wat"}, &EventHint{Data: "hint"})
+	assertEqual(t, *hintEventID, hub.LastEventID())
+
+	hintErrorID := hub.CaptureExceptionWithHint(fmt.Errorf("wat"), &EventHint{Mechanism: &Mechanism{Type: "test"}})
+	assertEqual(t, *hintErrorID, hub.LastEventID())
 }
 
 func TestLastEventIDNotChangedForTransactions(t *testing.T) {
@@ -257,6 +264,43 @@ func TestAddBreadcrumbShouldNeverExceedMaxBreadcrumbsConst(t *testing.T) {
 	assertEqual(t, len(scope.breadcrumbs), 100)
 }
 
+func TestAddBreadcrumbRespectMaxBreadcrumbsByCategoryOption(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	client.options.MaxBreadcrumbs = 5
+	client.options.MaxBreadcrumbsByCategory = map[string]int{"http": 2, "debug": 0}
+
+	hub.AddBreadcrumb(&Breadcrumb{Category: "query", Message: "q1"}, nil)
+	for i := 1; i <= 4; i++ {
+		hub.AddBreadcrumb(&Breadcrumb{Category: "http", Message: fmt.Sprintf("h%d", i)}, nil)
+	}
+	hub.AddBreadcrumb(&Breadcrumb{Category: "debug", Message: "d1"}, nil)
+	hub.AddBreadcrumb(&Breadcrumb{Category: "query", Message: "q2"}, nil)
+
+	var messages []string
+	for _, b := range scope.breadcrumbs {
+		messages = append(messages, b.Message)
+	}
+	assertEqual(t, messages, []string{"q1", "h3", "h4", "q2"})
+
+	for i := 3; i <= 6; i++ {
+		hub.AddBreadcrumb(&Breadcrumb{Category: "query", Message: fmt.Sprintf("q%d", i)}, nil)
+	}
+	assertEqual(t, len(scope.breadcrumbs), 5)
+}
+
+func TestAddBreadcrumbWithCategoryLimitDoesNotModifyClones(t *testing.T) {
+	scope := NewScope()
+	scope.AddBreadcrumb(&Breadcrumb{Category: "http", Message: "h1"}, 10)
+	scope.AddBreadcrumb(&Breadcrumb{Category: "query", Message: "q1"}, 10)
+	clone := scope.Clone()
+
+	scope.addBreadcrumbWithCategoryLimit(&Breadcrumb{Category: "http", Message: "h2"}, 10, 1)
+
+	assertEqual(t, clone.breadcrumbs[0].Message, "h1")
+	assertEqual(t, len(clone.breadcrumbs), 2)
+	assertEqual(t, len(scope.breadcrumbs), 2)
+}
+
 func TestAddBreadcrumbShouldWorkWithoutClient(t *testing.T) {
 	scope := NewScope()
 	hub := NewHub(nil, scope)
@@ -349,6 +393,44 @@ func TestSetHubOnContextReturnsNewContext(t *testing.T) {
 	}
 }
 
+func TestNewContext(t *testing.T) {
+	hub, _, _ := setupHubTest()
+	ctx := NewContext(context.Background(), hub)
+	assertEqual(t, hub, GetHubFromContext(ctx))
+
+	// A nil hub clones the hub found in the context.
+	child := NewContext(ctx, nil)
+	clone := GetHubFromContext(child)
+	if clone == nil || clone == hub {
+		t.Fatal("NewContext(ctx, nil) should store a clone of the hub")
+	}
+	assertEqual(t, hub.Client(), clone.Client())
+}
+
+func TestHubFromContext(t *testing.T) {
+	hub, _, _ := setupHubTest()
+	ctx := SetHubOnContext(context.Background(), hub)
+	assertEqual(t, hub, HubFromContext(ctx))
+
+	clone := HubFromContext(context.Background())
+	if clone == nil || clone == CurrentHub() {
+		t.Error("HubFromContext should return a clone of the current hub when ctx has no hub")
+	}
+}
+
+func TestWithScopeContext(t *testing.T) {
+	hub, _, _ := setupHubTest()
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	child := WithScopeContext(ctx, func(scope *Scope) {
+		scope.SetTag("tenant", "acme")
+	})
+
+	assertEqual(t, map[string]string{"tenant": "acme"}, GetHubFromContext(child).Scope().tags)
+	assertEqual(t, map[string]string{}, hub.Scope().tags)
+	assertEqual(t, hub, GetHubFromContext(ctx))
+}
+
 func TestConcurrentHubClone(t *testing.T) {
 	const goroutineCount = 3
 
@@ -410,3 +492,64 @@ func TestConcurrentHubClone(t *testing.T) {
 		t.Errorf("Events mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestMonitor(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+	monitorConfig := &MonitorConfig{
+		Schedule:              CrontabSchedule("0 * * * *"),
+		FailureIssueThreshold: 2,
+		RecoveryThreshold:     3,
+	}
+
+	jobErr := errors.New("job failed")
+	err := hub.Monitor("cron", monitorConfig, func() error {
+		return jobErr
+	})
+	if err != jobErr {
+		t.Errorf("Monitor() = %v, want the job error", err)
+	}
+	if err := hub.Monitor("cron", nil, func() error { return nil }); err != nil {
+		t.Errorf("Monitor() = %v, want nil", err)
+	}
+
+	events := transport.Events()
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4 check-ins", len(events))
+	}
+	var statuses []CheckInStatus
+	for _, event := range events {
+		statuses = append(statuses, event.CheckIn.Status)
+	}
+	want := []CheckInStatus{CheckInStatusInProgress, CheckInStatusError, CheckInStatusInProgress, CheckInStatusOK}
+	if diff := cmp.Diff(want, statuses); diff != "" {
+		t.Errorf("check-in statuses mismatch (-want +got):\n%s", diff)
+	}
+	assertEqual(t, events[0].CheckIn.ID, events[1].CheckIn.ID)
+	assertEqual(t, events[2].CheckIn.ID, events[3].CheckIn.ID)
+	if events[0].CheckIn.ID == events[2].CheckIn.ID {
+		t.Error("each run should have its own check-in ID")
+	}
+	if events[1].MonitorConfig != monitorConfig {
+		t.Error("the monitor config should be sent with the check-ins")
+	}
+}
+
+func TestMonitorPanic(t *testing.T) {
+	hub, client, _ := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recover() = %v, want the job panic", r)
+		}
+		events := transport.Events()
+		if len(events) != 2 {
+			t.Fatalf("got %d events, want 2 check-ins", len(events))
+		}
+		assertEqual(t, events[1].CheckIn.Status, CheckInStatusError)
+	}()
+	_ = hub.Monitor("cron", nil, func() error {
+		panic("boom")
+	})
+}
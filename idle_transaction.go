@@ -0,0 +1,80 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// WithIdleTimeout makes a transaction idle: it finishes automatically once all
+// its child spans have finished and no new child span was started for the
+// given timeout. It is meant for event-driven code, where there is no single
+// place to finish the transaction. The end time of the transaction is the end
+// time of its last child span, or the time it started if it has none.
+//
+// Calling Finish on an idle transaction finishes it right away. The option has
+// no effect on spans that aren't transactions.
+func WithIdleTimeout(timeout time.Duration) SpanOption {
+	return func(s *Span) {
+		if s.isTransaction && timeout > 0 {
+			s.idle = &idleTracker{timeout: timeout}
+		}
+	}
+}
+
+// idleTracker tracks the child spans of an idle transaction in progress. It is
+// safe for concurrent use.
+type idleTracker struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	active  int
+	timer   *time.Timer
+	lastEnd time.Time
+}
+
+// start starts waiting for the transaction to become idle.
+func (t *idleTracker) start(transaction *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastEnd = transaction.StartTime
+	t.timer = time.AfterFunc(t.timeout, func() {
+		t.mu.Lock()
+		end := t.lastEnd
+		t.mu.Unlock()
+		transaction.finishOnce.Do(func() {
+			if transaction.EndTime.IsZero() {
+				transaction.EndTime = end
+			}
+			transaction.doFinish()
+		})
+	})
+}
+
+// childStarted stops waiting for the transaction to become idle while a child
+// span is in progress.
+func (t *idleTracker) childStarted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active++
+	t.timer.Stop()
+}
+
+// childFinished resumes waiting for the transaction to become idle once all its
+// child spans are finished.
+func (t *idleTracker) childFinished(end time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if end.After(t.lastEnd) {
+		t.lastEnd = end
+	}
+	if t.active == 0 {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// stop stops waiting for the transaction to become idle.
+func (t *idleTracker) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer.Stop()
+}
@@ -0,0 +1,63 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleTransactionFinishesAfterChildren(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+
+	transaction := StartTransaction(ctx, "idle", WithIdleTimeout(20*time.Millisecond))
+	child := transaction.StartChild("work")
+	time.Sleep(50 * time.Millisecond)
+	if len(transport.Events()) != 0 {
+		t.Fatal("an idle transaction should not finish while a child span is in progress")
+	}
+
+	child.Finish()
+	time.Sleep(100 * time.Millisecond)
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	assertEqual(t, events[0].Transaction, "idle")
+	assertEqual(t, len(events[0].Spans), 1)
+	assertEqual(t, events[0].Timestamp, child.EndTime)
+}
+
+func TestIdleTransactionWithoutChildren(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+
+	transaction := StartTransaction(ctx, "idle", WithIdleTimeout(10*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	assertEqual(t, events[0].Timestamp, transaction.StartTime)
+}
+
+func TestIdleTransactionFinish(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	transport := hubFromContext(ctx).Client().Transport.(*TransportMock)
+
+	transaction := StartTransaction(ctx, "idle", WithIdleTimeout(10*time.Millisecond))
+	transaction.StartChild("work")
+	transaction.Finish()
+	time.Sleep(50 * time.Millisecond)
+
+	assertEqual(t, len(transport.Events()), 1)
+}
@@ -2,11 +2,13 @@ package sentry
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"runtime"
 	"runtime/debug"
 	"strings"
 	"sync"
+	"time"
 )
 
 // ================================
@@ -31,7 +33,7 @@ func (mi *modulesIntegration) processor(event *Event, hint *EventHint) *Event {
 		mi.once.Do(func() {
 			info, ok := debug.ReadBuildInfo()
 			if !ok {
-				Logger.Print("The Modules integration is not available in binaries built without module support.")
+				debugf(LevelWarning, "The Modules integration is not available in binaries built without module support.")
 				return
 			}
 			mi.modules = extractModules(info)
@@ -117,6 +119,168 @@ func (ei *environmentIntegration) processor(event *Event, hint *EventHint) *Even
 	return event
 }
 
+// ================================
+// Memory Integration
+// ================================
+
+// memoryIntegration adds a "memory" context with Go memory statistics to error
+// events. Reading the statistics briefly stops the world, so transactions are
+// left untouched. Remove the "Memory" integration with ClientOptions.Integrations
+// to opt out.
+type memoryIntegration struct{}
+
+func (mi *memoryIntegration) Name() string {
+	return "Memory"
+}
+
+func (mi *memoryIntegration) SetupOnce(client *Client) {
+	client.AddEventProcessor(mi.processor)
+}
+
+func (mi *memoryIntegration) processor(event *Event, hint *EventHint) *Event {
+	if event.Type == transactionType || event.Type == checkInType || event.Type == feedbackType {
+		return event
+	}
+
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]Context, 1)
+	}
+	memoryContext, ok := event.Contexts["memory"]
+	if !ok {
+		memoryContext = make(Context)
+		event.Contexts["memory"] = memoryContext
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	values := Context{
+		"heap_alloc":   ms.HeapAlloc,
+		"heap_inuse":   ms.HeapInuse,
+		"heap_objects": ms.HeapObjects,
+		"sys":          ms.Sys,
+		"num_gc":       ms.NumGC,
+	}
+	if ms.NumGC > 0 {
+		values["last_gc"] = time.Unix(0, int64(ms.LastGC)).UTC()
+		values["last_gc_pause_ns"] = ms.PauseNs[(ms.NumGC+255)%256]
+	}
+	// Preserve existing data.
+	for k, v := range values {
+		if _, ok := memoryContext[k]; !ok {
+			memoryContext[k] = v
+		}
+	}
+	return event
+}
+
+// ================================
+// Kubernetes Integration
+// ================================
+
+// kubernetesNamespaceFile is where Kubernetes mounts the namespace of the pod
+// along with the service account credentials.
+var kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// kubernetesIntegration adds a "k8s" context describing the pod the program runs
+// in, and defaults ServerName to the pod name. It does nothing outside of
+// Kubernetes. Remove the "Kubernetes" integration with
+// ClientOptions.Integrations to opt out.
+//
+// The pod metadata is read from environment variables, which can be populated
+// with the downward API:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	  - name: POD_NAMESPACE
+//	    valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	  - name: NODE_NAME
+//	    valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+//	  - name: CONTAINER_IMAGE
+//	    value: registry.example.com/app:v1.2.3
+//
+// The pod name falls back to the hostname, and the namespace to the one
+// mounted with the service account.
+type kubernetesIntegration struct {
+	context Context
+}
+
+func (ki *kubernetesIntegration) Name() string {
+	return "Kubernetes"
+}
+
+func (ki *kubernetesIntegration) SetupOnce(client *Client) {
+	ki.context = kubernetesContext()
+	if ki.context == nil {
+		return
+	}
+	if podName, ok := ki.context["pod_name"].(string); ok && client.options.ServerName == "" {
+		client.options.ServerName = podName
+	}
+	client.AddEventProcessor(ki.processor)
+}
+
+func (ki *kubernetesIntegration) processor(event *Event, hint *EventHint) *Event {
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]Context, 1)
+	}
+	k8sContext, ok := event.Contexts["k8s"]
+	if !ok {
+		k8sContext = make(Context, len(ki.context))
+		event.Contexts["k8s"] = k8sContext
+	}
+	// Preserve existing data.
+	for k, v := range ki.context {
+		if _, ok := k8sContext[k]; !ok {
+			k8sContext[k] = v
+		}
+	}
+	return event
+}
+
+// kubernetesContext returns the "k8s" context of the current pod, or nil when
+// not running in Kubernetes.
+func kubernetesContext() Context {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+
+	namespace := firstEnv("POD_NAMESPACE", "K8S_NAMESPACE")
+	if namespace == "" {
+		if b, err := os.ReadFile(kubernetesNamespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(b))
+		}
+	}
+	podName := firstEnv("POD_NAME", "K8S_POD_NAME")
+	if podName == "" {
+		podName, _ = os.Hostname()
+	}
+
+	context := Context{}
+	for k, v := range map[string]string{
+		"pod_name":        podName,
+		"namespace":       namespace,
+		"node_name":       firstEnv("NODE_NAME", "K8S_NODE_NAME"),
+		"container_image": firstEnv("CONTAINER_IMAGE", "K8S_CONTAINER_IMAGE"),
+	} {
+		if v != "" {
+			context[k] = v
+		}
+	}
+	return context
+}
+
+// firstEnv returns the value of the first of keys set in the environment.
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // ================================
 // Ignore Errors Integration
 // ================================
@@ -140,7 +304,7 @@ func (iei *ignoreErrorsIntegration) processor(event *Event, hint *EventHint) *Ev
 	for _, suspect := range suspects {
 		for _, pattern := range iei.ignoreErrors {
 			if pattern.Match([]byte(suspect)) {
-				Logger.Printf("Event dropped due to being matched by `IgnoreErrors` option."+
+				debugf(LevelWarning, "Event dropped due to being matched by `IgnoreErrors` option."+
 					"| Value matched: %s | Filter used: %s", suspect, pattern)
 				return nil
 			}
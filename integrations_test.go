@@ -2,6 +2,7 @@ package sentry
 
 import (
 	"encoding/json"
+	"os"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
@@ -388,3 +389,117 @@ func TestEnvironmentIntegrationDoesNotOverrideExistingContexts(t *testing.T) {
 		t.Errorf(`contexts["custom"]["key"] = %#v, want "value"`, contexts["custom"]["key"])
 	}
 }
+
+func TestMemoryIntegration(t *testing.T) {
+	integration := new(memoryIntegration)
+
+	event := integration.processor(&Event{
+		Contexts: map[string]Context{"memory": {"sys": "custom"}},
+	}, nil)
+	memory := event.Contexts["memory"]
+	for _, key := range []string{"heap_alloc", "heap_inuse", "heap_objects", "num_gc"} {
+		if _, ok := memory[key]; !ok {
+			t.Errorf("memory context is missing %q: %#v", key, memory)
+		}
+	}
+	if memory["sys"] != "custom" {
+		t.Errorf(`memory["sys"] = %#v, existing values should be preserved`, memory["sys"])
+	}
+
+	transaction := integration.processor(&Event{Type: transactionType}, nil)
+	if _, ok := transaction.Contexts["memory"]; ok {
+		t.Error("transactions should not have a memory context")
+	}
+}
+
+func TestMemoryIntegrationOptOut(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport: transport,
+		Integrations: func(integrations []Integration) []Integration {
+			var filtered []Integration
+			for _, integration := range integrations {
+				if integration.Name() != "Memory" {
+					filtered = append(filtered, integration)
+				}
+			}
+			return filtered
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewHub(client, NewScope()).CaptureMessage("test event")
+
+	if _, ok := transport.lastEvent.Contexts["memory"]; ok {
+		t.Error("memory context should not be set when the Memory integration is removed")
+	}
+	if _, ok := transport.lastEvent.Contexts["runtime"]; !ok {
+		t.Error("runtime context should still be set")
+	}
+}
+
+func TestKubernetesIntegration(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAME", "api-7d9f8b6c5-x2k4q")
+	t.Setenv("POD_NAMESPACE", "production")
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("CONTAINER_IMAGE", "registry.example.com/api:v1.2.3")
+
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(client, NewScope())
+	hub.Scope().SetContext("k8s", Context{"namespace": "custom"})
+	hub.CaptureMessage("test event")
+
+	want := Context{
+		"pod_name":        "api-7d9f8b6c5-x2k4q",
+		"namespace":       "custom",
+		"node_name":       "node-1",
+		"container_image": "registry.example.com/api:v1.2.3",
+	}
+	if diff := cmp.Diff(want, transport.lastEvent.Contexts["k8s"]); diff != "" {
+		t.Errorf("k8s context mismatch (-want +got):\n%s", diff)
+	}
+	assertEqual(t, transport.lastEvent.ServerName, "api-7d9f8b6c5-x2k4q")
+}
+
+func TestKubernetesIntegrationNamespaceFile(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAMESPACE", "")
+	t.Setenv("K8S_NAMESPACE", "")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "namespace")
+	if err := os.WriteFile(file, []byte("staging\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { kubernetesNamespaceFile = old }(kubernetesNamespaceFile)
+	kubernetesNamespaceFile = file
+
+	assertEqual(t, kubernetesContext()["namespace"], "staging")
+}
+
+func TestKubernetesIntegrationOutsideKubernetes(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Transport:  transport,
+		ServerName: "server",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewHub(client, NewScope()).CaptureMessage("test event")
+
+	if _, ok := transport.lastEvent.Contexts["k8s"]; ok {
+		t.Error("k8s context should not be set outside of Kubernetes")
+	}
+	assertEqual(t, transport.lastEvent.ServerName, "server")
+}
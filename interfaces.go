@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"reflect"
@@ -22,9 +23,18 @@ const eventType = "event"
 
 const profileType = "profile"
 
+// attachmentType is the type of an attachment envelope item.
+const attachmentType = "attachment"
+
 // checkInType is the type of a check in event.
 const checkInType = "check_in"
 
+// feedbackType is the type of a user feedback event.
+const feedbackType = "feedback"
+
+// userReportType is the type of a legacy user feedback envelope item.
+const userReportType = "user_report"
+
 // Level marks the severity of the event.
 type Level string
 
@@ -110,10 +120,18 @@ func (b *Breadcrumb) MarshalJSON() ([]byte, error) {
 
 // Attachment allows associating files with your events to aid in investigation.
 // An event may contain one or more attachments.
+//
+// The payload of an attachment is either held in memory in Payload, or streamed
+// from Open when the attachment is sent, which is preferable for large
+// payloads. See NewFileAttachment.
 type Attachment struct {
 	Filename    string
 	ContentType string
 	Payload     []byte
+	// Open, if not nil, is used instead of Payload. It is called every time
+	// the attachment is sent, and must return a reader producing exactly size
+	// bytes. The reader is closed after it has been consumed.
+	Open func() (r io.ReadCloser, size int64, err error)
 }
 
 // User describes the user associated with an Event. If this is used, at least
@@ -237,6 +255,16 @@ func (m *Mechanism) SetUnhandled() {
 	m.Handled = &h
 }
 
+// mechanismTypeGeneric is the type of the mechanism of the exceptions captured
+// by the SDK.
+const mechanismTypeGeneric = "generic"
+
+// newMechanism returns the mechanism of an exception captured by the SDK,
+// either explicitly (handled) or by recovering from a panic (unhandled).
+func newMechanism(handled bool) *Mechanism {
+	return &Mechanism{Type: mechanismTypeGeneric, Handled: &handled}
+}
+
 // Exception specifies an error that occurred.
 type Exception struct {
 	Type       string      `json:"type,omitempty"`  // used as the main issue title
@@ -252,6 +280,11 @@ type Exception struct {
 type SDKMetaData struct {
 	dsc                DynamicSamplingContext
 	transactionProfile *profileInfo
+	// dsn overrides the DSN of the transport for this event. It is set by
+	// ClientOptions.Router.
+	dsn *Dsn
+	// unhandled is set for events reporting a recovered panic.
+	unhandled bool
 }
 
 // Contains information about how the name of the transaction was determined.
@@ -321,10 +354,11 @@ type Event struct {
 
 	// The fields below are only relevant for transactions.
 
-	Type            string           `json:"type,omitempty"`
-	StartTime       time.Time        `json:"start_timestamp"`
-	Spans           []*Span          `json:"spans,omitempty"`
-	TransactionInfo *TransactionInfo `json:"transaction_info,omitempty"`
+	Type            string                 `json:"type,omitempty"`
+	StartTime       time.Time              `json:"start_timestamp"`
+	Spans           []*Span                `json:"spans,omitempty"`
+	TransactionInfo *TransactionInfo       `json:"transaction_info,omitempty"`
+	Measurements    map[string]Measurement `json:"measurements,omitempty"`
 
 	// The fields below are only relevant for crons/check ins
 
@@ -335,6 +369,16 @@ type Event struct {
 
 	sdkMetaData SDKMetaData
 	attachments []*Attachment
+	// meta records modifications made by the SDK, such as truncation.
+	meta eventMeta
+	// metricsSummary aggregates the metrics emitted within a transaction.
+	metricsSummary metricsSummary
+}
+
+// AddAttachment adds an attachment to be sent along with the event, in addition
+// to the attachments of the scope.
+func (e *Event) AddAttachment(attachment *Attachment) {
+	e.attachments = append(e.attachments, attachment)
 }
 
 // SetException appends the unwrapped errors to the event's exception list.
@@ -375,6 +419,27 @@ func (e *Event) SetException(exception error, maxErrorDepth int) {
 	reverse(e.Exception)
 }
 
+// setMechanism sets the mechanism of the most recent exception of the event.
+func (e *Event) setMechanism(mechanism *Mechanism) {
+	if len(e.Exception) > 0 {
+		e.Exception[len(e.Exception)-1].Mechanism = mechanism
+	}
+}
+
+// isUnhandled reports whether the event reports a panic or an exception that
+// was not handled.
+func (e *Event) isUnhandled() bool {
+	if e.sdkMetaData.unhandled {
+		return true
+	}
+	for _, exception := range e.Exception {
+		if exception.Mechanism != nil && exception.Mechanism.Handled != nil && !*exception.Mechanism.Handled {
+			return true
+		}
+	}
+	return false
+}
+
 // TODO: Event.Contexts map[string]interface{} => map[string]EventContext,
 // to prevent accidentally storing T when we mean *T.
 // For example, the TraceContext must be stored as *TraceContext to pick up the
@@ -420,9 +485,11 @@ func (e *Event) defaultMarshalJSON() ([]byte, error) {
 		StartTime       json.RawMessage `json:"start_timestamp,omitempty"`
 		Spans           json.RawMessage `json:"spans,omitempty"`
 		TransactionInfo json.RawMessage `json:"transaction_info,omitempty"`
+
+		Meta eventMeta `json:"_meta,omitempty"`
 	}
 
-	x := errorEvent{event: (*event)(e)}
+	x := errorEvent{event: (*event)(e), Meta: e.meta}
 	if !e.Timestamp.IsZero() {
 		b, err := e.Timestamp.MarshalJSON()
 		if err != nil {
@@ -430,6 +497,10 @@ func (e *Event) defaultMarshalJSON() ([]byte, error) {
 		}
 		x.Timestamp = b
 	}
+	if e.Type == feedbackType {
+		// Feedback events are otherwise serialized like error events.
+		x.Type = json.RawMessage(`"` + feedbackType + `"`)
+	}
 	return json.Marshal(x)
 }
 
@@ -448,9 +519,12 @@ func (e *Event) transactionMarshalJSON() ([]byte, error) {
 
 		StartTime json.RawMessage `json:"start_timestamp,omitempty"`
 		Timestamp json.RawMessage `json:"timestamp,omitempty"`
+
+		Meta           eventMeta      `json:"_meta,omitempty"`
+		MetricsSummary metricsSummary `json:"_metrics_summary,omitempty"`
 	}
 
-	x := transactionEvent{event: (*event)(e)}
+	x := transactionEvent{event: (*event)(e), Meta: e.meta, MetricsSummary: e.metricsSummary}
 	if !e.Timestamp.IsZero() {
 		b, err := e.Timestamp.MarshalJSON()
 		if err != nil {
@@ -520,4 +594,11 @@ type EventHint struct {
 	Context            context.Context
 	Request            *http.Request
 	Response           *http.Response
+	// Attachments are sent along with the event, in addition to the
+	// attachments of the scope.
+	Attachments []*Attachment
+	// Mechanism overrides the mechanism of the most recent exception of the
+	// event. Integrations use it to report an error as unhandled, which marks
+	// the current session as crashed.
+	Mechanism *Mechanism
 }
@@ -20,14 +20,20 @@ const (
 	CategoryAll         Category = ""
 	CategoryError       Category = "error"
 	CategoryTransaction Category = "transaction"
+	// CategorySpan counts the spans dropped from transactions in client
+	// reports. Spans are not rate limited on their own.
+	CategorySpan Category = "span"
+	// CategoryMetricBucket is the category of the buckets of metrics.
+	CategoryMetricBucket Category = "metric_bucket"
 )
 
 // knownCategories is the set of currently known categories. Other categories
 // are ignored for the purpose of rate-limiting.
 var knownCategories = map[Category]struct{}{
-	CategoryAll:         {},
-	CategoryError:       {},
-	CategoryTransaction: {},
+	CategoryAll:          {},
+	CategoryError:        {},
+	CategoryTransaction:  {},
+	CategoryMetricBucket: {},
 }
 
 // String returns the category formatted for debugging.
@@ -0,0 +1,106 @@
+// Package sentryfranz instruments the Kafka producers and consumers of
+// github.com/twmb/franz-go. See package sentrykafka.
+package sentryfranz
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	sentrykafka "github.com/getsentry/sentry-go/kafka"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Hook is a kgo hook producing records in "queue.publish" spans, which are
+// children of the span in the context of the records, and propagating the
+// trace in the record headers:
+//
+//	client, err := kgo.NewClient(kgo.WithHooks(sentryfranz.NewHook()))
+type Hook struct{}
+
+var (
+	_ kgo.HookProduceRecordBuffered   = (*Hook)(nil)
+	_ kgo.HookProduceRecordUnbuffered = (*Hook)(nil)
+)
+
+// NewHook returns a new Hook.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// spanContextKey is the key of the "queue.publish" span of a record in its
+// context.
+type spanContextKey struct{}
+
+// OnProduceRecordBuffered starts the span of a record.
+func (h *Hook) OnProduceRecordBuffered(r *kgo.Record) {
+	if r.Context == nil {
+		return
+	}
+	span := sentrykafka.StartPublishSpan(r.Context, r.Topic, (*recordHeaders)(r))
+	if span != nil {
+		r.Context = context.WithValue(r.Context, spanContextKey{}, span)
+	}
+}
+
+// OnProduceRecordUnbuffered finishes the span of a record.
+func (h *Hook) OnProduceRecordUnbuffered(r *kgo.Record, err error) {
+	if r.Context == nil {
+		return
+	}
+	if span, ok := r.Context.Value(spanContextKey{}).(*sentry.Span); ok {
+		span.SetError(err)
+		span.Finish()
+	}
+}
+
+// Handler processes consumed records in transactions, and reports the panics
+// of record handlers to Sentry.
+type Handler struct {
+	handler *sentrykafka.Handler
+}
+
+// New returns a new Handler.
+func New(options sentrykafka.Options) *Handler {
+	return &Handler{handler: sentrykafka.New(options)}
+}
+
+// ProcessRecord calls process with a context holding a "queue.process"
+// transaction for r, which continues the trace propagated in the record
+// headers:
+//
+//	fetches.EachRecord(func(r *kgo.Record) {
+//		err := handler.ProcessRecord(ctx, r, func(ctx context.Context) error {
+//			return process(ctx, r)
+//		})
+//		...
+//	})
+func (h *Handler) ProcessRecord(ctx context.Context, r *kgo.Record, process func(ctx context.Context) error) error {
+	return h.handler.Process(ctx, sentrykafka.Message{
+		Topic:     r.Topic,
+		Partition: r.Partition,
+		Offset:    r.Offset,
+		Headers:   (*recordHeaders)(r),
+	}, process)
+}
+
+// recordHeaders adapts the headers of a record to sentrykafka.Headers.
+type recordHeaders kgo.Record
+
+func (h *recordHeaders) Get(key string) string {
+	for _, header := range h.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h *recordHeaders) Set(key, value string) {
+	headers := h.Headers[:0]
+	for _, header := range h.Headers {
+		if header.Key != key {
+			headers = append(headers, header)
+		}
+	}
+	h.Headers = append(headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
@@ -0,0 +1,60 @@
+package sentryfranz_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrykafka "github.com/getsentry/sentry-go/kafka"
+	sentryfranz "github.com/getsentry/sentry-go/kafka/franz"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestHookAndProcessRecord(t *testing.T) {
+	transactions := make(chan *sentry.Event, 2)
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions <- event
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+
+	// Simulate the produce of a record by a client.
+	hook := sentryfranz.NewHook()
+	transaction := sentry.StartTransaction(ctx, "producer")
+	record := &kgo.Record{Topic: "orders", Context: transaction.Context()}
+	hook.OnProduceRecordBuffered(record)
+	hook.OnProduceRecordUnbuffered(record, errors.New("broker not available"))
+	transaction.Finish()
+	publish := <-transactions
+
+	if len(publish.Spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(publish.Spans))
+	}
+	span := publish.Spans[0]
+	if span.Op != sentrykafka.PublishOperation || span.Status != sentry.SpanStatusInternalError {
+		t.Errorf("got span op %q and status %v", span.Op, span.Status)
+	}
+	if len(record.Headers) != 2 {
+		t.Fatalf("got headers %v, want the trace headers", record.Headers)
+	}
+
+	err = sentryfranz.New(sentrykafka.Options{}).ProcessRecord(ctx, record, func(ctx context.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := <-transactions
+
+	if got, want := process.Contexts["trace"]["parent_span_id"], span.SpanID; got != want {
+		t.Errorf("process transaction parent = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+// Package sentrysarama instruments the Kafka producers and consumers of
+// github.com/IBM/sarama. See package sentrykafka.
+package sentrysarama
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	sentrykafka "github.com/getsentry/sentry-go/kafka"
+)
+
+// SendMessage sends msg with producer in a "queue.publish" span, which is a
+// child of the span in ctx, and propagates the trace in the message headers.
+func SendMessage(ctx context.Context, producer sarama.SyncProducer, msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	span := sentrykafka.StartPublishSpan(ctx, msg.Topic, (*producerHeaders)(msg))
+	partition, offset, err = producer.SendMessage(msg)
+	if span != nil {
+		span.SetError(err)
+		span.Finish()
+	}
+	return partition, offset, err
+}
+
+// Handler processes consumed messages in transactions, and reports the panics
+// of message handlers to Sentry.
+type Handler struct {
+	handler *sentrykafka.Handler
+}
+
+// New returns a new Handler.
+func New(options sentrykafka.Options) *Handler {
+	return &Handler{handler: sentrykafka.New(options)}
+}
+
+// ProcessMessage calls process with a context holding a "queue.process"
+// transaction for msg, which continues the trace propagated in the message
+// headers. Use it in the ConsumeClaim method of a sarama.ConsumerGroupHandler:
+//
+//	for msg := range claim.Messages() {
+//		err := h.sentry.ProcessMessage(session.Context(), msg, func(ctx context.Context) error {
+//			return h.process(ctx, msg)
+//		})
+//		...
+//	}
+func (h *Handler) ProcessMessage(ctx context.Context, msg *sarama.ConsumerMessage, process func(ctx context.Context) error) error {
+	return h.handler.Process(ctx, sentrykafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Headers:   consumerHeaders(msg.Headers),
+	}, process)
+}
+
+// producerHeaders adapts the headers of a producer message to
+// sentrykafka.Headers.
+type producerHeaders sarama.ProducerMessage
+
+func (h *producerHeaders) Get(key string) string {
+	for _, header := range h.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h *producerHeaders) Set(key, value string) {
+	headers := h.Headers[:0]
+	for _, header := range h.Headers {
+		if string(header.Key) != key {
+			headers = append(headers, header)
+		}
+	}
+	h.Headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// consumerHeaders adapts the headers of a consumer message to
+// sentrykafka.Headers.
+type consumerHeaders []*sarama.RecordHeader
+
+func (h consumerHeaders) Get(key string) string {
+	for _, header := range h {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set does nothing, as consumed messages are read-only.
+func (h consumerHeaders) Set(key, value string) {}
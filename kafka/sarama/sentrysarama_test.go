@@ -0,0 +1,67 @@
+package sentrysarama_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/getsentry/sentry-go"
+	sentrykafka "github.com/getsentry/sentry-go/kafka"
+	sentrysarama "github.com/getsentry/sentry-go/kafka/sarama"
+)
+
+func TestSendAndProcessMessage(t *testing.T) {
+	transactions := make(chan *sentry.Event, 2)
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions <- event
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.NewHub(client, sentry.NewScope()))
+
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+	producer.ExpectSendMessageAndSucceed()
+
+	msg := &sarama.ProducerMessage{
+		Topic:   "orders",
+		Value:   sarama.StringEncoder("order"),
+		Headers: []sarama.RecordHeader{{Key: []byte("key"), Value: []byte("value")}},
+	}
+	transaction := sentry.StartTransaction(ctx, "producer")
+	if _, _, err := sentrysarama.SendMessage(transaction.Context(), producer, msg); err != nil {
+		t.Fatal(err)
+	}
+	transaction.Finish()
+	publish := <-transactions
+
+	if len(publish.Spans) != 1 || publish.Spans[0].Op != sentrykafka.PublishOperation {
+		t.Fatalf("got spans %v, want one publish span", publish.Spans)
+	}
+	if len(msg.Headers) != 3 {
+		t.Fatalf("got headers %v, want the original header and the trace headers", msg.Headers)
+	}
+
+	consumed := &sarama.ConsumerMessage{Topic: msg.Topic}
+	for i := range msg.Headers {
+		consumed.Headers = append(consumed.Headers, &msg.Headers[i])
+	}
+	err = sentrysarama.New(sentrykafka.Options{}).ProcessMessage(ctx, consumed, func(ctx context.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := <-transactions
+
+	if got, want := process.Contexts["trace"]["parent_span_id"], publish.Spans[0].SpanID; got != want {
+		t.Errorf("process transaction parent = %v, want %v", got, want)
+	}
+}
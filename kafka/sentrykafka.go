@@ -0,0 +1,166 @@
+// Package sentrykafka provides Sentry instrumentation for Kafka producers and
+// consumers, independently of the Kafka client library.
+//
+// Producers send messages in "queue.publish" spans, and propagate the trace
+// to consumers in the sentry-trace and baggage message headers. Consumers
+// process messages in "queue.process" transactions continuing that trace, and
+// report the panics of message handlers along with the metadata of the
+// message.
+//
+// The sarama and franz subpackages instrument the clients of
+// github.com/IBM/sarama and github.com/twmb/franz-go.
+package sentrykafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Span operations.
+const (
+	PublishOperation = "queue.publish"
+	ProcessOperation = "queue.process"
+)
+
+// spanOrigin is the origin of spans.
+const spanOrigin = "auto.queue.kafka"
+
+// messagingSystem is the "messaging.system" data of spans.
+const messagingSystem = "kafka"
+
+// Headers reads and writes the headers of a Kafka message.
+type Headers interface {
+	// Get returns the value of the header with the given key, or the empty
+	// string.
+	Get(key string) string
+	// Set sets the value of the header with the given key, replacing existing
+	// values.
+	Set(key, value string)
+}
+
+// Message describes a consumed Kafka message.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Headers   Headers
+}
+
+// StartPublishSpan starts a "queue.publish" span for a message sent to topic,
+// as a child of the span in ctx, and sets the headers propagating the trace.
+// It returns nil and leaves headers unchanged if ctx has no span. Finish the
+// span once the message is acknowledged.
+func StartPublishSpan(ctx context.Context, topic string, headers Headers) *sentry.Span {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+	span := parent.StartChild(PublishOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.Description = topic
+	span.SetData("messaging.system", messagingSystem)
+	span.SetData("messaging.destination.name", topic)
+
+	headers.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+	if baggage := span.ToBaggage(); baggage != "" {
+		headers.Set(sentry.SentryBaggageHeader, baggage)
+	}
+	return span
+}
+
+// Handler processes consumed messages in transactions, and reports the panics
+// of message handlers to Sentry.
+type Handler struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// Options configure a Handler.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// in a message handler. Otherwise, the panic is returned as an error.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// New returns a new Handler.
+func New(options Options) *Handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &Handler{
+		repanic:         options.Repanic,
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+// Process calls process with a context holding a "queue.process" transaction
+// for msg, which continues the trace propagated in the message headers, and
+// a hub whose scope has the metadata of the message in its "kafka" context.
+// The error returned by process sets the status of the transaction.
+func (h *Handler) Process(ctx context.Context, msg Message, process func(ctx context.Context) error) (err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	hub.Scope().SetContext("kafka", sentry.Context{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+	})
+
+	var trace, baggage string
+	if msg.Headers != nil {
+		trace = msg.Headers.Get(sentry.SentryTraceHeader)
+		baggage = msg.Headers.Get(sentry.SentryBaggageHeader)
+	}
+	transaction := sentry.StartTransaction(ctx, msg.Topic,
+		sentry.WithOpName(ProcessOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(trace, baggage),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.Data = map[string]interface{}{
+		"messaging.system":           messagingSystem,
+		"messaging.destination.name": msg.Topic,
+		"messaging.kafka.partition":  msg.Partition,
+		"messaging.kafka.offset":     msg.Offset,
+	}
+	defer transaction.Finish()
+
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Status = sentry.SpanStatusInternalError
+			eventID := hub.RecoverWithContext(transaction.Context(), r)
+			if eventID != nil && h.waitForDelivery {
+				hub.Flush(h.timeout)
+			}
+			if h.repanic {
+				panic(r)
+			}
+			err = fmt.Errorf("sentrykafka: panic processing message: %v", r)
+		}
+	}()
+
+	err = process(transaction.Context())
+	if err != nil {
+		transaction.SetError(err)
+	} else {
+		transaction.Status = sentry.SpanStatusOK
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package sentrykafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrykafka "github.com/getsentry/sentry-go/kafka"
+	"github.com/google/go-cmp/cmp"
+)
+
+// record returns a BeforeSend callback adding the events to events and
+// dropping them.
+func record(events *[]*sentry.Event) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+type headers map[string]string
+
+func (h headers) Get(key string) string { return h[key] }
+func (h headers) Set(key, value string) { h[key] = value }
+
+func TestPublishAndProcess(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	h := headers{}
+	producer := sentry.StartTransaction(ctx, "producer")
+	if span := sentrykafka.StartPublishSpan(producer.Context(), "orders", h); span != nil {
+		span.Finish()
+	} else {
+		t.Fatal("publish span not started")
+	}
+	producer.Finish()
+	if h[sentry.SentryTraceHeader] == "" || h[sentry.SentryBaggageHeader] == "" {
+		t.Fatalf("trace headers not set: %v", h)
+	}
+
+	var processed bool
+	err = sentrykafka.New(sentrykafka.Options{}).Process(ctx, sentrykafka.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Headers:   h,
+	}, func(ctx context.Context) error {
+		processed = sentry.SpanFromContext(ctx) != nil
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !processed {
+		t.Error("process called without a span")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	publish, process := events[0], events[1]
+	if diff := cmp.Diff(sentrykafka.PublishOperation, publish.Spans[0].Op); diff != "" {
+		t.Errorf("publish span op mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(publish.Spans[0].TraceID.String(), process.Contexts["trace"]["trace_id"].(sentry.TraceID).String()); diff != "" {
+		t.Errorf("process transaction doesn't continue the trace (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(publish.Spans[0].SpanID.String(), process.Contexts["trace"]["parent_span_id"].(sentry.SpanID).String()); diff != "" {
+		t.Errorf("process transaction isn't a child of the publish span (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentrykafka.ProcessOperation, process.Contexts["trace"]["op"]); diff != "" {
+		t.Errorf("process transaction op mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("orders", process.Transaction); diff != "" {
+		t.Errorf("process transaction name mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProcessPanic(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	err = sentrykafka.New(sentrykafka.Options{}).Process(ctx, sentrykafka.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+	}, func(ctx context.Context) error {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("the panic wasn't returned as an error")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	event, transaction := events[0], events[1]
+	if diff := cmp.Diff("boom", event.Message); diff != "" {
+		t.Errorf("panic event mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.Context{"topic": "orders", "partition": int32(2), "offset": int64(42)}, event.Contexts["kafka"]); diff != "" {
+		t.Errorf("kafka context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transaction.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProcessError(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	want := errors.New("failed")
+	err = sentrykafka.New(sentrykafka.Options{}).Process(ctx, sentrykafka.Message{Topic: "orders"}, func(ctx context.Context) error {
+		return want
+	})
+	if err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/getsentry/sentry-go/klog
+
+go 1.21
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/go-logr/logr v1.4.4
+	github.com/google/go-cmp v0.6.0
+	k8s.io/klog/v2 v2.130.1
+)
+
+require (
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
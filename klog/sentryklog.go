@@ -0,0 +1,186 @@
+// Package sentryklog provides Sentry integration for k8s.io/klog/v2, the
+// logger of the Kubernetes client libraries.
+//
+// The libraries log errors, such as failing watches, which are otherwise
+// never seen in Sentry. Init routes the logs of klog to the current hub: the
+// errors are captured as events, and the other logs are added as breadcrumbs.
+// The logs are still written to stderr, in the format of klog:
+//
+//	sentryklog.Init(sentryklog.Options{})
+//	defer klog.ClearLogger()
+//
+// klog is the fork of github.com/golang/glog maintained by the Kubernetes
+// project. glog itself has no hook to route its logs elsewhere.
+package sentryklog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/textlogger"
+)
+
+// The identifier of the klog SDK.
+const sdkIdentifier = "sentry.go.klog"
+
+// loggerName is the logger of events and the category of breadcrumbs.
+const loggerName = "klog"
+
+// Options configure the log sink.
+type Options struct {
+	// Sink is the sink all logs are written to. Defaults to a sink writing to
+	// stderr in the format of klog.
+	Sink logr.LogSink
+	// PromotionRules, if not nil, decide which errors are captured as events,
+	// for example to capture an error once it occurs repeatedly. The logger
+	// of the logs is the name of the logr logger. Errors which are not
+	// promoted are added as breadcrumbs.
+	PromotionRules *sentry.PromotionRules
+	// Sampler, if not nil, samples the events, and drops the events repeating
+	// a recent event, such as the errors of a failing watch.
+	Sampler *sentry.LogSampler
+	// DisableBreadcrumbs disables the breadcrumbs of the logs other than
+	// errors.
+	DisableBreadcrumbs bool
+}
+
+// Init sets the logger of klog to a logr logger with the sink returned by
+// NewLogSink. Like klog.SetLogger, it must be called before logging starts,
+// usually during program initialization.
+func Init(options Options) {
+	klog.SetLogger(logr.New(NewLogSink(options)))
+}
+
+// logSink reports the logs of klog to Sentry.
+type logSink struct {
+	sink    logr.LogSink
+	options Options
+	name    string
+	values  []interface{}
+}
+
+// NewLogSink returns a logr.LogSink capturing the errors logged as events of
+// the current hub, adding the other logs as breadcrumbs, and writing all logs
+// to the Sink of options.
+func NewLogSink(options Options) logr.LogSink {
+	sink := options.Sink
+	if sink == nil {
+		sink = textlogger.NewLogger(textlogger.NewConfig()).GetSink()
+	}
+	return &logSink{sink: sink, options: options}
+}
+
+func (s *logSink) Init(info logr.RuntimeInfo) {
+	// Skip the frame of this sink.
+	info.CallDepth++
+	s.sink.Init(info)
+}
+
+// Enabled enables all levels the sink enables. klog checks its own verbosity
+// before logging.
+func (s *logSink) Enabled(level int) bool {
+	return s.sink.Enabled(level)
+}
+
+func (s *logSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.sink.Info(level, msg, keysAndValues...)
+	if !s.options.DisableBreadcrumbs {
+		s.addBreadcrumb(sentry.LevelInfo, nil, msg, keysAndValues)
+	}
+}
+
+func (s *logSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.sink.Error(err, msg, keysAndValues...)
+
+	if s.options.PromotionRules != nil && !s.options.PromotionRules.Promote(s.name, sentry.LevelError) {
+		s.addBreadcrumb(sentry.LevelError, err, msg, keysAndValues)
+		return
+	}
+
+	if s.options.Sampler != nil && !s.options.Sampler.Sample(s.name, msg) {
+		return
+	}
+
+	hub := sentry.CurrentHub()
+	client := hub.Client()
+	if client == nil {
+		return
+	}
+	client.SetSDKIdentifier(sdkIdentifier)
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Logger = loggerName
+	event.Message = msg
+	if err != nil {
+		event.SetException(err, client.Options().MaxErrorDepth)
+	}
+	if s.name != "" {
+		event.Tags["logger"] = s.name
+	}
+	event.Extra = s.data(keysAndValues)
+	hub.CaptureEvent(event)
+}
+
+func (s *logSink) addBreadcrumb(level sentry.Level, err error, msg string, keysAndValues []interface{}) {
+	data := s.data(keysAndValues)
+	if err != nil {
+		data["err"] = err.Error()
+	}
+	if s.name != "" {
+		data["logger"] = s.name
+	}
+	sentry.CurrentHub().AddBreadcrumb(&sentry.Breadcrumb{
+		Type:      "default",
+		Category:  loggerName,
+		Message:   msg,
+		Data:      data,
+		Level:     level,
+		Timestamp: time.Now(),
+	}, nil)
+}
+
+// data returns the key-value pairs of the sink and of a log.
+func (s *logSink) data(keysAndValues []interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, (len(s.values)+len(keysAndValues))/2)
+	for _, values := range [][]interface{}{s.values, keysAndValues} {
+		for i := 0; i+1 < len(values); i += 2 {
+			data[fmt.Sprint(values[i])] = logValue(values[i+1])
+		}
+	}
+	return data
+}
+
+func (s *logSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	c := *s
+	c.values = append(append([]interface{}{}, s.values...), keysAndValues...)
+	c.sink = s.sink.WithValues(keysAndValues...)
+	return &c
+}
+
+func (s *logSink) WithName(name string) logr.LogSink {
+	c := *s
+	if c.name != "" {
+		c.name += "." + name
+	} else {
+		c.name = name
+	}
+	c.sink = s.sink.WithName(name)
+	return &c
+}
+
+// logValue returns the value of a key-value pair logged with logr, as a
+// string unless it is a basic type.
+func logValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
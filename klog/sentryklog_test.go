@@ -0,0 +1,133 @@
+package sentryklog_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentryklog "github.com/getsentry/sentry-go/klog"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func initSentry(t *testing.T) *[]*sentry.Event {
+	var events []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentry.CurrentHub().Scope().Clear()
+	return &events
+}
+
+func TestInit(t *testing.T) {
+	events := initSentry(t)
+	var lines []string
+	sink := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{}).GetSink()
+	sentryklog.Init(sentryklog.Options{Sink: sink})
+	defer klog.ClearLogger()
+
+	klog.InfoS("watch started", "resource", "pods")
+	klog.ErrorS(errors.New("connection refused"), "watch failed", "resource", "pods")
+	klog.Errorf("failed to list %s", "nodes")
+
+	if len(lines) != 3 {
+		t.Errorf("got %d lines written to the sink, want 3", len(lines))
+	}
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2", len(*events))
+	}
+
+	event := (*events)[0]
+	if diff := cmp.Diff("watch failed", event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("connection refused", event.Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"resource": "pods"}, event.Extra); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+	wantBreadcrumbs := []*sentry.Breadcrumb{{
+		Type:     "default",
+		Category: "klog",
+		Message:  "watch started",
+		Data:     map[string]interface{}{"resource": "pods"},
+		Level:    sentry.LevelInfo,
+	}}
+	if diff := cmp.Diff(wantBreadcrumbs, event.Breadcrumbs, cmpIgnoreTimestamp); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+
+	event = (*events)[1]
+	if diff := cmp.Diff("failed to list nodes", event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelError, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLogSinkPromotionRules(t *testing.T) {
+	events := initSentry(t)
+	rules, err := sentry.ParsePromotionRules("logger=reflector AND count>1/min")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logr.New(sentryklog.NewLogSink(sentryklog.Options{
+		Sink:               funcr.New(func(prefix, args string) {}, funcr.Options{}).GetSink(),
+		PromotionRules:     rules,
+		DisableBreadcrumbs: true,
+	}))
+
+	logger.Info("ignored")
+	logger.WithName("cache").Error(nil, "cache miss")
+	for i := 0; i < 2; i++ {
+		logger.WithName("reflector").WithValues("attempt", i).Error(errors.New("watch failed"), "retrying")
+	}
+
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+	event := (*events)[0]
+	if diff := cmp.Diff("reflector", event.Tags["logger"]); diff != "" {
+		t.Errorf("logger mismatch (-want +got):\n%s", diff)
+	}
+	var messages []string
+	for _, b := range event.Breadcrumbs {
+		messages = append(messages, b.Message)
+	}
+	if diff := cmp.Diff([]string{"cache miss", "retrying"}, messages); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+var cmpIgnoreTimestamp = cmp.FilterPath(func(p cmp.Path) bool {
+	return p.Last().String() == ".Timestamp"
+}, cmp.Ignore())
+
+func TestLogSinkSampler(t *testing.T) {
+	events := initSentry(t)
+	logger := logr.New(sentryklog.NewLogSink(sentryklog.Options{
+		Sink:    funcr.New(func(prefix, args string) {}, funcr.Options{}).GetSink(),
+		Sampler: sentry.NewLogSampler(sentry.LogSamplerOptions{DedupeWindow: time.Minute}),
+	})).WithName("reflector")
+
+	for i := 0; i < 3; i++ {
+		logger.Error(errors.New("connection refused"), "watch failed", "attempt", i)
+	}
+
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+}
@@ -0,0 +1,181 @@
+// Package sentrylambda provides Sentry integration for AWS Lambda functions
+// built with github.com/aws/aws-lambda-go.
+//
+// Wrap the handler of the function:
+//
+//	func main() {
+//		sentry.Init(sentry.ClientOptions{})
+//		lambda.Start(sentrylambda.Wrap(handler, sentrylambda.Options{}))
+//	}
+//
+// Each invocation runs in a "function.aws.lambda" transaction, with a clone
+// of the current hub whose scope has the "cloud" and "function" contexts of
+// the invocation. Errors returned by the handler and panics are reported to
+// Sentry, and events are flushed before the invocation returns, as the
+// execution environment may be frozen afterwards.
+package sentrylambda
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/getsentry/sentry-go"
+)
+
+// The identifier of the AWS Lambda SDK.
+const sdkIdentifier = "sentry.go.lambda"
+
+// spanOperation is the operation of the transactions of invocations.
+const spanOperation = "function.aws.lambda"
+
+// spanOrigin is the origin of the transactions of invocations.
+const spanOrigin = "auto.function.aws_lambda"
+
+// flushMargin is the time left to the function to return after flushing.
+const flushMargin = 100 * time.Millisecond
+
+// coldStart is 1 until the first invocation of the execution environment.
+var coldStart int32 = 1
+
+// Options configure the wrapper.
+type Options struct {
+	// Timeout for the delivery of the events of an invocation, when it
+	// returns. Defaults to 2s, and is capped to the remaining time of the
+	// invocation.
+	Timeout time.Duration
+	// IgnoreErrors configures whether errors returned by the handler are
+	// reported to Sentry. Panics are always reported.
+	IgnoreErrors bool
+}
+
+type handler struct {
+	handler      lambda.Handler
+	timeout      time.Duration
+	ignoreErrors bool
+}
+
+// Wrap returns a lambda.Handler instrumenting h, which is any of the handler
+// types supported by lambda.Start.
+func Wrap(h interface{}, options Options) lambda.Handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	lh, ok := h.(lambda.Handler)
+	if !ok {
+		lh = lambda.NewHandler(h)
+	}
+	return &handler{
+		handler:      lh,
+		timeout:      timeout,
+		ignoreErrors: options.IgnoreErrors,
+	}
+}
+
+// Invoke implements lambda.Handler.
+func (h *handler) Invoke(ctx context.Context, payload []byte) (response []byte, err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	scope := hub.Scope()
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		scope.SetContext("cloud", sentry.Context{
+			"provider": "aws",
+			"region":   region,
+		})
+	}
+	function := functionContext(ctx)
+	scope.SetContext("function", function)
+	if requestID, ok := function["request_id"].(string); ok {
+		scope.SetTag("aws.request_id", requestID)
+	}
+
+	name := lambdacontext.FunctionName
+	if name == "" {
+		name = "lambda"
+	}
+	transaction := sentry.StartTransaction(ctx, name,
+		sentry.WithOpName(spanOperation),
+		sentry.WithTransactionSource(sentry.SourceComponent),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	defer func() {
+		if err != nil {
+			transaction.SetError(err)
+		} else if transaction.Status == sentry.SpanStatusUndefined {
+			transaction.Status = sentry.SpanStatusOK
+		}
+		transaction.Finish()
+		hub.Flush(h.flushTimeout(ctx))
+	}()
+
+	ctx = transaction.Context()
+	defer h.recoverWithSentry(ctx, hub, transaction)
+	response, err = h.handler.Invoke(ctx, payload)
+	if err != nil && !h.ignoreErrors {
+		hub.CaptureException(err)
+	}
+	return response, err
+}
+
+// recoverWithSentry reports panics of the handler and panics again, for the
+// runtime to report the failure of the invocation. The events of the
+// invocation are flushed once the transaction is finished.
+func (h *handler) recoverWithSentry(ctx context.Context, hub *sentry.Hub, transaction *sentry.Span) {
+	if r := recover(); r != nil {
+		hub.RecoverWithContext(ctx, r)
+		transaction.Status = sentry.SpanStatusInternalError
+		panic(r)
+	}
+}
+
+// flushTimeout returns the timeout of the delivery of events, capped to the
+// remaining time of the invocation.
+func (h *handler) flushTimeout(ctx context.Context) time.Duration {
+	timeout := h.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) - flushMargin; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// functionContext returns the "function" context of an invocation.
+func functionContext(ctx context.Context) sentry.Context {
+	function := sentry.Context{
+		"cold_start": atomic.SwapInt32(&coldStart, 0) == 1,
+	}
+	set := func(key, value string) {
+		if value != "" {
+			function[key] = value
+		}
+	}
+	set("name", lambdacontext.FunctionName)
+	set("version", lambdacontext.FunctionVersion)
+	set("log_group", lambdacontext.LogGroupName)
+	set("log_stream", lambdacontext.LogStreamName)
+	if lambdacontext.MemoryLimitInMB > 0 {
+		function["memory_limit_mb"] = lambdacontext.MemoryLimitInMB
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		set("request_id", lc.AwsRequestID)
+		set("invoked_function_arn", lc.InvokedFunctionArn)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		function["remaining_time_ms"] = time.Until(deadline).Milliseconds()
+	}
+	return function
+}
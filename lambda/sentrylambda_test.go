@@ -0,0 +1,118 @@
+package sentrylambda_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/getsentry/sentry-go"
+	sentrylambda "github.com/getsentry/sentry-go/lambda"
+	"github.com/google/go-cmp/cmp"
+)
+
+type request struct {
+	Name string `json:"name"`
+}
+
+func TestWrap(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := sentrylambda.Wrap(func(ctx context.Context, req request) (string, error) {
+		switch req.Name {
+		case "":
+			return "", errors.New("missing name")
+		case "panic":
+			panic("test")
+		}
+		return "hello " + req.Name, nil
+	}, sentrylambda.Options{})
+
+	invoke := func(requestID, payload string) ([]byte, error) {
+		ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+			AwsRequestID:       requestID,
+			InvokedFunctionArn: "arn:aws:lambda:eu-west-1:123456789012:function:greet",
+		})
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		return handler.Invoke(ctx, []byte(payload))
+	}
+
+	response, err := invoke("1", `{"name":"alice"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`"hello alice"`, string(response)); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := invoke("2", `{}`); err == nil {
+		t.Fatal("expected an error")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		_, _ = invoke("3", `{"name":"panic"}`)
+	}()
+
+	var statuses []sentry.SpanStatus
+	for _, transaction := range transactions {
+		statuses = append(statuses, transaction.Contexts["trace"]["status"].(sentry.SpanStatus))
+	}
+	if diff := cmp.Diff([]sentry.SpanStatus{
+		sentry.SpanStatusOK,
+		sentry.SpanStatusInternalError,
+		sentry.SpanStatusInternalError,
+	}, statuses); diff != "" {
+		t.Errorf("transaction statuses mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	errorEvent, panicEvent := events[0], events[1]
+	if diff := cmp.Diff("missing name", errorEvent.Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("2", errorEvent.Tags["aws.request_id"]); diff != "" {
+		t.Errorf("request ID tag mismatch (-want +got):\n%s", diff)
+	}
+	function := errorEvent.Contexts["function"]
+	if diff := cmp.Diff(false, function["cold_start"]); diff != "" {
+		t.Errorf("cold start mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("arn:aws:lambda:eu-west-1:123456789012:function:greet", function["invoked_function_arn"]); diff != "" {
+		t.Errorf("function ARN mismatch (-want +got):\n%s", diff)
+	}
+	if remaining, _ := function["remaining_time_ms"].(int64); remaining <= 0 || remaining > time.Minute.Milliseconds() {
+		t.Errorf("remaining time = %d ms, want within a minute", remaining)
+	}
+	if diff := cmp.Diff(sentry.Context{"provider": "aws", "region": "eu-west-1"}, errorEvent.Contexts["cloud"]); diff != "" {
+		t.Errorf("cloud context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, panicEvent.Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(true, transactions[0].Contexts["function"]["cold_start"]); diff != "" {
+		t.Errorf("cold start of first invocation mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,47 @@
+package sentry
+
+import "sync"
+
+var (
+	levelMappersMu sync.RWMutex
+	levelMappers   []func(error) (Level, bool)
+)
+
+// RegisterLevelMapper registers a function that determines the level of the
+// events captured for errors with CaptureException, which is LevelError
+// otherwise. This allows expected errors, such as validation errors, to be
+// reported with a lower level, or not at all.
+//
+// Mappers are called in the order they were registered, and the first one
+// returning true sets the level. Returning an empty level along with true
+// drops the event.
+//
+//	sentry.RegisterLevelMapper(func(err error) (sentry.Level, bool) {
+//		var validationErr *ValidationError
+//		switch {
+//		case errors.Is(err, context.Canceled):
+//			return "", true
+//		case errors.As(err, &validationErr):
+//			return sentry.LevelWarning, true
+//		}
+//		return "", false
+//	})
+func RegisterLevelMapper(mapper func(err error) (Level, bool)) {
+	levelMappersMu.Lock()
+	defer levelMappersMu.Unlock()
+	levelMappers = append(levelMappers, mapper)
+}
+
+// levelForError returns the level of events captured for err, as determined
+// by the registered level mappers, or level if none applies. It returns false
+// if the event must be dropped.
+func levelForError(err error, level Level) (Level, bool) {
+	levelMappersMu.RLock()
+	defer levelMappersMu.RUnlock()
+	for _, mapper := range levelMappers {
+		if l, ok := mapper(err); ok {
+			return l, l != ""
+		}
+	}
+	return level, true
+}
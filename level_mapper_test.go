@@ -0,0 +1,44 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestRegisterLevelMapper(t *testing.T) {
+	defer func(mappers []func(error) (Level, bool)) { levelMappers = mappers }(levelMappers)
+	levelMappers = nil
+
+	RegisterLevelMapper(func(err error) (Level, bool) {
+		if errors.Is(err, context.Canceled) {
+			return "", true
+		}
+		return "", false
+	})
+	RegisterLevelMapper(func(err error) (Level, bool) {
+		if errors.Is(err, io.EOF) {
+			return LevelInfo, true
+		}
+		return "", false
+	})
+	RegisterLevelMapper(func(err error) (Level, bool) {
+		return LevelDebug, errors.Is(err, io.EOF)
+	})
+
+	client, scope, transport := setupClientTest()
+
+	client.CaptureException(fmt.Errorf("reading body: %w", io.EOF), nil, scope)
+	assertEqual(t, transport.lastEvent.Level, LevelInfo)
+
+	client.CaptureException(errors.New("boom"), nil, scope)
+	assertEqual(t, transport.lastEvent.Level, LevelError)
+
+	eventID, err := client.TryCaptureException(fmt.Errorf("query: %w", context.Canceled), nil, scope)
+	if eventID != nil || !errors.Is(err, ErrEventDropped) {
+		t.Errorf("TryCaptureException() = %v, %v, want the event to be dropped", eventID, err)
+	}
+	assertEqual(t, len(transport.Events()), 2)
+}
@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logBreadcrumbCategory is the category of the breadcrumbs of log lines.
+const logBreadcrumbCategory = "log"
+
+// logDatePattern matches the date and time prefixes of the log package, as
+// set by the log.Ldate, log.Ltime and log.Lmicroseconds flags.
+var logDatePattern = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(\.\d+)? )?`)
+
+// logLevelNames matches the names of the levels of log lines.
+const logLevelNames = `(trace|debug|info|notice|warn|warning|error|err|fatal|panic|critical|crit)`
+
+// logLevelPattern matches the level prefixes of log lines, such as "ERROR:",
+// "[warn]" or "level=info". A level name must be delimited, for messages
+// starting with such a word, as in "Error connecting to db", to be kept.
+var logLevelPattern = regexp.MustCompile(`(?i)^(?:level=` + logLevelNames +
+	`|[\[(]` + logLevelNames + `[\])]:?|` + logLevelNames + `:)(?:\s+|$)`)
+
+var logLevels = map[string]Level{
+	"trace":    LevelDebug,
+	"debug":    LevelDebug,
+	"info":     LevelInfo,
+	"notice":   LevelInfo,
+	"warn":     LevelWarning,
+	"warning":  LevelWarning,
+	"error":    LevelError,
+	"err":      LevelError,
+	"fatal":    LevelFatal,
+	"panic":    LevelFatal,
+	"critical": LevelFatal,
+	"crit":     LevelFatal,
+}
+
+// BreadcrumbWriter is an io.Writer adding the lines written to it as
+// breadcrumbs, so that the output of loggers shows up in the events captured
+// afterwards. Create one with NewBreadcrumbWriter.
+type BreadcrumbWriter struct {
+	hub *Hub
+}
+
+// NewBreadcrumbWriter returns a BreadcrumbWriter adding breadcrumbs to hub, or
+// to the current hub if hub is nil. Set it as the output of the standard
+// logger, along with its current output:
+//
+//	log.SetOutput(io.MultiWriter(os.Stderr, sentry.NewBreadcrumbWriter(nil)))
+//
+// The level of the breadcrumbs is read from the level prefix of the lines, if
+// any, such as "ERROR:", "[warn]" or "level=info", after the date and time
+// prefixes of the log package, and is info otherwise. The lines are also added
+// to the LogBuffer of the scope of the hub, if any.
+func NewBreadcrumbWriter(hub *Hub) *BreadcrumbWriter {
+	return &BreadcrumbWriter{hub: hub}
+}
+
+// NewBreadcrumbLogger returns a log.Logger writing to a BreadcrumbWriter
+// adding breadcrumbs to hub, or to the current hub if hub is nil.
+func NewBreadcrumbLogger(hub *Hub) *log.Logger {
+	return log.New(NewBreadcrumbWriter(hub), "", 0)
+}
+
+// Write adds a breadcrumb for each non-empty line of p. It never fails.
+func (w *BreadcrumbWriter) Write(p []byte) (int, error) {
+	hub := w.hub
+	if hub == nil {
+		hub = CurrentHub()
+	}
+	buffer := hub.Scope().LogBuffer()
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		message := strings.TrimSpace(string(line))
+		if buffer != nil && message != "" {
+			buffer.Add(message)
+		}
+		message = strings.TrimSpace(logDatePattern.ReplaceAllString(message, ""))
+		if message == "" {
+			continue
+		}
+		level := LevelInfo
+		if m := logLevelPattern.FindStringSubmatch(message); m != nil {
+			for _, name := range m[1:] {
+				if name != "" {
+					level = logLevels[strings.ToLower(name)]
+					break
+				}
+			}
+			if rest := message[len(m[0]):]; rest != "" {
+				message = rest
+			}
+		}
+		hub.AddBreadcrumb(&Breadcrumb{
+			Type:      "default",
+			Category:  logBreadcrumbCategory,
+			Message:   message,
+			Level:     level,
+			Timestamp: time.Now(),
+		}, nil)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,50 @@
+package sentry
+
+import (
+	"log"
+	"testing"
+)
+
+func TestBreadcrumbWriter(t *testing.T) {
+	hub, _, scope := setupHubTest()
+	logger := log.New(NewBreadcrumbWriter(hub), "", log.LstdFlags|log.Lmicroseconds)
+
+	logger.Print("starting")
+	logger.Print("ERROR: connection refused")
+	logger.Print("[warn] retrying\n\nlevel=debug backoff=1s")
+	logger.Print("errors are not levels")
+	logger.Print("fatal:")
+	logger.Print("Error connecting to db")
+
+	want := []struct {
+		message string
+		level   Level
+	}{
+		{"starting", LevelInfo},
+		{"connection refused", LevelError},
+		{"retrying", LevelWarning},
+		{"backoff=1s", LevelDebug},
+		{"errors are not levels", LevelInfo},
+		{"fatal:", LevelFatal},
+		{"Error connecting to db", LevelInfo},
+	}
+	if len(scope.breadcrumbs) != len(want) {
+		t.Fatalf("got %d breadcrumbs, want %d", len(scope.breadcrumbs), len(want))
+	}
+	for i, w := range want {
+		b := scope.breadcrumbs[i]
+		if b.Message != w.message || b.Level != w.level || b.Category != "log" {
+			t.Errorf("breadcrumb %d = %q (%s, %s), want %q (%s, log)", i, b.Message, b.Level, b.Category, w.message, w.level)
+		}
+	}
+}
+
+func TestNewBreadcrumbLogger(t *testing.T) {
+	hub, _, scope := setupHubTest()
+	NewBreadcrumbLogger(hub).Printf("user %d logged in", 42)
+
+	if len(scope.breadcrumbs) != 1 {
+		t.Fatalf("got %d breadcrumbs, want 1", len(scope.breadcrumbs))
+	}
+	assertEqual(t, scope.breadcrumbs[0].Message, "user 42 logged in")
+}
@@ -0,0 +1,94 @@
+package sentry
+
+import (
+	"strings"
+	"sync"
+)
+
+// logBufferFilename is the filename of the attachment of the lines of a
+// LogBuffer.
+const logBufferFilename = "logs.txt"
+
+// LogBuffer retains the last lines logged, to attach them to the errors
+// captured with the scope it is set on, with Scope.SetLogBuffer. It is safe
+// for concurrent use.
+//
+// Clones of the scope, such as the scopes of cloned hubs, get their own
+// buffer of the same size, starting with the lines of the original buffer,
+// so that the lines logged by concurrent requests don't mix.
+//
+// Logging integrations, such as the slog handler, add the records they handle
+// to the buffer of the scope of their hub, if any.
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewLogBuffer returns a LogBuffer retaining the last size lines. size must be
+// positive.
+func NewLogBuffer(size int) *LogBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &LogBuffer{lines: make([]string, size)}
+}
+
+// Add adds line to the buffer, dropping the oldest line if the buffer is
+// full.
+func (b *LogBuffer) Add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines[b.next] = strings.TrimRight(line, "\n")
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Lines returns the lines of the buffer, from oldest to newest.
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]string(nil), b.lines[:b.next]...)
+	}
+	lines := make([]string, 0, len(b.lines))
+	lines = append(lines, b.lines[b.next:]...)
+	return append(lines, b.lines[:b.next]...)
+}
+
+// clone returns a new buffer of the same size with the same lines.
+func (b *LogBuffer) clone() *LogBuffer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &LogBuffer{
+		lines: append([]string(nil), b.lines...),
+		next:  b.next,
+		full:  b.full,
+	}
+}
+
+// attachment returns the lines of the buffer as a text attachment, or nil if
+// the buffer is empty.
+func (b *LogBuffer) attachment() *Attachment {
+	lines := b.Lines()
+	if len(lines) == 0 {
+		return nil
+	}
+	return &Attachment{
+		Filename:    logBufferFilename,
+		ContentType: "text/plain",
+		Payload:     []byte(strings.Join(lines, "\n") + "\n"),
+	}
+}
+
+// isErrorEvent reports whether event is an error, to which the lines of a
+// LogBuffer are attached.
+func isErrorEvent(event *Event) bool {
+	return event.Type == "" && (len(event.Exception) > 0 || event.Level == LevelError || event.Level == LevelFatal)
+}
@@ -0,0 +1,77 @@
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLogBuffer(t *testing.T) {
+	b := NewLogBuffer(3)
+	assertEqual(t, b.Lines(), []string(nil))
+	if b.attachment() != nil {
+		t.Error("empty buffer has an attachment")
+	}
+
+	b.Add("one\n")
+	b.Add("two")
+	assertEqual(t, b.Lines(), []string{"one", "two"})
+
+	for i := 3; i <= 7; i++ {
+		b.Add(fmt.Sprint(i))
+	}
+	assertEqual(t, b.Lines(), []string{"5", "6", "7"})
+	assertEqual(t, string(b.attachment().Payload), "5\n6\n7\n")
+}
+
+func TestScopeLogBuffer(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+	buffer := NewLogBuffer(10)
+	scope.SetLogBuffer(buffer)
+	NewBreadcrumbLogger(hub).Print("connecting")
+
+	hub.CaptureMessage("hello")
+	hub.CaptureException(errors.New("connection refused"))
+	hub.Clone().CaptureEvent(&Event{Level: LevelFatal})
+
+	events := transport.Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	assertEqual(t, len(events[0].attachments), 0)
+	for _, event := range events[1:] {
+		if len(event.attachments) != 1 {
+			t.Fatalf("got %d attachments, want 1", len(event.attachments))
+		}
+		attachment := event.attachments[0]
+		assertEqual(t, attachment.Filename, "logs.txt")
+		assertEqual(t, attachment.ContentType, "text/plain")
+		assertEqual(t, string(attachment.Payload), "connecting\n")
+	}
+}
+
+func TestScopeLogBufferClones(t *testing.T) {
+	hub, client, scope := setupHubTest()
+	transport := client.Transport.(*TransportMock)
+	scope.SetLogBuffer(NewLogBuffer(2))
+	scope.LogBuffer().Add("starting")
+
+	first, second := hub.Clone(), hub.Clone()
+	if first.Scope().LogBuffer() == second.Scope().LogBuffer() {
+		t.Fatal("cloned hubs share the log buffer")
+	}
+	first.Scope().LogBuffer().Add("first request")
+	second.Scope().LogBuffer().Add("second request")
+	second.Scope().LogBuffer().Add("second request done")
+	first.CaptureException(errors.New("first failed"))
+	second.CaptureException(errors.New("second failed"))
+
+	events := transport.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	assertEqual(t, string(events[0].attachments[0].Payload), "starting\nfirst request\n")
+	assertEqual(t, string(events[1].attachments[0].Payload), "second request\nsecond request done\n")
+	assertEqual(t, scope.LogBuffer().Lines(), []string{"starting"})
+}
@@ -0,0 +1,51 @@
+package sentry
+
+import "context"
+
+// Keys of the fields correlating logs with Sentry, set by the logging
+// integrations.
+const (
+	LogFieldTraceID = "trace_id"
+	LogFieldSpanID  = "span_id"
+	LogFieldEventID = "event_id"
+)
+
+// LogCorrelation identifies the trace and span in which a log is emitted, and
+// the last event captured before it, to navigate between logs and Sentry.
+type LogCorrelation struct {
+	// TraceID is the ID of the trace of the span of the context, if any.
+	TraceID string
+	// SpanID is the ID of the span of the context, if any.
+	SpanID string
+	// EventID is the ID of the last event captured with the hub of the
+	// context, or with the current hub, if any.
+	EventID string
+}
+
+// LogCorrelationFromContext returns the LogCorrelation of the logs emitted
+// with ctx.
+func LogCorrelationFromContext(ctx context.Context) LogCorrelation {
+	var c LogCorrelation
+	if span := SpanFromContext(ctx); span != nil {
+		c.TraceID = span.TraceID.String()
+		c.SpanID = span.SpanID.String()
+	}
+	c.EventID = string(hubFromContext(ctx).LastEventID())
+	return c
+}
+
+// Fields returns the non-empty identifiers of c, keyed by LogFieldTraceID,
+// LogFieldSpanID and LogFieldEventID.
+func (c LogCorrelation) Fields() map[string]string {
+	fields := make(map[string]string, 3)
+	if c.TraceID != "" {
+		fields[LogFieldTraceID] = c.TraceID
+	}
+	if c.SpanID != "" {
+		fields[LogFieldSpanID] = c.SpanID
+	}
+	if c.EventID != "" {
+		fields[LogFieldEventID] = c.EventID
+	}
+	return fields
+}
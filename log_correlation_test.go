@@ -0,0 +1,36 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLogCorrelationFromContext(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        &TransportMock{},
+	})
+	hub := GetHubFromContext(ctx)
+
+	assertEqual(t, LogCorrelationFromContext(ctx).Fields(), map[string]string{})
+
+	span := StartSpan(ctx, "op")
+	defer span.Finish()
+	eventID := hub.CaptureException(errors.New("boom"))
+
+	c := LogCorrelationFromContext(span.Context())
+	assertEqual(t, c, LogCorrelation{
+		TraceID: span.TraceID.String(),
+		SpanID:  span.SpanID.String(),
+		EventID: string(*eventID),
+	})
+	assertEqual(t, c.Fields(), map[string]string{
+		LogFieldTraceID: span.TraceID.String(),
+		LogFieldSpanID:  span.SpanID.String(),
+		LogFieldEventID: string(*eventID),
+	})
+
+	assertEqual(t, LogCorrelationFromContext(context.Background()).SpanID, "")
+}
@@ -0,0 +1,251 @@
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFieldLogger is the key of the field naming the logger of a log, matched
+// against the Logger of PromotionRules by the logging integrations.
+const LogFieldLogger = "logger"
+
+// levelRanks orders the levels, for the MinLevel of PromotionRules.
+var levelRanks = map[Level]int{
+	LevelDebug:   1,
+	LevelInfo:    2,
+	LevelWarning: 3,
+	LevelError:   4,
+	LevelFatal:   5,
+}
+
+// PromotionRule describes the logs promoted to events.
+type PromotionRule struct {
+	// Logger is the name of the logger of the logs, or empty to match the
+	// logs of all loggers.
+	Logger string
+	// MinLevel is the minimum level of the logs, or empty to match the logs of
+	// all levels.
+	MinLevel Level
+	// Count is the number of matching logs over Window above which a log is
+	// promoted. The count starts over after each promotion, so that a burst of
+	// logs is promoted once. Zero promotes every matching log.
+	Count int
+	// Window is the duration over which matching logs are counted. Defaults
+	// to a minute.
+	Window time.Duration
+}
+
+// String returns the rule in the syntax of ParsePromotionRule.
+func (rule PromotionRule) String() string {
+	var clauses []string
+	if rule.Logger != "" {
+		clauses = append(clauses, "logger="+rule.Logger)
+	}
+	if rule.MinLevel != "" {
+		clauses = append(clauses, "level>="+string(rule.MinLevel))
+	}
+	if rule.Count > 0 {
+		clauses = append(clauses, fmt.Sprintf("count>%d/%s", rule.Count, formatWindow(rule.window())))
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// formatWindow formats window in the syntax of ParsePromotionRule, rounded to
+// the second.
+func formatWindow(window time.Duration) string {
+	n, unit := int64(window/time.Second), "s"
+	switch {
+	case window%time.Hour == 0:
+		n, unit = int64(window/time.Hour), "h"
+	case window%time.Minute == 0:
+		n, unit = int64(window/time.Minute), "min"
+	}
+	if n == 1 {
+		return unit
+	}
+	return strconv.FormatInt(n, 10) + unit
+}
+
+func (rule PromotionRule) window() time.Duration {
+	if rule.Window <= 0 {
+		return time.Minute
+	}
+	return rule.Window
+}
+
+func (rule PromotionRule) matches(logger string, level Level) bool {
+	if rule.Logger != "" && rule.Logger != logger {
+		return false
+	}
+	if rule.MinLevel != "" && levelRanks[level] < levelRanks[rule.MinLevel] {
+		return false
+	}
+	return true
+}
+
+var (
+	promotionAndPattern    = regexp.MustCompile(`(?i)\s+AND\s+`)
+	promotionClausePattern = regexp.MustCompile(`^(logger|level|count)\s*(>=|>|=)\s*(\S+)$`)
+	promotionRatePattern   = regexp.MustCompile(`^(\d+)/(\d*)([a-z]+)$`)
+)
+
+var promotionLevels = map[string]Level{
+	"debug":   LevelDebug,
+	"info":    LevelInfo,
+	"warn":    LevelWarning,
+	"warning": LevelWarning,
+	"error":   LevelError,
+	"fatal":   LevelFatal,
+}
+
+var promotionUnits = map[string]time.Duration{
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+}
+
+// ParsePromotionRule parses a rule made of clauses joined with AND, such as
+// "logger=payments AND level>=error AND count>10/min". The clauses are:
+//
+//   - logger=NAME, setting Logger.
+//   - level>=LEVEL or level>LEVEL, setting MinLevel, where LEVEL is one of
+//     debug, info, warn, warning, error and fatal.
+//   - count>N/UNIT, setting Count to N and Window to UNIT, one of s, sec,
+//     second, m, min, minute, h and hour, optionally preceded by a number of
+//     units, as in count>100/5min.
+func ParsePromotionRule(s string) (PromotionRule, error) {
+	var rule PromotionRule
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return rule, errors.New("sentry: empty promotion rule")
+	}
+	for _, clause := range promotionAndPattern.Split(s, -1) {
+		m := promotionClausePattern.FindStringSubmatch(strings.TrimSpace(clause))
+		if m == nil {
+			return rule, fmt.Errorf("sentry: invalid promotion rule clause %q", clause)
+		}
+		field, op, value := m[1], m[2], m[3]
+		switch {
+		case field == "logger" && op == "=":
+			rule.Logger = value
+		case field == "level" && op != "=":
+			level, ok := promotionLevels[strings.ToLower(value)]
+			if !ok {
+				return rule, fmt.Errorf("sentry: invalid level %q in promotion rule", value)
+			}
+			if op == ">" {
+				if level == LevelFatal {
+					return rule, fmt.Errorf("sentry: no level above %q in promotion rule", value)
+				}
+				level = levelAbove(level)
+			}
+			rule.MinLevel = level
+		case field == "count" && op == ">":
+			r := promotionRatePattern.FindStringSubmatch(strings.ToLower(value))
+			if r == nil {
+				return rule, fmt.Errorf("sentry: invalid count %q in promotion rule", value)
+			}
+			unit, ok := promotionUnits[r[3]]
+			if !ok {
+				return rule, fmt.Errorf("sentry: invalid unit %q in promotion rule", r[3])
+			}
+			rule.Count, _ = strconv.Atoi(r[1])
+			rule.Window = unit
+			if r[2] != "" {
+				n, _ := strconv.Atoi(r[2])
+				rule.Window = time.Duration(n) * unit
+			}
+			if rule.Window <= 0 {
+				return rule, fmt.Errorf("sentry: invalid count %q in promotion rule", value)
+			}
+		default:
+			return rule, fmt.Errorf("sentry: unsupported operator %q for %s in promotion rule", op, field)
+		}
+	}
+	return rule, nil
+}
+
+func levelAbove(level Level) Level {
+	for l, rank := range levelRanks {
+		if rank == levelRanks[level]+1 {
+			return l
+		}
+	}
+	return level
+}
+
+// PromotionRules decide which logs the logging integrations capture as
+// events, so that this can be tuned centrally rather than at every call site.
+// A log is promoted if any of the rules promotes it. It is safe for concurrent
+// use.
+type PromotionRules struct {
+	rules []PromotionRule
+	now   func() time.Time
+
+	mu     sync.Mutex
+	counts [][]time.Time
+}
+
+// NewPromotionRules returns PromotionRules made of rules.
+func NewPromotionRules(rules ...PromotionRule) *PromotionRules {
+	return &PromotionRules{
+		rules:  rules,
+		now:    time.Now,
+		counts: make([][]time.Time, len(rules)),
+	}
+}
+
+// ParsePromotionRules returns PromotionRules made of the rules parsed with
+// ParsePromotionRule.
+func ParsePromotionRules(rules ...string) (*PromotionRules, error) {
+	parsed := make([]PromotionRule, 0, len(rules))
+	for _, s := range rules {
+		rule, err := ParsePromotionRule(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, rule)
+	}
+	return NewPromotionRules(parsed...), nil
+}
+
+// Promote counts a log of logger at level, and reports whether it must be
+// captured as an event.
+func (r *PromotionRules) Promote(logger string, level Level) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	promote := false
+	now := r.now()
+	for i, rule := range r.rules {
+		if !rule.matches(logger, level) {
+			continue
+		}
+		if rule.Count == 0 {
+			promote = true
+			continue
+		}
+		times := r.counts[i]
+		start := now.Add(-rule.window())
+		for len(times) > 0 && !times[0].After(start) {
+			times = times[1:]
+		}
+		times = append(times, now)
+		if len(times) > rule.Count {
+			promote = true
+			times = nil
+		}
+		r.counts[i] = times
+	}
+	return promote
+}
@@ -0,0 +1,72 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePromotionRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PromotionRule
+	}{
+		{"logger=payments AND level>=error AND count>10/min", PromotionRule{Logger: "payments", MinLevel: LevelError, Count: 10, Window: time.Minute}},
+		{"level>warn and count>100/5s", PromotionRule{MinLevel: LevelError, Count: 100, Window: 5 * time.Second}},
+		{"logger = db", PromotionRule{Logger: "db"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePromotionRule(tt.in)
+		if err != nil {
+			t.Errorf("ParsePromotionRule(%q): %v", tt.in, err)
+			continue
+		}
+		assertEqual(t, got, tt.want)
+	}
+
+	for _, in := range []string{"", "logger>payments", "level>=loud", "level>fatal", "count>10", "count>10/fortnight", "count>10/0s", "user=alice"} {
+		if _, err := ParsePromotionRule(in); err == nil {
+			t.Errorf("ParsePromotionRule(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestPromotionRuleString(t *testing.T) {
+	for _, s := range []string{
+		"logger=payments AND level>=error AND count>10/min",
+		"level>=warning AND count>100/5s",
+		"count>1/2h",
+	} {
+		rule, err := ParsePromotionRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, rule.String(), s)
+	}
+	assertEqual(t, PromotionRule{Count: 1}.String(), "count>1/min")
+}
+
+func TestPromotionRules(t *testing.T) {
+	rules, err := ParsePromotionRules(
+		"logger=payments AND level>=error AND count>2/min",
+		"level>=fatal",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	rules.now = func() time.Time { return now }
+
+	assertEqual(t, rules.Promote("db", LevelFatal), true)
+	assertEqual(t, rules.Promote("db", LevelError), false)
+
+	var got []bool
+	for i := 0; i < 7; i++ {
+		got = append(got, rules.Promote("payments", LevelError))
+		now = now.Add(10 * time.Second)
+	}
+	assertEqual(t, got, []bool{false, false, true, false, false, true, false})
+	assertEqual(t, rules.Promote("payments", LevelWarning), false)
+
+	now = now.Add(2 * time.Minute)
+	assertEqual(t, rules.Promote("payments", LevelError), false)
+}
@@ -0,0 +1,90 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// LogSamplerOptions configure a LogSampler.
+type LogSamplerOptions struct {
+	// SampleRate is the rate of the events of the loggers without a rate in
+	// SampleRates, between 0.0 and 1.0. Defaults to 1.0.
+	SampleRate float64
+	// SampleRates are the rates of the events of loggers, by name.
+	SampleRates map[string]float64
+	// DedupeWindow is the time during which the events repeating the message
+	// of an event of the same logger are dropped, starting from the first
+	// one. Zero disables deduplication.
+	DedupeWindow time.Duration
+}
+
+// LogSampler samples the events the logging integrations capture from logs,
+// with a rate per logger, and drops the events repeating a recent event, so
+// that an error logged in a loop doesn't create thousands of identical
+// events. It is safe for concurrent use.
+type LogSampler struct {
+	options LogSamplerOptions
+	now     func() time.Time
+	random  func() float64
+
+	mu        sync.Mutex
+	seen      map[logSamplerKey]time.Time
+	lastPrune time.Time
+}
+
+// logSamplerKey identifies the events considered the same by a LogSampler.
+type logSamplerKey struct {
+	logger, message string
+}
+
+// NewLogSampler returns a new LogSampler.
+func NewLogSampler(options LogSamplerOptions) *LogSampler {
+	if options.SampleRate == 0 {
+		options.SampleRate = 1.0
+	}
+	return &LogSampler{
+		options: options,
+		now:     time.Now,
+		random:  rng.Float64,
+		seen:    make(map[logSamplerKey]time.Time),
+	}
+}
+
+// Sample reports whether the event of a log of logger with message must be
+// captured.
+func (s *LogSampler) Sample(logger, message string) bool {
+	rate, ok := s.options.SampleRates[logger]
+	if !ok {
+		rate = s.options.SampleRate
+	}
+	if rate < 1.0 && s.random() >= rate {
+		debugf(LevelDebug, "Log event of logger %q dropped due to its sample rate %f.", logger, rate)
+		return false
+	}
+
+	window := s.options.DedupeWindow
+	if window <= 0 {
+		return true
+	}
+	key := logSamplerKey{logger, message}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPrune) > window {
+		for k, first := range s.seen {
+			if now.Sub(first) > window {
+				delete(s.seen, k)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	if first, ok := s.seen[key]; ok && now.Sub(first) <= window {
+		debugf(LevelWarning, "Log event of logger %q dropped as a duplicate of an event captured at %s.", logger, first.Format(time.RFC3339))
+		return false
+	}
+	s.seen[key] = now
+	return true
+}
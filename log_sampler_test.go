@@ -0,0 +1,39 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogSamplerSampleRates(t *testing.T) {
+	s := NewLogSampler(LogSamplerOptions{
+		SampleRates: map[string]float64{"noisy": 0.25, "muted": 0},
+	})
+	random := 0.5
+	s.random = func() float64 { return random }
+
+	assertEqual(t, s.Sample("payments", "charge failed"), true)
+	assertEqual(t, s.Sample("noisy", "cache miss"), false)
+	assertEqual(t, s.Sample("muted", "cache miss"), false)
+	random = 0.1
+	assertEqual(t, s.Sample("noisy", "cache miss"), true)
+	assertEqual(t, s.Sample("muted", "cache miss"), false)
+}
+
+func TestLogSamplerDedupeWindow(t *testing.T) {
+	s := NewLogSampler(LogSamplerOptions{DedupeWindow: time.Minute})
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	assertEqual(t, s.Sample("payments", "charge failed"), true)
+	assertEqual(t, s.Sample("payments", "charge failed"), false)
+	assertEqual(t, s.Sample("billing", "charge failed"), true)
+	assertEqual(t, s.Sample("payments", "refund failed"), true)
+
+	now = now.Add(30 * time.Second)
+	assertEqual(t, s.Sample("payments", "charge failed"), false)
+
+	now = now.Add(time.Minute)
+	assertEqual(t, s.Sample("payments", "charge failed"), true)
+	assertEqual(t, len(s.seen), 1)
+}
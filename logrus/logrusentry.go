@@ -40,10 +40,16 @@ const (
 // It is not safe to configure the hook while logging is happening. Please
 // perform all configuration before using it.
 type Hook struct {
-	hub      *sentry.Hub
-	fallback FallbackFunc
-	keys     map[string]string
-	levels   []logrus.Level
+	hub              *sentry.Hub
+	fallback         FallbackFunc
+	keys             map[string]string
+	levels           []logrus.Level
+	breadcrumbLevels []logrus.Level
+	levelMapping     map[logrus.Level]sentry.Level
+	allowedFields    map[string]bool
+	deniedFields     map[string]bool
+	promotionRules   *sentry.PromotionRules
+	sampler          *sentry.LogSampler
 }
 
 var _ logrus.Hook = &Hook{}
@@ -107,6 +113,87 @@ func (h *Hook) SetKey(oldKey, newKey string) {
 	h.keys[oldKey] = newKey
 }
 
+// SetLevelMapping sets the Sentry level of the events and breadcrumbs of the
+// entries logged at level, in place of the default mapping.
+func (h *Hook) SetLevelMapping(level logrus.Level, sentryLevel sentry.Level) {
+	if h.levelMapping == nil {
+		h.levelMapping = make(map[logrus.Level]sentry.Level)
+	}
+	h.levelMapping[level] = sentryLevel
+}
+
+func (h *Hook) sentryLevel(level logrus.Level) sentry.Level {
+	if l, ok := h.levelMapping[level]; ok {
+		return l
+	}
+	return levelMap[level]
+}
+
+// SetAllowedFields restricts the fields sent to Sentry, as extra data of
+// events and data of breadcrumbs, to the fields with the given keys. Calling
+// it with no keys sends all fields, which is the default.
+func (h *Hook) SetAllowedFields(keys ...string) {
+	h.allowedFields = keySet(keys)
+}
+
+// SetDeniedFields prevents the fields with the given keys from being sent to
+// Sentry, as extra data of events and data of breadcrumbs.
+func (h *Hook) SetDeniedFields(keys ...string) {
+	h.deniedFields = keySet(keys)
+}
+
+func keySet(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// filterFields deletes the fields that must not be sent to Sentry.
+func (h *Hook) filterFields(fields map[string]interface{}) {
+	for key := range fields {
+		if h.deniedFields[key] || (h.allowedFields != nil && !h.allowedFields[key]) {
+			delete(fields, key)
+		}
+	}
+}
+
+// SetBreadcrumbLevels sets the levels of the entries added as breadcrumbs to
+// the scope of the hook, rather than sent as events, so that they show up in
+// the events sent afterwards. Breadcrumb levels take precedence over the
+// levels passed to New.
+func (h *Hook) SetBreadcrumbLevels(levels ...logrus.Level) {
+	h.breadcrumbLevels = levels
+}
+
+// SetPromotionRules sets the rules deciding which entries are sent as events,
+// instead of the levels passed to New. The logger of entries is the value of
+// their sentry.LogFieldLogger field. Entries which are not promoted are added
+// as breadcrumbs if their level is one of the breadcrumb levels.
+func (h *Hook) SetPromotionRules(rules *sentry.PromotionRules) {
+	h.promotionRules = rules
+}
+
+// SetSampler sets the sampler of the events, dropping the events repeating a
+// recent event. The logger of entries is the value of their
+// sentry.LogFieldLogger field.
+func (h *Hook) SetSampler(sampler *sentry.LogSampler) {
+	h.sampler = sampler
+}
+
+// RegisterExitHandler registers a logrus exit handler flushing the events of
+// the hook, waiting for at most timeout, so that the events of entries logged
+// with Fatal are sent before the program exits.
+func (h *Hook) RegisterExitHandler(timeout time.Duration) {
+	logrus.RegisterExitHandler(func() {
+		h.Flush(timeout)
+	})
+}
+
 func (h *Hook) key(key string) string {
 	if val := h.keys[key]; val != "" {
 		return val
@@ -115,13 +202,56 @@ func (h *Hook) key(key string) string {
 }
 
 // Levels returns the list of logging levels that will be sent to
-// Sentry.
+// Sentry, as events or breadcrumbs.
 func (h *Hook) Levels() []logrus.Level {
-	return h.levels
+	if h.promotionRules != nil {
+		return logrus.AllLevels
+	}
+	if len(h.breadcrumbLevels) == 0 {
+		return h.levels
+	}
+	levels := append([]logrus.Level{}, h.breadcrumbLevels...)
+	for _, level := range h.levels {
+		if !h.isBreadcrumbLevel(level) {
+			levels = append(levels, level)
+		}
+	}
+	return levels
 }
 
-// Fire sends entry to Sentry.
+func (h *Hook) isBreadcrumbLevel(level logrus.Level) bool {
+	for _, l := range h.breadcrumbLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// isEvent reports whether entry is sent as an event.
+func (h *Hook) isEvent(entry *logrus.Entry) bool {
+	if h.promotionRules == nil {
+		return !h.isBreadcrumbLevel(entry.Level)
+	}
+	logger, _ := entry.Data[sentry.LogFieldLogger].(string)
+	return h.promotionRules.Promote(logger, h.sentryLevel(entry.Level))
+}
+
+// Fire sends entry to Sentry, or adds it as a breadcrumb if its level is one
+// of the breadcrumb levels.
 func (h *Hook) Fire(entry *logrus.Entry) error {
+	if !h.isEvent(entry) {
+		if h.isBreadcrumbLevel(entry.Level) {
+			h.hub.AddBreadcrumb(h.entryToBreadcrumb(entry), nil)
+		}
+		return nil
+	}
+	if h.sampler != nil {
+		logger, _ := entry.Data[sentry.LogFieldLogger].(string)
+		if !h.sampler.Sample(logger, entry.Message) {
+			return nil
+		}
+	}
 	event := h.entryToEvent(entry)
 	if id := h.hub.CaptureEvent(event); id == nil {
 		if h.fallback != nil {
@@ -148,7 +278,7 @@ func (h *Hook) entryToEvent(l *logrus.Entry) *sentry.Event {
 		data[k] = v
 	}
 	s := &sentry.Event{
-		Level:     levelMap[l.Level],
+		Level:     h.sentryLevel(l.Level),
 		Extra:     data,
 		Message:   l.Message,
 		Timestamp: l.Time,
@@ -184,9 +314,36 @@ func (h *Hook) entryToEvent(l *logrus.Entry) *sentry.Event {
 	}
 	delete(s.Extra, FieldGoVersion)
 	delete(s.Extra, FieldMaxProcs)
+	h.filterFields(s.Extra)
 	return s
 }
 
+func (h *Hook) entryToBreadcrumb(l *logrus.Entry) *sentry.Breadcrumb {
+	data := make(map[string]interface{}, len(l.Data))
+	for k, v := range l.Data {
+		switch v := v.(type) {
+		case error:
+			data[k] = v.Error()
+		case *http.Request:
+			// Requests are set on the scope by integrations, and can't be
+			// serialized.
+		default:
+			data[k] = v
+		}
+	}
+	delete(data, FieldGoVersion)
+	delete(data, FieldMaxProcs)
+	h.filterFields(data)
+	return &sentry.Breadcrumb{
+		Type:      "default",
+		Category:  "logrus",
+		Message:   l.Message,
+		Data:      data,
+		Level:     h.sentryLevel(l.Level),
+		Timestamp: l.Time,
+	}
+}
+
 func (h *Hook) exceptions(err error) []sentry.Exception {
 	if !h.hub.Client().Options().AttachStacktrace {
 		return []sentry.Exception{{
@@ -221,6 +378,33 @@ func (h *Hook) exceptions(err error) []sentry.Exception {
 	return excs
 }
 
+// CorrelationHook is a logrus hook adding the trace_id, span_id and event_id
+// fields of the context of entries, set with WithContext, as returned by
+// sentry.LogCorrelationFromContext, so that logs can be matched with the
+// traces and events in Sentry. Add it before the other hooks for them to see
+// the fields:
+//
+//	logger.AddHook(sentrylogrus.CorrelationHook{})
+type CorrelationHook struct{}
+
+var _ logrus.Hook = CorrelationHook{}
+
+// Levels returns all levels.
+func (CorrelationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds the correlation fields to entry.
+func (CorrelationHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	for key, value := range sentry.LogCorrelationFromContext(entry.Context).Fields() {
+		entry.Data[key] = value
+	}
+	return nil
+}
+
 // Flush waits until the underlying Sentry transport sends any buffered events,
 // blocking for at most the given timeout. It returns false if the timeout was
 // reached, in which case some events may not have been sent.
@@ -1,8 +1,12 @@
 package sentrylogrus
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -250,7 +254,7 @@ func Test_entryToEvent(t *testing.T) {
 			got := h.entryToEvent(tt.entry)
 			opts := cmp.Options{
 				cmpopts.IgnoreFields(sentry.Event{},
-					"sdkMetaData", "attachments",
+					"sdkMetaData", "attachments", "meta", "metricsSummary",
 				),
 			}
 			if d := cmp.Diff(tt.want, got, opts); d != "" {
@@ -337,3 +341,177 @@ func Test_exceptions(t *testing.T) {
 		})
 	}
 }
+
+func TestHookOptions(t *testing.T) {
+	t.Parallel()
+
+	var events []*sentry.Event
+	hook, err := New([]logrus.Level{logrus.ErrorLevel, logrus.WarnLevel}, sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.SetLevelMapping(logrus.ErrorLevel, sentry.LevelWarning)
+	hook.SetDeniedFields("password")
+	hook.SetBreadcrumbLevels(logrus.InfoLevel, logrus.WarnLevel)
+
+	wantLevels := []logrus.Level{logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel}
+	if diff := cmp.Diff(wantLevels, hook.Levels()); diff != "" {
+		t.Errorf("levels mismatch (-want +got):\n%s", diff)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+	logger.WithField("password", "hunter2").Info("logging in")
+	logger.WithFields(logrus.Fields{"user": "alice", "password": "hunter2"}).Error("login failed")
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff(sentry.LevelWarning, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"user": "alice"}, event.Extra); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+	wantBreadcrumbs := []*sentry.Breadcrumb{{
+		Type:     "default",
+		Category: "logrus",
+		Message:  "logging in",
+		Data:     map[string]interface{}{},
+		Level:    sentry.LevelInfo,
+	}}
+	if diff := cmp.Diff(wantBreadcrumbs, event.Breadcrumbs, cmpopts.IgnoreFields(sentry.Breadcrumb{}, "Timestamp")); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+
+	hook.SetDeniedFields()
+	hook.SetAllowedFields("user")
+	extra := hook.entryToEvent(&logrus.Entry{Data: logrus.Fields{"user": "alice", "request_id": "r1"}}).Extra
+	if diff := cmp.Diff(map[string]interface{}{"user": "alice"}, extra); diff != "" {
+		t.Errorf("allowed fields mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCorrelationHook(t *testing.T) {
+	t.Parallel()
+
+	client, err := sentry.NewClient(sentry.ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+	logger.AddHook(CorrelationHook{})
+
+	logger.Info("no context")
+	transaction := sentry.StartTransaction(ctx, "charge")
+	eventID := hub.CaptureException(errors.New("card declined"))
+	logger.WithContext(transaction.Context()).Info("charging")
+	transaction.Finish()
+
+	var got []map[string]interface{}
+	d := json.NewDecoder(&out)
+	for d.More() {
+		var line map[string]interface{}
+		if err := d.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, line)
+	}
+	want := []map[string]interface{}{{
+		"level": "info",
+		"msg":   "no context",
+	}, {
+		"level":    "info",
+		"msg":      "charging",
+		"trace_id": transaction.TraceID.String(),
+		"span_id":  transaction.SpanID.String(),
+		"event_id": string(*eventID),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("logs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHookPromotionRules(t *testing.T) {
+	t.Parallel()
+
+	var events []*sentry.Event
+	hook, err := New(nil, sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := sentry.ParsePromotionRules("logger=payments AND level>=error AND count>1/min")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.SetPromotionRules(rules)
+	hook.SetBreadcrumbLevels(logrus.ErrorLevel)
+
+	if diff := cmp.Diff(logrus.AllLevels, hook.Levels()); diff != "" {
+		t.Errorf("levels mismatch (-want +got):\n%s", diff)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+	logger.WithField("logger", "cache").Error("cache miss")
+	for i := 0; i < 3; i++ {
+		logger.WithFields(logrus.Fields{"logger": "payments", "attempt": i}).Error("charge failed")
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(1, events[0].Extra["attempt"]); diff != "" {
+		t.Errorf("attempt mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(2, len(events[0].Breadcrumbs)); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHookSampler(t *testing.T) {
+	t.Parallel()
+
+	var events []*sentry.Event
+	hook, err := New([]logrus.Level{logrus.ErrorLevel}, sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.SetSampler(sentry.NewLogSampler(sentry.LogSamplerOptions{DedupeWindow: time.Minute}))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+	for i := 0; i < 3; i++ {
+		logger.WithFields(logrus.Fields{"logger": "payments", "attempt": i}).Error("charge failed")
+	}
+	logger.WithField("logger", "billing").Error("charge failed")
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+}
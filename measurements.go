@@ -0,0 +1,54 @@
+package sentry
+
+// MeasurementUnit is the unit of a measurement.
+//
+// See https://develop.sentry.dev/sdk/event-payloads/measurements/.
+type MeasurementUnit string
+
+// Measurement units. Custom units are also supported.
+const (
+	MeasurementUnitNone MeasurementUnit = "none"
+	// Durations.
+	MeasurementUnitNanosecond  MeasurementUnit = "nanosecond"
+	MeasurementUnitMicrosecond MeasurementUnit = "microsecond"
+	MeasurementUnitMillisecond MeasurementUnit = "millisecond"
+	MeasurementUnitSecond      MeasurementUnit = "second"
+	MeasurementUnitMinute      MeasurementUnit = "minute"
+	MeasurementUnitHour        MeasurementUnit = "hour"
+	// Sizes.
+	MeasurementUnitByte     MeasurementUnit = "byte"
+	MeasurementUnitKilobyte MeasurementUnit = "kilobyte"
+	MeasurementUnitMegabyte MeasurementUnit = "megabyte"
+	MeasurementUnitGigabyte MeasurementUnit = "gigabyte"
+	// Fractions.
+	MeasurementUnitRatio   MeasurementUnit = "ratio"
+	MeasurementUnitPercent MeasurementUnit = "percent"
+)
+
+// Measurement is a numeric value attached to a transaction, shown in Sentry
+// performance views.
+type Measurement struct {
+	Value float64         `json:"value"`
+	Unit  MeasurementUnit `json:"unit,omitempty"`
+}
+
+// SetMeasurement sets a measurement on the transaction of the span, replacing
+// the measurement of the same name, if any. Measurements are numeric domain
+// values, such as the number of rows processed or a cache hit ratio, which can
+// be charted and alerted on in Sentry:
+//
+//	transaction.SetMeasurement("rows_processed", float64(n), sentry.MeasurementUnitNone)
+func (s *Span) SetMeasurement(name string, value float64, unit MeasurementUnit) {
+	transaction := s.GetTransaction()
+	if transaction == nil {
+		transaction = s
+	}
+
+	transaction.mu.Lock()
+	defer transaction.mu.Unlock()
+
+	if transaction.measurements == nil {
+		transaction.measurements = make(map[string]Measurement)
+	}
+	transaction.measurements[name] = Measurement{Value: value, Unit: unit}
+}
@@ -0,0 +1,573 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statsdType is the type of an envelope item of metrics.
+const statsdType = "statsd"
+
+// metricMetaType is the type of an envelope item of metadata of metrics,
+// like their code locations.
+const metricMetaType = "metric_meta"
+
+const (
+	// metricsBucketInterval is the time span of the buckets metrics are
+	// aggregated in.
+	metricsBucketInterval = 10 * time.Second
+	// metricsFlushInterval is the interval at which the buckets of past time
+	// spans are sent.
+	metricsFlushInterval = 5 * time.Second
+	// defaultMetricUnit is the unit of metrics emitted without a unit.
+	defaultMetricUnit = "none"
+	// defaultMaxMetricTagValues is the default maximum number of distinct
+	// values of each tag of a metric.
+	defaultMaxMetricTagValues = 1000
+)
+
+// MetricType is the type of a metric, which defines how its values are
+// aggregated.
+type MetricType string
+
+// Metric types.
+//
+// See https://develop.sentry.dev/sdk/metrics/#metric-types.
+const (
+	// MetricTypeCounter is the type of metrics whose values are summed.
+	MetricTypeCounter MetricType = "c"
+	// MetricTypeGauge is the type of metrics whose last, minimum, maximum,
+	// sum and count of values are kept.
+	MetricTypeGauge MetricType = "g"
+	// MetricTypeDistribution is the type of metrics whose values are all
+	// kept, for Sentry to compute percentiles.
+	MetricTypeDistribution MetricType = "d"
+	// MetricTypeSet is the type of metrics whose unique values are counted.
+	MetricTypeSet MetricType = "s"
+)
+
+// Metric is a value of a metric. Most programs emit metrics with the functions
+// of the metrics package rather than with EmitMetric.
+type Metric struct {
+	Type MetricType
+	// Name is the name of the metric. Characters other than letters, digits,
+	// "_", "-" and "." are replaced with "_".
+	Name string
+	// Unit is the unit of the values of the metric, for example "millisecond"
+	// or "byte". Defaults to "none".
+	Unit string
+	// Value is the value of the metric. The values of sets are unique
+	// identifiers of their members, within the range of uint32.
+	Value float64
+	// Tags are the tags of the metric, added to the release and environment
+	// of the client.
+	Tags map[string]string
+	// Timestamp is the time the value was observed. Defaults to now.
+	Timestamp time.Time
+}
+
+// mri returns the metric resource identifier of m, which identifies the metric
+// in Sentry.
+func (m *Metric) mri() string {
+	return string(m.Type) + ":custom/" + m.Name + "@" + m.Unit
+}
+
+// EmitMetric emits a metric with the client of the hub of ctx, or of the
+// current hub. Metrics are aggregated into buckets of 10 seconds by name, unit
+// and tags, and sent to Sentry periodically and when the client is flushed.
+// When ctx has a span, the metric is also recorded in the metrics summary of
+// the span.
+//
+// The code location of the first emission of each metric in a bucket, the
+// first caller outside of the SDK, is sent along with the bucket, which links
+// the metric to its source in Sentry.
+func EmitMetric(ctx context.Context, metric Metric) {
+	client := hubFromContext(ctx).Client()
+	if client == nil {
+		return
+	}
+	client.emitMetric(ctx, metric)
+}
+
+func (client *Client) emitMetric(ctx context.Context, metric Metric) {
+	metric.Tags = client.metricTags(metric.Tags)
+	if metric.Timestamp.IsZero() {
+		metric.Timestamp = client.now()
+	}
+	if client.options.BeforeEmitMetric != nil {
+		m := client.options.BeforeEmitMetric(&metric)
+		if m == nil {
+			client.debugf(LevelDebug, "Metric %q dropped by BeforeEmitMetric.", metric.Name)
+			return
+		}
+		metric = *m
+	}
+
+	switch metric.Type {
+	case MetricTypeCounter, MetricTypeGauge, MetricTypeDistribution, MetricTypeSet:
+	default:
+		client.debugf(LevelWarning, "Metric %q dropped: unknown metric type %q.", metric.Name, metric.Type)
+		return
+	}
+	metric.Name = sanitizeMetricName(metric.Name)
+	if metric.Name == "" {
+		client.debugf(LevelWarning, "Metric dropped: metrics require a name.")
+		return
+	}
+	metric.Unit = sanitizeMetricUnit(metric.Unit)
+	if metric.Unit == "" {
+		metric.Unit = defaultMetricUnit
+	}
+	metric.Tags = sanitizeMetricTags(metric.Tags)
+	client.metricTagValues.limit(metric.Name, metric.Tags, client.options.MaxMetricTagValues)
+
+	if span := SpanFromContext(ctx); span != nil {
+		value := metric.Value
+		if metric.Type == MetricTypeSet {
+			// The members of sets are not numbers, each of them counts as
+			// one emission.
+			value = 1
+		}
+		span.addMetricSummary(metric.mri(), value, metric.Tags)
+	}
+
+	client.metrics.add(&metric, metricLocation, func() { client.flushMetrics(false) })
+}
+
+// metricLocation returns the frame of the code emitting a metric, the first
+// caller outside of the SDK, or nil if there is none.
+func metricLocation() *Frame {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if f.Function != "" {
+			pkg, function := splitQualifiedFunctionName(f.Function)
+			if !shouldSkipFrame(pkg) {
+				frame := newFrame(pkg, function, f.File, f.Line)
+				return &frame
+			}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// metricTags returns a copy of tags, along with the release and the
+// environment of the client.
+func (client *Client) metricTags(tags map[string]string) map[string]string {
+	t := make(map[string]string, len(tags)+2)
+	if client.options.Release != "" {
+		t["release"] = client.options.Release
+	}
+	if client.options.Environment != "" {
+		t["environment"] = client.options.Environment
+	}
+	for k, v := range tags {
+		t[k] = v
+	}
+	return t
+}
+
+// metricTagLimiter limits the number of distinct values of each tag of a
+// metric, which protects against tags with unbounded values, like user IDs,
+// that would make the number of buckets explode. The zero value is ready to
+// use. It is safe for concurrent use.
+type metricTagLimiter struct {
+	mu     sync.Mutex
+	values map[metricTagKey]map[string]struct{}
+}
+
+// metricTagKey identifies a tag of a metric.
+type metricTagKey struct {
+	metric, tag string
+}
+
+// limit removes from tags the tags of the metric name whose value would
+// exceed maxValues distinct values. Zero means defaultMaxMetricTagValues, and a
+// negative value disables the limit.
+func (l *metricTagLimiter) limit(name string, tags map[string]string, maxValues int) {
+	if maxValues < 0 {
+		return
+	}
+	if maxValues == 0 {
+		maxValues = defaultMaxMetricTagValues
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.values == nil {
+		l.values = make(map[metricTagKey]map[string]struct{})
+	}
+	for k, v := range tags {
+		key := metricTagKey{name, k}
+		values := l.values[key]
+		if values == nil {
+			values = make(map[string]struct{})
+			l.values[key] = values
+		}
+		if _, ok := values[v]; ok {
+			continue
+		}
+		if len(values) >= maxValues {
+			debugf(LevelWarning, "Tag %q of metric %q dropped: the tag has more than %d distinct values.", k, name, maxValues)
+			delete(tags, k)
+			continue
+		}
+		values[v] = struct{}{}
+	}
+}
+
+// flushMetrics sends the metrics aggregated in the buckets of past time spans,
+// or in all buckets if force is true.
+func (client *Client) flushMetrics(force bool) {
+	items := client.metrics.flush(client.now(), force)
+	if len(items) == 0 || client.dsn == nil {
+		return
+	}
+	envelope := NewEnvelope(EnvelopeHeader{
+		SentAt: time.Now(),
+		Dsn:    client.dsn.String(),
+		Sdk: map[string]string{
+			"name":    client.GetSDKIdentifier(),
+			"version": client.sdkVersion,
+		},
+	})
+	for _, item := range items {
+		envelope.AddItem(item)
+	}
+	client.Transport.SendEnvelope(envelope)
+}
+
+// metricsAggregator aggregates metrics into buckets, by time span, type, name,
+// unit and tags. The zero value is ready to use. It is safe for concurrent use.
+type metricsAggregator struct {
+	mu      sync.Mutex
+	buckets map[int64]map[metricBucketKey]*metricBucket
+	// locations are the code locations of metrics, by time span and metric
+	// resource identifier. They are recorded once per time span.
+	locations map[int64]map[string]*Frame
+	// timer calls onFlush after the flush interval, while the aggregator
+	// isn't empty.
+	timer   *time.Timer
+	onFlush func()
+}
+
+// metricBucketKey identifies the metrics aggregated into the same bucket,
+// within a time span.
+type metricBucketKey struct {
+	typ        MetricType
+	name, unit string
+	tags       string
+}
+
+// metricBucket aggregates the values of a metric.
+type metricBucket struct {
+	typ  MetricType
+	tags map[string]string
+	// sum is the value of counters, and the sum of the values of gauges.
+	sum float64
+	// last, min, max and count are the state of gauges.
+	last, min, max float64
+	count          uint64
+	// values are the values of distributions.
+	values []float64
+	// members are the members of sets.
+	members map[uint32]struct{}
+}
+
+// metricLocations are the code locations of metrics in a time span, sent as a
+// metric_meta envelope item.
+//
+// See https://develop.sentry.dev/sdk/metrics/#code-locations.
+type metricLocations struct {
+	Timestamp int64                           `json:"timestamp"`
+	Mapping   map[string][]metricCodeLocation `json:"mapping"`
+}
+
+type metricCodeLocation struct {
+	Type string `json:"type"`
+	*Frame
+}
+
+// add adds metric to its bucket, and records its code location with location
+// if it is the first emission of the metric in the time span of the bucket.
+// When the aggregator was empty, onFlush is scheduled to be called after the
+// flush interval.
+func (a *metricsAggregator) add(metric *Metric, location func() *Frame, onFlush func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.buckets == nil {
+		a.buckets = make(map[int64]map[metricBucketKey]*metricBucket)
+		a.locations = make(map[int64]map[string]*Frame)
+	}
+	ts := metric.Timestamp.Truncate(metricsBucketInterval).Unix()
+	buckets := a.buckets[ts]
+	if buckets == nil {
+		buckets = make(map[metricBucketKey]*metricBucket)
+		a.buckets[ts] = buckets
+	}
+	key := metricBucketKey{metric.Type, metric.Name, metric.Unit, tagsKey(metric.Tags)}
+	bucket := buckets[key]
+	if bucket == nil {
+		bucket = &metricBucket{typ: metric.Type, tags: metric.Tags, min: metric.Value, max: metric.Value}
+		buckets[key] = bucket
+	}
+	bucket.add(metric.Value)
+
+	locations := a.locations[ts]
+	if locations == nil {
+		locations = make(map[string]*Frame)
+		a.locations[ts] = locations
+	}
+	mri := metric.mri()
+	if _, ok := locations[mri]; !ok {
+		// A nil frame is recorded too, so that the stack is walked once.
+		locations[mri] = location()
+	}
+
+	if a.timer == nil {
+		a.onFlush = onFlush
+		a.timer = time.AfterFunc(metricsFlushInterval, onFlush)
+	}
+}
+
+func (b *metricBucket) add(value float64) {
+	switch b.typ {
+	case MetricTypeCounter:
+		b.sum += value
+	case MetricTypeGauge:
+		b.last = value
+		if value < b.min {
+			b.min = value
+		}
+		if value > b.max {
+			b.max = value
+		}
+		b.sum += value
+		b.count++
+	case MetricTypeDistribution:
+		b.values = append(b.values, value)
+	case MetricTypeSet:
+		if b.members == nil {
+			b.members = make(map[uint32]struct{})
+		}
+		b.members[uint32(value)] = struct{}{}
+	}
+}
+
+// flush removes the buckets of the time spans ended at now, or all buckets if
+// force is true, and returns them as envelope items: a statsd item with the
+// buckets, and a metric_meta item with the code locations of each time span.
+// When buckets remain, the next flush is scheduled.
+func (a *metricsAggregator) flush(now time.Time, force bool) []*EnvelopeItem {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var timestamps []int64
+	for ts := range a.buckets {
+		if force || !time.Unix(ts, 0).Add(metricsBucketInterval).After(now) {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	var b strings.Builder
+	var items []*EnvelopeItem
+	for _, ts := range timestamps {
+		buckets := a.buckets[ts]
+		keys := make([]metricBucketKey, 0, len(buckets))
+		for key := range buckets {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].name != keys[j].name {
+				return keys[i].name < keys[j].name
+			}
+			if keys[i].typ != keys[j].typ {
+				return keys[i].typ < keys[j].typ
+			}
+			if keys[i].unit != keys[j].unit {
+				return keys[i].unit < keys[j].unit
+			}
+			return keys[i].tags < keys[j].tags
+		})
+		for _, key := range keys {
+			buckets[key].encode(&b, key, ts)
+		}
+		delete(a.buckets, ts)
+
+		if item := a.locationsItem(ts); item != nil {
+			items = append(items, item)
+		}
+		delete(a.locations, ts)
+	}
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	if len(a.buckets) > 0 {
+		a.timer = time.AfterFunc(metricsFlushInterval, a.onFlush)
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	return append([]*EnvelopeItem{{Type: statsdType, Payload: []byte(b.String())}}, items...)
+}
+
+// locationsItem returns the metric_meta item of the code locations of the
+// time span ts, or nil if there are none.
+func (a *metricsAggregator) locationsItem(ts int64) *EnvelopeItem {
+	mapping := make(map[string][]metricCodeLocation)
+	for mri, frame := range a.locations[ts] {
+		if frame != nil {
+			mapping[mri] = []metricCodeLocation{{Type: "location", Frame: frame}}
+		}
+	}
+	if len(mapping) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(metricLocations{Timestamp: ts, Mapping: mapping})
+	if err != nil {
+		debugf(LevelError, "Metric code locations couldn't be marshaled: %v", err)
+		return nil
+	}
+	return &EnvelopeItem{Type: metricMetaType, Payload: payload}
+}
+
+// encode writes the bucket as a statsd line, for example
+// "latency@millisecond:15:20|d|#route:/a|T1700000000".
+//
+// See https://develop.sentry.dev/sdk/metrics/#statsd-format.
+func (b *metricBucket) encode(w *strings.Builder, key metricBucketKey, ts int64) {
+	w.WriteString(key.name)
+	w.WriteByte('@')
+	w.WriteString(key.unit)
+	switch b.typ {
+	case MetricTypeCounter:
+		w.WriteByte(':')
+		w.WriteString(formatMetricValue(b.sum))
+	case MetricTypeGauge:
+		for _, v := range []float64{b.last, b.min, b.max, b.sum, float64(b.count)} {
+			w.WriteByte(':')
+			w.WriteString(formatMetricValue(v))
+		}
+	case MetricTypeDistribution:
+		for _, v := range b.values {
+			w.WriteByte(':')
+			w.WriteString(formatMetricValue(v))
+		}
+	case MetricTypeSet:
+		members := make([]uint32, 0, len(b.members))
+		for m := range b.members {
+			members = append(members, m)
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+		for _, m := range members {
+			w.WriteByte(':')
+			w.WriteString(strconv.FormatUint(uint64(m), 10))
+		}
+	}
+	w.WriteByte('|')
+	w.WriteString(string(b.typ))
+	if len(b.tags) > 0 {
+		keys := make([]string, 0, len(b.tags))
+		for k := range b.tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w.WriteString("|#")
+		for i, k := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(k)
+			w.WriteByte(':')
+			w.WriteString(escapeMetricTagValue(b.tags[k]))
+		}
+	}
+	w.WriteString("|T")
+	w.WriteString(strconv.FormatInt(ts, 10))
+	w.WriteByte('\n')
+}
+
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// sanitizeMetricName replaces the characters not allowed in metric names with
+// "_".
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if isMetricNameRune(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// sanitizeMetricUnit removes the characters not allowed in metric units.
+func sanitizeMetricUnit(unit string) string {
+	return strings.Map(func(r rune) rune {
+		if isAlphanumeric(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, unit)
+}
+
+// sanitizeMetricTags returns a copy of tags with sanitized keys, without the
+// tags whose key is empty once sanitized.
+func sanitizeMetricTags(tags map[string]string) map[string]string {
+	t := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if k = sanitizeMetricTagKey(k); k != "" {
+			t[k] = v
+		}
+	}
+	return t
+}
+
+// sanitizeMetricTagKey removes the characters not allowed in tag keys.
+func sanitizeMetricTagKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if isMetricNameRune(r) || r == '/' {
+			return r
+		}
+		return -1
+	}, key)
+}
+
+// metricTagValueReplacer escapes the characters of tag values that are
+// separators in the statsd format.
+var metricTagValueReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+	"|", "\\u{7c}",
+	",", "\\u{2c}",
+)
+
+func escapeMetricTagValue(value string) string {
+	return metricTagValueReplacer.Replace(value)
+}
+
+func isMetricNameRune(r rune) bool {
+	return isAlphanumeric(r) || r == '_' || r == '-' || r == '.'
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
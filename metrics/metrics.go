@@ -0,0 +1,139 @@
+// Package metrics emits metrics to Sentry: counters, gauges, distributions
+// and sets.
+//
+// Metrics are emitted with the client of the hub of the context, or of the
+// current hub:
+//
+//	metrics.Incr(ctx, "checkout.started", 1, metrics.WithTags(map[string]string{"plan": plan}))
+//	metrics.Distribution(ctx, "checkout.duration", 42.5, metrics.WithUnit("millisecond"))
+//	metrics.Gauge(ctx, "queue.depth", float64(len(queue)))
+//	metrics.Set(ctx, "checkout.users", user.ID)
+//
+// Metrics are aggregated in the client into buckets of 10 seconds, by name,
+// unit and tags, and sent to Sentry periodically. Flush the client before the
+// program exits, for example with sentry.Flush, to send the last buckets.
+//
+// Metrics emitted in a context with a span are also recorded in the metrics
+// summary of the span. The code locations of the calls emitting metrics are
+// sent along with them, to link metrics to their source in Sentry.
+package metrics
+
+import (
+	"context"
+	"hash/crc32"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// An Option configures a metric.
+type Option func(metric *sentry.Metric)
+
+// WithUnit sets the unit of a metric, for example "millisecond" or "byte".
+func WithUnit(unit string) Option {
+	return func(metric *sentry.Metric) {
+		metric.Unit = unit
+	}
+}
+
+// WithTags sets the tags of a metric.
+func WithTags(tags map[string]string) Option {
+	return func(metric *sentry.Metric) {
+		metric.Tags = tags
+	}
+}
+
+// WithTimestamp sets the time a value of a metric was observed, which
+// defaults to now.
+func WithTimestamp(timestamp time.Time) Option {
+	return func(metric *sentry.Metric) {
+		metric.Timestamp = timestamp
+	}
+}
+
+// Incr increments the counter name by value.
+func Incr(ctx context.Context, name string, value float64, options ...Option) {
+	emit(ctx, sentry.MetricTypeCounter, name, value, options)
+}
+
+// Gauge sets the gauge name to value. Sentry keeps the last, minimum, maximum,
+// sum and count of the values of gauges.
+func Gauge(ctx context.Context, name string, value float64, options ...Option) {
+	emit(ctx, sentry.MetricTypeGauge, name, value, options)
+}
+
+// Distribution adds value to the distribution name, whose percentiles are
+// computed by Sentry.
+func Distribution(ctx context.Context, name string, value float64, options ...Option) {
+	emit(ctx, sentry.MetricTypeDistribution, name, value, options)
+}
+
+// Set adds value to the set name, which counts unique values, for example the
+// users of a feature.
+func Set(ctx context.Context, name string, value string, options ...Option) {
+	emit(ctx, sentry.MetricTypeSet, name, float64(crc32.ChecksumIEEE([]byte(value))), options)
+}
+
+// Timing calls f and adds its duration to the distribution name, in seconds
+// unless another time unit is set with WithUnit: "nanosecond", "microsecond",
+// "millisecond", "minute", "hour", "day" or "week". The duration is recorded
+// even if f panics.
+//
+// When ctx has a span, the duration is also recorded in the metrics summary of
+// the span, which makes it easy to measure critical sections consistently:
+//
+//	metrics.Timing(ctx, "checkout.charge", func() {
+//		err = charge(ctx, order)
+//	}, metrics.WithUnit("millisecond"))
+func Timing(ctx context.Context, name string, f func(), options ...Option) {
+	start := time.Now()
+	defer func() {
+		metric := sentry.Metric{
+			Type:      sentry.MetricTypeDistribution,
+			Name:      name,
+			Unit:      "second",
+			Timestamp: start,
+		}
+		for _, option := range options {
+			option(&metric)
+		}
+		metric.Value = durationIn(time.Since(start), metric.Unit)
+		sentry.EmitMetric(ctx, metric)
+	}()
+	f()
+}
+
+// durationIn returns d in the time unit unit, or in seconds if unit isn't a
+// time unit.
+func durationIn(d time.Duration, unit string) float64 {
+	switch unit {
+	case "nanosecond":
+		return float64(d.Nanoseconds())
+	case "microsecond":
+		return float64(d) / float64(time.Microsecond)
+	case "millisecond":
+		return float64(d) / float64(time.Millisecond)
+	case "minute":
+		return d.Minutes()
+	case "hour":
+		return d.Hours()
+	case "day":
+		return d.Hours() / 24
+	case "week":
+		return d.Hours() / (24 * 7)
+	default:
+		return d.Seconds()
+	}
+}
+
+func emit(ctx context.Context, typ sentry.MetricType, name string, value float64, options []Option) {
+	metric := sentry.Metric{
+		Type:  typ,
+		Name:  name,
+		Value: value,
+	}
+	for _, option := range options {
+		option(&metric)
+	}
+	sentry.EmitMetric(ctx, metric)
+}
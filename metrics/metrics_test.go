@@ -0,0 +1,213 @@
+package metrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"hash/crc32"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+type transport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+	lines  []string
+	metas  [][]byte
+}
+
+func (t *transport) Configure(sentry.ClientOptions) {}
+func (t *transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+func (t *transport) Flush(time.Duration) bool { return true }
+func (t *transport) SendEnvelope(envelope *sentry.Envelope) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, item := range envelope.Items {
+		switch item.Type {
+		case "statsd":
+			t.lines = append(t.lines, strings.Split(strings.TrimSuffix(string(item.Payload), "\n"), "\n")...)
+		case "metric_meta":
+			t.metas = append(t.metas, item.Payload)
+		}
+	}
+}
+
+func setup(t *testing.T, now time.Time) (context.Context, *sentry.Hub, *transport) {
+	t.Helper()
+	tr := &transport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:                  "http://whatever@example.com/1337",
+		Release:              "app@1.0.0",
+		Transport:            tr,
+		DisableClientReports: true,
+		EnableTracing:        true,
+		TracesSampleRate:     1.0,
+		Clock:                func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	return sentry.SetHubOnContext(context.Background(), hub), hub, tr
+}
+
+func TestMetrics(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ctx, hub, tr := setup(t, now)
+
+	metrics.Incr(ctx, "checkout.started", 1, metrics.WithTags(map[string]string{"plan": "pro"}))
+	metrics.Incr(ctx, "checkout.started", 1, metrics.WithTags(map[string]string{"plan": "pro"}))
+	metrics.Distribution(ctx, "checkout.duration", 42.5, metrics.WithUnit("millisecond"))
+	metrics.Gauge(ctx, "queue.depth", 3)
+	metrics.Set(ctx, "checkout.users", "jane")
+	metrics.Set(ctx, "checkout.users", "jane")
+	metrics.Incr(ctx, "checkout.started", 1, metrics.WithTimestamp(now.Add(-time.Minute)))
+	hub.Flush(time.Second)
+
+	want := []string{
+		"checkout.started@none:1|c|#release:app@1.0.0|T1699999940",
+		"checkout.duration@millisecond:42.5|d|#release:app@1.0.0|T1700000000",
+		"checkout.started@none:2|c|#plan:pro,release:app@1.0.0|T1700000000",
+		"checkout.users@none:" + strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte("jane"))), 10) + "|s|#release:app@1.0.0|T1700000000",
+		"queue.depth@none:3:3:3:3:1|g|#release:app@1.0.0|T1700000000",
+	}
+	if diff := cmp.Diff(want, tr.lines); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMetricsCodeLocations(t *testing.T) {
+	ctx, hub, tr := setup(t, time.Unix(1700000000, 0))
+
+	for i := 0; i < 3; i++ {
+		metrics.Incr(ctx, "checkout.started", 1)
+	}
+	_, _, line, _ := runtime.Caller(0)
+	hub.Flush(time.Second)
+
+	if len(tr.metas) != 1 {
+		t.Fatalf("sent %d metric_meta items, want 1", len(tr.metas))
+	}
+	var got struct {
+		Timestamp int64 `json:"timestamp"`
+		Mapping   map[string][]struct {
+			Type     string `json:"type"`
+			Function string `json:"function"`
+			Module   string `json:"module"`
+			Lineno   int    `json:"lineno"`
+		} `json:"mapping"`
+	}
+	if err := json.Unmarshal(tr.metas[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Timestamp != 1700000000 {
+		t.Errorf("timestamp = %d, want 1700000000", got.Timestamp)
+	}
+	locations := got.Mapping["c:custom/checkout.started@none"]
+	if len(locations) != 1 {
+		t.Fatalf("got %d locations, want 1: %s", len(locations), tr.metas[0])
+	}
+	location := locations[0]
+	if location.Type != "location" || location.Function != "TestMetricsCodeLocations" ||
+		location.Module != "github.com/getsentry/sentry-go/metrics_test" || location.Lineno != line-2 {
+		t.Errorf("unexpected location %+v, want line %d", location, line-2)
+	}
+}
+
+func TestStartRuntimeCollector(t *testing.T) {
+	ctx, hub, tr := setup(t, time.Unix(1700000000, 0))
+
+	runtime.GC()
+	stop := metrics.StartRuntimeCollector(ctx, metrics.RuntimeOptions{Service: "checkout"})
+	stop()
+	stop()
+	hub.Flush(time.Second)
+
+	got := make(map[string]bool)
+	for _, line := range tr.lines {
+		name, _, _ := strings.Cut(line, ":")
+		got[name] = true
+		if !strings.Contains(line, "|#release:app@1.0.0,service:checkout|") {
+			t.Errorf("line %q is missing the service and release tags", line)
+		}
+	}
+	want := []string{
+		"runtime.goroutines@none",
+		"runtime.gomaxprocs@none",
+		"runtime.heap_alloc@byte",
+		"runtime.heap_inuse@byte",
+		"runtime.stack_inuse@byte",
+		"runtime.gc.pause@nanosecond",
+	}
+	if runtime.GOOS == "linux" {
+		want = append(want, "runtime.fd.open@none", "runtime.fd.max@none")
+	}
+	for _, name := range want {
+		if !got[name] {
+			t.Errorf("metric %s not emitted, got %q", name, tr.lines)
+		}
+	}
+}
+
+func TestTiming(t *testing.T) {
+	ctx, hub, tr := setup(t, time.Unix(1700000000, 0))
+	transaction := sentry.StartTransaction(ctx, "checkout")
+
+	metrics.Timing(transaction.Context(), "checkout.charge", func() {
+		time.Sleep(10 * time.Millisecond)
+	}, metrics.WithUnit("millisecond"))
+	func() {
+		defer func() { _ = recover() }()
+		metrics.Timing(ctx, "checkout.panic", func() { panic("charge failed") })
+	}()
+	transaction.Finish()
+	hub.Flush(time.Second)
+
+	if len(tr.lines) != 2 {
+		t.Fatalf("got statsd lines %q, want 2", tr.lines)
+	}
+	line := tr.lines[0]
+	if !strings.HasPrefix(line, "checkout.charge@millisecond:") || !strings.Contains(line, "|d|") {
+		t.Fatalf("unexpected line %q", line)
+	}
+	value, err := strconv.ParseFloat(strings.TrimPrefix(strings.Split(line, "|")[0], "checkout.charge@millisecond:"), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value < 10 || value > 1000 {
+		t.Errorf("duration = %vms, want at least 10ms", value)
+	}
+	if !strings.HasPrefix(tr.lines[1], "checkout.panic@second:") {
+		t.Errorf("unexpected line %q", tr.lines[1])
+	}
+
+	event := tr.events[0]
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		MetricsSummary map[string][]struct {
+			Sum   float64 `json:"sum"`
+			Count int     `json:"count"`
+		} `json:"_metrics_summary"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	summary := got.MetricsSummary["d:custom/checkout.charge@millisecond"]
+	if len(summary) != 1 || summary[0].Count != 1 || summary[0].Sum != value {
+		t.Errorf("metrics summary = %+v, want the duration %v", got.MetricsSummary, value)
+	}
+}
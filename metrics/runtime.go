@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// defaultRuntimeInterval is the default interval of the runtime collector.
+const defaultRuntimeInterval = 10 * time.Second
+
+// RuntimeOptions configure the runtime collector.
+type RuntimeOptions struct {
+	// Interval is the interval at which runtime metrics are emitted. Defaults
+	// to 10 seconds.
+	Interval time.Duration
+	// Service is the name of the service, added to runtime metrics as the
+	// "service" tag, if set. The release is added to all metrics.
+	Service string
+}
+
+// StartRuntimeCollector starts emitting metrics of the Go runtime with the hub
+// of ctx, or the current hub, once right away and then periodically, until the
+// returned function is called:
+//
+//   - runtime.goroutines: the number of goroutines
+//   - runtime.gomaxprocs: the value of GOMAXPROCS
+//   - runtime.heap_alloc and runtime.heap_inuse: the bytes of heap objects,
+//     and of the heap spans in use
+//   - runtime.stack_inuse: the bytes of goroutine stacks
+//   - runtime.gc.pause: the duration of each garbage collection pause, as a
+//     distribution of which Sentry computes percentiles
+//   - runtime.fd.open and runtime.fd.max: the number of open file descriptors
+//     and their limit, on Linux
+//
+// Reading the memory statistics of the runtime briefly stops the world, so
+// intervals under a second are not recommended.
+func StartRuntimeCollector(ctx context.Context, options RuntimeOptions) (stop func()) {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = defaultRuntimeInterval
+	}
+	c := &runtimeCollector{}
+	if options.Service != "" {
+		c.tags = map[string]string{"service": options.Service}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.collect(ctx)
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// runtimeCollector emits runtime metrics. It is used by a single goroutine.
+type runtimeCollector struct {
+	tags map[string]string
+	// numGC is the number of garbage collections at the last collection.
+	numGC uint32
+}
+
+func (c *runtimeCollector) collect(ctx context.Context) {
+	now := time.Now()
+	gauge := func(name, unit string, value float64) {
+		Gauge(ctx, name, value, WithUnit(unit), WithTags(c.tags), WithTimestamp(now))
+	}
+
+	gauge("runtime.goroutines", "none", float64(runtime.NumGoroutine()))
+	gauge("runtime.gomaxprocs", "none", float64(runtime.GOMAXPROCS(0)))
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	gauge("runtime.heap_alloc", "byte", float64(stats.HeapAlloc))
+	gauge("runtime.heap_inuse", "byte", float64(stats.HeapInuse))
+	gauge("runtime.stack_inuse", "byte", float64(stats.StackInuse))
+
+	// PauseNs is a circular buffer of the pauses of the last garbage
+	// collections, the one of the collection n being at (n+255)%256.
+	pauses := uint32(len(stats.PauseNs))
+	first := c.numGC + 1
+	if stats.NumGC-c.numGC > pauses {
+		first = stats.NumGC - pauses + 1
+	}
+	for n := first; n <= stats.NumGC; n++ {
+		pause := stats.PauseNs[(n+pauses-1)%pauses]
+		Distribution(ctx, "runtime.gc.pause", float64(pause), WithUnit("nanosecond"), WithTags(c.tags), WithTimestamp(now))
+	}
+	c.numGC = stats.NumGC
+
+	if open, limit, ok := fileDescriptors(); ok {
+		gauge("runtime.fd.open", "none", float64(open))
+		gauge("runtime.fd.max", "none", float64(limit))
+	}
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+package metrics
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileDescriptors returns the number of open file descriptors of the process,
+// and their soft limit.
+func fileDescriptors() (open, limit int, ok bool) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, 0, false
+	}
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, 0, false
+	}
+	// The directory itself is open while it is read.
+	return len(entries) - 1, int(rlimit.Cur), true
+}
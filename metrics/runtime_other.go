@@ -0,0 +1,8 @@
+//go:build !linux
+
+package metrics
+
+// fileDescriptors is only implemented on Linux.
+func fileDescriptors() (open, limit int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,112 @@
+package sentry
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// metricSummary aggregates the values of a metric emitted within a span with
+// a given set of tags.
+type metricSummary struct {
+	Min   float64           `json:"min"`
+	Max   float64           `json:"max"`
+	Sum   float64           `json:"sum"`
+	Count uint64            `json:"count"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// metricsSummary aggregates the metrics emitted within a span, by metric
+// resource identifier (MRI) and by set of tags. It is sent as the
+// "_metrics_summary" of the span, which lets Sentry correlate metrics with
+// the spans they were emitted in.
+//
+// See https://develop.sentry.dev/sdk/metrics/#span-summaries.
+type metricsSummary map[string]map[string]*metricSummary
+
+// add records value for the metric identified by mri with the given tags.
+func (m metricsSummary) add(mri string, value float64, tags map[string]string) {
+	buckets := m[mri]
+	if buckets == nil {
+		buckets = make(map[string]*metricSummary)
+		m[mri] = buckets
+	}
+	key := tagsKey(tags)
+	summary := buckets[key]
+	if summary == nil {
+		summary = &metricSummary{Min: value, Max: value}
+		if len(tags) > 0 {
+			summary.Tags = make(map[string]string, len(tags))
+			for k, v := range tags {
+				summary.Tags[k] = v
+			}
+		}
+		buckets[key] = summary
+	}
+	if value < summary.Min {
+		summary.Min = value
+	}
+	if value > summary.Max {
+		summary.Max = value
+	}
+	summary.Sum += value
+	summary.Count++
+}
+
+// clone returns a deep copy of m, or nil if m is empty.
+func (m metricsSummary) clone() metricsSummary {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(metricsSummary, len(m))
+	for mri, buckets := range m {
+		c[mri] = make(map[string]*metricSummary, len(buckets))
+		for key, summary := range buckets {
+			s := *summary
+			c[mri][key] = &s
+		}
+	}
+	return c
+}
+
+// MarshalJSON encodes the summaries of each metric as a list, ordered by
+// tags.
+func (m metricsSummary) MarshalJSON() ([]byte, error) {
+	x := make(map[string][]*metricSummary, len(m))
+	for mri, buckets := range m {
+		keys := make([]string, 0, len(buckets))
+		for key := range buckets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		summaries := make([]*metricSummary, 0, len(buckets))
+		for _, key := range keys {
+			summaries = append(summaries, buckets[key])
+		}
+		x[mri] = summaries
+	}
+	return json.Marshal(x)
+}
+
+// tagsKey returns a string uniquely identifying a set of tags.
+func tagsKey(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// addMetricSummary records value in the metrics summary of the span, for the
+// metric identified by mri with the given tags. Metrics emitted while the
+// span is active are recorded in its summary.
+func (s *Span) addMetricSummary(mri string, value float64, tags map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metricsSummary == nil {
+		s.metricsSummary = make(metricsSummary)
+	}
+	s.metricsSummary.add(mri, value, tags)
+}
@@ -0,0 +1,88 @@
+package sentry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetricsSummary(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+
+	transaction := StartTransaction(ctx, "transaction")
+	transaction.addMetricSummary("c:custom/orders@none", 1, nil)
+	transaction.addMetricSummary("c:custom/orders@none", 2, nil)
+	span := transaction.StartChild("op")
+	span.addMetricSummary("d:custom/latency@millisecond", 30, map[string]string{"route": "/b"})
+	span.addMetricSummary("d:custom/latency@millisecond", 10, map[string]string{"route": "/a"})
+	span.addMetricSummary("d:custom/latency@millisecond", 20, map[string]string{"route": "/a"})
+	span.Finish()
+	transaction.Finish()
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("sent %d events, want 1", len(events))
+	}
+
+	data, err := json.Marshal(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		MetricsSummary map[string][]metricSummary `json:"_metrics_summary"`
+		Spans          []struct {
+			MetricsSummary map[string][]metricSummary `json:"_metrics_summary"`
+		} `json:"spans"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]metricSummary{
+		"c:custom/orders@none": {{Min: 1, Max: 2, Sum: 3, Count: 2}},
+	}
+	if diff := cmp.Diff(want, got.MetricsSummary); diff != "" {
+		t.Errorf("transaction metrics summary mismatch (-want +got):\n%s", diff)
+	}
+	want = map[string][]metricSummary{
+		"d:custom/latency@millisecond": {
+			{Min: 10, Max: 20, Sum: 30, Count: 2, Tags: map[string]string{"route": "/a"}},
+			{Min: 30, Max: 30, Sum: 30, Count: 1, Tags: map[string]string{"route": "/b"}},
+		},
+	}
+	if len(got.Spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(got.Spans))
+	}
+	if diff := cmp.Diff(want, got.Spans[0].MetricsSummary); diff != "" {
+		t.Errorf("span metrics summary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTagsKey(t *testing.T) {
+	assertEqual(t, tagsKey(nil), "")
+	assertEqual(t, tagsKey(map[string]string{"b": "2", "a": "1"}), "a=1,b=2")
+}
+
+func TestMetricsSummaryConcurrentMarshal(t *testing.T) {
+	span := StartSpan(NewTestContext(ClientOptions{EnableTracing: true}), "op")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			span.addMetricSummary("c:custom/orders@none", 1, nil)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if _, err := json.Marshal(span); err != nil {
+			t.Fatal(err)
+		}
+		_ = span.shallowCopy()
+	}
+	<-done
+}
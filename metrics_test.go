@@ -0,0 +1,219 @@
+package sentry
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func setupMetricsTest(t *testing.T, now *time.Time) (*Hub, *TransportMock) {
+	t.Helper()
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:                  "http://whatever@example.com/1337",
+		Release:              "app@1.0.0",
+		Transport:            transport,
+		DisableClientReports: true,
+		Clock:                func() time.Time { return *now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHub(client, NewScope()), transport
+}
+
+// statsdLines returns the lines of the statsd items sent to transport.
+func statsdLines(transport *TransportMock) []string {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	var lines []string
+	for _, envelope := range transport.envelopes {
+		for _, item := range envelope.Items {
+			if item.Type != statsdType {
+				continue
+			}
+			lines = append(lines, strings.Split(strings.TrimSuffix(string(item.Payload), "\n"), "\n")...)
+		}
+	}
+	return lines
+}
+
+func TestEmitMetric(t *testing.T) {
+	now := time.Unix(1700000003, 0)
+	hub, transport := setupMetricsTest(t, &now)
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 1})
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 2})
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 1, Tags: map[string]string{"plan": "pro"}})
+	EmitMetric(ctx, Metric{Type: MetricTypeGauge, Name: "queue.depth", Value: 4})
+	EmitMetric(ctx, Metric{Type: MetricTypeGauge, Name: "queue.depth", Value: 2})
+	EmitMetric(ctx, Metric{Type: MetricTypeGauge, Name: "queue.depth", Value: 3})
+	EmitMetric(ctx, Metric{Type: MetricTypeDistribution, Name: "latency", Unit: "millisecond", Value: 15})
+	EmitMetric(ctx, Metric{Type: MetricTypeDistribution, Name: "latency", Unit: "millisecond", Value: 20.5})
+	EmitMetric(ctx, Metric{Type: MetricTypeSet, Name: "users", Value: 7})
+	EmitMetric(ctx, Metric{Type: MetricTypeSet, Name: "users", Value: 3})
+	EmitMetric(ctx, Metric{Type: MetricTypeSet, Name: "users", Value: 7})
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 1, Timestamp: now.Add(-time.Minute)})
+	EmitMetric(ctx, Metric{Type: "x", Name: "unknown", Value: 1})
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Value: 1})
+	hub.Flush(time.Second)
+
+	want := []string{
+		"orders@none:1|c|#release:app@1.0.0|T1699999940",
+		"latency@millisecond:15:20.5|d|#release:app@1.0.0|T1700000000",
+		"orders@none:1|c|#plan:pro,release:app@1.0.0|T1700000000",
+		"orders@none:3|c|#release:app@1.0.0|T1700000000",
+		"queue.depth@none:3:2:4:9:3|g|#release:app@1.0.0|T1700000000",
+		"users@none:3:7|s|#release:app@1.0.0|T1700000000",
+	}
+	if diff := cmp.Diff(want, statsdLines(transport)); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEmitMetricSanitization(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	hub, transport := setupMetricsTest(t, &now)
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	EmitMetric(ctx, Metric{
+		Type:  MetricTypeCounter,
+		Name:  "checkout/started ok",
+		Unit:  "milli-second",
+		Value: 1,
+		Tags:  map[string]string{"route name": "/a|b,c\n", "release": "override"},
+	})
+	hub.Flush(time.Second)
+
+	want := []string{`checkout_started_ok@millisecond:1|c|#release:override,routename:/a\u{7c}b\u{2c}c\n|T1700000000`}
+	if diff := cmp.Diff(want, statsdLines(transport)); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFlushMetricsPastBuckets(t *testing.T) {
+	now := time.Unix(1700000005, 0)
+	hub, transport := setupMetricsTest(t, &now)
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 1})
+	hub.Client().flushMetrics(false)
+	if lines := statsdLines(transport); len(lines) != 0 {
+		t.Fatalf("sent %q before the end of the bucket", lines)
+	}
+
+	now = now.Add(5 * time.Second)
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 2})
+	hub.Client().flushMetrics(false)
+	want := []string{"orders@none:1|c|#release:app@1.0.0|T1700000000"}
+	if diff := cmp.Diff(want, statsdLines(transport)); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+	if hub.Client().metrics.timer == nil {
+		t.Error("flush of the remaining bucket not scheduled")
+	}
+
+	hub.Flush(time.Second)
+	if hub.Client().metrics.timer != nil {
+		t.Error("flush scheduled with no remaining buckets")
+	}
+}
+
+func TestEmitMetricSpanSummary(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	hub, _ := setupMetricsTest(t, &now)
+	ctx := SetHubOnContext(context.Background(), hub)
+	span := StartSpan(ctx, "op")
+
+	EmitMetric(span.Context(), Metric{Type: MetricTypeDistribution, Name: "latency", Unit: "millisecond", Value: 10})
+	EmitMetric(span.Context(), Metric{Type: MetricTypeDistribution, Name: "latency", Unit: "millisecond", Value: 30})
+	EmitMetric(span.Context(), Metric{Type: MetricTypeSet, Name: "users", Value: 12345})
+
+	tags := map[string]string{"release": "app@1.0.0"}
+	want := metricsSummary{
+		"d:custom/latency@millisecond": {tagsKey(tags): {Min: 10, Max: 30, Sum: 40, Count: 2, Tags: tags}},
+		"s:custom/users@none":          {tagsKey(tags): {Min: 1, Max: 1, Sum: 1, Count: 1, Tags: tags}},
+	}
+	if diff := cmp.Diff(want, span.metricsSummary); diff != "" {
+		t.Errorf("metrics summary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMetricsAggregatorLocations(t *testing.T) {
+	var a metricsAggregator
+	defer a.flush(time.Time{}, true)
+
+	calls := 0
+	location := func() *Frame {
+		calls++
+		return &Frame{Function: "handler", Module: "example.com/app", Lineno: 12}
+	}
+	now := time.Unix(1700000000, 0)
+	for _, ts := range []time.Time{now, now.Add(time.Second), now.Add(metricsBucketInterval)} {
+		a.add(&Metric{Type: MetricTypeCounter, Name: "orders", Unit: "none", Value: 1, Timestamp: ts}, location, func() {})
+	}
+	if calls != 2 {
+		t.Errorf("location resolved %d times, want once per time span", calls)
+	}
+
+	items := a.flush(now.Add(metricsBucketInterval), false)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	assertEqual(t, items[0].Type, statsdType)
+	assertEqual(t, items[1].Type, metricMetaType)
+	want := `{"timestamp":1700000000,"mapping":{"c:custom/orders@none":[{"type":"location","function":"handler","module":"example.com/app","lineno":12,"in_app":false}]}}`
+	assertEqual(t, string(items[1].Payload), want)
+}
+
+func TestBeforeEmitMetric(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	hub, transport := setupMetricsTest(t, &now)
+	hub.Client().options.BeforeEmitMetric = func(metric *Metric) *Metric {
+		if metric.Name == "debug" {
+			return nil
+		}
+		delete(metric.Tags, "release")
+		metric.Tags["region"] = "eu"
+		metric.Name = "app." + metric.Name
+		return metric
+	}
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 1})
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "debug", Value: 1})
+	hub.Flush(time.Second)
+
+	want := []string{"app.orders@none:1|c|#region:eu|T1700000000"}
+	if diff := cmp.Diff(want, statsdLines(transport)); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaxMetricTagValues(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	hub, transport := setupMetricsTest(t, &now)
+	hub.Client().options.MaxMetricTagValues = 2
+	ctx := SetHubOnContext(context.Background(), hub)
+
+	for _, user := range []string{"a", "b", "c", "a"} {
+		EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "logins", Value: 1, Tags: map[string]string{"user": user, "plan": "pro"}})
+	}
+	EmitMetric(ctx, Metric{Type: MetricTypeCounter, Name: "orders", Value: 1, Tags: map[string]string{"user": "c"}})
+	hub.Flush(time.Second)
+
+	want := []string{
+		"logins@none:1|c|#plan:pro,release:app@1.0.0|T1700000000",
+		"logins@none:2|c|#plan:pro,release:app@1.0.0,user:a|T1700000000",
+		"logins@none:1|c|#plan:pro,release:app@1.0.0,user:b|T1700000000",
+		"orders@none:1|c|#release:app@1.0.0,user:c|T1700000000",
+	}
+	if diff := cmp.Diff(want, statsdLines(transport)); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -25,6 +25,8 @@ type TransportMock struct {
 	mu        sync.Mutex
 	events    []*Event
 	lastEvent *Event
+	envelopes []*Envelope
+	flushes   int
 }
 
 func (t *TransportMock) Configure(options ClientOptions) {}
@@ -34,7 +36,15 @@ func (t *TransportMock) SendEvent(event *Event) {
 	t.events = append(t.events, event)
 	t.lastEvent = event
 }
+func (t *TransportMock) SendEnvelope(envelope *Envelope) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.envelopes = append(t.envelopes, envelope)
+}
 func (t *TransportMock) Flush(timeout time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushes++
 	return true
 }
 func (t *TransportMock) Events() []*Event {
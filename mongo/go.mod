@@ -0,0 +1,16 @@
+module github.com/getsentry/sentry-go/mongo
+
+go 1.21
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+	go.mongodb.org/mongo-driver v1.17.4
+)
+
+require (
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
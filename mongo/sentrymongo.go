@@ -0,0 +1,144 @@
+// Package sentrymongo provides Sentry instrumentation for the MongoDB Go
+// driver.
+//
+// Commands run with a context containing a span are recorded in "db.query"
+// child spans, named after the command and the collection it operates on, and
+// failed commands are recorded as breadcrumbs:
+//
+//	client, err := mongo.Connect(ctx, options.Client().
+//		ApplyURI(uri).
+//		SetMonitor(sentrymongo.NewMonitor(sentrymongo.Options{})))
+package sentrymongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// spanOperation is the operation of the spans recorded for commands.
+const spanOperation = "db.query"
+
+// spanOrigin is the origin of the spans recorded for commands.
+const spanOrigin = "auto.db.mongo"
+
+// databaseSystem is the "db.system" data of spans.
+const databaseSystem = "mongodb"
+
+// Options configure a command monitor.
+type Options struct {
+	// CaptureErrors configures whether command failures are reported to
+	// Sentry, in addition to being recorded as breadcrumbs and setting the
+	// status of the span. Failures are reported to the hub of the command
+	// context, or to the current hub.
+	CaptureErrors bool
+}
+
+// spanKey identifies a command in progress.
+type spanKey struct {
+	connectionID string
+	requestID    int64
+}
+
+type monitor struct {
+	options Options
+
+	mu    sync.Mutex
+	spans map[spanKey]*sentry.Span
+}
+
+// NewMonitor returns a command monitor instrumenting the commands of a
+// client. Use it with options.ClientOptions.SetMonitor.
+func NewMonitor(options Options) *event.CommandMonitor {
+	m := &monitor{
+		options: options,
+		spans:   make(map[spanKey]*sentry.Span),
+	}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+	}
+}
+
+func (m *monitor) started(ctx context.Context, e *event.CommandStartedEvent) {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		return
+	}
+
+	span := parent.StartChild(spanOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.Description = e.CommandName
+	span.SetData("db.system", databaseSystem)
+	span.SetData("db.name", e.DatabaseName)
+	span.SetData("db.operation", e.CommandName)
+	// The first element of a command is the command name, whose value is
+	// the collection for commands operating on a collection.
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		span.Description += " " + collection
+		span.SetData("db.mongodb.collection", collection)
+	}
+
+	m.mu.Lock()
+	m.spans[spanKey{e.ConnectionID, e.RequestID}] = span
+	m.mu.Unlock()
+}
+
+func (m *monitor) succeeded(_ context.Context, e *event.CommandSucceededEvent) {
+	if span := m.finished(e.CommandFinishedEvent); span != nil {
+		span.Status = sentry.SpanStatusOK
+		span.Finish()
+	}
+}
+
+func (m *monitor) failed(ctx context.Context, e *event.CommandFailedEvent) {
+	err := errors.New(e.Failure)
+	if span := m.finished(e.CommandFinishedEvent); span != nil {
+		span.SetError(err)
+		span.Finish()
+	}
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Type:     "error",
+		Category: "mongodb",
+		Message:  fmt.Sprintf("%s failed: %s", e.CommandName, e.Failure),
+		Data: map[string]interface{}{
+			"database":    e.DatabaseName,
+			"command":     e.CommandName,
+			"duration_ms": e.Duration.Milliseconds(),
+		},
+		Level: sentry.LevelError,
+	}, nil)
+
+	if m.options.CaptureErrors &&
+		!errors.Is(ctx.Err(), context.Canceled) &&
+		!errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetContext("database", sentry.Context{
+				"system":  databaseSystem,
+				"name":    e.DatabaseName,
+				"command": e.CommandName,
+			})
+			hub.CaptureException(err)
+		})
+	}
+}
+
+// finished returns the span of a finished command, if any.
+func (m *monitor) finished(e event.CommandFinishedEvent) *sentry.Span {
+	key := spanKey{e.ConnectionID, e.RequestID}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	span := m.spans[key]
+	delete(m.spans, key)
+	return span
+}
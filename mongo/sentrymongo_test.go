@@ -0,0 +1,132 @@
+package sentrymongo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrymongo "github.com/getsentry/sentry-go/mongo"
+	"github.com/google/go-cmp/cmp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestMonitor(t *testing.T) {
+	var errors, transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			errors = append(errors, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	monitor := sentrymongo.NewMonitor(sentrymongo.Options{CaptureErrors: true})
+
+	command := func(ctx context.Context, requestID int64, name string, doc bson.D, failure string) {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		monitor.Started(ctx, &event.CommandStartedEvent{
+			Command:      raw,
+			DatabaseName: "app",
+			CommandName:  name,
+			RequestID:    requestID,
+			ConnectionID: "localhost:27017[-1]",
+		})
+		finished := event.CommandFinishedEvent{
+			CommandName:  name,
+			DatabaseName: "app",
+			RequestID:    requestID,
+			ConnectionID: "localhost:27017[-1]",
+		}
+		if failure != "" {
+			monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished, Failure: failure})
+		} else {
+			monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished})
+		}
+	}
+
+	// Commands without a span are not recorded.
+	command(ctx, 1, "ping", bson.D{{Key: "ping", Value: 1}}, "")
+
+	transaction := sentry.StartTransaction(ctx, "test")
+	command(transaction.Context(), 2, "find", bson.D{{Key: "find", Value: "users"}, {Key: "filter", Value: bson.D{}}}, "")
+	command(transaction.Context(), 3, "insert", bson.D{{Key: "insert", Value: "users"}}, "E11000 duplicate key error")
+	transaction.Finish()
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	spans := transactions[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for i, want := range []struct {
+		description string
+		status      sentry.SpanStatus
+		data        map[string]interface{}
+	}{
+		{
+			description: "find users",
+			status:      sentry.SpanStatusOK,
+			data: map[string]interface{}{
+				"db.system":             "mongodb",
+				"db.name":               "app",
+				"db.operation":          "find",
+				"db.mongodb.collection": "users",
+			},
+		},
+		{
+			description: "insert users",
+			status:      sentry.SpanStatusInternalError,
+			data: map[string]interface{}{
+				"db.system":             "mongodb",
+				"db.name":               "app",
+				"db.operation":          "insert",
+				"db.mongodb.collection": "users",
+			},
+		},
+	} {
+		if diff := cmp.Diff(want.description, spans[i].Description); diff != "" {
+			t.Errorf("span %d description mismatch (-want +got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(want.status, spans[i].Status); diff != "" {
+			t.Errorf("span %d status mismatch (-want +got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(want.data, spans[i].Data); diff != "" {
+			t.Errorf("span %d data mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+
+	if len(errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errors))
+	}
+	if diff := cmp.Diff("E11000 duplicate key error", errors[0].Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.Context{
+		"system":  "mongodb",
+		"name":    "app",
+		"command": "insert",
+	}, errors[0].Contexts["database"]); diff != "" {
+		t.Errorf("database context mismatch (-want +got):\n%s", diff)
+	}
+	breadcrumbs := errors[0].Breadcrumbs
+	if len(breadcrumbs) != 1 {
+		t.Fatalf("got %d breadcrumbs, want 1", len(breadcrumbs))
+	}
+	if diff := cmp.Diff("insert failed: E11000 duplicate key error", breadcrumbs[0].Message); diff != "" {
+		t.Errorf("breadcrumb message mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,237 @@
+// Package sentrynats provides Sentry instrumentation for NATS and JetStream
+// publishers and subscribers built with github.com/nats-io/nats.go.
+//
+// Publishers send messages in "queue.publish" spans, and propagate the trace
+// to subscribers in the sentry-trace and baggage message headers:
+//
+//	err := sentrynats.Publish(ctx, nc, &nats.Msg{Subject: "orders.created", Data: data})
+//
+// Subscribers process messages in "queue.process" transactions continuing
+// that trace, and report the panics of message handlers along with the
+// subject of the message and, for JetStream messages, its stream metadata:
+//
+//	h := sentrynats.New(sentrynats.Options{})
+//	sub, err := nc.Subscribe("orders.*", h.MsgHandler(func(ctx context.Context, msg *nats.Msg) error {
+//		return handle(ctx, msg)
+//	}))
+//	cc, err := consumer.Consume(h.JetStreamHandler(func(ctx context.Context, msg jetstream.Msg) error {
+//		return handle(ctx, msg)
+//	}))
+package sentrynats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The identifier of the NATS SDK.
+const sdkIdentifier = "sentry.go.nats"
+
+// Span operations.
+const (
+	PublishOperation = "queue.publish"
+	ProcessOperation = "queue.process"
+)
+
+// spanOrigin is the origin of spans.
+const spanOrigin = "auto.queue.nats"
+
+// messagingSystem is the "messaging.system" data of spans.
+const messagingSystem = "nats"
+
+// StartPublishSpan starts a "queue.publish" span for msg, as a child of the
+// span in ctx, and sets the headers of msg propagating the trace. It returns
+// nil and leaves msg unchanged if ctx has no span. Finish the span once the
+// message is published.
+func StartPublishSpan(ctx context.Context, msg *nats.Msg) *sentry.Span {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+	span := parent.StartChild(PublishOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.Description = msg.Subject
+	span.SetData("messaging.system", messagingSystem)
+	span.SetData("messaging.destination.name", msg.Subject)
+
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	msg.Header.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+	if baggage := span.ToBaggage(); baggage != "" {
+		msg.Header.Set(sentry.SentryBaggageHeader, baggage)
+	}
+	return span
+}
+
+// Publish publishes msg with nc in a "queue.publish" span, if ctx has a span.
+func Publish(ctx context.Context, nc *nats.Conn, msg *nats.Msg) error {
+	span := StartPublishSpan(ctx, msg)
+	err := nc.PublishMsg(msg)
+	finishPublishSpan(span, err)
+	return err
+}
+
+// PublishJetStream publishes msg to a JetStream stream with js in a
+// "queue.publish" span, if ctx has a span.
+func PublishJetStream(ctx context.Context, js jetstream.JetStream, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	span := StartPublishSpan(ctx, msg)
+	ack, err := js.PublishMsg(ctx, msg, opts...)
+	if span != nil && ack != nil {
+		span.SetData("messaging.nats.stream", ack.Stream)
+	}
+	finishPublishSpan(span, err)
+	return ack, err
+}
+
+func finishPublishSpan(span *sentry.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.Status = sentry.SpanStatusOK
+	}
+	span.Finish()
+}
+
+// Handler processes consumed messages in transactions, and reports the panics
+// of message handlers to Sentry.
+type Handler struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// Options configure a Handler.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// in a message handler. As the NATS client calls message handlers in its
+	// own goroutines, a panic stops the program.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// New returns a new Handler.
+func New(options Options) *Handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &Handler{
+		repanic:         options.Repanic,
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+// MsgHandler returns a nats.MsgHandler calling handler with a context holding
+// a "queue.process" transaction for the message, which continues the trace
+// propagated in the message headers, and a hub whose scope has the subject
+// of the message, and its stream metadata for JetStream messages, in its
+// "nats" context. The error returned by handler sets the status of the
+// transaction.
+func (h *Handler) MsgHandler(handler func(ctx context.Context, msg *nats.Msg) error) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		var queue string
+		if msg.Sub != nil {
+			queue = msg.Sub.Queue
+		}
+		var metadata *jetstream.MsgMetadata
+		if m, err := msg.Metadata(); err == nil {
+			metadata = &jetstream.MsgMetadata{
+				Sequence:     jetstream.SequencePair{Consumer: m.Sequence.Consumer, Stream: m.Sequence.Stream},
+				NumDelivered: m.NumDelivered,
+				Stream:       m.Stream,
+				Consumer:     m.Consumer,
+			}
+		}
+		_ = h.process(msg.Subject, queue, msg.Header, metadata, func(ctx context.Context) error {
+			return handler(ctx, msg)
+		})
+	}
+}
+
+// JetStreamHandler returns a jetstream.MessageHandler calling handler as
+// MsgHandler does.
+func (h *Handler) JetStreamHandler(handler func(ctx context.Context, msg jetstream.Msg) error) jetstream.MessageHandler {
+	return func(msg jetstream.Msg) {
+		metadata, err := msg.Metadata()
+		if err != nil {
+			metadata = nil
+		}
+		_ = h.process(msg.Subject(), "", msg.Headers(), metadata, func(ctx context.Context) error {
+			return handler(ctx, msg)
+		})
+	}
+}
+
+// process calls process in the transaction of a message.
+func (h *Handler) process(subject, queue string, header nats.Header, metadata *jetstream.MsgMetadata, process func(ctx context.Context) error) (err error) {
+	hub := sentry.CurrentHub().Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	natsContext := sentry.Context{"subject": subject}
+	data := map[string]interface{}{
+		"messaging.system":           messagingSystem,
+		"messaging.destination.name": subject,
+	}
+	if queue != "" {
+		natsContext["queue"] = queue
+		data["messaging.nats.queue"] = queue
+	}
+	if metadata != nil {
+		natsContext["stream"] = metadata.Stream
+		natsContext["consumer"] = metadata.Consumer
+		natsContext["stream_sequence"] = metadata.Sequence.Stream
+		natsContext["consumer_sequence"] = metadata.Sequence.Consumer
+		natsContext["num_delivered"] = metadata.NumDelivered
+		data["messaging.nats.stream"] = metadata.Stream
+		data["messaging.nats.consumer"] = metadata.Consumer
+		data["messaging.message.delivery_count"] = metadata.NumDelivered
+	}
+	hub.Scope().SetContext("nats", natsContext)
+
+	transaction := sentry.StartTransaction(ctx, subject,
+		sentry.WithOpName(ProcessOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(header.Get(sentry.SentryTraceHeader), header.Get(sentry.SentryBaggageHeader)),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.Data = data
+	defer transaction.Finish()
+
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Status = sentry.SpanStatusInternalError
+			eventID := hub.RecoverWithContext(transaction.Context(), r)
+			if eventID != nil && h.waitForDelivery {
+				hub.Flush(h.timeout)
+			}
+			if h.repanic {
+				panic(r)
+			}
+			err = fmt.Errorf("sentrynats: panic processing message: %v", r)
+		}
+	}()
+
+	err = process(transaction.Context())
+	if err != nil {
+		transaction.SetError(err)
+	} else {
+		transaction.Status = sentry.SpanStatusOK
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+package sentrynats_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrynats "github.com/getsentry/sentry-go/nats"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats.go"
+)
+
+func initSentry(t *testing.T) *[]*sentry.Event {
+	var events []*sentry.Event
+	record := func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		events = append(events, event)
+		return nil
+	}
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record,
+		BeforeSendTransaction: record,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &events
+}
+
+func TestPublishAndProcess(t *testing.T) {
+	events := initSentry(t)
+
+	msg := &nats.Msg{Subject: "orders.created", Sub: &nats.Subscription{Queue: "workers"}}
+	producer := sentry.StartTransaction(context.Background(), "producer")
+	span := sentrynats.StartPublishSpan(producer.Context(), msg)
+	if span == nil {
+		t.Fatal("publish span not started")
+	}
+	span.Finish()
+	producer.Finish()
+	if msg.Header.Get(sentry.SentryTraceHeader) == "" || msg.Header.Get(sentry.SentryBaggageHeader) == "" {
+		t.Fatalf("trace headers not set: %v", msg.Header)
+	}
+
+	var processed bool
+	sentrynats.New(sentrynats.Options{}).MsgHandler(func(ctx context.Context, msg *nats.Msg) error {
+		processed = sentry.SpanFromContext(ctx) != nil
+		return nil
+	})(msg)
+	if !processed {
+		t.Error("handler called without a span")
+	}
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2", len(*events))
+	}
+	publish, process := (*events)[0], (*events)[1]
+	if diff := cmp.Diff(sentrynats.PublishOperation, publish.Spans[0].Op); diff != "" {
+		t.Errorf("publish span op mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(publish.Spans[0].SpanID.String(), process.Contexts["trace"]["parent_span_id"].(sentry.SpanID).String()); diff != "" {
+		t.Errorf("process transaction isn't a child of the publish span (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentrynats.ProcessOperation, process.Contexts["trace"]["op"]); diff != "" {
+		t.Errorf("process transaction op mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusOK, process.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("process transaction status mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("orders.created", process.Transaction); diff != "" {
+		t.Errorf("process transaction name mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStartPublishSpanWithoutSpan(t *testing.T) {
+	msg := &nats.Msg{Subject: "orders.created"}
+	if span := sentrynats.StartPublishSpan(context.Background(), msg); span != nil {
+		t.Error("publish span started without a parent span")
+	}
+	if msg.Header != nil {
+		t.Errorf("headers set without a span: %v", msg.Header)
+	}
+}
+
+func TestMsgHandlerError(t *testing.T) {
+	events := initSentry(t)
+
+	sentrynats.New(sentrynats.Options{}).MsgHandler(func(ctx context.Context, msg *nats.Msg) error {
+		return errors.New("not found")
+	})(&nats.Msg{Subject: "orders.created"})
+
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, (*events)[0].Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMsgHandlerPanic(t *testing.T) {
+	events := initSentry(t)
+
+	sentrynats.New(sentrynats.Options{}).MsgHandler(func(ctx context.Context, msg *nats.Msg) error {
+		panic("boom")
+	})(&nats.Msg{
+		Subject: "orders.created",
+		Reply:   "$JS.ACK.ORDERS.processor.2.42.7.1700000000000000000.0",
+		Sub:     &nats.Subscription{},
+	})
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2", len(*events))
+	}
+	event, transaction := (*events)[0], (*events)[1]
+	if diff := cmp.Diff("boom", event.Message); diff != "" {
+		t.Errorf("panic message mismatch (-want +got):\n%s", diff)
+	}
+	want := sentry.Context{
+		"subject":           "orders.created",
+		"stream":            "ORDERS",
+		"consumer":          "processor",
+		"stream_sequence":   uint64(42),
+		"consumer_sequence": uint64(7),
+		"num_delivered":     uint64(2),
+	}
+	if diff := cmp.Diff(want, event.Contexts["nats"]); diff != "" {
+		t.Errorf("nats context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transaction.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMsgHandlerRepanic(t *testing.T) {
+	initSentry(t)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("got panic %v, want boom", r)
+		}
+	}()
+	sentrynats.New(sentrynats.Options{Repanic: true}).MsgHandler(func(ctx context.Context, msg *nats.Msg) error {
+		panic("boom")
+	})(&nats.Msg{Subject: "orders.created"})
+}
@@ -0,0 +1,18 @@
+module github.com/getsentry/sentry-go/openfeature
+
+go 1.18
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.5.9
+	github.com/open-feature/go-sdk v1.8.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	golang.org/x/exp v0.0.0-20230811145659-89c5cff77bcb // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
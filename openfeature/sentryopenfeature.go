@@ -0,0 +1,41 @@
+// Package sentryopenfeature provides an OpenFeature hook that records the
+// feature flags evaluated by an application, so that they are attached to the
+// errors reported to Sentry.
+package sentryopenfeature
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// Hook is an OpenFeature hook recording the results of boolean flag
+// evaluations in the scope of the hub bound to the context of the evaluation,
+// or of the current hub if there is none. Errors captured in that scope then
+// include the flags in their "flags" context.
+//
+//	openfeature.AddHooks(sentryopenfeature.NewHook())
+type Hook struct {
+	openfeature.UnimplementedHook
+}
+
+// NewHook returns a new Hook.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// After records the result of a successful flag evaluation. Flags that don't
+// evaluate to a boolean are ignored.
+func (h *Hook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, hookHints openfeature.HookHints) error {
+	result, ok := details.Value.(bool)
+	if !ok {
+		return nil
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.Scope().AddFeatureFlag(details.FlagKey, result)
+	return nil
+}
@@ -0,0 +1,50 @@
+package sentryopenfeature
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-cmp/cmp"
+	"github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+func TestHookRecordsFlags(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	flags := openfeature.NewClient("test")
+	flags.AddHooks(NewHook())
+	if _, err := flags.BooleanValue(ctx, "new-checkout", true, openfeature.EvaluationContext{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := flags.BooleanValue(ctx, "dark-mode", false, openfeature.EvaluationContext{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := flags.StringValue(ctx, "theme", "blue", openfeature.EvaluationContext{}); err != nil {
+		t.Fatal(err)
+	}
+	hub.CaptureException(errors.New("checkout failed"))
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	want := sentry.Context{"values": []sentry.FeatureFlag{
+		{Flag: "new-checkout", Result: true},
+		{Flag: "dark-mode", Result: false},
+	}}
+	if diff := cmp.Diff(want, events[0].Contexts["flags"]); diff != "" {
+		t.Errorf("flags context mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -50,6 +50,7 @@ func TestLinkTraceContextToErrorEventSetsContext(t *testing.T) {
 					"trace_id":       sentrySpan.TraceID.String(),
 					"span_id":        sentrySpan.SpanID.String(),
 					"parent_span_id": sentrySpan.ParentSpanID.String(),
+					"origin":         sentrySpan.Origin,
 				},
 			)
 		})
@@ -136,6 +136,7 @@ func (t *TransportMock) SendEvent(event *sentry.Event) {
 	t.events = append(t.events, event)
 	t.lastEvent = event
 }
+func (t *TransportMock) SendEnvelope(envelope *sentry.Envelope) {}
 func (t *TransportMock) Flush(timeout time.Duration) bool {
 	return true
 }
@@ -0,0 +1,224 @@
+// Package sentryotellog provides an OpenTelemetry log processor reporting the
+// log records of the OpenTelemetry SDK to Sentry.
+//
+// Records at EventSeverity or above are captured as events, and records at
+// BreadcrumbSeverity or above as breadcrumbs, with the hub of the context of
+// the record, or the current hub. Register the processor with the logger
+// provider, along with the processors exporting the records elsewhere:
+//
+//	provider := log.NewLoggerProvider(
+//		log.WithProcessor(log.NewBatchProcessor(exporter)),
+//		log.WithProcessor(sentryotellog.NewProcessor(sentryotellog.Options{})),
+//	)
+//
+// The attributes of records are attached to events as extra data, and the
+// attributes of the resource of records as tags. Events are linked to the
+// trace and span of their record, if any. The exception.type and
+// exception.message attributes of records, as set by the OpenTelemetry
+// semantic conventions, are captured as the exception of events.
+package sentryotellog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// The identifier of the OpenTelemetry log SDK.
+const sdkIdentifier = "sentry.go.otel.log"
+
+// loggerName is the category of breadcrumbs, and the logger of events of
+// records without an instrumentation scope.
+const loggerName = "otel"
+
+// defaultFlushTimeout is the timeout of ForceFlush for contexts without a
+// deadline.
+const defaultFlushTimeout = 2 * time.Second
+
+// Options configure a Processor.
+type Options struct {
+	// EventSeverity is the minimum severity of the records captured as
+	// events. Defaults to log.SeverityError.
+	EventSeverity log.Severity
+	// BreadcrumbSeverity is the minimum severity of the records added as
+	// breadcrumbs, when lower than EventSeverity. Defaults to
+	// log.SeverityInfo.
+	BreadcrumbSeverity log.Severity
+}
+
+// Processor is an sdklog.Processor reporting records to Sentry.
+type Processor struct {
+	eventSeverity      log.Severity
+	breadcrumbSeverity log.Severity
+}
+
+var _ sdklog.Processor = (*Processor)(nil)
+
+// NewProcessor returns a new Processor.
+func NewProcessor(options Options) *Processor {
+	p := &Processor{
+		eventSeverity:      options.EventSeverity,
+		breadcrumbSeverity: options.BreadcrumbSeverity,
+	}
+	if p.eventSeverity == log.SeverityUndefined {
+		p.eventSeverity = log.SeverityError
+	}
+	if p.breadcrumbSeverity == log.SeverityUndefined {
+		p.breadcrumbSeverity = log.SeverityInfo
+	}
+	return p
+}
+
+// OnEmit implements sdklog.Processor. It never fails.
+func (p *Processor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	severity := record.Severity()
+	if severity < p.eventSeverity && severity < p.breadcrumbSeverity {
+		return nil
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	attrs := make(map[string]interface{}, record.AttributesLen())
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = value(kv.Value)
+		return true
+	})
+	message := record.Body().String()
+	if record.Body().Kind() == log.KindString {
+		message = record.Body().AsString()
+	}
+
+	if severity < p.eventSeverity {
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Type:      "default",
+			Category:  loggerName,
+			Message:   message,
+			Data:      attrs,
+			Level:     sentryLevel(severity),
+			Timestamp: timestamp(record),
+		}, nil)
+		return nil
+	}
+
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+	client.SetSDKIdentifier(sdkIdentifier)
+
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(severity)
+	event.Message = message
+	event.Logger = loggerName
+	if scope := record.InstrumentationScope(); scope.Name != "" {
+		event.Logger = scope.Name
+	}
+	event.Timestamp = timestamp(record)
+	exceptionType, _ := attrs["exception.type"].(string)
+	exceptionMessage, _ := attrs["exception.message"].(string)
+	if exceptionType != "" || exceptionMessage != "" {
+		if exceptionType == "" {
+			exceptionType = "error"
+		}
+		event.Exception = []sentry.Exception{{Type: exceptionType, Value: exceptionMessage}}
+		delete(attrs, "exception.type")
+		delete(attrs, "exception.message")
+	}
+	event.Extra = attrs
+	resource := record.Resource()
+	for iter := resource.Iter(); iter.Next(); {
+		attr := iter.Attribute()
+		event.Tags[string(attr.Key)] = attr.Value.Emit()
+	}
+	if traceID := record.TraceID(); traceID.IsValid() {
+		trace := sentry.Context{"trace_id": traceID.String()}
+		if spanID := record.SpanID(); spanID.IsValid() {
+			trace["span_id"] = spanID.String()
+		}
+		event.Contexts["trace"] = trace
+	}
+	hub.CaptureEvent(event)
+	return nil
+}
+
+// Shutdown implements sdklog.Processor.
+func (p *Processor) Shutdown(ctx context.Context) error {
+	return p.ForceFlush(ctx)
+}
+
+// ForceFlush implements sdklog.Processor. It waits until the events of the
+// current hub are sent, until the deadline of ctx, or for at most 2 seconds.
+func (p *Processor) ForceFlush(ctx context.Context) error {
+	timeout := defaultFlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if !sentry.CurrentHub().Flush(timeout) {
+		return fmt.Errorf("sentryotellog: flush timed out after %s", timeout)
+	}
+	return nil
+}
+
+// sentryLevel returns the Sentry level of an OpenTelemetry severity.
+func sentryLevel(severity log.Severity) sentry.Level {
+	switch {
+	case severity >= log.SeverityFatal:
+		return sentry.LevelFatal
+	case severity >= log.SeverityError:
+		return sentry.LevelError
+	case severity >= log.SeverityWarn:
+		return sentry.LevelWarning
+	case severity >= log.SeverityInfo:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
+
+// timestamp returns the timestamp of record, or the time it was observed if it
+// has none.
+func timestamp(record *sdklog.Record) time.Time {
+	if t := record.Timestamp(); !t.IsZero() {
+		return t
+	}
+	if t := record.ObservedTimestamp(); !t.IsZero() {
+		return t
+	}
+	return time.Now()
+}
+
+// value returns the Go value of an OpenTelemetry log value.
+func value(v log.Value) interface{} {
+	switch v.Kind() {
+	case log.KindBool:
+		return v.AsBool()
+	case log.KindInt64:
+		return v.AsInt64()
+	case log.KindFloat64:
+		return v.AsFloat64()
+	case log.KindString:
+		return v.AsString()
+	case log.KindBytes:
+		return v.AsBytes()
+	case log.KindSlice:
+		values := make([]interface{}, 0, len(v.AsSlice()))
+		for _, e := range v.AsSlice() {
+			values = append(values, value(e))
+		}
+		return values
+	case log.KindMap:
+		values := make(map[string]interface{}, len(v.AsMap()))
+		for _, kv := range v.AsMap() {
+			values[kv.Key] = value(kv.Value)
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package sentryotellog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryotellog "github.com/getsentry/sentry-go/otel/log"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestProcessor(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(resource.NewSchemaless(attribute.String("service.name", "checkout"))),
+		sdklog.WithProcessor(sentryotellog.NewProcessor(sentryotellog.Options{})),
+	)
+	logger := provider.Logger("payments")
+
+	emit := func(ctx context.Context, severity log.Severity, body string, attrs ...log.KeyValue) {
+		var record log.Record
+		record.SetSeverity(severity)
+		record.SetBody(log.StringValue(body))
+		record.AddAttributes(attrs...)
+		logger.Emit(ctx, record)
+	}
+	emit(ctx, log.SeverityDebug, "ignored")
+	emit(ctx, log.SeverityInfo, "charging", log.Int("amount", 42))
+	spanCtx := trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	}))
+	emit(spanCtx, log.SeverityError2, "charge failed",
+		log.String("exception.type", "CardError"),
+		log.String("exception.message", "card declined"),
+		log.Map("card", log.String("brand", "visa")),
+	)
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff(sentry.LevelError, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("charge failed", event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("payments", event.Logger); diff != "" {
+		t.Errorf("logger mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]sentry.Exception{{Type: "CardError", Value: "card declined"}}, event.Exception); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"card": map[string]interface{}{"brand": "visa"}}, event.Extra); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("checkout", event.Tags["service.name"]); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+	wantTrace := sentry.Context{
+		"trace_id": trace.TraceID{1}.String(),
+		"span_id":  trace.SpanID{2}.String(),
+	}
+	if diff := cmp.Diff(wantTrace, event.Contexts["trace"]); diff != "" {
+		t.Errorf("trace mismatch (-want +got):\n%s", diff)
+	}
+
+	wantBreadcrumbs := []*sentry.Breadcrumb{{
+		Type:     "default",
+		Category: "otel",
+		Message:  "charging",
+		Data:     map[string]interface{}{"amount": int64(42)},
+		Level:    sentry.LevelInfo,
+	}}
+	if diff := cmp.Diff(wantBreadcrumbs, event.Breadcrumbs, cmpIgnoreTimestamp); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+var cmpIgnoreTimestamp = cmp.FilterPath(func(p cmp.Path) bool {
+	return p.Last().String() == ".Timestamp"
+}, cmp.Ignore())
@@ -18,6 +18,9 @@ type sentrySpanProcessor struct{}
 // At the moment we do not support multiple instances.
 var sentrySpanProcessorInstance *sentrySpanProcessor
 
+// spanOrigin is the origin of the spans converted from OpenTelemetry spans.
+const spanOrigin = "auto.otel"
+
 func NewSentrySpanProcessor() otelSdkTrace.SpanProcessor {
 	if sentrySpanProcessorInstance != nil {
 		return sentrySpanProcessorInstance
@@ -40,7 +43,7 @@ func (ssp *sentrySpanProcessor) OnStart(parent context.Context, s otelSdkTrace.R
 	}
 
 	if sentryParentSpan != nil {
-		span := sentryParentSpan.StartChild(s.Name())
+		span := sentryParentSpan.StartChild(s.Name(), sentry.WithSpanOrigin(spanOrigin))
 		span.SpanID = sentry.SpanID(otelSpanID)
 		span.StartTime = s.StartTime()
 
@@ -51,6 +54,7 @@ func (ssp *sentrySpanProcessor) OnStart(parent context.Context, s otelSdkTrace.R
 			parent,
 			s.Name(),
 			sentry.WithSpanSampled(traceParentContext.Sampled),
+			sentry.WithSpanOrigin(spanOrigin),
 		)
 		transaction.SpanID = sentry.SpanID(otelSpanID)
 		transaction.TraceID = sentry.TraceID(otelTraceID)
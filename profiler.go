@@ -44,7 +44,7 @@ func getCurrentGoID() uint64 {
 	// We shouldn't panic but let's be super safe.
 	defer func() {
 		if err := recover(); err != nil {
-			Logger.Printf("Profiler panic in getCurrentGoID(): %v\n", err)
+			debugf(LevelError, "Profiler panic in getCurrentGoID(): %v\n", err)
 		}
 	}()
 
@@ -130,7 +130,7 @@ func (p *profileRecorder) run(started chan struct{}) {
 	// We shouldn't panic but let's be super safe.
 	defer func() {
 		if err := recover(); err != nil {
-			Logger.Printf("Profiler panic in run(): %v\n", err)
+			debugf(LevelError, "Profiler panic in run(): %v\n", err)
 		}
 		atomic.StoreInt64(&testProfilerPanic, 0)
 		close(started)
@@ -141,7 +141,7 @@ func (p *profileRecorder) run(started chan struct{}) {
 
 	p.testProfilerPanic = atomic.LoadInt64(&testProfilerPanic)
 	if p.testProfilerPanic < 0 {
-		Logger.Printf("Profiler panicking during startup because testProfilerPanic == %v\n", p.testProfilerPanic)
+		debugf(LevelDebug, "Profiler panicking during startup because testProfilerPanic == %v\n", p.testProfilerPanic)
 		panic("This is an expected panic in profilerGoroutine() during tests")
 	}
 
@@ -266,9 +266,9 @@ func (p *profileRecorder) onTick() {
 	elapsedNs := time.Since(p.startTime).Nanoseconds()
 
 	if p.testProfilerPanic > 0 {
-		Logger.Printf("Profiler testProfilerPanic == %v\n", p.testProfilerPanic)
+		debugf(LevelDebug, "Profiler testProfilerPanic == %v\n", p.testProfilerPanic)
 		if p.testProfilerPanic == 1 {
-			Logger.Println("Profiler panicking onTick()")
+			debugf(LevelDebug, "Profiler panicking onTick()")
 			panic("This is an expected panic in Profiler.OnTick() during tests")
 		}
 		p.testProfilerPanic--
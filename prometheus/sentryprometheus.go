@@ -0,0 +1,220 @@
+// Package sentryprometheus forwards the metrics of an in-process Prometheus
+// registry to Sentry, to reuse existing instrumentation.
+//
+// Start a bridge gathering the registry periodically:
+//
+//	bridge := sentryprometheus.NewBridge(sentryprometheus.Options{
+//		Gatherer: prometheus.DefaultGatherer,
+//		Filter: func(name string) bool {
+//			return strings.HasPrefix(name, "http_")
+//		},
+//	})
+//	stop := bridge.Start(ctx)
+//	defer stop()
+//
+// Each series is forwarded as a Sentry metric named after its family, with its
+// labels as tags:
+//
+//   - gauges and untyped metrics as gauges
+//   - counters as counters, incremented by the increase of the series since
+//     the previous gather. The first gather only records the values of the
+//     series, which include the increases from before the bridge started.
+//   - histograms and summaries as the counters name_count and name_sum, and
+//     the quantiles of summaries as the gauge name with the "quantile" tag
+//
+// Metric families ending with _seconds or _bytes have the unit "second" or
+// "byte".
+package sentryprometheus
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// defaultInterval is the default interval at which a started bridge gathers
+// metrics.
+const defaultInterval = 10 * time.Second
+
+// Options configure a Bridge.
+type Options struct {
+	// Gatherer is the registry the metrics are gathered from. Defaults to
+	// prometheus.DefaultGatherer.
+	Gatherer prometheus.Gatherer
+	// Filter selects the metric families forwarded to Sentry, by name. All
+	// metric families are forwarded if nil.
+	Filter func(name string) bool
+	// Interval is the interval at which a started bridge gathers metrics.
+	// Defaults to 10 seconds.
+	Interval time.Duration
+}
+
+// Bridge forwards the metrics of a Prometheus registry to Sentry. It is safe
+// for concurrent use.
+type Bridge struct {
+	options Options
+
+	mu sync.Mutex
+	// counters are the values of the counters at the previous gather, by
+	// series.
+	counters map[string]float64
+	// gathered reports whether the registry was gathered before.
+	gathered bool
+}
+
+// NewBridge returns a new Bridge.
+func NewBridge(options Options) *Bridge {
+	if options.Gatherer == nil {
+		options.Gatherer = prometheus.DefaultGatherer
+	}
+	if options.Interval <= 0 {
+		options.Interval = defaultInterval
+	}
+	return &Bridge{
+		options:  options,
+		counters: make(map[string]float64),
+	}
+}
+
+// Start starts forwarding metrics with the hub of ctx, or the current hub,
+// once right away and then periodically, until the returned function is
+// called.
+func (b *Bridge) Start(ctx context.Context) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(b.options.Interval)
+		defer ticker.Stop()
+		for {
+			_ = b.Forward(ctx)
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// Forward gathers the metrics of the registry and emits them with the hub of
+// ctx, or the current hub. The metrics gathered despite an error of the
+// registry are forwarded, and the error is returned.
+func (b *Bridge) Forward(ctx context.Context) error {
+	families, err := b.options.Gatherer.Gather()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for _, family := range families {
+		name := family.GetName()
+		if b.options.Filter != nil && !b.options.Filter(name) {
+			continue
+		}
+		unit := unitOf(name)
+		for _, m := range family.GetMetric() {
+			tags := labels(m)
+			options := []metrics.Option{metrics.WithUnit(unit), metrics.WithTags(tags), metrics.WithTimestamp(now)}
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				metrics.Gauge(ctx, name, m.GetGauge().GetValue(), options...)
+			case dto.MetricType_UNTYPED:
+				metrics.Gauge(ctx, name, m.GetUntyped().GetValue(), options...)
+			case dto.MetricType_COUNTER:
+				b.increment(ctx, name, tags, m.GetCounter().GetValue(), options)
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := m.GetHistogram()
+				b.increment(ctx, name+"_count", tags, float64(h.GetSampleCount()), []metrics.Option{metrics.WithTags(tags), metrics.WithTimestamp(now)})
+				b.increment(ctx, name+"_sum", tags, h.GetSampleSum(), options)
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				b.increment(ctx, name+"_count", tags, float64(s.GetSampleCount()), []metrics.Option{metrics.WithTags(tags), metrics.WithTimestamp(now)})
+				b.increment(ctx, name+"_sum", tags, s.GetSampleSum(), options)
+				for _, q := range s.GetQuantile() {
+					quantileTags := make(map[string]string, len(tags)+1)
+					for k, v := range tags {
+						quantileTags[k] = v
+					}
+					quantileTags["quantile"] = strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64)
+					metrics.Gauge(ctx, name, q.GetValue(), metrics.WithUnit(unit), metrics.WithTags(quantileTags), metrics.WithTimestamp(now))
+				}
+			}
+		}
+	}
+	b.gathered = true
+	return err
+}
+
+// increment increments the counter name by the increase of the cumulative
+// value of the series since the previous gather. A value lower than the
+// previous one means the series was reset, and is the increase itself. The
+// series of the first gather are only recorded, and the ones appearing later
+// are incremented by their value.
+func (b *Bridge) increment(ctx context.Context, name string, tags map[string]string, value float64, options []metrics.Option) {
+	key := seriesKey(name, tags)
+	previous, ok := b.counters[key]
+	b.counters[key] = value
+	if !ok && !b.gathered {
+		return
+	}
+	delta := value
+	if ok && value >= previous {
+		delta = value - previous
+	}
+	if delta == 0 {
+		return
+	}
+	metrics.Incr(ctx, name, delta, options...)
+}
+
+// labels returns the labels of m as tags.
+func labels(m *dto.Metric) map[string]string {
+	if len(m.GetLabel()) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		tags[label.GetName()] = label.GetValue()
+	}
+	return tags
+}
+
+// seriesKey returns a string uniquely identifying the series name with tags.
+func seriesKey(name string, tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// unitOf returns the unit of a metric family, following the naming
+// conventions of Prometheus.
+func unitOf(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "second"
+	case strings.HasSuffix(name, "_bytes"):
+		return "byte"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,140 @@
+package sentryprometheus_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentryprometheus "github.com/getsentry/sentry-go/prometheus"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type transport struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (t *transport) Configure(sentry.ClientOptions) {}
+func (t *transport) SendEvent(*sentry.Event)        {}
+func (t *transport) Flush(time.Duration) bool       { return true }
+func (t *transport) SendEnvelope(envelope *sentry.Envelope) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, item := range envelope.Items {
+		if item.Type != "statsd" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(item.Payload), "\n"), "\n") {
+			// Drop the timestamp of the bucket.
+			line, _, _ = strings.Cut(line, "|T")
+			t.lines = append(t.lines, line)
+		}
+	}
+}
+
+func setup(t *testing.T) (context.Context, *sentry.Hub, *transport) {
+	t.Helper()
+	tr := &transport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:                  "http://whatever@example.com/1337",
+		Release:              "app@1.0.0",
+		Transport:            tr,
+		DisableClientReports: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	return sentry.SetHubOnContext(context.Background(), hub), hub, tr
+}
+
+func TestBridgeForward(t *testing.T) {
+	ctx, hub, tr := setup(t)
+
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total"}, []string{"code"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "http_inflight"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "http_duration_seconds"})
+	size := prometheus.NewSummary(prometheus.SummaryOpts{Name: "http_response_bytes", Objectives: map[float64]float64{0.5: 0.05}})
+	ignored := prometheus.NewGauge(prometheus.GaugeOpts{Name: "ignored"})
+	registry.MustRegister(requests, inflight, duration, size, ignored)
+
+	bridge := sentryprometheus.NewBridge(sentryprometheus.Options{
+		Gatherer: registry,
+		Filter: func(name string) bool {
+			return strings.HasPrefix(name, "http_")
+		},
+	})
+
+	requests.WithLabelValues("200").Add(3)
+	inflight.Set(2)
+	duration.Observe(0.5)
+	size.Observe(100)
+	if err := bridge.Forward(ctx); err != nil {
+		t.Fatal(err)
+	}
+	requests.WithLabelValues("200").Add(2)
+	requests.WithLabelValues("500").Inc()
+	inflight.Set(1)
+	duration.Observe(0.25)
+	if err := bridge.Forward(ctx); err != nil {
+		t.Fatal(err)
+	}
+	hub.Flush(time.Second)
+
+	// The counters of the first gather are the baseline of the second one,
+	// and the series created since are forwarded with their value.
+	want := []string{
+		"http_duration_seconds_count@none:1|c|#release:app@1.0.0",
+		"http_duration_seconds_sum@second:0.25|c|#release:app@1.0.0",
+		"http_inflight@none:1:1:2:3:2|g|#release:app@1.0.0",
+		"http_requests_total@none:2|c|#code:200,release:app@1.0.0",
+		"http_requests_total@none:1|c|#code:500,release:app@1.0.0",
+		"http_response_bytes@byte:100:100:100:200:2|g|#quantile:0.5,release:app@1.0.0",
+	}
+	if diff := cmp.Diff(want, tr.lines); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBridgeForwardFirstGather(t *testing.T) {
+	ctx, hub, tr := setup(t)
+
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounter(prometheus.CounterOpts{Name: "http_requests_total"})
+	registry.MustRegister(requests)
+	requests.Add(1000)
+
+	if err := sentryprometheus.NewBridge(sentryprometheus.Options{Gatherer: registry}).Forward(ctx); err != nil {
+		t.Fatal(err)
+	}
+	hub.Flush(time.Second)
+
+	// The requests counted before the bridge started aren't forwarded.
+	if len(tr.lines) != 0 {
+		t.Errorf("got statsd lines %q, want none", tr.lines)
+	}
+}
+
+func TestBridgeStart(t *testing.T) {
+	ctx, hub, tr := setup(t)
+
+	registry := prometheus.NewRegistry()
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "http_inflight"})
+	registry.MustRegister(inflight)
+	inflight.Set(4)
+
+	stop := sentryprometheus.NewBridge(sentryprometheus.Options{Gatherer: registry}).Start(ctx)
+	stop()
+	stop()
+	hub.Flush(time.Second)
+
+	want := []string{"http_inflight@none:4:4:4:4:1|g|#release:app@1.0.0"}
+	if diff := cmp.Diff(want, tr.lines); diff != "" {
+		t.Errorf("statsd lines mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,85 @@
+package sentry
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set by load balancers and proxies to the time at which they
+// received a request, before forwarding it to the server. See
+// RecordRequestQueueTime.
+const (
+	RequestStartHeader = "X-Request-Start"
+	QueueStartHeader   = "X-Queue-Start"
+)
+
+// queueOperation is the operation of the spans recording the time requests
+// spend queued before reaching the server.
+const queueOperation = "http.server.queue"
+
+// RecordRequestQueueTime records the time r spent queued in a load balancer or
+// proxy before the server started transaction, as read from the
+// X-Request-Start or X-Queue-Start header of r. The transaction is extended to
+// begin when the request was received by the proxy, with an
+// "http.server.queue" child span covering the queueing time, which is also
+// set as the "queue_time" measurement.
+//
+// The header holds a Unix timestamp in seconds, milliseconds, microseconds or
+// nanoseconds, optionally prefixed with "t=", as set by nginx, Apache, HAProxy
+// and Heroku. Nothing is recorded when the header is missing, is invalid, or
+// is later than the start of the transaction. Only call it for requests from
+// trusted proxies, since clients can set the header to any value.
+func RecordRequestQueueTime(transaction *Span, r *http.Request) {
+	start, ok := requestQueueStart(r.Header)
+	if !ok || !start.Before(transaction.StartTime) {
+		return
+	}
+
+	span := transaction.StartChild(queueOperation, WithSpanOrigin(transaction.Origin))
+	span.Description = "request queue"
+	span.StartTime = start
+	span.EndTime = transaction.StartTime
+	span.Finish()
+
+	queueTime := transaction.StartTime.Sub(start)
+	transaction.StartTime = start
+	transaction.SetMeasurement("queue_time", milliseconds(queueTime), MeasurementUnitMillisecond)
+}
+
+// requestQueueStart returns the time read from the X-Request-Start or
+// X-Queue-Start header.
+func requestQueueStart(header http.Header) (time.Time, bool) {
+	value := header.Get(RequestStartHeader)
+	if value == "" {
+		value = header.Get(QueueStartHeader)
+	}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "t=")
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := strconv.ParseFloat(value, 64)
+	if err != nil || t <= 0 || math.IsInf(t, 0) {
+		return time.Time{}, false
+	}
+
+	// Guess the unit from the magnitude of the timestamp, which is unambiguous
+	// for dates between 1973 and 5138.
+	var ns float64
+	switch {
+	case t < 1e11:
+		ns = t * 1e9
+	case t < 1e14:
+		ns = t * 1e6
+	case t < 1e17:
+		ns = t * 1e3
+	default:
+		ns = t
+	}
+	if ns >= math.MaxInt64 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(ns)), true
+}
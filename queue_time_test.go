@@ -0,0 +1,76 @@
+package sentry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRequestQueueStart(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	tests := []struct {
+		header http.Header
+		want   time.Time
+		ok     bool
+	}{
+		{http.Header{}, time.Time{}, false},
+		{http.Header{RequestStartHeader: {"t=1704164645.123"}}, want, true},
+		{http.Header{RequestStartHeader: {"1704164645123"}}, want, true},
+		{http.Header{RequestStartHeader: {"t=1704164645123000"}}, want, true},
+		{http.Header{RequestStartHeader: {"1704164645123000000"}}, want, true},
+		{http.Header{QueueStartHeader: {"t=1704164645123"}}, want, true},
+		{http.Header{RequestStartHeader: {"t="}}, time.Time{}, false},
+		{http.Header{RequestStartHeader: {"yesterday"}}, time.Time{}, false},
+		{http.Header{RequestStartHeader: {"-1"}}, time.Time{}, false},
+		{http.Header{RequestStartHeader: {"1e300"}}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := requestQueueStart(tt.header)
+		if d := got.Sub(tt.want); ok != tt.ok || d > time.Microsecond || d < -time.Microsecond {
+			t.Errorf("requestQueueStart(%v) = %v, %t, want %v, %t", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRecordRequestQueueTime(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+
+	transaction := StartTransaction(ctx, "GET /")
+	start := transaction.StartTime
+	queueStart := start.Add(-50 * time.Millisecond)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	// Times later than the start of the transaction are ignored.
+	r.Header.Set(RequestStartHeader, "t="+strconv.FormatInt(start.Add(time.Second).UnixMicro(), 10))
+	RecordRequestQueueTime(transaction, r)
+	assertEqual(t, transaction.StartTime, start)
+
+	r.Header.Set(RequestStartHeader, "t="+strconv.FormatInt(queueStart.UnixMicro(), 10))
+	RecordRequestQueueTime(transaction, r)
+	transaction.Finish()
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("sent %d events, want 1", len(events))
+	}
+	event := events[0]
+	// Timestamps are parsed as floats, losing precision below a microsecond.
+	if d := queueStart.Sub(event.StartTime); d < -2*time.Microsecond || d > 2*time.Microsecond {
+		t.Errorf("transaction starts at %v, want %v", event.StartTime, queueStart)
+	}
+	assertEqual(t, event.Measurements["queue_time"].Unit, MeasurementUnitMillisecond)
+	if len(event.Spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(event.Spans))
+	}
+	span := event.Spans[0]
+	assertEqual(t, span.Op, "http.server.queue")
+	assertEqual(t, span.StartTime, event.StartTime)
+	assertEqual(t, span.EndTime, start)
+	assertEqual(t, event.Measurements["queue_time"].Value, milliseconds(start.Sub(span.StartTime)))
+}
@@ -0,0 +1,229 @@
+// Package sentryrabbitmq provides Sentry instrumentation for RabbitMQ
+// publishers and consumers built with github.com/rabbitmq/amqp091-go.
+//
+// Publishers send messages in "queue.publish" spans, and propagate the trace
+// to consumers in the sentry-trace and baggage message headers:
+//
+//	err := sentryrabbitmq.Publish(ctx, ch, "orders", "orders.created", false, false, amqp.Publishing{Body: body})
+//
+// Consumers process deliveries in "queue.process" transactions continuing
+// that trace, and report the errors and panics of message handlers along
+// with the exchange, routing key and redelivery flag of the delivery:
+//
+//	h := sentryrabbitmq.New(sentryrabbitmq.Options{})
+//	for d := range deliveries {
+//		err := h.Process(ctx, "orders", d, func(ctx context.Context, d amqp.Delivery) error {
+//			return handle(ctx, d)
+//		})
+//		if err != nil {
+//			d.Nack(false, !d.Redelivered)
+//			continue
+//		}
+//		d.Ack(false)
+//	}
+package sentryrabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// The identifier of the RabbitMQ SDK.
+const sdkIdentifier = "sentry.go.rabbitmq"
+
+// Span operations.
+const (
+	PublishOperation = "queue.publish"
+	ProcessOperation = "queue.process"
+)
+
+// spanOrigin is the origin of spans.
+const spanOrigin = "auto.queue.rabbitmq"
+
+// messagingSystem is the "messaging.system" data of spans.
+const messagingSystem = "rabbitmq"
+
+// StartPublishSpan starts a "queue.publish" span for msg, published to
+// exchange with routing key key, as a child of the span in ctx, and sets the
+// headers of msg propagating the trace. It returns nil and leaves msg
+// unchanged if ctx has no span. Finish the span once the message is
+// published, or confirmed.
+func StartPublishSpan(ctx context.Context, exchange, key string, msg *amqp.Publishing) *sentry.Span {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+	span := parent.StartChild(PublishOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.Description = destination(exchange, key)
+	span.Data = map[string]interface{}{
+		"messaging.system":                           messagingSystem,
+		"messaging.destination.name":                 exchange,
+		"messaging.rabbitmq.destination.routing_key": key,
+	}
+	if msg.MessageId != "" {
+		span.Data["messaging.message.id"] = msg.MessageId
+	}
+
+	// Copy the headers, as the table may be shared by several messages.
+	headers := make(amqp.Table, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[sentry.SentryTraceHeader] = span.ToSentryTrace()
+	if baggage := span.ToBaggage(); baggage != "" {
+		headers[sentry.SentryBaggageHeader] = baggage
+	}
+	msg.Headers = headers
+	return span
+}
+
+// Publish publishes msg with ch to exchange with routing key key, in a
+// "queue.publish" span if ctx has a span.
+func Publish(ctx context.Context, ch *amqp.Channel, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	span := StartPublishSpan(ctx, exchange, key, &msg)
+	err := ch.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	if span != nil {
+		if err != nil {
+			span.SetError(err)
+		} else {
+			span.Status = sentry.SpanStatusOK
+		}
+		span.Finish()
+	}
+	return err
+}
+
+// Handler processes deliveries in transactions, and reports the errors and
+// panics of message handlers to Sentry.
+type Handler struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// Options configure a Handler.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// in a message handler. Otherwise, the panic is returned as an error.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// New returns a new Handler.
+func New(options Options) *Handler {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &Handler{
+		repanic:         options.Repanic,
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+// Process calls process with a context holding a "queue.process" transaction
+// named after queue, the queue d was consumed from, which continues the trace
+// propagated in the headers of d, and a hub whose scope has the metadata of d
+// in its "rabbitmq" context. The error returned by process is reported to
+// Sentry and sets the status of the transaction.
+func (h *Handler) Process(ctx context.Context, queue string, d amqp.Delivery, process func(ctx context.Context, d amqp.Delivery) error) (err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	rabbitmq := sentry.Context{
+		"queue":        queue,
+		"exchange":     d.Exchange,
+		"routing_key":  d.RoutingKey,
+		"redelivered":  d.Redelivered,
+		"delivery_tag": d.DeliveryTag,
+	}
+	if d.ConsumerTag != "" {
+		rabbitmq["consumer_tag"] = d.ConsumerTag
+	}
+	if d.MessageId != "" {
+		rabbitmq["message_id"] = d.MessageId
+	}
+	scope := hub.Scope()
+	scope.SetContext("rabbitmq", rabbitmq)
+	scope.SetTag("rabbitmq.queue", queue)
+
+	transaction := sentry.StartTransaction(ctx, queue,
+		sentry.WithOpName(ProcessOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(header(d.Headers, sentry.SentryTraceHeader), header(d.Headers, sentry.SentryBaggageHeader)),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.Data = map[string]interface{}{
+		"messaging.system":                           messagingSystem,
+		"messaging.destination.name":                 d.Exchange,
+		"messaging.rabbitmq.destination.routing_key": d.RoutingKey,
+		"messaging.rabbitmq.message.delivery_tag":    d.DeliveryTag,
+		"messaging.rabbitmq.message.redelivered":     d.Redelivered,
+	}
+	if d.MessageId != "" {
+		transaction.Data["messaging.message.id"] = d.MessageId
+	}
+	defer transaction.Finish()
+
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Status = sentry.SpanStatusInternalError
+			eventID := hub.RecoverWithContext(transaction.Context(), r)
+			if eventID != nil && h.waitForDelivery {
+				hub.Flush(h.timeout)
+			}
+			if h.repanic {
+				panic(r)
+			}
+			err = fmt.Errorf("sentryrabbitmq: panic processing delivery: %v", r)
+		}
+	}()
+
+	err = process(transaction.Context(), d)
+	if err != nil {
+		transaction.SetError(err)
+		hub.CaptureException(err)
+	} else {
+		transaction.Status = sentry.SpanStatusOK
+	}
+	return err
+}
+
+// destination returns the description of the span of a message published to
+// exchange with routing key key.
+func destination(exchange, key string) string {
+	if exchange == "" {
+		// The default exchange routes messages to the queue named key.
+		return key
+	}
+	return exchange + " " + key
+}
+
+// header returns the value of a string header of a message, or the empty
+// string.
+func header(headers amqp.Table, key string) string {
+	switch v := headers[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
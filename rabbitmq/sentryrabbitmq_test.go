@@ -0,0 +1,166 @@
+package sentryrabbitmq_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryrabbitmq "github.com/getsentry/sentry-go/rabbitmq"
+	"github.com/google/go-cmp/cmp"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// record returns a BeforeSend callback adding the events to events and
+// dropping them.
+func record(events *[]*sentry.Event) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func TestPublishAndProcess(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	shared := amqp.Table{"tenant": "acme"}
+	msg := amqp.Publishing{Headers: shared}
+	producer := sentry.StartTransaction(ctx, "producer")
+	span := sentryrabbitmq.StartPublishSpan(producer.Context(), "orders", "orders.created", &msg)
+	if span == nil {
+		t.Fatal("publish span not started")
+	}
+	span.Finish()
+	producer.Finish()
+	if msg.Headers[sentry.SentryTraceHeader] == nil || msg.Headers[sentry.SentryBaggageHeader] == nil {
+		t.Fatalf("trace headers not set: %v", msg.Headers)
+	}
+	if diff := cmp.Diff(amqp.Table{"tenant": "acme"}, shared); diff != "" {
+		t.Errorf("shared headers modified (-want +got):\n%s", diff)
+	}
+
+	var processed bool
+	err = sentryrabbitmq.New(sentryrabbitmq.Options{}).Process(ctx, "orders-worker", amqp.Delivery{
+		Headers:    msg.Headers,
+		Exchange:   "orders",
+		RoutingKey: "orders.created",
+	}, func(ctx context.Context, d amqp.Delivery) error {
+		processed = sentry.SpanFromContext(ctx) != nil
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !processed {
+		t.Error("process called without a span")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	publish, process := events[0], events[1]
+	if diff := cmp.Diff("orders orders.created", publish.Spans[0].Description); diff != "" {
+		t.Errorf("publish span description mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(publish.Spans[0].SpanID.String(), process.Contexts["trace"]["parent_span_id"].(sentry.SpanID).String()); diff != "" {
+		t.Errorf("process transaction isn't a child of the publish span (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("orders-worker", process.Transaction); diff != "" {
+		t.Errorf("process transaction name mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusOK, process.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("process transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProcessError(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	err = sentryrabbitmq.New(sentryrabbitmq.Options{}).Process(ctx, "orders-worker", amqp.Delivery{
+		Exchange:    "orders",
+		RoutingKey:  "orders.created",
+		DeliveryTag: 3,
+		Redelivered: true,
+		MessageId:   "m1",
+	}, func(ctx context.Context, d amqp.Delivery) error {
+		return errors.New("invalid order")
+	})
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	event, transaction := events[0], events[1]
+	if diff := cmp.Diff("invalid order", event.Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	want := sentry.Context{
+		"queue":        "orders-worker",
+		"exchange":     "orders",
+		"routing_key":  "orders.created",
+		"redelivered":  true,
+		"delivery_tag": uint64(3),
+		"message_id":   "m1",
+	}
+	if diff := cmp.Diff(want, event.Contexts["rabbitmq"]); diff != "" {
+		t.Errorf("rabbitmq context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("orders-worker", event.Tags["rabbitmq.queue"]); diff != "" {
+		t.Errorf("queue tag mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transaction.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProcessPanic(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	err = sentryrabbitmq.New(sentryrabbitmq.Options{}).Process(ctx, "orders-worker", amqp.Delivery{}, func(ctx context.Context, d amqp.Delivery) error {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("boom", events[0].Message); diff != "" {
+		t.Errorf("panic message mismatch (-want +got):\n%s", diff)
+	}
+}
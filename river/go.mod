@@ -0,0 +1,16 @@
+module github.com/getsentry/sentry-go/river
+
+go 1.22.0
+
+require (
+	github.com/getsentry/sentry-go v0.24.0
+	github.com/google/go-cmp v0.6.0
+	github.com/riverqueue/river/rivertype v0.19.0
+)
+
+require (
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
+
+replace github.com/getsentry/sentry-go => ../
@@ -0,0 +1,210 @@
+// Package sentryriver provides Sentry integration for job queues built with
+// github.com/riverqueue/river.
+//
+// Add the middleware to the client, to propagate the trace of the inserting
+// code in the metadata of jobs, and to work each job in a "queue.task.river"
+// transaction continuing that trace:
+//
+//	client, err := river.NewClient(riverpgxv5.New(pool), &river.Config{
+//		Middleware: []rivertype.Middleware{sentryriver.NewMiddleware(sentryriver.Options{})},
+//		Workers:    workers,
+//	})
+//
+// Errors and panics of workers are reported to Sentry, tagged with the kind,
+// attempt and queue of the job.
+package sentryriver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/riverqueue/river/rivertype"
+)
+
+// The identifier of the River SDK.
+const sdkIdentifier = "sentry.go.river"
+
+// Span operations.
+const (
+	InsertOperation = "queue.publish"
+	WorkOperation   = "queue.task.river"
+)
+
+// spanOrigin is the origin of spans.
+const spanOrigin = "auto.queue.river"
+
+// messagingSystem is the "messaging.system" data of spans.
+const messagingSystem = "river"
+
+// Options configure the middleware.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// in a worker. Otherwise, the panic is returned as an error, which River
+	// handles as any other error of the job.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// Middleware instruments the insertion and the work of jobs. It implements
+// rivertype.JobInsertMiddleware and rivertype.WorkerMiddleware.
+type Middleware struct {
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// NewMiddleware returns a new Middleware.
+func NewMiddleware(options Options) *Middleware {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &Middleware{
+		repanic:         options.Repanic,
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+// IsMiddleware implements rivertype.Middleware.
+func (m *Middleware) IsMiddleware() bool { return true }
+
+// InsertMany inserts jobs in a "queue.publish" span, if ctx has a span, and
+// sets the metadata of the jobs propagating the trace. It implements
+// rivertype.JobInsertMiddleware.
+func (m *Middleware) InsertMany(ctx context.Context, manyParams []*rivertype.JobInsertParams, doInner func(context.Context) ([]*rivertype.JobInsertResult, error)) ([]*rivertype.JobInsertResult, error) {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil || len(manyParams) == 0 {
+		return doInner(ctx)
+	}
+	span := parent.StartChild(InsertOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.Description = manyParams[0].Kind
+	span.Data = map[string]interface{}{
+		"messaging.system":              messagingSystem,
+		"messaging.destination.name":    manyParams[0].Queue,
+		"messaging.batch.message_count": len(manyParams),
+	}
+
+	trace := map[string]string{sentry.SentryTraceHeader: span.ToSentryTrace()}
+	if baggage := span.ToBaggage(); baggage != "" {
+		trace[sentry.SentryBaggageHeader] = baggage
+	}
+	for _, params := range manyParams {
+		params.Metadata = withTrace(params.Metadata, trace)
+	}
+
+	results, err := doInner(span.Context())
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.Status = sentry.SpanStatusOK
+	}
+	span.Finish()
+	return results, err
+}
+
+// Work works job in a "queue.task.river" transaction continuing the trace
+// propagated in its metadata, with a hub whose scope is tagged with the kind,
+// attempt and queue of the job, and reports the error returned by the
+// worker. It implements rivertype.WorkerMiddleware.
+func (m *Middleware) Work(ctx context.Context, job *rivertype.JobRow, doInner func(context.Context) error) (err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	scope := hub.Scope()
+	scope.SetTags(map[string]string{
+		"river.kind":    job.Kind,
+		"river.attempt": strconv.Itoa(job.Attempt),
+		"river.queue":   job.Queue,
+	})
+	scope.SetContext("river", sentry.Context{
+		"id":           job.ID,
+		"kind":         job.Kind,
+		"queue":        job.Queue,
+		"attempt":      job.Attempt,
+		"max_attempts": job.MaxAttempts,
+		"priority":     job.Priority,
+		"tags":         job.Tags,
+	})
+
+	// Ignore the metadata that isn't a JSON object.
+	var trace map[string]interface{}
+	_ = json.Unmarshal(job.Metadata, &trace)
+	sentryTrace, _ := trace[sentry.SentryTraceHeader].(string)
+	baggage, _ := trace[sentry.SentryBaggageHeader].(string)
+
+	transaction := sentry.StartTransaction(sentry.SetHubOnContext(ctx, hub), job.Kind,
+		sentry.WithOpName(WorkOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(sentryTrace, baggage),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.Data = map[string]interface{}{
+		"messaging.system":              messagingSystem,
+		"messaging.destination.name":    job.Queue,
+		"messaging.message.id":          strconv.FormatInt(job.ID, 10),
+		"messaging.message.retry.count": job.Attempt - 1,
+	}
+	defer transaction.Finish()
+
+	ctx = transaction.Context()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Status = sentry.SpanStatusInternalError
+			eventID := hub.RecoverWithContext(ctx, r)
+			if eventID != nil && m.waitForDelivery {
+				hub.Flush(m.timeout)
+			}
+			if m.repanic {
+				panic(r)
+			}
+			err = fmt.Errorf("sentryriver: panic: %v", r)
+		}
+	}()
+
+	err = doInner(ctx)
+	if err != nil {
+		transaction.SetError(err)
+		hub.CaptureException(err)
+	} else {
+		transaction.Status = sentry.SpanStatusOK
+	}
+	return err
+}
+
+// withTrace returns metadata, a JSON object, with the keys of trace set. It
+// returns metadata unchanged if it isn't a JSON object.
+func withTrace(metadata []byte, trace map[string]string) []byte {
+	fields := make(map[string]json.RawMessage)
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &fields); err != nil || fields == nil {
+			return metadata
+		}
+	}
+	for key, value := range trace {
+		field, err := json.Marshal(value)
+		if err != nil {
+			return metadata
+		}
+		fields[key] = field
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return metadata
+	}
+	return b
+}
@@ -0,0 +1,172 @@
+package sentryriver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryriver "github.com/getsentry/sentry-go/river"
+	"github.com/google/go-cmp/cmp"
+	"github.com/riverqueue/river/rivertype"
+)
+
+// record returns a BeforeSend callback adding the events to events and
+// dropping them.
+func record(events *[]*sentry.Event) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+var (
+	_ rivertype.JobInsertMiddleware = (*sentryriver.Middleware)(nil)
+	_ rivertype.WorkerMiddleware    = (*sentryriver.Middleware)(nil)
+)
+
+func TestInsertAndWork(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	middleware := sentryriver.NewMiddleware(sentryriver.Options{})
+
+	params := []*rivertype.JobInsertParams{
+		{Kind: "email", Queue: "default", Metadata: []byte(`{"tenant":"acme"}`)},
+	}
+	producer := sentry.StartTransaction(ctx, "producer")
+	_, err = middleware.InsertMany(producer.Context(), params, func(ctx context.Context) ([]*rivertype.JobInsertResult, error) {
+		if sentry.SpanFromContext(ctx).Op != sentryriver.InsertOperation {
+			t.Error("jobs inserted outside of the insert span")
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	producer.Finish()
+
+	var metadata map[string]string
+	if err := json.Unmarshal(params[0].Metadata, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata["tenant"] != "acme" || metadata[sentry.SentryTraceHeader] == "" {
+		t.Fatalf("trace not propagated in metadata: %v", metadata)
+	}
+
+	var worked bool
+	err = middleware.Work(ctx, &rivertype.JobRow{
+		ID:          1,
+		Kind:        "email",
+		Queue:       "default",
+		Attempt:     1,
+		MaxAttempts: 25,
+		Metadata:    params[0].Metadata,
+	}, func(ctx context.Context) error {
+		worked = sentry.SpanFromContext(ctx) != nil
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !worked {
+		t.Error("job worked without a span")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	insert, work := events[0], events[1]
+	if diff := cmp.Diff(insert.Spans[0].SpanID, work.Contexts["trace"]["parent_span_id"]); diff != "" {
+		t.Errorf("work transaction isn't a child of the insert span (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("email", work.Transaction); diff != "" {
+		t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusOK, work.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWorkError(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	err = sentryriver.NewMiddleware(sentryriver.Options{}).Work(ctx, &rivertype.JobRow{
+		Kind:    "email",
+		Queue:   "mail",
+		Attempt: 3,
+	}, func(ctx context.Context) error {
+		return errors.New("smtp unavailable")
+	})
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	event, transaction := events[0], events[1]
+	want := map[string]string{
+		"river.kind":    "email",
+		"river.attempt": "3",
+		"river.queue":   "mail",
+	}
+	if diff := cmp.Diff(want, event.Tags); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("smtp unavailable", event.Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transaction.Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWorkPanic(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	err = sentryriver.NewMiddleware(sentryriver.Options{}).Work(ctx, &rivertype.JobRow{Kind: "email"}, func(ctx context.Context) error {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff("boom", events[0].Message); diff != "" {
+		t.Errorf("panic message mismatch (-want +got):\n%s", diff)
+	}
+}
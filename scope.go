@@ -22,6 +22,11 @@ import (
 // The scope is meant to be modified but not inspected directly. When preparing
 // an event for reporting, the current client adds information from the current
 // scope into the event.
+//
+// Data is copied on write: clones of a scope share its maps and slices, and
+// events share the breadcrumbs of the scope and the spans of the transaction.
+// Code modifying this data, in the scope or in events, replaces the map, slice
+// or element with a modified copy instead of modifying it in place.
 type Scope struct {
 	mu          sync.RWMutex
 	breadcrumbs []*Breadcrumb
@@ -31,6 +36,8 @@ type Scope struct {
 	contexts    map[string]Context
 	extra       map[string]interface{}
 	fingerprint []string
+	flags       []FeatureFlag
+	session     *session
 	level       Level
 	request     *http.Request
 	// requestBody holds a reference to the original request.Body.
@@ -42,7 +49,16 @@ type Scope struct {
 		// size.
 		Overflow() bool
 	}
-	eventProcessors []EventProcessor
+	eventProcessors eventProcessors
+	logBuffer       *LogBuffer
+
+	// Cloning a scope shares its maps with the clone instead of copying them.
+	// A shared map is copied by the first of the two scopes that modifies it.
+	// Slices are shared too, but capped at their length so that appending to
+	// them always allocates a new backing array.
+	tagsShared     bool
+	contextsShared bool
+	extraShared    bool
 }
 
 // NewScope creates a new Scope.
@@ -75,6 +91,40 @@ func (scope *Scope) AddBreadcrumb(breadcrumb *Breadcrumb, limit int) {
 	}
 }
 
+// addBreadcrumbWithCategoryLimit is like AddBreadcrumb, but also evicts the
+// oldest breadcrumbs of the category of breadcrumb when there are more than
+// categoryLimit of them.
+func (scope *Scope) addBreadcrumbWithCategoryLimit(breadcrumb *Breadcrumb, limit, categoryLimit int) {
+	if breadcrumb.Timestamp.IsZero() {
+		breadcrumb.Timestamp = time.Now()
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	count := 1
+	for _, b := range scope.breadcrumbs {
+		if b.Category == breadcrumb.Category {
+			count++
+		}
+	}
+	breadcrumbs := make([]*Breadcrumb, 0, len(scope.breadcrumbs)+1)
+	for _, b := range scope.breadcrumbs {
+		if b.Category == breadcrumb.Category && count > categoryLimit {
+			count--
+			continue
+		}
+		breadcrumbs = append(breadcrumbs, b)
+	}
+	if count <= categoryLimit {
+		breadcrumbs = append(breadcrumbs, breadcrumb)
+	}
+	if len(breadcrumbs) > limit {
+		breadcrumbs = breadcrumbs[len(breadcrumbs)-limit:]
+	}
+	scope.breadcrumbs = breadcrumbs
+}
+
 // ClearBreadcrumbs clears all breadcrumbs from the current scope.
 func (scope *Scope) ClearBreadcrumbs() {
 	scope.mu.Lock()
@@ -99,6 +149,25 @@ func (scope *Scope) ClearAttachments() {
 	scope.attachments = []*Attachment{}
 }
 
+// SetLogBuffer sets the buffer of the last lines logged, attached to the
+// errors captured with the current scope. Clones of the scope get a copy of
+// the buffer. Pass nil to stop attaching them.
+func (scope *Scope) SetLogBuffer(buffer *LogBuffer) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	scope.logBuffer = buffer
+}
+
+// LogBuffer returns the buffer of the last lines logged set on the current
+// scope, or nil.
+func (scope *Scope) LogBuffer() *LogBuffer {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	return scope.logBuffer
+}
+
 // SetUser sets the user for the current scope.
 func (scope *Scope) SetUser(user User) {
 	scope.mu.Lock()
@@ -107,6 +176,34 @@ func (scope *Scope) SetUser(user User) {
 	scope.user = user
 }
 
+// UpdateUser modifies the user of the current scope in place, keeping the
+// fields that f doesn't change. Unlike SetUser, it lets code that knows part of
+// the user, like a middleware setting the IP address, and code that knows the
+// rest, like an authentication handler setting the ID, not overwrite each
+// other:
+//
+//	scope.UpdateUser(func(user *sentry.User) {
+//		user.ID = session.UserID
+//	})
+//
+// The Data map of the user passed to f can be modified safely, it isn't shared
+// with clones of the scope.
+func (scope *Scope) UpdateUser(f func(user *User)) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	user := scope.user
+	if user.Data != nil {
+		data := make(map[string]string, len(user.Data))
+		for k, v := range user.Data {
+			data[k] = v
+		}
+		user.Data = data
+	}
+	f(&user)
+	scope.user = user
+}
+
 // SetRequest sets the request for the current scope.
 func (scope *Scope) SetRequest(r *http.Request) {
 	scope.mu.Lock()
@@ -203,7 +300,7 @@ func (scope *Scope) SetTag(key, value string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	scope.tags[key] = value
+	scope.writableTags()[key] = value
 }
 
 // SetTags assigns multiple tags to the current scope.
@@ -211,17 +308,27 @@ func (scope *Scope) SetTags(tags map[string]string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	writable := scope.writableTags()
 	for k, v := range tags {
-		scope.tags[k] = v
+		writable[k] = v
 	}
 }
 
+// tag returns the value of the tag with the given key, if it is set.
+func (scope *Scope) tag(key string) (string, bool) {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	value, ok := scope.tags[key]
+	return value, ok
+}
+
 // RemoveTag removes a tag from the current scope.
 func (scope *Scope) RemoveTag(key string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	delete(scope.tags, key)
+	delete(scope.writableTags(), key)
 }
 
 // SetContext adds a context to the current scope.
@@ -229,7 +336,7 @@ func (scope *Scope) SetContext(key string, value Context) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	scope.contexts[key] = value
+	scope.writableContexts()[key] = value
 }
 
 // SetContexts assigns multiple contexts to the current scope.
@@ -237,8 +344,9 @@ func (scope *Scope) SetContexts(contexts map[string]Context) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	writable := scope.writableContexts()
 	for k, v := range contexts {
-		scope.contexts[k] = v
+		writable[k] = v
 	}
 }
 
@@ -247,7 +355,7 @@ func (scope *Scope) RemoveContext(key string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	delete(scope.contexts, key)
+	delete(scope.writableContexts(), key)
 }
 
 // SetExtra adds an extra to the current scope.
@@ -255,7 +363,7 @@ func (scope *Scope) SetExtra(key string, value interface{}) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	scope.extra[key] = value
+	scope.writableExtra()[key] = value
 }
 
 // SetExtras assigns multiple extras to the current scope.
@@ -263,8 +371,9 @@ func (scope *Scope) SetExtras(extra map[string]interface{}) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
+	writable := scope.writableExtra()
 	for k, v := range extra {
-		scope.extra[k] = v
+		writable[k] = v
 	}
 }
 
@@ -273,7 +382,7 @@ func (scope *Scope) RemoveExtra(key string) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	delete(scope.extra, key)
+	delete(scope.writableExtra(), key)
 }
 
 // SetFingerprint sets new fingerprint for the current scope.
@@ -293,34 +402,88 @@ func (scope *Scope) SetLevel(level Level) {
 }
 
 // Clone returns a copy of the current scope with all data copied over.
+//
+// Cloning is cheap: the data is shared between the scope and its clone, and
+// only copied when one of them modifies it.
 func (scope *Scope) Clone() *Scope {
-	scope.mu.RLock()
-	defer scope.mu.RUnlock()
+	// Cloning marks the data of the scope as shared, hence the write lock.
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
 
-	clone := NewScope()
-	clone.user = scope.user
-	clone.breadcrumbs = make([]*Breadcrumb, len(scope.breadcrumbs))
-	copy(clone.breadcrumbs, scope.breadcrumbs)
-	clone.attachments = make([]*Attachment, len(scope.attachments))
-	copy(clone.attachments, scope.attachments)
-	for key, value := range scope.tags {
-		clone.tags[key] = value
+	scope.breadcrumbs = scope.breadcrumbs[:len(scope.breadcrumbs):len(scope.breadcrumbs)]
+	scope.attachments = scope.attachments[:len(scope.attachments):len(scope.attachments)]
+	scope.tagsShared = true
+	scope.contextsShared = true
+	scope.extraShared = true
+
+	clone := &Scope{
+		breadcrumbs:    scope.breadcrumbs,
+		attachments:    scope.attachments,
+		tags:           scope.tags,
+		contexts:       scope.contexts,
+		extra:          scope.extra,
+		fingerprint:    scope.fingerprint,
+		flags:          scope.flags,
+		session:        scope.session,
+		tagsShared:     true,
+		contextsShared: true,
+		extraShared:    true,
 	}
-	for key, value := range scope.contexts {
-		clone.contexts[key] = cloneContext(value)
-	}
-	for key, value := range scope.extra {
-		clone.extra[key] = value
-	}
-	clone.fingerprint = make([]string, len(scope.fingerprint))
-	copy(clone.fingerprint, scope.fingerprint)
+	clone.user = scope.user
 	clone.level = scope.level
 	clone.request = scope.request
 	clone.requestBody = scope.requestBody
 	clone.eventProcessors = scope.eventProcessors
+	if scope.logBuffer != nil {
+		clone.logBuffer = scope.logBuffer.clone()
+	}
 	return clone
 }
 
+// writableTags returns the tags of the scope, copying them first if they are
+// shared with a clone. It must be called with the write lock held.
+func (scope *Scope) writableTags() map[string]string {
+	if scope.tagsShared {
+		tags := make(map[string]string, len(scope.tags)+1)
+		for k, v := range scope.tags {
+			tags[k] = v
+		}
+		scope.tags = tags
+		scope.tagsShared = false
+	}
+	return scope.tags
+}
+
+// writableContexts returns the contexts of the scope, copying them first if
+// they are shared with a clone. It must be called with the write lock held.
+//
+// Context values are never modified in place by the scope, and are not copied.
+func (scope *Scope) writableContexts() map[string]Context {
+	if scope.contextsShared {
+		contexts := make(map[string]Context, len(scope.contexts)+1)
+		for k, v := range scope.contexts {
+			contexts[k] = v
+		}
+		scope.contexts = contexts
+		scope.contextsShared = false
+	}
+	return scope.contexts
+}
+
+// writableExtra returns the extra data of the scope, copying it first if it is
+// shared with a clone. It must be called with the write lock held.
+func (scope *Scope) writableExtra() map[string]interface{} {
+	if scope.extraShared {
+		extra := make(map[string]interface{}, len(scope.extra)+1)
+		for k, v := range scope.extra {
+			extra[k] = v
+		}
+		scope.extra = extra
+		scope.extraShared = false
+	}
+	return scope.extra
+}
+
 // Clear removes the data from the current scope. Not safe for concurrent use.
 func (scope *Scope) Clear() {
 	*scope = *NewScope()
@@ -331,7 +494,31 @@ func (scope *Scope) AddEventProcessor(processor EventProcessor) {
 	scope.mu.Lock()
 	defer scope.mu.Unlock()
 
-	scope.eventProcessors = append(scope.eventProcessors, processor)
+	scope.eventProcessors = scope.eventProcessors.add("", 0, processor)
+}
+
+// AddNamedEventProcessor adds an event processor identified by name to the
+// current scope, replacing any processor previously added with the same name.
+//
+// Processors run in ascending order of priority, and in order of registration
+// for equal priorities. Processors added with AddEventProcessor have priority
+// 0.
+func (scope *Scope) AddNamedEventProcessor(name string, priority int, processor EventProcessor) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	scope.eventProcessors = scope.eventProcessors.add(name, priority, processor)
+}
+
+// RemoveEventProcessor removes the event processor added to the current scope
+// with the given name, and reports whether it was found.
+func (scope *Scope) RemoveEventProcessor(name string) bool {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	var ok bool
+	scope.eventProcessors, ok = scope.eventProcessors.remove(name)
+	return ok
 }
 
 // ApplyToEvent takes the data from the current scope and attaches it to the event.
@@ -347,6 +534,12 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint) *Event {
 		event.attachments = append(event.attachments, scope.attachments...)
 	}
 
+	if scope.logBuffer != nil && isErrorEvent(event) {
+		if attachment := scope.logBuffer.attachment(); attachment != nil {
+			event.attachments = append(event.attachments, attachment)
+		}
+	}
+
 	if len(scope.tags) > 0 {
 		if event.Tags == nil {
 			event.Tags = make(map[string]string, len(scope.tags))
@@ -379,6 +572,15 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint) *Event {
 		}
 	}
 
+	if len(scope.flags) > 0 && event.Type == "" {
+		if event.Contexts == nil {
+			event.Contexts = make(map[string]Context)
+		}
+		if _, ok := event.Contexts[flagsContextKey]; !ok {
+			event.Contexts[flagsContextKey] = flagsContext(scope.flags)
+		}
+	}
+
 	if len(scope.extra) > 0 {
 		if event.Extra == nil {
 			event.Extra = make(map[string]interface{}, len(scope.extra))
@@ -417,16 +619,7 @@ func (scope *Scope) ApplyToEvent(event *Event, hint *EventHint) *Event {
 		}
 	}
 
-	for _, processor := range scope.eventProcessors {
-		id := event.EventID
-		event = processor(event, hint)
-		if event == nil {
-			Logger.Printf("Event dropped by one of the Scope EventProcessors: %s\n", id)
-			return nil
-		}
-	}
-
-	return event
+	return scope.eventProcessors.apply(event, hint, "Scope")
 }
 
 // cloneContext returns a new context with keys and values copied from the passed one.
@@ -0,0 +1,137 @@
+package sentry
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// structTagKey is the key of the struct field tags read by
+// Scope.AddTagsFromStruct.
+const structTagKey = "sentry"
+
+// AddTagsFromStruct assigns the fields of a struct, or of a pointer to a
+// struct, to scope tags and contexts in one call. Only exported fields with a
+// `sentry` struct tag are used:
+//
+//	type RequestMetadata struct {
+//		TenantID string `sentry:"tenant_id"`
+//		Region   string `sentry:"region,omitempty"`
+//		Plan     *Plan  `sentry:"plan,context"`
+//		Internal string `sentry:"-"`
+//	}
+//
+// A field is set as the tag named in its struct tag, formatted with fmt.Sprint.
+// With the "context" option, the field is set as a context instead, converted
+// through its JSON representation. With the "omitempty" option, fields with a
+// zero value are skipped. Nil pointers are always skipped. Embedded structs
+// without a struct tag have their fields assigned as if they were fields of
+// the outer struct.
+func (scope *Scope) AddTagsFromStruct(v interface{}) {
+	value := indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		debugf(LevelWarning, "AddTagsFromStruct expects a struct, got %T.", v)
+		return
+	}
+
+	tags := make(map[string]string)
+	contexts := make(map[string]Context)
+	collectStructFields(value, tags, contexts)
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	if len(tags) > 0 {
+		writable := scope.writableTags()
+		for k, v := range tags {
+			writable[k] = v
+		}
+	}
+	if len(contexts) > 0 {
+		writable := scope.writableContexts()
+		for k, v := range contexts {
+			writable[k] = v
+		}
+	}
+}
+
+func collectStructFields(value reflect.Value, tags map[string]string, contexts map[string]Context) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldValue := value.Field(i)
+		tag, hasTag := field.Tag.Lookup(structTagKey)
+
+		if !hasTag && field.Anonymous {
+			if fieldValue = indirect(fieldValue); fieldValue.Kind() == reflect.Struct {
+				collectStructFields(fieldValue, tags, contexts)
+			}
+			continue
+		}
+		if !hasTag || tag == "-" || !fieldValue.CanInterface() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		var asContext, omitEmpty bool
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "context":
+				asContext = true
+			case "omitempty":
+				omitEmpty = true
+			}
+		}
+
+		if omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		if fieldValue = indirect(fieldValue); !fieldValue.IsValid() {
+			continue
+		}
+
+		if asContext {
+			if context, ok := structFieldContext(fieldValue); ok {
+				contexts[name] = context
+			}
+			continue
+		}
+		tags[name] = fmt.Sprint(fieldValue.Interface())
+	}
+}
+
+// indirect dereferences pointers and interfaces, returning the zero Value if
+// one of them is nil.
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// structFieldContext converts a field value to a context through its JSON
+// representation. Values that aren't JSON objects are wrapped in a context
+// with a single "value" key.
+func structFieldContext(value reflect.Value) (Context, bool) {
+	b, err := json.Marshal(value.Interface())
+	if err != nil {
+		debugf(LevelWarning, "Struct field couldn't be converted to a context: %v", err)
+		return nil, false
+	}
+	var context Context
+	if err := json.Unmarshal(b, &context); err == nil && context != nil {
+		return context, true
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, false
+	}
+	return Context{"value": v}, true
+}
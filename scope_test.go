@@ -3,6 +3,7 @@ package sentry
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -69,6 +70,29 @@ func TestScopeSetUserOverrides(t *testing.T) {
 	assertEqual(t, User{ID: "bar"}, scope.user)
 }
 
+func TestScopeUpdateUserMerges(t *testing.T) {
+	scope := NewScope()
+	scope.SetUser(User{IPAddress: "127.0.0.1"})
+	scope.UpdateUser(func(user *User) {
+		user.ID = "foo"
+	})
+
+	assertEqual(t, User{ID: "foo", IPAddress: "127.0.0.1"}, scope.user)
+}
+
+func TestScopeUpdateUserDoesNotAffectClone(t *testing.T) {
+	scope := NewScope()
+	scope.SetUser(User{ID: "foo", Data: map[string]string{"a": "b"}})
+	clone := scope.Clone()
+	clone.UpdateUser(func(user *User) {
+		user.ID = "bar"
+		user.Data["a"] = "c"
+	})
+
+	assertEqual(t, User{ID: "foo", Data: map[string]string{"a": "b"}}, scope.user)
+	assertEqual(t, User{ID: "bar", Data: map[string]string{"a": "c"}}, clone.user)
+}
+
 func TestScopeSetRequest(t *testing.T) {
 	r := httptest.NewRequest("GET", "/foo", nil)
 	scope := NewScope()
@@ -621,16 +645,14 @@ func TestApplyToEventUsingEmptyEvent(t *testing.T) {
 func TestEventProcessorsModifiesEvent(t *testing.T) {
 	scope := NewScope()
 	event := NewEvent()
-	scope.eventProcessors = []EventProcessor{
-		func(event *Event, hint *EventHint) *Event {
-			event.Level = LevelFatal
-			return event
-		},
-		func(event *Event, hint *EventHint) *Event {
-			event.Fingerprint = []string{"wat"}
-			return event
-		},
-	}
+	scope.AddEventProcessor(func(event *Event, hint *EventHint) *Event {
+		event.Level = LevelFatal
+		return event
+	})
+	scope.AddEventProcessor(func(event *Event, hint *EventHint) *Event {
+		event.Fingerprint = []string{"wat"}
+		return event
+	})
 	processedEvent := scope.ApplyToEvent(event, nil)
 
 	if processedEvent == nil {
@@ -643,11 +665,9 @@ func TestEventProcessorsModifiesEvent(t *testing.T) {
 func TestEventProcessorsCanDropEvent(t *testing.T) {
 	scope := NewScope()
 	event := NewEvent()
-	scope.eventProcessors = []EventProcessor{
-		func(event *Event, hint *EventHint) *Event {
-			return nil
-		},
-	}
+	scope.AddEventProcessor(func(event *Event, hint *EventHint) *Event {
+		return nil
+	})
 	processedEvent := scope.ApplyToEvent(event, nil)
 
 	if processedEvent != nil {
@@ -695,3 +715,98 @@ func TestCloneContext(t *testing.T) {
 		t.Error("complex values are not supposed to be copied")
 	}
 }
+
+func TestScopeCloneIsCopyOnWrite(t *testing.T) {
+	scope := NewScope()
+	scope.SetTag("a", "1")
+	scope.SetContext("ctx", Context{"k": "v"})
+	scope.SetExtra("e", 1)
+	scope.AddBreadcrumb(&Breadcrumb{Message: "first"}, maxBreadcrumbs)
+
+	clone := scope.Clone()
+
+	// Data is shared until modified.
+	if reflect.ValueOf(scope.tags).Pointer() != reflect.ValueOf(clone.tags).Pointer() {
+		t.Error("Clone should share tags until they are modified")
+	}
+
+	clone.SetTag("b", "2")
+	clone.SetContext("other", Context{})
+	clone.RemoveExtra("e")
+	clone.AddBreadcrumb(&Breadcrumb{Message: "clone"}, maxBreadcrumbs)
+	scope.AddBreadcrumb(&Breadcrumb{Message: "parent"}, maxBreadcrumbs)
+	scope.SetTag("c", "3")
+
+	assertEqual(t, map[string]string{"a": "1", "c": "3"}, scope.tags)
+	assertEqual(t, map[string]string{"a": "1", "b": "2"}, clone.tags)
+	assertEqual(t, map[string]Context{"ctx": {"k": "v"}}, scope.contexts)
+	assertEqual(t, map[string]Context{"ctx": {"k": "v"}, "other": {}}, clone.contexts)
+	assertEqual(t, map[string]interface{}{"e": 1}, scope.extra)
+	assertEqual(t, map[string]interface{}{}, clone.extra)
+	assertEqual(t, "parent", scope.breadcrumbs[1].Message)
+	assertEqual(t, "clone", clone.breadcrumbs[1].Message)
+}
+
+func BenchmarkScopeClone(b *testing.B) {
+	scope := fillScopeWithData(NewScope())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clone := scope.Clone()
+		clone.SetTag("request", "id")
+	}
+}
+
+func TestScopeAddTagsFromStruct(t *testing.T) {
+	type Tenant struct {
+		Name  string `json:"name"`
+		Seats int    `json:"seats"`
+	}
+	type Base struct {
+		Service string `sentry:"service"`
+	}
+	type RequestMetadata struct {
+		Base
+		TenantID   string   `sentry:"tenant_id"`
+		Region     string   `sentry:"region,omitempty"`
+		Attempt    int      `sentry:"attempt"`
+		Tenant     *Tenant  `sentry:"tenant,context"`
+		Missing    *Tenant  `sentry:"missing,context"`
+		Flags      []string `sentry:"flags,context"`
+		Secret     string   `sentry:"-"`
+		Untagged   string
+		unexported string `sentry:"unexported"`
+	}
+
+	scope := NewScope()
+	scope.SetTag("existing", "tag")
+	scope.AddTagsFromStruct(&RequestMetadata{
+		Base:       Base{Service: "api"},
+		TenantID:   "t-1",
+		Attempt:    2,
+		Tenant:     &Tenant{Name: "Acme", Seats: 10},
+		Flags:      []string{"a"},
+		Secret:     "secret",
+		Untagged:   "untagged",
+		unexported: "unexported",
+	})
+
+	assertEqual(t, map[string]string{
+		"existing":  "tag",
+		"service":   "api",
+		"tenant_id": "t-1",
+		"attempt":   "2",
+	}, scope.tags)
+	assertEqual(t, map[string]Context{
+		"tenant": {"name": "Acme", "seats": float64(10)},
+		"flags":  {"value": []interface{}{"a"}},
+	}, scope.contexts)
+}
+
+func TestScopeAddTagsFromStructIgnoresNonStructs(t *testing.T) {
+	scope := NewScope()
+	scope.AddTagsFromStruct("foo")
+	scope.AddTagsFromStruct((*struct{})(nil))
+
+	assertEqual(t, map[string]string{}, scope.tags)
+}
@@ -0,0 +1,234 @@
+package sentry
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// filteredValue replaces the values removed by a DataScrubber.
+const filteredValue = "[Filtered]"
+
+// DefaultDenyKeys are the keys scrubbed by a DataScrubber that doesn't set
+// DenyKeys.
+var DefaultDenyKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"auth",
+	"cookie",
+	"credential",
+	"session",
+	"csrf",
+	"xsrf",
+	"privatekey",
+}
+
+// DataScrubber removes personally identifiable information and secrets from
+// events before they are sent, replacing values with "[Filtered]".
+//
+// It scrubs the request data (headers, cookies, query string, body and
+// environment), breadcrumb data and messages, extra data, and stack frame
+// variables. Values are removed when their key matches one of DenyKeys, or
+// partially replaced when they match one of ValuePatterns. Nested maps and
+// slices are scrubbed recursively.
+//
+// Set it with the DataScrubber client option, or call Scrub directly, for
+// example from BeforeSend.
+type DataScrubber struct {
+	// DenyKeys are the keys whose values are removed. A key matches if it
+	// contains one of DenyKeys, ignoring case, dashes and underscores, so that
+	// "token" matches "X-Auth-Token" and "refresh_token". Defaults to
+	// DefaultDenyKeys.
+	DenyKeys []string
+	// AllowKeys are keys that are never scrubbed, even if they match DenyKeys,
+	// compared ignoring case. For example, "author" would otherwise be
+	// scrubbed because of "auth".
+	AllowKeys []string
+	// ValuePatterns match sensitive substrings of values, such as email
+	// addresses or card numbers, which are replaced wherever they appear.
+	ValuePatterns []*regexp.Regexp
+}
+
+// Scrub removes sensitive data from event.
+func (s *DataScrubber) Scrub(event *Event) {
+	if event.Request != nil {
+		request := *event.Request
+		request.Headers = s.scrubStringMap(request.Headers)
+		request.Env = s.scrubStringMap(request.Env)
+		request.Cookies = s.scrubPairs(request.Cookies, ";")
+		request.QueryString = s.scrubPairs(request.QueryString, "&")
+		request.Data = s.scrubBody(request.Data)
+		event.Request = &request
+	}
+
+	for i, b := range event.Breadcrumbs {
+		c := *b
+		c.Message = s.scrubString(b.Message)
+		if b.Data != nil {
+			c.Data = s.scrubMap(b.Data)
+		}
+		event.Breadcrumbs[i] = &c
+	}
+
+	if event.Extra != nil {
+		event.Extra = s.scrubMap(event.Extra)
+	}
+
+	for i := range event.Exception {
+		if event.Exception[i].Stacktrace != nil {
+			event.Exception[i].Stacktrace = s.scrubStacktrace(event.Exception[i].Stacktrace)
+		}
+	}
+	for i := range event.Threads {
+		if event.Threads[i].Stacktrace != nil {
+			event.Threads[i].Stacktrace = s.scrubStacktrace(event.Threads[i].Stacktrace)
+		}
+	}
+}
+
+// denied reports whether the value of key must be removed.
+func (s *DataScrubber) denied(key string) bool {
+	for _, allowed := range s.AllowKeys {
+		if strings.EqualFold(key, allowed) {
+			return false
+		}
+	}
+	denyKeys := s.DenyKeys
+	if denyKeys == nil {
+		denyKeys = DefaultDenyKeys
+	}
+	normalized := normalizeScrubbingKey(key)
+	for _, denied := range denyKeys {
+		if strings.Contains(normalized, normalizeScrubbingKey(denied)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeScrubbingKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "-", "")
+	return strings.ReplaceAll(key, "_", "")
+}
+
+func (s *DataScrubber) scrubString(v string) string {
+	for _, pattern := range s.ValuePatterns {
+		v = pattern.ReplaceAllLiteralString(v, filteredValue)
+	}
+	return v
+}
+
+// scrubValue returns a scrubbed copy of v.
+func (s *DataScrubber) scrubValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return s.scrubString(v)
+	case map[string]interface{}:
+		return s.scrubMap(v)
+	case map[string]string:
+		return s.scrubStringMap(v)
+	case []interface{}:
+		scrubbed := make([]interface{}, len(v))
+		for i, e := range v {
+			scrubbed[i] = s.scrubValue(e)
+		}
+		return scrubbed
+	case []string:
+		scrubbed := make([]string, len(v))
+		for i, e := range v {
+			scrubbed[i] = s.scrubString(e)
+		}
+		return scrubbed
+	default:
+		return v
+	}
+}
+
+func (s *DataScrubber) scrubMap(m map[string]interface{}) map[string]interface{} {
+	scrubbed := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if s.denied(k) {
+			scrubbed[k] = filteredValue
+		} else {
+			scrubbed[k] = s.scrubValue(v)
+		}
+	}
+	return scrubbed
+}
+
+func (s *DataScrubber) scrubStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	scrubbed := make(map[string]string, len(m))
+	for k, v := range m {
+		if s.denied(k) {
+			scrubbed[k] = filteredValue
+		} else {
+			scrubbed[k] = s.scrubString(v)
+		}
+	}
+	return scrubbed
+}
+
+// scrubPairs scrubs a list of key=value pairs separated by sep, such as a
+// query string or a Cookie header, preserving the order of the pairs.
+func (s *DataScrubber) scrubPairs(pairs, sep string) string {
+	if pairs == "" {
+		return ""
+	}
+	parts := strings.Split(pairs, sep)
+	for i, part := range parts {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			parts[i] = s.scrubString(part)
+			continue
+		}
+		name := strings.TrimSpace(key)
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if s.denied(name) {
+			value = filteredValue
+		} else {
+			value = s.scrubString(value)
+		}
+		parts[i] = key + "=" + value
+	}
+	return strings.Join(parts, sep)
+}
+
+// scrubBody scrubs a request body, which is handled as a JSON object or form
+// values if it parses as such.
+func (s *DataScrubber) scrubBody(body string) string {
+	if body == "" {
+		return ""
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &object); err == nil {
+		if b, err := json.Marshal(s.scrubMap(object)); err == nil {
+			return string(b)
+		}
+	}
+	if strings.Contains(body, "=") && !strings.ContainsAny(body, " \n{") {
+		return s.scrubPairs(body, "&")
+	}
+	return s.scrubString(body)
+}
+
+func (s *DataScrubber) scrubStacktrace(stacktrace *Stacktrace) *Stacktrace {
+	scrubbed := *stacktrace
+	scrubbed.Frames = make([]Frame, len(stacktrace.Frames))
+	for i, frame := range stacktrace.Frames {
+		if frame.Vars != nil {
+			frame.Vars = s.scrubMap(frame.Vars)
+		}
+		scrubbed.Frames[i] = frame
+	}
+	return &scrubbed
+}
@@ -0,0 +1,116 @@
+package sentry
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDataScrubber(t *testing.T) {
+	scrubber := &DataScrubber{
+		AllowKeys:     []string{"author"},
+		ValuePatterns: []*regexp.Regexp{regexp.MustCompile(`[\w.]+@example\.com`)},
+	}
+
+	breadcrumb := &Breadcrumb{
+		Message: "login by jane@example.com",
+		Data:    map[string]interface{}{"session_id": "abc", "status": 200},
+	}
+	event := &Event{
+		Request: &Request{
+			Headers: map[string]string{
+				"Authorization": "Bearer abc",
+				"X-Auth-Token":  "abc",
+				"Content-Type":  "application/json",
+			},
+			Cookies:     "sessionid=abc; theme=dark",
+			QueryString: "q=shoes&api_key=abc",
+			Data:        `{"user":{"email":"jane@example.com","password":"hunter2"}}`,
+		},
+		Breadcrumbs: []*Breadcrumb{breadcrumb},
+		Extra: map[string]interface{}{
+			"author": "jane",
+			"config": map[string]interface{}{
+				"db_password": "hunter2",
+				"hosts":       []interface{}{"a", "b"},
+			},
+		},
+		Exception: []Exception{{
+			Stacktrace: &Stacktrace{Frames: []Frame{{
+				Function: "login",
+				Vars:     map[string]interface{}{"refreshToken": "abc", "attempts": 3},
+			}}},
+		}},
+	}
+	scrubber.Scrub(event)
+
+	wantRequest := &Request{
+		Headers: map[string]string{
+			"Authorization": "[Filtered]",
+			"X-Auth-Token":  "[Filtered]",
+			"Content-Type":  "application/json",
+		},
+		Cookies:     "sessionid=[Filtered]; theme=dark",
+		QueryString: "q=shoes&api_key=[Filtered]",
+		Data:        `{"user":{"email":"[Filtered]","password":"[Filtered]"}}`,
+	}
+	if diff := cmp.Diff(wantRequest, event.Request); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+
+	assertEqual(t, event.Breadcrumbs[0].Message, "login by [Filtered]")
+	assertEqual(t, event.Breadcrumbs[0].Data, map[string]interface{}{"session_id": "[Filtered]", "status": 200})
+	if breadcrumb.Data["session_id"] != "abc" {
+		t.Error("breadcrumbs shared with the scope should not be modified")
+	}
+
+	wantExtra := map[string]interface{}{
+		"author": "jane",
+		"config": map[string]interface{}{
+			"db_password": "[Filtered]",
+			"hosts":       []interface{}{"a", "b"},
+		},
+	}
+	if diff := cmp.Diff(wantExtra, event.Extra); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+
+	wantVars := map[string]interface{}{"refreshToken": "[Filtered]", "attempts": 3}
+	if diff := cmp.Diff(wantVars, event.Exception[0].Stacktrace.Frames[0].Vars); diff != "" {
+		t.Errorf("frame vars mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDataScrubberCustomDenyKeys(t *testing.T) {
+	scrubber := &DataScrubber{DenyKeys: []string{"ssn"}}
+	event := &Event{
+		Extra: map[string]interface{}{"user_ssn": "123-45-6789", "password": "hunter2"},
+	}
+	scrubber.Scrub(event)
+
+	assertEqual(t, event.Extra, map[string]interface{}{"user_ssn": "[Filtered]", "password": "hunter2"})
+}
+
+func TestDataScrubberFormBody(t *testing.T) {
+	scrubber := &DataScrubber{}
+	assertEqual(t, scrubber.scrubBody("username=jane&password=hunter2"), "username=jane&password=[Filtered]")
+	assertEqual(t, scrubber.scrubBody("plain text body"), "plain text body")
+}
+
+func TestDataScrubberOption(t *testing.T) {
+	client, scope, transport := setupClientTest()
+	client.options.DataScrubber = &DataScrubber{}
+	client.options.BeforeSend = func(event *Event, hint *EventHint) *Event {
+		if event.Extra["token"] != "[Filtered]" {
+			t.Error("events should be scrubbed before BeforeSend")
+		}
+		return event
+	}
+
+	event := NewEvent()
+	event.Extra["token"] = "abc"
+	client.CaptureEvent(event, nil, scope)
+
+	assertEqual(t, transport.lastEvent.Extra["token"], "[Filtered]")
+}
@@ -0,0 +1,127 @@
+package sentry
+
+import "regexp"
+
+// SecretDetector matches a format of secret, such as an API key, in text.
+type SecretDetector struct {
+	// Name identifies the detector, for example "aws_access_key_id".
+	Name string
+	// Pattern matches the secrets, which are replaced with "[Filtered]".
+	Pattern *regexp.Regexp
+}
+
+// DefaultSecretDetectors detect common formats of credentials.
+var DefaultSecretDetectors = []SecretDetector{
+	{
+		Name:    "aws_access_key_id",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	},
+	{
+		Name:    "aws_secret_access_key",
+		Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+	},
+	{
+		Name:    "bearer_token",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	},
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	},
+	{
+		Name:    "stripe_key",
+		Pattern: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}\b`),
+	},
+	{
+		Name:    "github_token",
+		Pattern: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b`),
+	},
+	{
+		Name:    "slack_token",
+		Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		Name:    "private_key",
+		Pattern: regexp.MustCompile(`(?s)-----BEGIN[A-Z ]*PRIVATE KEY-----.*?-----END[A-Z ]*PRIVATE KEY-----`),
+	},
+}
+
+// SecretScrubbingIntegration redacts secrets, such as API keys, access tokens
+// and private keys, from all text in events: messages, exception values, tags,
+// contexts, extra data, request data, user data, breadcrumbs, frame variables
+// and spans. It guards against credentials accidentally ending up in Sentry,
+// regardless of the key they are stored under. See DataScrubber to remove
+// values by key.
+//
+// Matching every string of every event has a cost, so the integration is not
+// enabled by default:
+//
+//	sentry.Init(sentry.ClientOptions{
+//		Integrations: func(integrations []sentry.Integration) []sentry.Integration {
+//			return append(integrations, &sentry.SecretScrubbingIntegration{})
+//		},
+//	})
+type SecretScrubbingIntegration struct {
+	// Detectors are the secret formats to redact. Defaults to
+	// DefaultSecretDetectors.
+	Detectors []SecretDetector
+
+	scrubber *DataScrubber
+}
+
+func (si *SecretScrubbingIntegration) Name() string {
+	return "SecretScrubbing"
+}
+
+func (si *SecretScrubbingIntegration) SetupOnce(client *Client) {
+	detectors := si.Detectors
+	if detectors == nil {
+		detectors = DefaultSecretDetectors
+	}
+	patterns := make([]*regexp.Regexp, len(detectors))
+	for i, detector := range detectors {
+		patterns[i] = detector.Pattern
+	}
+	// Secrets are only detected by value, no key is denied.
+	si.scrubber = &DataScrubber{DenyKeys: []string{}, ValuePatterns: patterns}
+	client.AddEventProcessor(si.processor)
+}
+
+func (si *SecretScrubbingIntegration) processor(event *Event, hint *EventHint) *Event {
+	s := si.scrubber
+	// Request data, breadcrumbs, extra data and frame variables.
+	s.Scrub(event)
+	if event.Request != nil {
+		event.Request.URL = s.scrubString(event.Request.URL)
+	}
+
+	event.Message = s.scrubString(event.Message)
+	event.Transaction = s.scrubString(event.Transaction)
+	for i := range event.Exception {
+		event.Exception[i].Value = s.scrubString(event.Exception[i].Value)
+	}
+	event.Tags = s.scrubStringMap(event.Tags)
+	if event.Contexts != nil {
+		contexts := make(map[string]Context, len(event.Contexts))
+		for k, c := range event.Contexts {
+			contexts[k] = s.scrubMap(c)
+		}
+		event.Contexts = contexts
+	}
+
+	event.User.Email = s.scrubString(event.User.Email)
+	event.User.Username = s.scrubString(event.User.Username)
+	event.User.Name = s.scrubString(event.User.Name)
+	event.User.Data = s.scrubStringMap(event.User.Data)
+
+	for i, span := range event.Spans {
+		c := span.shallowCopy()
+		c.Description = s.scrubString(c.Description)
+		c.Tags = s.scrubStringMap(c.Tags)
+		if c.Data != nil {
+			c.Data = s.scrubMap(c.Data)
+		}
+		event.Spans[i] = c
+	}
+	return event
+}
@@ -2,6 +2,7 @@ package sentry
 
 import (
 	"context"
+	"os"
 	"time"
 )
 
@@ -29,6 +30,17 @@ func Init(options ClientOptions) error {
 		return err
 	}
 	hub.BindClient(client)
+	if !client.options.DisableEnvScope {
+		hub.ConfigureScope(func(scope *Scope) {
+			setScopeFromEnv(scope, os.Environ(), client.options.EnvTagsPrefix, client.options.EnvContextsPrefix)
+		})
+	}
+	if client.options.AutoSessionTracking {
+		hub.StartSession()
+	}
+	if client.options.ContinuousProfiling {
+		StartProfiler()
+	}
 	return nil
 }
 
@@ -53,12 +65,57 @@ func CaptureException(exception error) *EventID {
 	return hub.CaptureException(exception)
 }
 
+// TryCaptureException captures an error, and returns an error describing why
+// it was not sent, if so. See Hub.TryCaptureException.
+func TryCaptureException(exception error) (*EventID, error) {
+	hub := CurrentHub()
+	return hub.TryCaptureException(exception)
+}
+
+// TryCaptureMessage captures an arbitrary message, and returns an error
+// describing why it was not sent, if so. See Hub.TryCaptureMessage.
+func TryCaptureMessage(message string) (*EventID, error) {
+	hub := CurrentHub()
+	return hub.TryCaptureMessage(message)
+}
+
 // CaptureCheckIn captures a (cron) monitor check-in.
 func CaptureCheckIn(checkIn *CheckIn, monitorConfig *MonitorConfig) *EventID {
 	hub := CurrentHub()
 	return hub.CaptureCheckIn(checkIn, monitorConfig)
 }
 
+// CaptureUserFeedback sends feedback from a user about an event to Sentry.
+func CaptureUserFeedback(feedback UserFeedback) {
+	hub := CurrentHub()
+	hub.CaptureUserFeedback(feedback)
+}
+
+// CaptureFeedback captures feedback from a user, optionally about an event.
+func CaptureFeedback(feedback *Feedback) *EventID {
+	hub := CurrentHub()
+	return hub.CaptureFeedback(feedback)
+}
+
+// Monitor runs job and reports its execution to a (cron) monitor. See
+// Hub.Monitor.
+func Monitor(monitorSlug string, monitorConfig *MonitorConfig, job func() error) error {
+	hub := CurrentHub()
+	return hub.Monitor(monitorSlug, monitorConfig, job)
+}
+
+// StartSession starts a release health session in the current scope.
+func StartSession() {
+	hub := CurrentHub()
+	hub.StartSession()
+}
+
+// EndSession ends the session of the current scope, if any.
+func EndSession() {
+	hub := CurrentHub()
+	hub.EndSession()
+}
+
 // CaptureEvent captures an event on the currently active client if any.
 //
 // The event must already be assembled. Typically code would instead use
@@ -69,6 +126,13 @@ func CaptureEvent(event *Event) *EventID {
 	return hub.CaptureEvent(event)
 }
 
+// TryCaptureEvent captures an event, and returns an error describing why it
+// was not sent, if so. See Hub.TryCaptureEvent.
+func TryCaptureEvent(event *Event) (*EventID, error) {
+	hub := CurrentHub()
+	return hub.TryCaptureEvent(event)
+}
+
 // Recover captures a panic.
 func Recover() *EventID {
 	if err := recover(); err != nil {
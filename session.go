@@ -0,0 +1,231 @@
+package sentry
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// sessionType is the type of a session envelope item.
+const sessionType = "session"
+
+// SessionStatus is the status of a release health session.
+type SessionStatus string
+
+// Session statuses.
+//
+// See https://develop.sentry.dev/sdk/sessions/.
+const (
+	// SessionStatusOK is the status of a session in progress, which may have
+	// errors.
+	SessionStatusOK SessionStatus = "ok"
+	// SessionStatusExited is the status of a session that ended normally.
+	SessionStatusExited SessionStatus = "exited"
+	// SessionStatusCrashed is the status of a session that ended with an
+	// unhandled error, like a panic.
+	SessionStatusCrashed SessionStatus = "crashed"
+	// SessionStatusAbnormal is the status of a session that ended for an
+	// unknown reason.
+	SessionStatusAbnormal SessionStatus = "abnormal"
+)
+
+// session tracks the health of a release over a period of use of the program,
+// for example from start to exit. Sentry uses sessions to compute the
+// crash-free rate of releases. It is safe for concurrent use.
+type session struct {
+	mu          sync.Mutex
+	id          EventID
+	distinctID  string
+	started     time.Time
+	status      SessionStatus
+	errors      int
+	sent        bool
+	release     string
+	environment string
+	ipAddress   string
+}
+
+type sessionAttributes struct {
+	Release     string `json:"release"`
+	Environment string `json:"environment,omitempty"`
+	IPAddress   string `json:"ip_address,omitempty"`
+}
+
+type sessionUpdate struct {
+	ID         EventID           `json:"sid"`
+	DistinctID string            `json:"did,omitempty"`
+	Init       bool              `json:"init"`
+	Started    time.Time         `json:"started"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Duration   float64           `json:"duration"`
+	Status     SessionStatus     `json:"status"`
+	Errors     int               `json:"errors"`
+	Attributes sessionAttributes `json:"attrs"`
+}
+
+// newSession starts a session for the given user.
+func (client *Client) newSession(user User) *session {
+	distinctID := user.ID
+	if distinctID == "" {
+		distinctID = user.Email
+	}
+	if distinctID == "" {
+		distinctID = user.Username
+	}
+	return &session{
+		id:          client.newEventID(),
+		distinctID:  distinctID,
+		started:     client.now(),
+		status:      SessionStatusOK,
+		release:     client.options.Release,
+		environment: client.options.Environment,
+		ipAddress:   user.IPAddress,
+	}
+}
+
+// ended reports whether the session has a final status.
+func (s *session) ended() bool {
+	return s.status != SessionStatusOK
+}
+
+// update returns the current state of the session, and marks it as sent.
+func (s *session) update(now time.Time) sessionUpdate {
+	u := sessionUpdate{
+		ID:         s.id,
+		DistinctID: s.distinctID,
+		Init:       !s.sent,
+		Started:    s.started.UTC(),
+		Timestamp:  now.UTC(),
+		Duration:   now.Sub(s.started).Seconds(),
+		Status:     s.status,
+		Errors:     s.errors,
+		Attributes: sessionAttributes{
+			Release:     s.release,
+			Environment: s.environment,
+			IPAddress:   s.ipAddress,
+		},
+	}
+	s.sent = true
+	return u
+}
+
+// sendSession sends the current state of a session to Sentry. It must be
+// called with the session locked.
+func (client *Client) sendSession(s *session) {
+	if client.dsn == nil {
+		return
+	}
+	payload, err := json.Marshal(s.update(client.now()))
+	if err != nil {
+		client.debugf(LevelError, "Session update couldn't be marshaled: %v", err)
+		return
+	}
+	envelope := NewEnvelope(EnvelopeHeader{
+		SentAt: time.Now(),
+		Dsn:    client.dsn.String(),
+		Sdk: map[string]string{
+			"name":    client.GetSDKIdentifier(),
+			"version": client.sdkVersion,
+		},
+	})
+	envelope.AddItem(&EnvelopeItem{
+		Type:    sessionType,
+		Payload: payload,
+	})
+	client.Transport.SendEnvelope(envelope)
+}
+
+// updateSession records a captured error event in the session of the scope,
+// if any. Unhandled errors mark the session as crashed, which ends it.
+func (client *Client) updateSession(scope EventModifier, event *Event) {
+	if event.Type != "" {
+		return
+	}
+	s, ok := scope.(*Scope)
+	if !ok {
+		return
+	}
+	session := s.currentSession()
+	if session == nil {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.ended() {
+		return
+	}
+	session.errors++
+	if event.isUnhandled() {
+		session.status = SessionStatusCrashed
+	} else if session.errors > 1 {
+		// Only the first error of a session is reported right away, the
+		// count of later ones is reported when the session ends.
+		return
+	}
+	client.sendSession(session)
+}
+
+func (scope *Scope) currentSession() *session {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	return scope.session
+}
+
+// StartSession starts a release health session in the current scope, ending
+// the session in progress, if any. Errors captured in the scope and its clones
+// are counted in the session, and a panic recovered by the SDK or one of its
+// integrations marks it as crashed.
+//
+// Sessions require ClientOptions.Release to be set. They are started on Init
+// when ClientOptions.AutoSessionTracking is set.
+func (hub *Hub) StartSession() {
+	client := hub.Client()
+	if client == nil {
+		return
+	}
+	if client.options.Release == "" {
+		debugf(LevelWarning, "Session not started: sessions require a release.")
+		return
+	}
+
+	hub.EndSession()
+
+	scope := hub.Scope()
+	scope.mu.Lock()
+	session := client.newSession(scope.user)
+	scope.session = session
+	scope.mu.Unlock()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	client.sendSession(session)
+}
+
+// EndSession ends the session of the current scope, if any. Sessions that are
+// still in progress end with the exited status. Call EndSession before
+// flushing events when the program exits.
+func (hub *Hub) EndSession() {
+	client := hub.Client()
+	scope := hub.Scope()
+
+	scope.mu.Lock()
+	session := scope.session
+	scope.session = nil
+	scope.mu.Unlock()
+
+	if session == nil || client == nil {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.ended() {
+		return
+	}
+	session.status = SessionStatusExited
+	client.sendSession(session)
+}
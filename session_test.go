@@ -0,0 +1,111 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func setupSessionTest(t *testing.T) (*Hub, *TransportMock) {
+	t.Helper()
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:                  "http://whatever@example.com/1337",
+		Release:              "app@1.0.0",
+		Transport:            transport,
+		DisableClientReports: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHub(client, NewScope()), transport
+}
+
+func sessionUpdates(t *testing.T, transport *TransportMock) []sessionUpdate {
+	t.Helper()
+	var updates []sessionUpdate
+	for _, envelope := range transport.envelopes {
+		for _, item := range envelope.Items {
+			if item.Type != sessionType {
+				continue
+			}
+			var update sessionUpdate
+			if err := json.Unmarshal(item.Payload, &update); err != nil {
+				t.Fatal(err)
+			}
+			updates = append(updates, update)
+		}
+	}
+	return updates
+}
+
+func TestSessionExited(t *testing.T) {
+	hub, transport := setupSessionTest(t)
+	hub.Scope().SetUser(User{ID: "user"})
+	hub.StartSession()
+	hub.CaptureException(errors.New("handled"))
+	hub.CaptureException(errors.New("handled again"))
+	hub.EndSession()
+	hub.EndSession()
+
+	updates := sessionUpdates(t, transport)
+	if len(updates) != 3 {
+		t.Fatalf("got %d session updates, want 3", len(updates))
+	}
+	assertEqual(t, updates[0].Init, true)
+	assertEqual(t, updates[0].Status, SessionStatusOK)
+	assertEqual(t, updates[0].DistinctID, "user")
+	assertEqual(t, updates[0].Attributes.Release, "app@1.0.0")
+	assertEqual(t, updates[1].Init, false)
+	assertEqual(t, updates[1].Errors, 1)
+	assertEqual(t, updates[2].Status, SessionStatusExited)
+	assertEqual(t, updates[2].Errors, 2)
+	assertEqual(t, updates[2].ID, updates[0].ID)
+}
+
+func TestSessionCrashed(t *testing.T) {
+	hub, transport := setupSessionTest(t)
+	hub.StartSession()
+	func() {
+		defer hub.Recover(nil)
+		panic("boom")
+	}()
+	hub.CaptureException(errors.New("after the crash"))
+	hub.EndSession()
+
+	updates := sessionUpdates(t, transport)
+	if len(updates) != 2 {
+		t.Fatalf("got %d session updates, want 2", len(updates))
+	}
+	assertEqual(t, updates[1].Status, SessionStatusCrashed)
+	assertEqual(t, updates[1].Errors, 1)
+}
+
+func TestSessionCrashedByHintMechanism(t *testing.T) {
+	hub, transport := setupSessionTest(t)
+	hub.StartSession()
+	hub.Client().CaptureException(errors.New("unhandled"), &EventHint{Mechanism: newMechanism(false)}, hub.Scope())
+
+	updates := sessionUpdates(t, transport)
+	assertEqual(t, updates[len(updates)-1].Status, SessionStatusCrashed)
+	assertEqual(t, *transport.lastEvent.Exception[0].Mechanism.Handled, false)
+}
+
+func TestSessionRequiresRelease(t *testing.T) {
+	transport := &TransportMock{}
+	client, err := NewClient(ClientOptions{
+		Dsn:       "http://whatever@example.com/1337",
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The release may default to the VCS revision of the test binary.
+	client.options.Release = ""
+	hub := NewHub(client, NewScope())
+	hub.StartSession()
+
+	if hub.Scope().session != nil {
+		t.Error("a session should not be started without a release")
+	}
+}
@@ -0,0 +1,60 @@
+package sentryslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// CorrelationHandler is a slog.Handler adding the trace_id, span_id and
+// event_id attributes of the context of records, as returned by
+// sentry.LogCorrelationFromContext, before passing them on to another handler.
+// It lets logs written elsewhere be matched with the traces and events in
+// Sentry:
+//
+//	logger := slog.New(sentryslog.NewCorrelationHandler(slog.NewJSONHandler(os.Stderr, nil)))
+type CorrelationHandler struct {
+	next slog.Handler
+}
+
+var _ slog.Handler = (*CorrelationHandler)(nil)
+
+// NewCorrelationHandler returns a CorrelationHandler passing records on to
+// next.
+func NewCorrelationHandler(next slog.Handler) *CorrelationHandler {
+	return &CorrelationHandler{next: next}
+}
+
+// Enabled implements slog.Handler.
+func (h *CorrelationHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *CorrelationHandler) Handle(ctx context.Context, r slog.Record) error {
+	c := sentry.LogCorrelationFromContext(ctx)
+	if c.TraceID != "" || c.EventID != "" {
+		r = r.Clone()
+		for _, field := range []struct{ key, value string }{
+			{sentry.LogFieldTraceID, c.TraceID},
+			{sentry.LogFieldSpanID, c.SpanID},
+			{sentry.LogFieldEventID, c.EventID},
+		} {
+			if field.value != "" {
+				r.AddAttrs(slog.String(field.key, field.value))
+			}
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *CorrelationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &CorrelationHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *CorrelationHandler) WithGroup(name string) slog.Handler {
+	return &CorrelationHandler{next: h.next.WithGroup(name)}
+}
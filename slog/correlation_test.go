@@ -0,0 +1,56 @@
+package sentryslog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentryslog "github.com/getsentry/sentry-go/slog"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCorrelationHandler(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	var buf bytes.Buffer
+	logger := slog.New(sentryslog.NewCorrelationHandler(slog.NewJSONHandler(&buf, nil)))
+
+	logger.InfoContext(ctx, "no trace")
+	transaction := sentry.StartTransaction(ctx, "charge")
+	eventID := hub.CaptureException(errors.New("card declined"))
+	logger.With("order", "42").InfoContext(transaction.Context(), "charging")
+	transaction.Finish()
+
+	var got []map[string]interface{}
+	d := json.NewDecoder(&buf)
+	for d.More() {
+		var line map[string]interface{}
+		if err := d.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		delete(line, "time")
+		got = append(got, line)
+	}
+	want := []map[string]interface{}{{
+		"level": "INFO",
+		"msg":   "no trace",
+	}, {
+		"level":    "INFO",
+		"msg":      "charging",
+		"order":    "42",
+		"trace_id": transaction.TraceID.String(),
+		"span_id":  transaction.SpanID.String(),
+		"event_id": string(*eventID),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("logs mismatch (-want +got):\n%s", diff)
+	}
+}
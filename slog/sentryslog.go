@@ -0,0 +1,288 @@
+// Package sentryslog provides a log/slog handler reporting logs to Sentry.
+//
+// Records at EventLevel or above are captured as events, and records at
+// BreadcrumbLevel or above as breadcrumbs, with the hub of the context of the
+// record, or the current hub:
+//
+//	logger := slog.New(sentryslog.NewHandler(sentryslog.Options{}))
+//	logger.ErrorContext(ctx, "charge failed", "order", order.ID, "err", err)
+//
+// Records are also added to the sentry.LogBuffer of the scope of the hub, if
+// any, to be attached to the errors captured later on.
+//
+// Events captured with the context of a span are linked to its trace. The
+// attributes of records are attached to events as extra data, or as tags for
+// the attributes named in TagKeys, and the first attribute holding an error is
+// captured as the exception of the event.
+package sentryslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The identifier of the slog SDK.
+const sdkIdentifier = "sentry.go.slog"
+
+// loggerName is the logger of events and the category of breadcrumbs.
+const loggerName = "slog"
+
+// Options configure a Handler.
+type Options struct {
+	// EventLevel is the minimum level of the records captured as events.
+	// Defaults to slog.LevelError.
+	EventLevel slog.Leveler
+	// BreadcrumbLevel is the minimum level of the records added as
+	// breadcrumbs, when lower than EventLevel. Defaults to slog.LevelInfo.
+	BreadcrumbLevel slog.Leveler
+	// TagKeys are the keys of the attributes set as tags of events, rather
+	// than extra data. Keys of attributes in groups are prefixed with the
+	// names of the groups, separated by dots.
+	TagKeys []string
+	// PromotionRules, if not nil, decide which records are captured as
+	// events, instead of EventLevel. The logger of records is the value of
+	// their sentry.LogFieldLogger attribute. Records which are not promoted
+	// are added as breadcrumbs if they are at BreadcrumbLevel or above.
+	PromotionRules *sentry.PromotionRules
+	// Sampler, if not nil, samples the events, and drops the events repeating
+	// a recent event.
+	Sampler *sentry.LogSampler
+}
+
+// Handler is a slog.Handler reporting records to Sentry.
+type Handler struct {
+	eventLevel      slog.Leveler
+	breadcrumbLevel slog.Leveler
+	tagKeys         map[string]bool
+	promotionRules  *sentry.PromotionRules
+	sampler         *sentry.LogSampler
+	attrs           []slog.Attr
+	group           string
+}
+
+var _ slog.Handler = (*Handler)(nil)
+
+// NewHandler returns a new Handler.
+func NewHandler(options Options) *Handler {
+	h := &Handler{
+		eventLevel:      options.EventLevel,
+		breadcrumbLevel: options.BreadcrumbLevel,
+		tagKeys:         make(map[string]bool, len(options.TagKeys)),
+		promotionRules:  options.PromotionRules,
+		sampler:         options.Sampler,
+	}
+	if h.eventLevel == nil {
+		h.eventLevel = slog.LevelError
+	}
+	if h.breadcrumbLevel == nil {
+		h.breadcrumbLevel = slog.LevelInfo
+	}
+	for _, key := range options.TagKeys {
+		h.tagKeys[key] = true
+	}
+	return h
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.promotionRules != nil {
+		return true
+	}
+	return level >= h.eventLevel.Level() || level >= h.breadcrumbLevel.Level()
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.Enabled(ctx, r.Level) {
+		return nil
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	attrs := make(map[string]interface{})
+	var err error
+	add := func(key string, value interface{}) {
+		if e, ok := value.(error); ok && err == nil {
+			err = e
+			return
+		}
+		attrs[key] = value
+	}
+	for _, attr := range h.attrs {
+		addAttr(add, "", attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addAttr(add, h.group, attr)
+		return true
+	})
+
+	if buffer := hub.Scope().LogBuffer(); buffer != nil {
+		buffer.Add(logLine(r, attrs, err))
+	}
+
+	level := sentryLevel(r.Level)
+	if !h.isEvent(r.Level, attrs) {
+		if r.Level < h.breadcrumbLevel.Level() {
+			return nil
+		}
+		data := attrs
+		if err != nil {
+			data["error"] = err.Error()
+		}
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Type:      "default",
+			Category:  loggerName,
+			Message:   r.Message,
+			Data:      data,
+			Level:     level,
+			Timestamp: timestamp(r.Time),
+		}, nil)
+		return nil
+	}
+
+	if h.sampler != nil {
+		logger, _ := attrs[sentry.LogFieldLogger].(string)
+		if !h.sampler.Sample(logger, r.Message) {
+			return nil
+		}
+	}
+
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+	client.SetSDKIdentifier(sdkIdentifier)
+	event := sentry.NewEvent()
+	event.Level = level
+	event.Message = r.Message
+	event.Logger = loggerName
+	event.Timestamp = timestamp(r.Time)
+	if err != nil {
+		event.SetException(err, client.Options().MaxErrorDepth)
+	}
+	for key, value := range attrs {
+		if h.tagKeys[key] {
+			event.Tags[key] = fmt.Sprint(value)
+		} else {
+			event.Extra[key] = value
+		}
+	}
+	hub.CaptureEventWithHint(event, &sentry.EventHint{Context: ctx})
+	return nil
+}
+
+// isEvent reports whether a record is captured as an event.
+func (h *Handler) isEvent(level slog.Level, attrs map[string]interface{}) bool {
+	if h.promotionRules == nil {
+		return level >= h.eventLevel.Level()
+	}
+	logger, _ := attrs[sentry.LogFieldLogger].(string)
+	return h.promotionRules.Promote(logger, sentryLevel(level))
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	c.attrs = append(c.attrs, h.attrs...)
+	for _, attr := range attrs {
+		if h.group != "" {
+			attr = slog.Group(h.group, attr)
+		}
+		c.attrs = append(c.attrs, attr)
+	}
+	return &c
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	if c.group != "" {
+		c.group += "." + name
+	} else {
+		c.group = name
+	}
+	return &c
+}
+
+// addAttr calls add with the key, prefixed with group, and the value of attr,
+// or of each attribute of attr if it is a group.
+func addAttr(add func(key string, value interface{}), group string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			group = prefix(group, attr.Key)
+		}
+		for _, a := range value.Group() {
+			addAttr(add, group, a)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	add(prefix(group, attr.Key), value.Any())
+}
+
+func prefix(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
+
+// logLine formats a record for a sentry.LogBuffer, with its attributes sorted
+// by key.
+func logLine(r slog.Record, attrs map[string]interface{}, err error) string {
+	var b strings.Builder
+	b.WriteString(timestamp(r.Time).Format(time.RFC3339Nano))
+	b.WriteByte(' ')
+	b.WriteString(r.Level.String())
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, attrs[key])
+	}
+	if err != nil {
+		fmt.Fprintf(&b, " error=%q", err.Error())
+	}
+	return b.String()
+}
+
+// sentryLevel returns the Sentry level of a slog level.
+func sentryLevel(level slog.Level) sentry.Level {
+	switch {
+	case level >= slog.LevelError:
+		return sentry.LevelError
+	case level >= slog.LevelWarn:
+		return sentry.LevelWarning
+	case level >= slog.LevelInfo:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
+
+// timestamp returns t, or the current time if t is zero, as slog allows.
+func timestamp(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
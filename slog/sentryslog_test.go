@@ -0,0 +1,236 @@
+package sentryslog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentryslog "github.com/getsentry/sentry-go/slog"
+	"github.com/google/go-cmp/cmp"
+)
+
+// record returns a BeforeSend callback adding the events to events and
+// dropping them.
+func record(events *[]*sentry.Event) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	logger := slog.New(sentryslog.NewHandler(sentryslog.Options{
+		TagKeys: []string{"request.tenant"},
+	})).With("service", "billing")
+
+	logger.DebugContext(ctx, "ignored")
+	logger.InfoContext(ctx, "charging", "amount", 42)
+	transaction := sentry.StartTransaction(ctx, "charge")
+	logger.WithGroup("request").ErrorContext(transaction.Context(), "charge failed",
+		"tenant", "acme",
+		"err", errors.New("card declined"),
+		slog.Group("card", "brand", "visa"),
+	)
+	transaction.Finish()
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff(sentry.LevelError, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("charge failed", event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("card declined", event.Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("acme", event.Tags["request.tenant"]); diff != "" {
+		t.Errorf("tag mismatch (-want +got):\n%s", diff)
+	}
+	wantExtra := map[string]interface{}{
+		"service":            "billing",
+		"request.card.brand": "visa",
+	}
+	if diff := cmp.Diff(wantExtra, event.Extra); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(transaction.TraceID, event.Contexts["trace"]["trace_id"]); diff != "" {
+		t.Errorf("event isn't linked to the trace (-want +got):\n%s", diff)
+	}
+
+	wantBreadcrumbs := []*sentry.Breadcrumb{{
+		Type:     "default",
+		Category: "slog",
+		Message:  "charging",
+		Data:     map[string]interface{}{"service": "billing", "amount": int64(42)},
+		Level:    sentry.LevelInfo,
+	}}
+	if diff := cmp.Diff(wantBreadcrumbs, event.Breadcrumbs, cmpIgnoreTimestamp); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandlerLevels(t *testing.T) {
+	h := sentryslog.NewHandler(sentryslog.Options{
+		EventLevel:      slog.LevelWarn,
+		BreadcrumbLevel: slog.LevelDebug,
+	})
+	for _, tt := range []struct {
+		level slog.Level
+		want  bool
+	}{
+		{slog.LevelDebug - 1, false},
+		{slog.LevelDebug, true},
+		{slog.LevelWarn, true},
+	} {
+		if got := h.Enabled(context.Background(), tt.level); got != tt.want {
+			t.Errorf("Enabled(%v) = %t, want %t", tt.level, got, tt.want)
+		}
+	}
+
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	slog.New(h).WarnContext(ctx, "slow query")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(sentry.LevelWarning, events[0].Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+}
+
+var cmpIgnoreTimestamp = cmp.FilterPath(func(p cmp.Path) bool {
+	return p.Last().String() == ".Timestamp"
+}, cmp.Ignore())
+
+func TestHandlerLogBuffer(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	buffer := sentry.NewLogBuffer(10)
+	hub.Scope().SetLogBuffer(buffer)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	logger := slog.New(sentryslog.NewHandler(sentryslog.Options{}))
+
+	logger.DebugContext(ctx, "ignored")
+	logger.InfoContext(ctx, "charging", "order", "42", "amount", 10)
+	logger.ErrorContext(ctx, "charge failed", "err", errors.New("card declined"))
+
+	lines := buffer.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, want := range []string{
+		` INFO charging amount=10 order=42`,
+		` ERROR charge failed error="card declined"`,
+	} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestHandlerPromotionRules(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	rules, err := sentry.ParsePromotionRules("logger=payments AND level>=warn AND count>1/min")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(sentryslog.NewHandler(sentryslog.Options{PromotionRules: rules}))
+
+	logger.ErrorContext(ctx, "cache miss", "logger", "cache")
+	for i := 0; i < 3; i++ {
+		logger.WarnContext(ctx, "charge retried", "logger", "payments", "attempt", i)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff(sentry.LevelWarning, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(int64(1), event.Extra["attempt"]); diff != "" {
+		t.Errorf("attempt mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(2, len(event.Breadcrumbs)); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandlerSampler(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record(&events),
+		BeforeSendTransaction: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	logger := slog.New(sentryslog.NewHandler(sentryslog.Options{
+		Sampler: sentry.NewLogSampler(sentry.LogSamplerOptions{
+			SampleRates:  map[string]float64{"cache": 0},
+			DedupeWindow: time.Minute,
+		}),
+	}))
+
+	for i := 0; i < 3; i++ {
+		logger.ErrorContext(ctx, "charge failed", "logger", "payments", "attempt", i)
+		logger.ErrorContext(ctx, "cache miss", "logger", "cache")
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(int64(0), events[0].Extra["attempt"]); diff != "" {
+		t.Errorf("attempt mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -9,31 +9,39 @@ import (
 type spanRecorder struct {
 	mu           sync.Mutex
 	spans        []*Span
+	dropped      int
 	overflowOnce sync.Once
 }
 
 // record stores a span. The first stored span is assumed to be the root of a
 // span tree.
 func (r *spanRecorder) record(s *Span) {
-	maxSpans := defaultMaxSpans
-	if client := CurrentHub().Client(); client != nil {
-		maxSpans = client.options.MaxSpans
+	maxSpans := s.clientOptions().MaxSpans
+	if maxSpans == 0 {
+		maxSpans = defaultMaxSpans
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if len(r.spans) >= maxSpans {
 		r.overflowOnce.Do(func() {
 			root := r.spans[0]
-			Logger.Printf("Too many spans: dropping spans from transaction with TraceID=%s SpanID=%s limit=%d",
+			debugf(LevelWarning, "Too many spans: dropping spans from transaction with TraceID=%s SpanID=%s limit=%d",
 				root.TraceID, root.SpanID, maxSpans)
 		})
-		// TODO(tracing): mark the transaction event in some way to
-		// communicate that spans were dropped.
+		r.dropped++
 		return
 	}
 	r.spans = append(r.spans, s)
 }
 
+// droppedCount returns the number of spans that were not stored because of
+// the MaxSpans limit.
+func (r *spanRecorder) droppedCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
 // root returns the first recorded span. Returns nil if none have been recorded.
 func (r *spanRecorder) root() *Span {
 	r.mu.Lock()
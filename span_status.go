@@ -0,0 +1,128 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// SpanStatusMapping customizes how the status of a span is derived from the
+// error, HTTP status code or gRPC code set on it. See Span.SetError,
+// Span.SetHTTPStatus and Span.SetGRPCCode.
+type SpanStatusMapping struct {
+	// HTTP maps HTTP status codes to span statuses, overriding
+	// HTTPtoSpanStatus.
+	HTTP map[int]SpanStatus
+	// GRPC maps gRPC codes to span statuses, overriding GRPCtoSpanStatus.
+	GRPC map[uint32]SpanStatus
+	// Error, if set, returns the status of a span with the given error, or
+	// SpanStatusUndefined to use SpanStatusFromError.
+	Error func(err error) SpanStatus
+}
+
+// GRPCtoSpanStatus converts a gRPC code, as defined in
+// google.golang.org/grpc/codes, to a SpanStatus.
+func GRPCtoSpanStatus(code uint32) SpanStatus {
+	if code >= uint32(maxSpanStatus-1) {
+		return SpanStatusUnknown
+	}
+	// Span statuses follow the order of gRPC codes, after
+	// SpanStatusUndefined.
+	return SpanStatus(code + 1)
+}
+
+// SpanStatusFromError converts an error to a SpanStatus. Context
+// cancellations, timeouts, and missing or forbidden files have their own
+// status, other errors are internal errors.
+func SpanStatusFromError(err error) SpanStatus {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case err == nil:
+		return SpanStatusOK
+	case errors.Is(err, context.Canceled):
+		return SpanStatusCanceled
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &timeout) && timeout.Timeout():
+		return SpanStatusDeadlineExceeded
+	case errors.Is(err, fs.ErrNotExist):
+		return SpanStatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return SpanStatusPermissionDenied
+	default:
+		return SpanStatusInternalError
+	}
+}
+
+// SetError attaches an error to the span. When the span finishes without a
+// status, its status is derived from the error, unless a gRPC or HTTP status
+// code was also set.
+func (s *Span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// SetHTTPStatus sets the HTTP status code of the response to the request that
+// the span describes. It is recorded in the "http.response.status_code" data
+// of the span and, when the span finishes without a status, its status is
+// derived from it, unless a gRPC code was also set.
+func (s *Span) SetHTTPStatus(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Data == nil {
+		s.Data = make(map[string]interface{})
+	}
+	s.Data["http.response.status_code"] = code
+	s.httpStatusCode = code
+}
+
+// SetGRPCCode sets the gRPC code of the response to the call that the span
+// describes. When the span finishes without a status, its status is derived
+// from it.
+func (s *Span) SetGRPCCode(code uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.grpcCode = &code
+}
+
+// deriveStatus sets the status of a finished span from its gRPC code, HTTP
+// status code, or error, if it has no status yet.
+func (s *Span) deriveStatus() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Status != SpanStatusUndefined {
+		return
+	}
+	if s.grpcCode == nil && s.httpStatusCode == 0 && s.err == nil {
+		return
+	}
+
+	mapping := s.clientOptions().SpanStatusMapping
+	switch {
+	case s.grpcCode != nil:
+		if status, ok := mapping.GRPC[*s.grpcCode]; ok {
+			s.Status = status
+		} else {
+			s.Status = GRPCtoSpanStatus(*s.grpcCode)
+		}
+	case s.httpStatusCode != 0:
+		if status, ok := mapping.HTTP[s.httpStatusCode]; ok {
+			s.Status = status
+		} else {
+			s.Status = HTTPtoSpanStatus(s.httpStatusCode)
+		}
+	default:
+		if mapping.Error != nil {
+			s.Status = mapping.Error(s.err)
+		}
+		if s.Status == SpanStatusUndefined {
+			s.Status = SpanStatusFromError(s.err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package sentrysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+// sentryConn instruments a driver connection. It implements all the optional
+// interfaces of database/sql, delegating to the wrapped connection when it
+// implements them and falling back to the behavior of database/sql otherwise.
+type sentryConn struct {
+	conn    driver.Conn
+	options Options
+}
+
+func (c *sentryConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sentryConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if cp, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = cp.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+		if err == nil && ctx.Err() != nil {
+			stmt.Close()
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sentryStmt{stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *sentryConn) Close() error {
+	return c.conn.Close()
+}
+
+//nolint:staticcheck // Begin is deprecated but still part of driver.Conn.
+func (c *sentryConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *sentryConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cb, ok := c.conn.(driver.ConnBeginTx); ok {
+		return cb.BeginTx(ctx, opts)
+	}
+	// Same checks as database/sql for drivers without BeginTx.
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	//nolint:staticcheck // Begin is the fallback of BeginTx.
+	return c.conn.Begin()
+}
+
+func (c *sentryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var run func() (driver.Result, error)
+	switch conn := c.conn.(type) {
+	case driver.ExecerContext:
+		run = func() (driver.Result, error) {
+			return conn.ExecContext(ctx, query, args)
+		}
+	case driver.Execer: //nolint:staticcheck // Execer is deprecated but still used by drivers.
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		run = func() (driver.Result, error) {
+			return conn.Exec(query, values)
+		}
+	default:
+		// database/sql prepares a statement instead.
+		return nil, driver.ErrSkip
+	}
+
+	var result driver.Result
+	err := instrument(ctx, c.options, query, func() (driver.Result, error) {
+		var err error
+		result, err = run()
+		return result, err
+	})
+	return result, err
+}
+
+func (c *sentryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var run func() (driver.Rows, error)
+	switch conn := c.conn.(type) {
+	case driver.QueryerContext:
+		run = func() (driver.Rows, error) {
+			return conn.QueryContext(ctx, query, args)
+		}
+	case driver.Queryer: //nolint:staticcheck // Queryer is deprecated but still used by drivers.
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		run = func() (driver.Rows, error) {
+			return conn.Query(query, values)
+		}
+	default:
+		// database/sql prepares a statement instead.
+		return nil, driver.ErrSkip
+	}
+
+	var rows driver.Rows
+	err := instrument(ctx, c.options, query, func() (driver.Result, error) {
+		var err error
+		rows, err = run()
+		return nil, err
+	})
+	return rows, err
+}
+
+func (c *sentryConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sentryConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sentryConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *sentryConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	// database/sql converts the value itself.
+	return driver.ErrSkip
+}
+
+// sentryStmt instruments a prepared statement.
+type sentryStmt struct {
+	stmt  driver.Stmt
+	conn  *sentryConn
+	query string
+}
+
+func (s *sentryStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *sentryStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+//nolint:staticcheck // Exec is deprecated but still part of driver.Stmt.
+func (s *sentryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamedValues(args))
+}
+
+//nolint:staticcheck // Query is deprecated but still part of driver.Stmt.
+func (s *sentryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (s *sentryStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var result driver.Result
+	err := instrument(ctx, s.conn.options, s.query, func() (driver.Result, error) {
+		var err error
+		if se, ok := s.stmt.(driver.StmtExecContext); ok {
+			result, err = se.ExecContext(ctx, args)
+			return result, err
+		}
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		result, err = s.stmt.Exec(values) //nolint:staticcheck // fallback for drivers without ExecContext.
+		return result, err
+	})
+	return result, err
+}
+
+func (s *sentryStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := instrument(ctx, s.conn.options, s.query, func() (driver.Result, error) {
+		var err error
+		if sq, ok := s.stmt.(driver.StmtQueryContext); ok {
+			rows, err = sq.QueryContext(ctx, args)
+			return nil, err
+		}
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		rows, err = s.stmt.Query(values) //nolint:staticcheck // fallback for drivers without QueryContext.
+		return nil, err
+	})
+	return rows, err
+}
+
+// CheckNamedValue delegates to the statement, or to its connection, which
+// database/sql would use if the statement weren't wrapped.
+func (s *sentryStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
@@ -0,0 +1,103 @@
+package sentrysql
+
+import (
+	"strings"
+)
+
+// SanitizeQuery removes the values that a query may contain, so that it can
+// be used as a span description: string and number literals are replaced with
+// "?", comments are removed, and whitespace is collapsed. Quoted identifiers
+// and placeholders, like "$1", "?" or ":name", are kept as is.
+func SanitizeQuery(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	space := false
+	write := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case isSpace(c):
+			space = true
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			space = true
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			space = true
+			i += end + 4
+		case c == '\'':
+			i = skipQuoted(query, i)
+			write("?")
+		case c == '"' || c == '`':
+			end := skipQuoted(query, i)
+			write(query[i:end])
+			i = end
+		case isDigit(c):
+			end := i
+			for end < len(query) && (isIdentifier(query[end]) || query[end] == '.') {
+				end++
+			}
+			write("?")
+			i = end
+		case isIdentifier(c):
+			// Identifiers, keywords and placeholders, which may contain
+			// digits that aren't literals.
+			end := i
+			for end < len(query) && isIdentifier(query[end]) {
+				end++
+			}
+			write(query[i:end])
+			i = end
+		default:
+			write(query[i : i+1])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipQuoted returns the index following the quoted string or identifier
+// starting at i. Quotes are escaped by doubling them.
+func skipQuoted(query string, i int) int {
+	quote := query[i]
+	for i++; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentifier(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		c == '_' || c == '$' || c == '@' || c == ':' || c >= 0x80
+}
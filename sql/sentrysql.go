@@ -0,0 +1,199 @@
+// Package sentrysql provides Sentry instrumentation for database/sql drivers.
+//
+// Queries and statements executed with a context containing a span are
+// recorded in "db.query" child spans, with the SQL stripped of its literal
+// values as description:
+//
+//	db := sql.OpenDB(sentrysql.NewConnector(connector, sentrysql.Options{
+//		DatabaseSystem: "postgresql",
+//	}))
+//	rows, err := db.QueryContext(transaction.Context(), "SELECT * FROM users WHERE id = $1", id)
+//
+// For drivers that don't provide a driver.Connector, register a wrapped driver
+// instead:
+//
+//	sql.Register("sentry-sqlite3", sentrysql.WrapDriver(&sqlite3.SQLiteDriver{}, sentrysql.Options{
+//		DatabaseSystem: "sqlite",
+//	}))
+//	db, err := sql.Open("sentry-sqlite3", "file:app.db")
+package sentrysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// spanOperation is the operation of the spans recorded for queries.
+const spanOperation = "db.query"
+
+// spanOrigin is the origin of the spans recorded for queries.
+const spanOrigin = "auto.db.sql"
+
+// Options configure the instrumentation of a driver.
+type Options struct {
+	// DatabaseSystem identifies the database management system, for example
+	// "postgresql" or "mysql". It is recorded as the "db.system" span data.
+	DatabaseSystem string
+	// DatabaseName is the name of the database being accessed. It is recorded
+	// as the "db.name" span data.
+	DatabaseName string
+	// CaptureErrors configures whether errors returned by the driver are
+	// reported to Sentry, in addition to setting the status of the span.
+	// Errors are reported to the hub of the query context, or to the current
+	// hub. Context cancellations and errors handled by database/sql, like
+	// driver.ErrBadConn, are never reported.
+	CaptureErrors bool
+}
+
+type sentryDriver struct {
+	driver  driver.Driver
+	options Options
+}
+
+// WrapDriver returns a driver instrumenting the connections opened by d.
+func WrapDriver(d driver.Driver, options Options) driver.Driver {
+	return &sentryDriver{driver: d, options: options}
+}
+
+func (d *sentryDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sentryConn{conn: conn, options: d.options}, nil
+}
+
+func (d *sentryDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &sentryConnector{connector: connector, driver: d}, nil
+	}
+	return &dsnConnector{name: name, driver: d}, nil
+}
+
+type sentryConnector struct {
+	connector driver.Connector
+	driver    *sentryDriver
+}
+
+// NewConnector returns a connector instrumenting the connections opened by c.
+// Use it with sql.OpenDB.
+func NewConnector(c driver.Connector, options Options) driver.Connector {
+	return &sentryConnector{
+		connector: c,
+		driver:    &sentryDriver{driver: c.Driver(), options: options},
+	}
+}
+
+func (c *sentryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sentryConn{conn: conn, options: c.driver.options}, nil
+}
+
+func (c *sentryConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close closes the wrapped connector if it implements io.Closer, as done by
+// sql.DB.Close.
+func (c *sentryConnector) Close() error {
+	if closer, ok := c.connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dsnConnector is the connector of drivers that don't implement
+// driver.DriverContext, opening connections with a fixed data source name.
+type dsnConnector struct {
+	name   string
+	driver *sentryDriver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrument runs a query in a "db.query" child span of the span in ctx, if
+// any, and reports the error it returns. Errors are recorded in the span
+// status, and results in the number of rows affected.
+func instrument(ctx context.Context, options Options, query string, run func() (driver.Result, error)) error {
+	parent := sentry.SpanFromContext(ctx)
+	if parent == nil {
+		_, err := run()
+		captureError(ctx, options, query, err)
+		return err
+	}
+
+	start := time.Now()
+	result, err := run()
+	if errors.Is(err, driver.ErrSkip) {
+		// database/sql runs the query another way, which is recorded in its
+		// own span.
+		return err
+	}
+
+	span := parent.StartChild(spanOperation, sentry.WithSpanOrigin(spanOrigin))
+	span.StartTime = start
+	span.Description = SanitizeQuery(query)
+	if options.DatabaseSystem != "" {
+		span.SetData("db.system", options.DatabaseSystem)
+	}
+	if options.DatabaseName != "" {
+		span.SetData("db.name", options.DatabaseName)
+	}
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.Status = sentry.SpanStatusOK
+		if result != nil {
+			if n, err := result.RowsAffected(); err == nil {
+				span.SetData("db.rows_affected", strconv.FormatInt(n, 10))
+			}
+		}
+	}
+	span.Finish()
+	captureError(ctx, options, query, err)
+	return err
+}
+
+// captureError reports a driver error to Sentry, if enabled.
+func captureError(ctx context.Context, options Options, query string, err error) {
+	if !options.CaptureErrors || err == nil ||
+		errors.Is(err, driver.ErrSkip) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, driver.ErrRemoveArgument) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetContext("database", sentry.Context{
+			"query":  SanitizeQuery(query),
+			"system": options.DatabaseSystem,
+			"name":   options.DatabaseName,
+		})
+		hub.CaptureException(err)
+	})
+}
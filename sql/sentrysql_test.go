@@ -0,0 +1,189 @@
+package sentrysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT * FROM users WHERE id = $1",
+			want:  "SELECT * FROM users WHERE id = $1",
+		},
+		{
+			query: "SELECT * FROM users WHERE name = 'O''Brien' AND age > 42",
+			want:  "SELECT * FROM users WHERE name = ? AND age > ?",
+		},
+		{
+			query: "INSERT INTO t1 (a, b)\n\tVALUES (1.5, 0x1F), (?, :name)",
+			want:  "INSERT INTO t1 (a, b) VALUES (?, ?), (?, :name)",
+		},
+		{
+			query: `SELECT "weird""column" FROM t2 -- secret 'token'` + "\nWHERE x = 1 /* id=42 */",
+			want:  `SELECT "weird""column" FROM t2 WHERE x = ?`,
+		},
+		{
+			query: "SELECT `user` FROM t WHERE s = 'unterminated",
+			want:  "SELECT `user` FROM t WHERE s = ?",
+		},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, SanitizeQuery(tt.query)); diff != "" {
+			t.Errorf("SanitizeQuery(%q) mismatch (-want +got):\n%s", tt.query, diff)
+		}
+	}
+}
+
+var errQuery = errors.New("syntax error")
+
+// fakeConn is a connection executing queries without a database. The query
+// "fail" returns errQuery, and the query "skip" is run with a prepared
+// statement.
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	switch query {
+	case "fail":
+		return nil, errQuery
+	case "skip":
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(3), nil
+}
+
+// fakeStmt is a statement of a driver without context support.
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+func TestConnector(t *testing.T) {
+	var events []*sentry.Event
+	record := func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		events = append(events, event)
+		return nil
+	}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:         true,
+		TracesSampleRate:      1.0,
+		BeforeSend:            record,
+		BeforeSendTransaction: record,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	db := sql.OpenDB(NewConnector(fakeConnector{}, Options{
+		DatabaseSystem: "postgresql",
+		DatabaseName:   "app",
+		CaptureErrors:  true,
+	}))
+	defer db.Close()
+
+	transaction := sentry.StartTransaction(ctx, "test")
+	if _, err := db.ExecContext(transaction.Context(), "UPDATE users SET name = 'bob' WHERE id = 7"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.QueryContext(transaction.Context(), "SELECT id FROM users WHERE id = $1", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if _, err := db.ExecContext(transaction.Context(), "fail"); !errors.Is(err, errQuery) {
+		t.Fatalf("got error %v, want %v", err, errQuery)
+	}
+	if _, err := db.ExecContext(transaction.Context(), "skip"); err != nil {
+		t.Fatal(err)
+	}
+	// Queries outside of a transaction aren't recorded.
+	if _, err := db.ExecContext(ctx, "DELETE FROM users"); err != nil {
+		t.Fatal(err)
+	}
+	transaction.Finish()
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if diff := cmp.Diff(errQuery.Error(), events[0].Exception[0].Value); diff != "" {
+		t.Errorf("error event mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("fail", events[0].Contexts["database"]["query"]); diff != "" {
+		t.Errorf("error event query mismatch (-want +got):\n%s", diff)
+	}
+
+	type span struct {
+		Description string
+		Status      sentry.SpanStatus
+		Data        map[string]interface{}
+	}
+	var got []span
+	for _, s := range events[1].Spans {
+		if s.Op != "db.query" {
+			t.Errorf("span op = %q, want db.query", s.Op)
+		}
+		got = append(got, span{Description: s.Description, Status: s.Status, Data: s.Data})
+	}
+	data := func(extra map[string]interface{}) map[string]interface{} {
+		m := map[string]interface{}{"db.system": "postgresql", "db.name": "app"}
+		for k, v := range extra {
+			m[k] = v
+		}
+		return m
+	}
+	want := []span{
+		{
+			Description: "UPDATE users SET name = ? WHERE id = ?",
+			Status:      sentry.SpanStatusOK,
+			Data:        data(map[string]interface{}{"db.rows_affected": "3"}),
+		},
+		{
+			Description: "SELECT id FROM users WHERE id = $1",
+			Status:      sentry.SpanStatusOK,
+			Data:        data(nil),
+		},
+		{
+			Description: "fail",
+			Status:      sentry.SpanStatusInternalError,
+			Data:        data(nil),
+		},
+		{
+			Description: "skip",
+			Status:      sentry.SpanStatusOK,
+			Data:        data(map[string]interface{}{"db.rows_affected": "1"}),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+}
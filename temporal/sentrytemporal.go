@@ -0,0 +1,294 @@
+// Package sentrytemporal provides Sentry instrumentation for Temporal clients
+// and workers built with go.temporal.io/sdk.
+//
+// Register the interceptor with both the client and the workers:
+//
+//	c, err := client.Dial(client.Options{
+//		Interceptors: []interceptor.ClientInterceptor{sentrytemporal.New(sentrytemporal.Options{})},
+//	})
+//	w := worker.New(c, "task-queue", worker.Options{
+//		Interceptors: []interceptor.WorkerInterceptor{sentrytemporal.New(sentrytemporal.Options{})},
+//	})
+//
+// Clients propagate the trace of the span in the context starting a workflow
+// in the sentry-trace and baggage Temporal headers, which workflows forward to
+// their activities and child workflows. Activities run in "temporal.activity"
+// transactions continuing that trace.
+//
+// Workflow and activity failures and panics are reported to Sentry, with the
+// workflow and run IDs as tags. Workflow failures are only reported while the
+// workflow is not replaying its history, so that they are reported once, with
+// the stack trace of the execution that failed.
+package sentrytemporal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// activityOperation is the operation of the transactions of activities.
+const activityOperation = "temporal.activity"
+
+// spanOrigin is the origin of the transactions of activities.
+const spanOrigin = "auto.temporal"
+
+// Options configure the interceptor.
+type Options struct {
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before panicking again for Temporal to handle the panic.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+type sentryInterceptor struct {
+	interceptor.InterceptorBase
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// New returns a Temporal interceptor, to register with clients as an
+// interceptor.ClientInterceptor and with workers as an
+// interceptor.WorkerInterceptor.
+func New(options Options) interceptor.Interceptor {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	return &sentryInterceptor{
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+}
+
+func (i *sentryInterceptor) InterceptClient(next interceptor.ClientOutboundInterceptor) interceptor.ClientOutboundInterceptor {
+	c := &clientOutbound{}
+	c.Next = next
+	return c
+}
+
+func (i *sentryInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	a := &activityInbound{root: i}
+	a.Next = next
+	return a
+}
+
+func (i *sentryInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	w := &workflowInbound{root: i}
+	w.Next = next
+	return w
+}
+
+// recoverWithHub reports a panic to hub. The caller panics again.
+func (i *sentryInterceptor) recoverWithHub(ctx context.Context, hub *sentry.Hub, r interface{}) {
+	eventID := hub.RecoverWithContext(ctx, r)
+	if eventID != nil && i.waitForDelivery {
+		hub.Flush(i.timeout)
+	}
+}
+
+type clientOutbound struct {
+	interceptor.ClientOutboundInterceptorBase
+}
+
+func (c *clientOutbound) ExecuteWorkflow(ctx context.Context, in *interceptor.ClientExecuteWorkflowInput) (client.WorkflowRun, error) {
+	traceHeadersFromContext(ctx).write(interceptor.Header(ctx))
+	return c.Next.ExecuteWorkflow(ctx, in)
+}
+
+func (c *clientOutbound) SignalWithStartWorkflow(ctx context.Context, in *interceptor.ClientSignalWithStartWorkflowInput) (client.WorkflowRun, error) {
+	traceHeadersFromContext(ctx).write(interceptor.Header(ctx))
+	return c.Next.SignalWithStartWorkflow(ctx, in)
+}
+
+type activityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+	root *sentryInterceptor
+}
+
+func (a *activityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (result interface{}, err error) {
+	info := activity.GetInfo(ctx)
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	hub.Scope().SetTags(map[string]string{
+		"temporal.workflow_id": info.WorkflowExecution.ID,
+		"temporal.run_id":      info.WorkflowExecution.RunID,
+	})
+	hub.Scope().SetContext("temporal", sentry.Context{
+		"workflow_type": info.WorkflowType.Name,
+		"activity_id":   info.ActivityID,
+		"activity_type": info.ActivityType.Name,
+		"task_queue":    info.TaskQueue,
+		"attempt":       info.Attempt,
+	})
+
+	headers := readTraceHeaders(interceptor.Header(ctx))
+	transaction := sentry.StartTransaction(ctx, info.ActivityType.Name,
+		sentry.WithOpName(activityOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.ContinueFromHeaders(headers.trace, headers.baggage),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	defer transaction.Finish()
+
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Status = sentry.SpanStatusInternalError
+			a.root.recoverWithHub(transaction.Context(), hub, r)
+			panic(r)
+		}
+	}()
+
+	result, err = a.Next.ExecuteActivity(transaction.Context(), in)
+	if err != nil && !errors.Is(err, activity.ErrResultPending) {
+		transaction.SetError(err)
+		if reportable(err) {
+			hub.CaptureException(err)
+		}
+	} else {
+		transaction.Status = sentry.SpanStatusOK
+	}
+	return result, err
+}
+
+type workflowInbound struct {
+	interceptor.WorkflowInboundInterceptorBase
+	root *sentryInterceptor
+	// headers are the trace headers of the workflow, forwarded to its
+	// activities and child workflows.
+	headers traceHeaders
+}
+
+func (w *workflowInbound) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	o := &workflowOutbound{root: w}
+	o.Next = outbound
+	return w.Next.Init(o)
+}
+
+func (w *workflowInbound) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (result interface{}, err error) {
+	w.headers = readTraceHeaders(interceptor.WorkflowHeader(ctx))
+
+	defer func() {
+		if r := recover(); r != nil {
+			if !workflow.IsReplaying(ctx) {
+				w.root.recoverWithHub(context.Background(), workflowHub(ctx), r)
+			}
+			panic(r)
+		}
+	}()
+
+	result, err = w.Next.ExecuteWorkflow(ctx, in)
+	if err != nil && reportable(err) && !workflow.IsContinueAsNewError(err) && !workflow.IsReplaying(ctx) {
+		workflowHub(ctx).CaptureException(err)
+	}
+	return result, err
+}
+
+// workflowHub returns a hub whose scope has the metadata of the workflow of
+// ctx. Workflows have no context.Context to hold a hub, it is cloned from the
+// current hub.
+func workflowHub(ctx workflow.Context) *sentry.Hub {
+	info := workflow.GetInfo(ctx)
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().SetTags(map[string]string{
+		"temporal.workflow_id": info.WorkflowExecution.ID,
+		"temporal.run_id":      info.WorkflowExecution.RunID,
+	})
+	hub.Scope().SetContext("temporal", sentry.Context{
+		"workflow_type": info.WorkflowType.Name,
+		"task_queue":    info.TaskQueueName,
+		"attempt":       info.Attempt,
+	})
+	return hub
+}
+
+type workflowOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+	root *workflowInbound
+}
+
+func (o *workflowOutbound) ExecuteActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	o.root.headers.write(interceptor.WorkflowHeader(ctx))
+	return o.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+func (o *workflowOutbound) ExecuteLocalActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	o.root.headers.write(interceptor.WorkflowHeader(ctx))
+	return o.Next.ExecuteLocalActivity(ctx, activityType, args...)
+}
+
+func (o *workflowOutbound) ExecuteChildWorkflow(ctx workflow.Context, childWorkflowType string, args ...interface{}) workflow.ChildWorkflowFuture {
+	o.root.headers.write(interceptor.WorkflowHeader(ctx))
+	return o.Next.ExecuteChildWorkflow(ctx, childWorkflowType, args...)
+}
+
+// reportable reports whether err is reported to Sentry. Cancellations are not.
+func reportable(err error) bool {
+	return !temporal.IsCanceledError(err) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// traceHeaders propagate a trace in Temporal headers.
+type traceHeaders struct {
+	trace   string
+	baggage string
+}
+
+func traceHeadersFromContext(ctx context.Context) traceHeaders {
+	span := sentry.SpanFromContext(ctx)
+	if span == nil {
+		return traceHeaders{}
+	}
+	return traceHeaders{trace: span.ToSentryTrace(), baggage: span.ToBaggage()}
+}
+
+func readTraceHeaders(header map[string]*commonpb.Payload) traceHeaders {
+	read := func(key string) string {
+		var value string
+		if payload := header[key]; payload != nil {
+			_ = converter.GetDefaultDataConverter().FromPayload(payload, &value)
+		}
+		return value
+	}
+	return traceHeaders{
+		trace:   read(sentry.SentryTraceHeader),
+		baggage: read(sentry.SentryBaggageHeader),
+	}
+}
+
+// write sets the headers in header, which may be nil when the operation
+// doesn't support headers.
+func (h traceHeaders) write(header map[string]*commonpb.Payload) {
+	if header == nil || h.trace == "" {
+		return
+	}
+	for key, value := range map[string]string{
+		sentry.SentryTraceHeader:   h.trace,
+		sentry.SentryBaggageHeader: h.baggage,
+	} {
+		if value == "" {
+			continue
+		}
+		if payload, err := converter.GetDefaultDataConverter().ToPayload(value); err == nil {
+			header[key] = payload
+		}
+	}
+}
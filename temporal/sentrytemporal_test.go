@@ -0,0 +1,126 @@
+package sentrytemporal_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentrytemporal "github.com/getsentry/sentry-go/temporal"
+	"github.com/google/go-cmp/cmp"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+func Charge(ctx context.Context) error {
+	return errors.New("card declined")
+}
+
+func Checkout(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+	})
+	return workflow.ExecuteActivity(ctx, Charge).Get(ctx, nil)
+}
+
+func TestInterceptor(t *testing.T) {
+	var (
+		mu                   sync.Mutex
+		errors, transactions []*sentry.Event
+	)
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			mu.Lock()
+			defer mu.Unlock()
+			errors = append(errors, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			mu.Lock()
+			defer mu.Unlock()
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentry.CurrentHub().BindClient(client)
+	defer sentry.CurrentHub().BindClient(nil)
+
+	// The header set by the client starting the workflow.
+	producer := sentry.StartTransaction(context.Background(), "producer")
+	payload, err := converter.GetDefaultDataConverter().ToPayload(producer.ToSentryTrace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{
+		Interceptors: []interceptor.WorkerInterceptor{sentrytemporal.New(sentrytemporal.Options{})},
+	})
+	env.SetHeader(&commonpb.Header{Fields: map[string]*commonpb.Payload{sentry.SentryTraceHeader: payload}})
+	env.RegisterWorkflow(Checkout)
+	env.RegisterActivity(Charge)
+	env.ExecuteWorkflow(Checkout)
+	if env.GetWorkflowError() == nil {
+		t.Fatal("expected a workflow error")
+	}
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	trace := transactions[0].Contexts["trace"]
+	if diff := cmp.Diff("temporal.activity", trace["op"]); diff != "" {
+		t.Errorf("transaction operation mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(producer.TraceID, trace["trace_id"]); diff != "" {
+		t.Errorf("trace ID mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(producer.SpanID, trace["parent_span_id"]); diff != "" {
+		t.Errorf("parent span ID mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, trace["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+
+	// The activity failure, then the workflow failure.
+	if len(errors) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errors))
+	}
+	for _, event := range errors {
+		if diff := cmp.Diff([]string{"temporal.run_id", "temporal.workflow_id"}, keys(event.Tags)); diff != "" {
+			t.Errorf("tags mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff("Checkout", event.Contexts["temporal"]["workflow_type"]); diff != "" {
+			t.Errorf("workflow type mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if diff := cmp.Diff("Charge", errors[0].Contexts["temporal"]["activity_type"]); diff != "" {
+		t.Errorf("activity type mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(trace["trace_id"], errors[0].Contexts["trace"]["trace_id"]); diff != "" {
+		t.Errorf("activity error trace mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func keys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
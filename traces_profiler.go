@@ -11,19 +11,23 @@ func (span *Span) sampleTransactionProfile() {
 	var sampleRate = span.clientOptions().ProfilesSampleRate
 	switch {
 	case sampleRate < 0.0 || sampleRate > 1.0:
-		Logger.Printf("Skipping transaction profiling: ProfilesSampleRate out of range [0.0, 1.0]: %f\n", sampleRate)
+		span.debugf(LevelWarning, "Skipping transaction profiling: ProfilesSampleRate out of range [0.0, 1.0]: %f\n", sampleRate)
 	case sampleRate == 0.0 || rng.Float64() >= sampleRate:
-		Logger.Printf("Skipping transaction profiling: ProfilesSampleRate is: %f\n", sampleRate)
+		span.debugf(LevelWarning, "Skipping transaction profiling: ProfilesSampleRate is: %f\n", sampleRate)
 	default:
 		startProfilerOnce.Do(startGlobalProfiler)
 		if globalProfiler == nil {
-			Logger.Println("Skipping transaction profiling: the profiler couldn't be started")
+			span.debugf(LevelWarning, "Skipping transaction profiling: the profiler couldn't be started")
 		} else {
 			span.collectProfile = collectTransactionProfile
 		}
 	}
 }
 
+// profileContextKey is the key of the context linking a transaction to its
+// profile.
+const profileContextKey = "profile"
+
 // transactionProfiler collects a profile for a given span.
 type transactionProfiler func(span *Span) *profileInfo
 
@@ -62,6 +66,8 @@ func collectTransactionProfile(span *Span) *profileInfo {
 	return info
 }
 
+// UpdateFromEvent fills in the attributes of the profile from those of the
+// transaction it belongs to.
 func (info *profileInfo) UpdateFromEvent(event *Event) {
 	info.Environment = event.Environment
 	info.Platform = event.Platform
@@ -69,22 +75,16 @@ func (info *profileInfo) UpdateFromEvent(event *Event) {
 	info.Dist = event.Dist
 	info.Transaction.ID = event.EventID
 
-	if runtimeContext, ok := event.Contexts["runtime"]; ok {
-		if value, ok := runtimeContext["name"]; !ok {
-			info.Runtime.Name = value.(string)
-		}
-		if value, ok := runtimeContext["version"]; !ok {
-			info.Runtime.Version = value.(string)
-		}
+	if value, ok := event.Contexts["runtime"]["name"].(string); ok {
+		info.Runtime.Name = value
 	}
-	if osContext, ok := event.Contexts["os"]; ok {
-		if value, ok := osContext["name"]; !ok {
-			info.OS.Name = value.(string)
-		}
+	if value, ok := event.Contexts["runtime"]["version"].(string); ok {
+		info.Runtime.Version = value
 	}
-	if deviceContext, ok := event.Contexts["device"]; ok {
-		if value, ok := deviceContext["arch"]; !ok {
-			info.Device.Architecture = value.(string)
-		}
+	if value, ok := event.Contexts["os"]["name"].(string); ok {
+		info.OS.Name = value
+	}
+	if value, ok := event.Contexts["device"]["arch"].(string); ok {
+		info.Device.Architecture = value
 	}
 }
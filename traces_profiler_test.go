@@ -2,6 +2,7 @@ package sentry
 
 import (
 	"io"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -57,6 +58,11 @@ func TestTraceProfiling(t *testing.T) {
 	require.Equal(event.EventID, profileInfo.Transaction.ID)
 	require.Greater(profileInfo.Transaction.ActiveThreadID, uint64(0))
 	require.Equal(span.TraceID.String(), profileInfo.Transaction.TraceID)
+	require.Equal(Context{"profile_id": profileInfo.EventID}, event.Contexts["profile"])
+	require.Equal("go", profileInfo.Runtime.Name)
+	require.Equal(runtime.Version(), profileInfo.Runtime.Version)
+	require.Equal(runtime.GOOS, profileInfo.OS.Name)
+	require.Equal(runtime.GOARCH, profileInfo.Device.Architecture)
 	validateProfile(t, profileInfo.Trace, span.EndTime.Sub(span.StartTime))
 	require.NotNil(globalProfiler)
 }
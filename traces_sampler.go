@@ -2,12 +2,17 @@ package sentry
 
 // A SamplingContext is passed to a TracesSampler to determine a sampling
 // decision.
-//
-// TODO(tracing): possibly expand SamplingContext to include custom /
-// user-provided data.
 type SamplingContext struct {
 	Span   *Span // The current span, always non-nil.
 	Parent *Span // The parent span, may be nil.
+	// ParentSampled is the sampling decision of the parent span, either local
+	// or propagated by an upstream service. Samplers usually return 1.0 or 0.0
+	// when it is defined, so that traces are either complete or dropped.
+	ParentSampled Sampled
+	// TransactionName is the name of the transaction being started.
+	TransactionName string
+	// Data is the custom data given to WithSamplingContext, may be nil.
+	Data map[string]interface{}
 }
 
 // The TracesSample type is an adapter to allow the use of ordinary
@@ -17,3 +22,72 @@ type TracesSampler func(ctx SamplingContext) float64
 func (f TracesSampler) Sample(ctx SamplingContext) float64 {
 	return f(ctx)
 }
+
+// A TracesSamplingRule sets the sample rate of the transactions matching it.
+// See ClientOptions.TracesSamplingRules.
+type TracesSamplingRule struct {
+	// Name is a glob pattern matched against the transaction name, in which
+	// '*' matches any sequence of characters and '?' matches any single
+	// character. An empty Name matches all transactions.
+	Name string
+	// Tags must all be set to the given values, either on the transaction or
+	// on the scope of the hub it is started with.
+	Tags map[string]string
+	// Rate is the sample rate of the matching transactions, in the range
+	// [0.0, 1.0].
+	Rate float64
+}
+
+// matches reports whether the rule applies to the transaction s.
+func (r TracesSamplingRule) matches(s *Span) bool {
+	if r.Name != "" && !matchGlob(r.Name, s.Name) {
+		return false
+	}
+	if len(r.Tags) == 0 {
+		return true
+	}
+	var scope *Scope
+	if hub := hubFromContext(s.ctx); hub != nil {
+		scope = hub.Scope()
+	}
+	for key, value := range r.Tags {
+		tag, ok := s.Tags[key]
+		if !ok && scope != nil {
+			tag, ok = scope.tag(key)
+		}
+		if !ok || tag != value {
+			return false
+		}
+	}
+	return true
+}
+
+// matchGlob reports whether name matches pattern, in which '*' matches any
+// sequence of characters, including none, and '?' matches any single
+// character.
+func matchGlob(pattern, name string) bool {
+	p, n := []rune(pattern), []rune(name)
+	// Position of the last '*' in p and the position in n it was matched at,
+	// to backtrack to when the rest of the pattern does not match.
+	star, match := -1, 0
+	i, j := 0, 0
+	for j < len(n) {
+		switch {
+		case i < len(p) && (p[i] == '?' || p[i] == n[j]):
+			i++
+			j++
+		case i < len(p) && p[i] == '*':
+			star, match = i, j
+			i++
+		case star >= 0:
+			match++
+			i, j = star+1, match
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
@@ -58,3 +58,119 @@ func repeatedSample(sampler TracesSampler, ctx SamplingContext, count int) (obse
 	}
 	return n / float64(count)
 }
+
+func TestTracesSamplerSamplingContext(t *testing.T) {
+	var got SamplingContext
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing: true,
+		TracesSampler: func(ctx SamplingContext) float64 {
+			got = ctx
+			if ctx.Data["route"] == "/health" {
+				return 0.0
+			}
+			return 1.0
+		},
+	})
+
+	transaction := StartTransaction(ctx, "GET /health", WithSamplingContext(map[string]interface{}{"route": "/health"}))
+	assertEqual(t, transaction.Sampled, SampledFalse)
+	assertEqual(t, got.TransactionName, "GET /health")
+	assertEqual(t, got.ParentSampled, SampledUndefined)
+
+	transaction = StartTransaction(ctx, "POST /checkout", WithSamplingContext(map[string]interface{}{"route": "/checkout"}))
+	assertEqual(t, transaction.Sampled, SampledTrue)
+}
+
+func TestTracesSamplerOverridesRemoteParentDecision(t *testing.T) {
+	var got SamplingContext
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing: true,
+		TracesSampler: func(ctx SamplingContext) float64 {
+			got = ctx
+			return 0.0
+		},
+	})
+
+	transaction := StartTransaction(ctx, "GET /health", ContinueFromTrace("bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1"))
+	assertEqual(t, got.ParentSampled, SampledTrue)
+	assertEqual(t, transaction.Sampled, SampledFalse)
+
+	// Explicit decisions are not passed to the TracesSampler.
+	transaction = StartTransaction(ctx, "GET /health", WithSpanSampled(SampledTrue))
+	assertEqual(t, transaction.Sampled, SampledTrue)
+
+	// An explicit decision equal to the one of the remote parent isn't
+	// overridden either.
+	transaction = StartTransaction(ctx, "GET /health",
+		ContinueFromTrace("bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1"),
+		WithSpanSampled(SampledTrue),
+	)
+	assertEqual(t, transaction.Sampled, SampledTrue)
+
+	// Without a TracesSampler, the decision of the remote parent is used.
+	ctx = NewTestContext(ClientOptions{EnableTracing: true})
+	transaction = StartTransaction(ctx, "GET /health", ContinueFromTrace("bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1"))
+	assertEqual(t, transaction.Sampled, SampledTrue)
+}
+
+func TestTracesSamplingRules(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		TracesSamplingRules: []TracesSamplingRule{
+			{Name: "GET /health*", Rate: 0.0},
+			{Tags: map[string]string{"tenant": "internal"}, Rate: 0.0},
+			{Name: "POST /*", Tags: map[string]string{"tenant": "acme"}, Rate: 0.5},
+		},
+	})
+
+	transaction := StartTransaction(ctx, "GET /healthz")
+	assertEqual(t, transaction.Sampled, SampledFalse)
+	assertEqual(t, transaction.sampleRate, 0.0)
+
+	transaction = StartTransaction(ctx, "GET /users")
+	assertEqual(t, transaction.Sampled, SampledTrue)
+	assertEqual(t, transaction.sampleRate, 1.0)
+
+	// Tags are read from the scope.
+	hubFromContext(ctx).Scope().SetTag("tenant", "internal")
+	transaction = StartTransaction(ctx, "GET /users")
+	assertEqual(t, transaction.Sampled, SampledFalse)
+
+	hubFromContext(ctx).Scope().SetTag("tenant", "acme")
+	transaction = StartTransaction(ctx, "POST /orders")
+	assertEqual(t, transaction.sampleRate, 0.5)
+	transaction = StartTransaction(ctx, "GET /orders")
+	assertEqual(t, transaction.sampleRate, 1.0)
+
+	// Decisions of remote parents are not overridden.
+	transaction = StartTransaction(ctx, "GET /healthz", ContinueFromTrace("bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1"))
+	assertEqual(t, transaction.Sampled, SampledTrue)
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"", "", true},
+		{"", "GET /", false},
+		{"*", "", true},
+		{"*", "GET /users/{id}", true},
+		{"GET /users/*", "GET /users/{id}/orders", true},
+		{"GET /users/*", "POST /users/{id}", false},
+		{"*/orders", "GET /users/{id}/orders", true},
+		{"*/orders", "GET /users/{id}/orders/{id}", false},
+		{"GET /user?", "GET /users", true},
+		{"GET /user?", "GET /user", false},
+		{"*a*b*c", "xaxbxbxc", true},
+		{"*a*b*c", "xaxbxbxcx", false},
+		{"café ?", "café ☕", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %t, want %t", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -11,11 +11,19 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/getsentry/sentry-go/internal/ratelimit"
 )
 
 const (
 	SentryTraceHeader   = "sentry-trace"
 	SentryBaggageHeader = "baggage"
+	// TraceparentHeader and TracestateHeader are the headers of the W3C Trace
+	// Context specification, used by OpenTelemetry.
+	//
+	// See https://www.w3.org/TR/trace-context/.
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
 )
 
 // A Span is the building block of a Sentry transaction. Spans build up a tree
@@ -35,6 +43,7 @@ type Span struct { //nolint: maligned // prefer readability over optimal memory
 	StartTime    time.Time              `json:"start_timestamp"`
 	EndTime      time.Time              `json:"timestamp"`
 	Data         map[string]interface{} `json:"data,omitempty"`
+	Origin       SpanOrigin             `json:"origin,omitempty"`
 	Sampled      Sampled                `json:"-"`
 	Source       TransactionSource      `json:"-"`
 
@@ -46,6 +55,21 @@ type Span struct { //nolint: maligned // prefer readability over optimal memory
 	ctx context.Context
 	// Dynamic Sampling context
 	dynamicSamplingContext DynamicSamplingContext
+	// tracestate is the W3C tracestate received along with the trace, which is
+	// propagated unchanged. It is only set on transactions.
+	tracestate string
+	// parentSampled is the sampling decision of a remote parent span,
+	// propagated in the sentry-trace or traceparent header.
+	parentSampled Sampled
+	// sampledFromParent is true when Sampled is the decision of the remote
+	// parent span, set by ContinueFromHeaders or ContinueFromTrace rather
+	// than by WithSpanSampled. The TracesSampler may override it.
+	sampledFromParent bool
+	// samplingData is passed to the TracesSampler. See WithSamplingContext.
+	samplingData map[string]interface{}
+	// idle tracks the child spans of an idle transaction, see
+	// WithIdleTimeout. It is only set on transactions.
+	idle *idleTracker
 	// parent refers to the immediate local parent span. A remote parent span is
 	// only referenced by setting ParentSpanID.
 	parent *Span
@@ -58,6 +82,18 @@ type Span struct { //nolint: maligned // prefer readability over optimal memory
 	recorder *spanRecorder
 	// span context, can only be set on transactions
 	contexts map[string]Context
+	// measurements of the transaction, see SetMeasurement.
+	measurements map[string]Measurement
+	// err, httpStatusCode and grpcCode are used to derive the status of the
+	// span when it finishes, see SetError, SetHTTPStatus and SetGRPCCode.
+	err            error
+	httpStatusCode int
+	grpcCode       *uint32
+	// exclusiveTime is the part of the duration of the span not covered by
+	// its children, set when its transaction finishes.
+	exclusiveTime time.Duration
+	// metricsSummary aggregates the metrics emitted within the span.
+	metricsSummary metricsSummary
 	// collectProfile is a function that collects a profile of the current transaction. May be nil.
 	collectProfile transactionProfiler
 	// a Once instance to make sure that Finish() is only called once.
@@ -113,6 +149,7 @@ func StartSpan(ctx context.Context, operation string, options ...SpanOption) *Sp
 		Op:        operation,
 		StartTime: time.Now(),
 		Sampled:   SampledUndefined,
+		Origin:    SpanOriginManual,
 
 		ctx:           context.WithValue(ctx, spanContextKey{}, &span),
 		parent:        parent,
@@ -182,6 +219,14 @@ func StartSpan(ctx context.Context, operation string, options ...SpanOption) *Sp
 	}
 	span.recorder.record(&span)
 
+	if span.idle != nil {
+		span.idle.start(&span)
+	} else if hasParent {
+		if root := span.recorder.root(); root != nil && root.idle != nil {
+			root.idle.childStarted()
+		}
+	}
+
 	hub := hubFromContext(ctx)
 
 	// Update scope so that all events include a trace context, allowing
@@ -306,28 +351,62 @@ func (s *Span) ToSentryTrace() string {
 	return b.String()
 }
 
+// ToTraceparent returns the W3C traceparent of the span. Use this function to
+// propagate the trace to services instrumented with OpenTelemetry, as the
+// value of the "traceparent" HTTP header. Spans that aren't sampled are
+// propagated with the sampled flag unset.
+func (s *Span) ToTraceparent() string {
+	flags := "00"
+	if s.Sampled == SampledTrue {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", s.TraceID.Hex(), s.SpanID.Hex(), flags)
+}
+
+// ToTracestate returns the W3C tracestate received along with the trace of the
+// span, if any, to be propagated as the value of the "tracestate" HTTP header.
+func (s *Span) ToTracestate() string {
+	if t := s.GetTransaction(); t != nil {
+		return t.tracestate
+	}
+	return ""
+}
+
 // ToBaggage returns the serialized DynamicSamplingContext from a transaction.
 // Use this function to propagate the DynamicSamplingContext to a downstream SDK,
 // either as the value of the "baggage" HTTP header, or as an html "baggage" meta tag.
 func (s *Span) ToBaggage() string {
-	if containingTransaction := s.GetTransaction(); containingTransaction != nil {
-		// In case there is currently no frozen DynamicSamplingContext attached to the transaction,
-		// create one from the properties of the transaction.
-		if !s.dynamicSamplingContext.IsFrozen() {
-			// This will return a frozen DynamicSamplingContext.
-			s.dynamicSamplingContext = DynamicSamplingContextFromTransaction(containingTransaction)
-		}
+	return s.transactionDynamicSamplingContext().String()
+}
 
-		return containingTransaction.dynamicSamplingContext.String()
+// transactionDynamicSamplingContext returns the DynamicSamplingContext of the
+// transaction of the span. Once propagated, the DynamicSamplingContext of a
+// transaction is frozen, so that all the services of the trace make the same
+// sampling decisions.
+func (s *Span) transactionDynamicSamplingContext() DynamicSamplingContext {
+	containingTransaction := s.GetTransaction()
+	if containingTransaction == nil {
+		return DynamicSamplingContext{}
 	}
-	return ""
+	containingTransaction.mu.Lock()
+	defer containingTransaction.mu.Unlock()
+
+	// In case there is currently no frozen DynamicSamplingContext attached to the transaction,
+	// create one from the properties of the transaction.
+	if !containingTransaction.dynamicSamplingContext.IsFrozen() {
+		// This will return a frozen DynamicSamplingContext.
+		containingTransaction.dynamicSamplingContext = DynamicSamplingContextFromTransaction(containingTransaction)
+	}
+	return containingTransaction.dynamicSamplingContext
 }
 
 // SetDynamicSamplingContext sets the given dynamic sampling context on the
 // current transaction.
 func (s *Span) SetDynamicSamplingContext(dsc DynamicSamplingContext) {
 	if s.isTransaction {
+		s.mu.Lock()
 		s.dynamicSamplingContext = dsc
+		s.mu.Unlock()
 	}
 }
 
@@ -336,6 +415,15 @@ func (s *Span) doFinish() {
 	if s.EndTime.IsZero() {
 		s.EndTime = monotonicTimeSince(s.StartTime)
 	}
+	s.deriveStatus()
+
+	if s.idle != nil {
+		s.idle.stop()
+	} else if !s.isTransaction && s.recorder != nil {
+		if root := s.recorder.root(); root != nil && root.idle != nil {
+			root.idle.childFinished(s.EndTime)
+		}
+	}
 
 	if !s.Sampled.Bool() {
 		return
@@ -346,13 +434,26 @@ func (s *Span) doFinish() {
 	}
 
 	if s.collectProfile != nil {
-		event.sdkMetaData.transactionProfile = s.collectProfile(s)
+		if profile := s.collectProfile(s); profile != nil {
+			event.sdkMetaData.transactionProfile = profile
+			event.Contexts[profileContextKey] = Context{"profile_id": profile.EventID}
+		}
+	}
+	if _, ok := event.Contexts[profileContextKey]; !ok {
+		if id := continuousProfilerID(); id != "" {
+			event.Contexts[profileContextKey] = Context{"profiler_id": id}
+		}
 	}
 
 	// TODO(tracing): add breadcrumbs
 	// (see https://github.com/getsentry/sentry-python/blob/f6f3525f8812f609/sentry_sdk/tracing.py#L372)
 
 	hub := hubFromContext(s.ctx)
+	if dropped := s.recorder.droppedCount(); dropped > 0 {
+		if client := hub.Client(); client != nil {
+			client.reports.recordN(DropReasonBufferOverflow, ratelimit.CategorySpan, int64(dropped))
+		}
+	}
 	hub.CaptureEvent(event)
 }
 
@@ -387,6 +488,50 @@ func (s *Span) updateFromSentryTrace(header []byte) (updated bool) {
 			s.Sampled = SampledTrue
 		}
 	}
+	s.parentSampled = s.Sampled
+	s.sampledFromParent = s.Sampled != SampledUndefined
+	return true
+}
+
+// traceparentPattern matches a W3C traceparent header
+//
+//	VERSION - TRACE_ID - PARENT_ID - FLAGS
+//	[[:xdigit:]]{2}-[[:xdigit:]]{32}-[[:xdigit:]]{16}-[[:xdigit:]]{2}
+//
+// Headers of future versions may have more fields, which are ignored.
+var traceparentPattern = regexp.MustCompile(`^([[:xdigit:]]{2})-([[:xdigit:]]{32})-([[:xdigit:]]{16})-([[:xdigit:]]{2})(-.*)?$`)
+
+// updateFromTraceparent parses a W3C traceparent HTTP header and updates
+// fields of the span. If the header cannot be recognized as valid, the span is
+// left unchanged. The returned value indicates whether the span was updated.
+func (s *Span) updateFromTraceparent(header []byte) (updated bool) {
+	m := traceparentPattern.FindSubmatch(header)
+	if m == nil {
+		return false
+	}
+	version := strings.ToLower(string(m[1]))
+	if version == "ff" || (version == "00" && len(m[5]) != 0) {
+		return false
+	}
+	var traceID TraceID
+	var parentSpanID SpanID
+	_, _ = hex.Decode(traceID[:], m[2])
+	_, _ = hex.Decode(parentSpanID[:], m[3])
+	if traceID == zeroTraceID || parentSpanID == zeroSpanID {
+		return false
+	}
+	var flags [1]byte
+	_, _ = hex.Decode(flags[:], m[4])
+
+	s.TraceID = traceID
+	s.ParentSpanID = parentSpanID
+	if flags[0]&1 == 1 {
+		s.Sampled = SampledTrue
+	} else {
+		s.Sampled = SampledFalse
+	}
+	s.parentSampled = s.Sampled
+	s.sampledFromParent = true
 	return true
 }
 
@@ -397,7 +542,9 @@ func (s *Span) updateFromBaggage(header []byte) {
 			return
 		}
 
+		s.mu.Lock()
 		s.dynamicSamplingContext = dsc
+		s.mu.Unlock()
 	}
 }
 
@@ -409,15 +556,50 @@ func (s *Span) MarshalJSON() ([]byte, error) {
 	if s.ParentSpanID != zeroSpanID {
 		parentSpanID = s.ParentSpanID.String()
 	}
+	// Metrics may still be emitted within the span while it is serialized.
+	s.mu.RLock()
+	summary := s.metricsSummary.clone()
+	s.mu.RUnlock()
 	return json.Marshal(struct {
 		*span
-		ParentSpanID string `json:"parent_span_id,omitempty"`
+		ParentSpanID   string         `json:"parent_span_id,omitempty"`
+		ExclusiveTime  float64        `json:"exclusive_time,omitempty"`
+		MetricsSummary metricsSummary `json:"_metrics_summary,omitempty"`
 	}{
-		span:         (*span)(s),
-		ParentSpanID: parentSpanID,
+		span:           (*span)(s),
+		ParentSpanID:   parentSpanID,
+		ExclusiveTime:  milliseconds(s.exclusiveTime),
+		MetricsSummary: summary,
 	})
 }
 
+// shallowCopy returns a copy of the serialized fields of s, which can be
+// modified without modifying s. Maps are shared with s, except for the
+// metrics summary, which is still updated while the span is active.
+func (s *Span) shallowCopy() *Span {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Span{
+		TraceID:        s.TraceID,
+		SpanID:         s.SpanID,
+		ParentSpanID:   s.ParentSpanID,
+		Name:           s.Name,
+		Op:             s.Op,
+		Description:    s.Description,
+		Status:         s.Status,
+		Tags:           s.Tags,
+		StartTime:      s.StartTime,
+		EndTime:        s.EndTime,
+		Data:           s.Data,
+		Origin:         s.Origin,
+		Sampled:        s.Sampled,
+		Source:         s.Source,
+		exclusiveTime:  s.exclusiveTime,
+		metricsSummary: s.metricsSummary.clone(),
+	}
+}
+
 func (s *Span) clientOptions() *ClientOptions {
 	client := hubFromContext(s.ctx).Client()
 	if client != nil {
@@ -431,14 +613,19 @@ func (s *Span) sample() Sampled {
 	// https://develop.sentry.dev/sdk/performance/#sampling
 	// #1 tracing is not enabled.
 	if !clientOptions.EnableTracing {
-		Logger.Printf("Dropping transaction: EnableTracing is set to %t", clientOptions.EnableTracing)
+		s.debugf(LevelWarning, "Dropping transaction: EnableTracing is set to %t", clientOptions.EnableTracing)
 		s.sampleRate = 0.0
 		return SampledFalse
 	}
 
+	sampler := clientOptions.TracesSampler
+
 	// #2 explicit sampling decision via StartSpan/StartTransaction options.
-	if s.Sampled != SampledUndefined {
-		Logger.Printf("Using explicit sampling decision from StartSpan/StartTransaction: %v", s.Sampled)
+	// The decision of a remote parent is not explicit, the TracesSampler may
+	// override it.
+	inherited := s.sampledFromParent && sampler != nil && s.isTransaction
+	if s.Sampled != SampledUndefined && !inherited {
+		s.debugf(LevelDebug, "Using explicit sampling decision from StartSpan/StartTransaction: %v", s.Sampled)
 		switch s.Sampled {
 		case SampledTrue:
 			s.sampleRate = 1.0
@@ -457,33 +644,38 @@ func (s *Span) sample() Sampled {
 	}
 
 	// #3 use TracesSampler from ClientOptions.
-	sampler := clientOptions.TracesSampler
 	samplingContext := SamplingContext{
-		Span:   s,
-		Parent: s.parent,
+		Span:            s,
+		Parent:          s.parent,
+		ParentSampled:   s.parentSampled,
+		TransactionName: s.Name,
+		Data:            s.samplingData,
+	}
+	if s.parent != nil {
+		samplingContext.ParentSampled = s.parent.Sampled
 	}
 
 	if sampler != nil {
 		tracesSamplerSampleRate := sampler.Sample(samplingContext)
 		s.sampleRate = tracesSamplerSampleRate
 		if tracesSamplerSampleRate < 0.0 || tracesSamplerSampleRate > 1.0 {
-			Logger.Printf("Dropping transaction: Returned TracesSampler rate is out of range [0.0, 1.0]: %f", tracesSamplerSampleRate)
+			s.debugf(LevelWarning, "Dropping transaction: Returned TracesSampler rate is out of range [0.0, 1.0]: %f", tracesSamplerSampleRate)
 			return SampledFalse
 		}
 		if tracesSamplerSampleRate == 0 {
-			Logger.Printf("Dropping transaction: Returned TracesSampler rate is: %f", tracesSamplerSampleRate)
+			s.debugf(LevelWarning, "Dropping transaction: Returned TracesSampler rate is: %f", tracesSamplerSampleRate)
 			return SampledFalse
 		}
 
 		if rng.Float64() < tracesSamplerSampleRate {
 			return SampledTrue
 		}
-		Logger.Printf("Dropping transaction: TracesSampler returned rate: %f", tracesSamplerSampleRate)
+		s.debugf(LevelWarning, "Dropping transaction: TracesSampler returned rate: %f", tracesSamplerSampleRate)
 		return SampledFalse
 	}
 	// #4 inherit parent decision.
 	if s.parent != nil {
-		Logger.Printf("Using sampling decision from parent: %v", s.parent.Sampled)
+		s.debugf(LevelDebug, "Using sampling decision from parent: %v", s.parent.Sampled)
 		switch s.parent.Sampled {
 		case SampledTrue:
 			s.sampleRate = 1.0
@@ -493,15 +685,23 @@ func (s *Span) sample() Sampled {
 		return s.parent.Sampled
 	}
 
-	// #5 use TracesSampleRate from ClientOptions.
+	// #5 use the first matching rule of TracesSamplingRules, or
+	// TracesSampleRate from ClientOptions.
 	sampleRate := clientOptions.TracesSampleRate
+	for _, rule := range clientOptions.TracesSamplingRules {
+		if rule.matches(s) {
+			s.debugf(LevelDebug, "Using sample rate of the sampling rule for %q: %f", rule.Name, rule.Rate)
+			sampleRate = rule.Rate
+			break
+		}
+	}
 	s.sampleRate = sampleRate
 	if sampleRate < 0.0 || sampleRate > 1.0 {
-		Logger.Printf("Dropping transaction: TracesSamplerRate out of range [0.0, 1.0]: %f", sampleRate)
+		s.debugf(LevelWarning, "Dropping transaction: TracesSamplerRate out of range [0.0, 1.0]: %f", sampleRate)
 		return SampledFalse
 	}
 	if sampleRate == 0.0 {
-		Logger.Printf("Dropping transaction: TracesSampleRate rate is: %f", sampleRate)
+		s.debugf(LevelWarning, "Dropping transaction: TracesSampleRate rate is: %f", sampleRate)
 		return SampledFalse
 	}
 
@@ -524,11 +724,12 @@ func (s *Span) toEvent() *Event {
 	finished := make([]*Span, 0, len(children))
 	for _, child := range children {
 		if child.EndTime.IsZero() {
-			Logger.Printf("Dropped unfinished span: Op=%q TraceID=%s SpanID=%s", child.Op, child.TraceID, child.SpanID)
+			s.debugf(LevelWarning, "Dropped unfinished span: Op=%q TraceID=%s SpanID=%s", child.Op, child.TraceID, child.SpanID)
 			continue
 		}
 		finished = append(finished, child)
 	}
+	setExclusiveTimes(s, finished)
 
 	// Create and attach a DynamicSamplingContext to the transaction.
 	// If the DynamicSamplingContext is not frozen at this point, we can assume being head of trace.
@@ -541,6 +742,15 @@ func (s *Span) toEvent() *Event {
 		contexts[k] = cloneContext(v)
 	}
 	contexts["trace"] = s.traceContext().Map()
+	contexts["trace"]["exclusive_time"] = milliseconds(s.exclusiveTime)
+
+	var measurements map[string]Measurement
+	if len(s.measurements) > 0 {
+		measurements = make(map[string]Measurement, len(s.measurements))
+		for k, v := range s.measurements {
+			measurements[k] = v
+		}
+	}
 
 	// Make sure that the transaction source is valid
 	transactionSource := s.Source
@@ -548,22 +758,35 @@ func (s *Span) toEvent() *Event {
 		transactionSource = SourceCustom
 	}
 
-	return &Event{
-		Type:        transactionType,
-		Transaction: s.Name,
-		Contexts:    contexts,
-		Tags:        s.Tags,
-		Extra:       s.Data,
-		Timestamp:   s.EndTime,
-		StartTime:   s.StartTime,
-		Spans:       finished,
+	event := &Event{
+		Type:         transactionType,
+		Transaction:  s.Name,
+		Contexts:     contexts,
+		Tags:         s.Tags,
+		Extra:        s.Data,
+		Timestamp:    s.EndTime,
+		StartTime:    s.StartTime,
+		Spans:        finished,
+		Measurements: measurements,
 		TransactionInfo: &TransactionInfo{
 			Source: transactionSource,
 		},
 		sdkMetaData: SDKMetaData{
 			dsc: s.dynamicSamplingContext,
 		},
+		meta:           eventMeta{},
+		metricsSummary: s.metricsSummary.clone(),
 	}
+
+	options := s.clientOptions()
+	limitSpans(event, options.MaxSpanDescriptionLength, options.MaxSpanDataSize)
+	if dropped := s.recorder.droppedCount(); dropped > 0 {
+		event.meta.setLength(len(children)+dropped, "spans")
+	}
+	if len(event.meta) == 0 {
+		event.meta = nil
+	}
+	return event
 }
 
 func (s *Span) traceContext() *TraceContext {
@@ -574,6 +797,7 @@ func (s *Span) traceContext() *TraceContext {
 		Op:           s.Op,
 		Description:  s.Description,
 		Status:       s.Status,
+		Origin:       s.Origin,
 	}
 }
 
@@ -649,6 +873,15 @@ const (
 	SourceTask      TransactionSource = "task"
 )
 
+// SpanOrigin identifies the code that started a span, either the application
+// or an integration, for example "manual" or "auto.http.server". See
+// https://develop.sentry.dev/sdk/telemetry/traces/trace-origin/.
+type SpanOrigin string
+
+// SpanOriginManual is the origin of spans started by the application. It is
+// the default origin of spans.
+const SpanOriginManual SpanOrigin = "manual"
+
 // A set of all valid transaction sources.
 var allTransactionSources = map[TransactionSource]struct{}{
 	SourceCustom:    {},
@@ -667,6 +900,36 @@ func (ts TransactionSource) isValid() bool {
 	return found
 }
 
+// TransactionNameFromPattern returns the name of the transaction of an HTTP
+// request matched by a route pattern, for example "GET /users/{id}". Use it
+// with SourceRoute, to name transactions after routes rather than URLs, which
+// may contain IDs and other values with high cardinality.
+//
+// The pattern may be a route of any router, like "/users/:id", or a pattern of
+// http.ServeMux, like "GET example.com/users/{id}/{$}", whose method takes
+// precedence over the method of the request, and whose host and "{$}" suffix
+// are removed.
+func TransactionNameFromPattern(method, pattern string) string {
+	path := strings.TrimSpace(pattern)
+	if i := strings.IndexAny(path, " \t"); i >= 0 {
+		method, path = path[:i], strings.TrimLeft(path[i:], " \t")
+	}
+	if !strings.HasPrefix(path, "/") {
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			path = path[i:]
+		} else {
+			path = "/"
+		}
+	}
+	if trimmed := strings.TrimSuffix(path, "{$}"); trimmed != path && trimmed != "" {
+		path = trimmed
+	}
+	if method == "" {
+		return path
+	}
+	return method + " " + path
+}
+
 // SpanStatus is the status of a span.
 type SpanStatus uint8
 
@@ -752,6 +1015,7 @@ type TraceContext struct {
 	Op           string     `json:"op,omitempty"`
 	Description  string     `json:"description,omitempty"`
 	Status       SpanStatus `json:"status,omitempty"`
+	Origin       SpanOrigin `json:"origin,omitempty"`
 }
 
 func (tc *TraceContext) MarshalJSON() ([]byte, error) {
@@ -794,6 +1058,10 @@ func (tc TraceContext) Map() map[string]interface{} {
 		m["status"] = tc.Status
 	}
 
+	if tc.Origin != "" {
+		m["origin"] = tc.Origin
+	}
+
 	return m
 }
 
@@ -883,6 +1151,14 @@ func WithTransactionSource(source TransactionSource) SpanOption {
 	}
 }
 
+// WithSpanOrigin sets the origin of a span. Integrations set it to tell apart
+// the spans they start from the spans of the application.
+func WithSpanOrigin(origin SpanOrigin) SpanOption {
+	return func(s *Span) {
+		s.Origin = origin
+	}
+}
+
 // SpanSampled updates the sampling flag for a given span.
 //
 // Deprecated: Use WithSpanSampled() instead.
@@ -894,6 +1170,20 @@ func SpanSampled(sampled Sampled) SpanOption {
 func WithSpanSampled(sampled Sampled) SpanOption {
 	return func(s *Span) {
 		s.Sampled = sampled
+		s.sampledFromParent = false
+	}
+}
+
+// WithSamplingContext sets custom data passed to the TracesSampler in
+// SamplingContext.Data, to take sampling decisions on data that isn't part of
+// the transaction, for example the route of a request:
+//
+//	sentry.StartTransaction(ctx, name, sentry.WithSamplingContext(map[string]interface{}{
+//		"route": route,
+//	}))
+func WithSamplingContext(data map[string]interface{}) SpanOption {
+	return func(s *Span) {
+		s.samplingData = data
 	}
 }
 
@@ -904,24 +1194,55 @@ func WithSpanSampled(sampled Sampled) SpanOption {
 // ContinueFromRequest is an alias for:
 //
 // ContinueFromHeaders(r.Header.Get(SentryTraceHeader), r.Header.Get(SentryBaggageHeader)).
+//
+// If the request has no valid "sentry-trace" header, the trace is continued
+// from its W3C "traceparent" and "tracestate" headers, if any, as with
+// ContinueFromW3CHeaders.
 func ContinueFromRequest(r *http.Request) SpanOption {
-	return ContinueFromHeaders(r.Header.Get(SentryTraceHeader), r.Header.Get(SentryBaggageHeader))
+	return continueFromHeaders(
+		r.Header.Get(SentryTraceHeader),
+		r.Header.Get(SentryBaggageHeader),
+		r.Header.Get(TraceparentHeader),
+		r.Header.Get(TracestateHeader),
+	)
 }
 
 // ContinueFromHeaders returns a span option that updates the span to continue
 // an existing TraceID and propagates the Dynamic Sampling context.
 func ContinueFromHeaders(trace, baggage string) SpanOption {
+	return continueFromHeaders(trace, baggage, "", "")
+}
+
+// ContinueFromW3CHeaders returns a span option that updates the span to
+// continue a trace propagated with the W3C Trace Context headers, for example
+// by a service instrumented with OpenTelemetry. The tracestate is propagated
+// unchanged by ToTracestate.
+func ContinueFromW3CHeaders(traceparent, tracestate string) SpanOption {
+	return continueFromHeaders("", "", traceparent, tracestate)
+}
+
+// continueFromHeaders continues the trace of a sentry-trace header, or of a
+// W3C traceparent header if there is no valid sentry-trace header.
+func continueFromHeaders(trace, baggage, traceparent, tracestate string) SpanOption {
 	return func(s *Span) {
+		continued := false
 		if trace != "" {
-			s.updateFromSentryTrace([]byte(trace))
+			continued = s.updateFromSentryTrace([]byte(trace))
+		}
+		if !continued && traceparent != "" {
+			continued = s.updateFromTraceparent([]byte(traceparent))
+		}
+		if continued && tracestate != "" && s.isTransaction {
+			s.tracestate = tracestate
 		}
 		if baggage != "" {
 			s.updateFromBaggage([]byte(baggage))
 		}
 
-		// In case a sentry-trace header is present but there are no sentry-related
-		// values in the baggage, create an empty, frozen DynamicSamplingContext.
-		if trace != "" && !s.dynamicSamplingContext.HasEntries() {
+		// In case a sentry-trace or traceparent header is present but there
+		// are no sentry-related values in the baggage, create an empty, frozen
+		// DynamicSamplingContext.
+		if (trace != "" || continued) && !s.dynamicSamplingContext.HasEntries() {
 			s.dynamicSamplingContext = DynamicSamplingContext{
 				Frozen: true,
 			}
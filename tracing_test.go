@@ -7,9 +7,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -140,6 +142,7 @@ func TestStartSpan(t *testing.T) {
 				Op:           op,
 				Description:  description,
 				Status:       status,
+				Origin:       SpanOriginManual,
 			}.Map(),
 		},
 		Tags: nil,
@@ -157,7 +160,7 @@ func TestStartSpan(t *testing.T) {
 		cmpopts.IgnoreFields(Event{},
 			"Contexts", "EventID", "Level", "Platform",
 			"Release", "Sdk", "ServerName", "Modules",
-			"sdkMetaData", "attachments",
+			"sdkMetaData", "attachments", "meta", "metricsSummary",
 		),
 		cmpopts.EquateEmpty(),
 	}
@@ -165,7 +168,9 @@ func TestStartSpan(t *testing.T) {
 		t.Fatalf("Event mismatch (-want +got):\n%s", diff)
 	}
 	// Check trace context explicitly, as we ignored all contexts above to
-	// disregard other contexts.
+	// disregard other contexts. Without child spans, the exclusive time of
+	// the transaction is its duration.
+	want.Contexts["trace"]["exclusive_time"] = milliseconds(endTime.Sub(startTime))
 	if diff := cmp.Diff(want.Contexts["trace"], events[0].Contexts["trace"]); diff != "" {
 		t.Fatalf("TraceContext mismatch (-want +got):\n%s", diff)
 	}
@@ -210,6 +215,7 @@ func TestStartChild(t *testing.T) {
 				SpanID:       child.SpanID,
 				ParentSpanID: child.ParentSpanID,
 				Op:           child.Op,
+				Origin:       SpanOriginManual,
 				Sampled:      SampledTrue,
 			},
 		},
@@ -221,7 +227,7 @@ func TestStartChild(t *testing.T) {
 		cmpopts.IgnoreFields(Event{},
 			"EventID", "Level", "Platform", "Modules",
 			"Release", "Sdk", "ServerName", "Timestamp", "StartTime",
-			"sdkMetaData", "attachments",
+			"sdkMetaData", "attachments", "meta", "metricsSummary",
 		),
 		cmpopts.IgnoreMapEntries(func(k string, v interface{}) bool {
 			return k != "trace"
@@ -284,6 +290,7 @@ func TestStartTransaction(t *testing.T) {
 				SpanID:      transaction.SpanID,
 				Description: description,
 				Status:      status,
+				Origin:      SpanOriginManual,
 			}.Map(),
 			"otel": {"k": "v"},
 		},
@@ -302,7 +309,7 @@ func TestStartTransaction(t *testing.T) {
 		cmpopts.IgnoreFields(Event{},
 			"Contexts", "EventID", "Level", "Platform",
 			"Release", "Sdk", "ServerName", "Modules",
-			"sdkMetaData", "attachments",
+			"sdkMetaData", "attachments", "meta", "metricsSummary",
 		),
 		cmpopts.EquateEmpty(),
 	}
@@ -310,7 +317,9 @@ func TestStartTransaction(t *testing.T) {
 		t.Fatalf("Event mismatch (-want +got):\n%s", diff)
 	}
 	// Check trace context explicitly, as we ignored all contexts above to
-	// disregard other contexts.
+	// disregard other contexts. Without child spans, the exclusive time of
+	// the transaction is its duration.
+	want.Contexts["trace"]["exclusive_time"] = milliseconds(endTime.Sub(startTime))
 	if diff := cmp.Diff(want.Contexts["trace"], events[0].Contexts["trace"]); diff != "" {
 		t.Fatalf("TraceContext mismatch (-want +got):\n%s", diff)
 	}
@@ -476,6 +485,77 @@ func TestContinueSpanFromRequest(t *testing.T) {
 	}
 }
 
+func TestToTraceparent(t *testing.T) {
+	tests := []struct {
+		span *Span
+		want string
+	}{
+		{&Span{}, "00-00000000000000000000000000000000-0000000000000000-00"},
+		{&Span{Sampled: SampledTrue}, "00-00000000000000000000000000000000-0000000000000000-01"},
+		{&Span{Sampled: SampledFalse}, "00-00000000000000000000000000000000-0000000000000000-00"},
+		{&Span{TraceID: TraceID{1}, SpanID: SpanID{1}}, "00-01000000000000000000000000000000-0100000000000000-00"},
+	}
+	for _, tt := range tests {
+		if got := tt.span.ToTraceparent(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestContinueSpanFromW3CHeaders(t *testing.T) {
+	traceID := TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID := SpanIDFromHex("00f067aa0ba902b7")
+
+	tests := []struct {
+		traceparent string
+		valid       bool
+		sampled     Sampled
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, SampledTrue},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, SampledFalse},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-extra", true, SampledTrue},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, SampledUndefined},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, SampledUndefined},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, SampledUndefined},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, SampledUndefined},
+		{"invalid", false, SampledUndefined},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.traceparent, func(t *testing.T) {
+			s := &Span{isTransaction: true}
+			ContinueFromW3CHeaders(tt.traceparent, "vendor=value")(s)
+			if !tt.valid {
+				assertEqual(t, s.TraceID, TraceID{})
+				assertEqual(t, s.tracestate, "")
+				return
+			}
+			assertEqual(t, s.TraceID, traceID)
+			assertEqual(t, s.ParentSpanID, spanID)
+			assertEqual(t, s.Sampled, tt.sampled)
+			assertEqual(t, s.tracestate, "vendor=value")
+			assertEqual(t, s.dynamicSamplingContext.IsFrozen(), true)
+		})
+	}
+}
+
+func TestContinueSpanFromRequestPrefersSentryTrace(t *testing.T) {
+	header := http.Header{}
+	header.Set(SentryTraceHeader, "bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-0")
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	var s Span
+	ContinueFromRequest(&http.Request{Header: header})(&s)
+	assertEqual(t, s.TraceID, TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"))
+	assertEqual(t, s.Sampled, SampledFalse)
+
+	// An invalid sentry-trace header falls back to traceparent.
+	header.Set(SentryTraceHeader, "invalid")
+	s = Span{}
+	ContinueFromRequest(&http.Request{Header: header})(&s)
+	assertEqual(t, s.TraceID, TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736"))
+	assertEqual(t, s.Sampled, SampledTrue)
+}
+
 func TestContinueTransactionFromHeaders(t *testing.T) {
 	tests := []struct {
 		traceStr   string
@@ -515,10 +595,12 @@ func TestContinueTransactionFromHeaders(t *testing.T) {
 			traceStr:   "bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
 			baggageStr: "",
 			wantSpan: &Span{
-				isTransaction: true,
-				TraceID:       TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
-				ParentSpanID:  SpanIDFromHex("b72fa28504b07285"),
-				Sampled:       1,
+				isTransaction:     true,
+				TraceID:           TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
+				ParentSpanID:      SpanIDFromHex("b72fa28504b07285"),
+				Sampled:           1,
+				parentSampled:     1,
+				sampledFromParent: true,
 				dynamicSamplingContext: DynamicSamplingContext{
 					Frozen: true,
 				},
@@ -529,10 +611,12 @@ func TestContinueTransactionFromHeaders(t *testing.T) {
 			traceStr:   "bc6d53f15eb88f4320054569b8c553d4-b72fa28504b07285-1",
 			baggageStr: "sentry-trace_id=d49d9bf66f13450b81f65bc51cf49c03,sentry-public_key=public,sentry-sample_rate=1",
 			wantSpan: &Span{
-				isTransaction: true,
-				TraceID:       TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
-				ParentSpanID:  SpanIDFromHex("b72fa28504b07285"),
-				Sampled:       1,
+				isTransaction:     true,
+				TraceID:           TraceIDFromHex("bc6d53f15eb88f4320054569b8c553d4"),
+				ParentSpanID:      SpanIDFromHex("b72fa28504b07285"),
+				Sampled:           1,
+				parentSampled:     1,
+				sampledFromParent: true,
 				dynamicSamplingContext: DynamicSamplingContext{
 					Frozen: true,
 					Entries: map[string]string{
@@ -842,6 +926,43 @@ func TestToBaggage(t *testing.T) {
 	)
 }
 
+func TestToBaggageFromChildSpanFirst(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Release:          "test-release",
+	})
+	transaction := StartTransaction(ctx, "transaction-name")
+	transaction.TraceID = TraceIDFromHex("f1a4c5c9071eca1cdf04e4132527ed16")
+	child := transaction.StartChild("op-name")
+
+	want := "sentry-trace_id=f1a4c5c9071eca1cdf04e4132527ed16,sentry-release=test-release,sentry-transaction=transaction-name,sentry-sample_rate=1,sentry-sampled=true"
+	assertBaggageStringsEqual(t, child.ToBaggage(), want)
+	assertBaggageStringsEqual(t, transaction.ToBaggage(), want)
+}
+
+func TestErrorWithSpanContextIsLinkedToTrace(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Dsn:              "http://public@example.com/sentry/1",
+		Release:          "test-release",
+	})
+	transaction := StartTransaction(ctx, "transaction-name")
+	child := transaction.StartChild("op-name")
+
+	hub := GetHubFromContext(ctx)
+	hub.Client().CaptureException(errors.New("error"), &EventHint{Context: child.Context()}, hub.Scope())
+
+	event := hub.Client().Transport.(*TransportMock).lastEvent
+	trace := event.Contexts["trace"]
+	assertEqual(t, trace["trace_id"], transaction.TraceID)
+	assertEqual(t, trace["span_id"], child.SpanID)
+	assertEqual(t, event.sdkMetaData.dsc.Entries["trace_id"], transaction.TraceID.String())
+	assertEqual(t, event.sdkMetaData.dsc.Entries["public_key"], "public")
+	assertEqual(t, event.sdkMetaData.dsc.IsFrozen(), true)
+}
+
 func TestSpanSetContext(t *testing.T) {
 	ctx := NewTestContext(ClientOptions{
 		EnableTracing: true,
@@ -1008,3 +1129,220 @@ func TestSpanFinishConcurrentlyWithoutRaces(t *testing.T) {
 
 	time.Sleep(50 * time.Millisecond)
 }
+
+// Without the lock of the transaction, this test fails when run with race
+// detection ("-race").
+func TestToBaggageConcurrentlyWithoutRaces(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1,
+	})
+	transaction := StartTransaction(ctx, "op")
+	child := transaction.StartChild("child")
+	hub := GetHubFromContext(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = child.ToBaggage()
+		}()
+		go func() {
+			defer wg.Done()
+			hub.Client().CaptureException(errors.New("error"), &EventHint{Context: child.Context()}, hub.Scope())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSpanSetMeasurement(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+	})
+	transaction := StartTransaction(ctx, "transaction")
+	transaction.SetMeasurement("rows_processed", 10, MeasurementUnitNone)
+	child := transaction.StartChild("child")
+	child.SetMeasurement("cache_hit_ratio", 0.5, MeasurementUnitRatio)
+	child.SetMeasurement("rows_processed", 20, MeasurementUnitNone)
+	child.Finish()
+	transaction.Finish()
+
+	event := hubFromContext(ctx).Client().Transport.(*TransportMock).lastEvent
+	assertEqual(t, event.Measurements, map[string]Measurement{
+		"rows_processed":  {Value: 20, Unit: MeasurementUnitNone},
+		"cache_hit_ratio": {Value: 0.5, Unit: MeasurementUnitRatio},
+	})
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"cache_hit_ratio":{"value":0.5,"unit":"ratio"}`) {
+		t.Errorf("measurements missing from %s", b)
+	}
+}
+
+func TestTransactionNameFromPattern(t *testing.T) {
+	tests := []struct {
+		method  string
+		pattern string
+		want    string
+	}{
+		{"GET", "/users/{id}", "GET /users/{id}"},
+		{"GET", "/users/:id", "GET /users/:id"},
+		{"POST", "GET /users/{id}", "GET /users/{id}"},
+		{"GET", "example.com/users/{id}", "GET /users/{id}"},
+		{"GET", "DELETE example.com/users/{id}/{$}", "DELETE /users/{id}/"},
+		{"GET", "/{$}", "GET /"},
+		{"GET", "example.com", "GET /"},
+		{"", "/files/{path...}", "/files/{path...}"},
+	}
+	for _, tt := range tests {
+		if got := TransactionNameFromPattern(tt.method, tt.pattern); got != tt.want {
+			t.Errorf("TransactionNameFromPattern(%q, %q) = %q, want %q", tt.method, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSpanStatusDerivation(t *testing.T) {
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		SpanStatusMapping: SpanStatusMapping{
+			HTTP: map[int]SpanStatus{http.StatusNotFound: SpanStatusOK},
+			Error: func(err error) SpanStatus {
+				if errors.Is(err, io.ErrUnexpectedEOF) {
+					return SpanStatusDataLoss
+				}
+				return SpanStatusUndefined
+			},
+		},
+	})
+
+	tests := []struct {
+		name string
+		set  func(span *Span)
+		want SpanStatus
+	}{
+		{"none", func(span *Span) {}, SpanStatusUndefined},
+		{"explicit", func(span *Span) {
+			span.Status = SpanStatusAborted
+			span.SetError(errors.New("failed"))
+		}, SpanStatusAborted},
+		{"error", func(span *Span) { span.SetError(errors.New("failed")) }, SpanStatusInternalError},
+		{"wrapped error", func(span *Span) {
+			span.SetError(fmt.Errorf("query: %w", context.DeadlineExceeded))
+		}, SpanStatusDeadlineExceeded},
+		{"mapped error", func(span *Span) { span.SetError(io.ErrUnexpectedEOF) }, SpanStatusDataLoss},
+		{"http", func(span *Span) { span.SetHTTPStatus(http.StatusServiceUnavailable) }, SpanStatusUnavailable},
+		{"mapped http", func(span *Span) { span.SetHTTPStatus(http.StatusNotFound) }, SpanStatusOK},
+		{"http and error", func(span *Span) {
+			span.SetError(context.Canceled)
+			span.SetHTTPStatus(http.StatusTooManyRequests)
+		}, SpanStatusResourceExhausted},
+		{"grpc", func(span *Span) {
+			span.SetHTTPStatus(http.StatusOK)
+			span.SetGRPCCode(5)
+		}, SpanStatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := StartSpan(ctx, "op")
+			tt.set(span)
+			span.Finish()
+			assertEqual(t, span.Status, tt.want)
+		})
+	}
+}
+
+func TestGRPCtoSpanStatus(t *testing.T) {
+	assertEqual(t, GRPCtoSpanStatus(0), SpanStatusOK)
+	assertEqual(t, GRPCtoSpanStatus(1), SpanStatusCanceled)
+	assertEqual(t, GRPCtoSpanStatus(13), SpanStatusInternalError)
+	assertEqual(t, GRPCtoSpanStatus(16), SpanStatusUnauthenticated)
+	assertEqual(t, GRPCtoSpanStatus(17), SpanStatusUnknown)
+}
+
+func TestExclusiveTime(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	transaction := StartTransaction(ctx, "transaction")
+	transaction.StartTime = at(0)
+	startChild := func(parent *Span, from, to int) *Span {
+		span := parent.StartChild("op")
+		span.StartTime, span.EndTime = at(from), at(to)
+		return span
+	}
+	a := startChild(transaction, 10, 50)
+	b := startChild(transaction, 30, 70)
+	c := startChild(transaction, 90, 120)
+	d := startChild(a, 20, 30)
+	for _, span := range []*Span{d, a, b, c} {
+		span.Finish()
+	}
+	transaction.EndTime = at(100)
+	transaction.Finish()
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("sent %d events, want 1", len(events))
+	}
+	// The children of the transaction cover 10-70 and 90-100.
+	assertEqual(t, events[0].Contexts["trace"]["exclusive_time"], 30.0)
+	for _, test := range []struct {
+		span *Span
+		want time.Duration
+	}{
+		{a, 30 * time.Millisecond},
+		{b, 40 * time.Millisecond},
+		{c, 30 * time.Millisecond},
+		{d, 10 * time.Millisecond},
+	} {
+		assertEqual(t, test.span.exclusiveTime, test.want)
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"exclusive_time":30`) {
+		t.Errorf("exclusive time missing from span JSON: %s", data)
+	}
+}
+
+func TestSpanOrigin(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+	transaction := StartTransaction(ctx, "transaction", WithSpanOrigin("auto.http.server"))
+	child := transaction.StartChild("child")
+	child.Finish()
+	transaction.Finish()
+
+	assertEqual(t, child.Origin, SpanOriginManual)
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("sent %d events, want 1", len(events))
+	}
+	assertEqual(t, events[0].Contexts["trace"]["origin"], SpanOrigin("auto.http.server"))
+
+	data, err := json.Marshal(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"origin":"manual"`) {
+		t.Errorf("origin missing from span JSON: %s", data)
+	}
+}
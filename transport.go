@@ -2,13 +2,16 @@ package sentry
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"sync"
 	"time"
 
@@ -34,9 +37,14 @@ type Transport interface {
 	Flush(timeout time.Duration) bool
 	Configure(options ClientOptions)
 	SendEvent(event *Event)
+	SendEnvelope(envelope *Envelope)
 }
 
 func getProxyConfig(options ClientOptions) func(*http.Request) (*url.URL, error) {
+	if options.UnixSocket != "" {
+		return nil
+	}
+
 	if options.HTTPSProxy != "" {
 		return func(*http.Request) (*url.URL, error) {
 			return url.Parse(options.HTTPSProxy)
@@ -52,6 +60,17 @@ func getProxyConfig(options ClientOptions) func(*http.Request) (*url.URL, error)
 	return http.ProxyFromEnvironment
 }
 
+func getDialContext(options ClientOptions) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if options.UnixSocket != "" {
+		var dialer net.Dialer
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", options.UnixSocket)
+		}
+	}
+
+	return options.DialContext
+}
+
 func getTLSConfig(options ClientOptions) *tls.Config {
 	if options.CaCerts != nil {
 		// #nosec G402 -- We should be using `MinVersion: tls.VersionTLS12`,
@@ -67,6 +86,9 @@ func getTLSConfig(options ClientOptions) *tls.Config {
 func getRequestBodyFromEvent(event *Event) []byte {
 	body, err := json.Marshal(event)
 	if err == nil {
+		if len(body) > maxEventBytes {
+			body = truncateEvent(event, body, maxEventBytes)
+		}
 		return body
 	}
 
@@ -82,70 +104,21 @@ func getRequestBodyFromEvent(event *Event) []byte {
 	}
 	body, err = json.Marshal(event)
 	if err == nil {
-		Logger.Println(msg)
+		debugf(LevelWarning, "%s", msg)
 		return body
 	}
 
 	// This should _only_ happen when Event.Exception[0].Stacktrace.Frames[0].Vars is unserializable
 	// Which won't ever happen, as we don't use it now (although it's the part of public interface accepted by Sentry)
 	// Juuust in case something, somehow goes utterly wrong.
-	Logger.Println("Event couldn't be marshaled, even with stripped contextual data. Skipping delivery. " +
+	debugf(LevelError, "Event couldn't be marshaled, even with stripped contextual data. Skipping delivery. "+
 		"Please notify the SDK owners with possibly broken payload.")
 	return nil
 }
 
-func encodeAttachment(enc *json.Encoder, b io.Writer, attachment *Attachment) error {
-	// Attachment header
-	err := enc.Encode(struct {
-		Type        string `json:"type"`
-		Length      int    `json:"length"`
-		Filename    string `json:"filename"`
-		ContentType string `json:"content_type,omitempty"`
-	}{
-		Type:        "attachment",
-		Length:      len(attachment.Payload),
-		Filename:    attachment.Filename,
-		ContentType: attachment.ContentType,
-	})
-	if err != nil {
-		return err
-	}
-
-	// Attachment payload
-	if _, err = b.Write(attachment.Payload); err != nil {
-		return err
-	}
-
-	// "Envelopes should be terminated with a trailing newline."
-	//
-	// [1]: https://develop.sentry.dev/sdk/envelopes/#envelopes
-	if _, err := b.Write([]byte("\n")); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func encodeEnvelopeItem(enc *json.Encoder, itemType string, body json.RawMessage) error {
-	// Item header
-	err := enc.Encode(struct {
-		Type   string `json:"type"`
-		Length int    `json:"length"`
-	}{
-		Type:   itemType,
-		Length: len(body),
-	})
-	if err == nil {
-		// payload
-		err = enc.Encode(body)
-	}
-	return err
-}
-
-func envelopeFromBody(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMessage) (*bytes.Buffer, error) {
-	var b bytes.Buffer
-	enc := json.NewEncoder(&b)
-
+// envelopeFromEvent builds an Envelope out of an event and its JSON encoded
+// body.
+func envelopeFromEvent(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMessage) (*Envelope, error) {
 	// Construct the trace envelope header
 	var trace = map[string]string{}
 	if dsc := event.sdkMetaData.dsc; dsc.HasEntries() {
@@ -154,14 +127,7 @@ func envelopeFromBody(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMes
 		}
 	}
 
-	// Envelope header
-	err := enc.Encode(struct {
-		EventID EventID           `json:"event_id"`
-		SentAt  time.Time         `json:"sent_at"`
-		Dsn     string            `json:"dsn"`
-		Sdk     map[string]string `json:"sdk"`
-		Trace   map[string]string `json:"trace,omitempty"`
-	}{
+	envelope := NewEnvelope(EnvelopeHeader{
 		EventID: event.EventID,
 		SentAt:  sentAt,
 		Trace:   trace,
@@ -171,86 +137,237 @@ func envelopeFromBody(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMes
 			"version": event.Sdk.Version,
 		},
 	})
-	if err != nil {
-		return nil, err
-	}
 
-	if event.Type == transactionType || event.Type == checkInType {
-		err = encodeEnvelopeItem(enc, event.Type, body)
-	} else {
-		err = encodeEnvelopeItem(enc, eventType, body)
-	}
-	if err != nil {
-		return nil, err
+	itemType := eventType
+	if event.Type == transactionType || event.Type == checkInType || event.Type == feedbackType {
+		itemType = event.Type
 	}
+	envelope.AddItem(&EnvelopeItem{
+		Type:    itemType,
+		Payload: body,
+	})
 
 	// Attachments
 	for _, attachment := range event.attachments {
-		if err := encodeAttachment(enc, &b, attachment); err != nil {
-			return nil, err
-		}
+		envelope.AddItem(&EnvelopeItem{
+			Type:        attachmentType,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Payload:     attachment.Payload,
+			open:        attachment.Open,
+		})
 	}
 
 	// Profile data
 	if event.sdkMetaData.transactionProfile != nil {
-		body, err = json.Marshal(event.sdkMetaData.transactionProfile)
-		if err != nil {
-			return nil, err
-		}
-		err = encodeEnvelopeItem(enc, profileType, body)
+		profile, err := json.Marshal(event.sdkMetaData.transactionProfile)
 		if err != nil {
 			return nil, err
 		}
+		envelope.AddItem(&EnvelopeItem{
+			Type:    profileType,
+			Payload: profile,
+		})
 	}
 
-	return &b, nil
+	return envelope, nil
 }
 
-func getRequestFromEvent(event *Event, dsn *Dsn) (r *http.Request, err error) {
-	defer func() {
-		if r != nil {
-			r.Header.Set("User-Agent", fmt.Sprintf("%s/%s", event.Sdk.Name, event.Sdk.Version))
-			r.Header.Set("Content-Type", "application/x-sentry-envelope")
+func envelopeFromBody(event *Event, dsn *Dsn, sentAt time.Time, body json.RawMessage) (*bytes.Buffer, error) {
+	envelope, err := envelopeFromEvent(event, dsn, sentAt, body)
+	if err != nil {
+		return nil, err
+	}
 
-			auth := fmt.Sprintf("Sentry sentry_version=%s, "+
-				"sentry_client=%s/%s, sentry_key=%s", apiVersion, event.Sdk.Name, event.Sdk.Version, dsn.publicKey)
+	var b bytes.Buffer
+	if err := envelope.encode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
 
-			// The key sentry_secret is effectively deprecated and no longer needs to be set.
-			// However, since it was required in older self-hosted versions,
-			// it should still passed through to Sentry if set.
-			if dsn.secretKey != "" {
-				auth = fmt.Sprintf("%s, sentry_secret=%s", auth, dsn.secretKey)
-			}
+// eventDsn returns the DSN an event is sent to: the one chosen by
+// ClientOptions.Router, if any, or else dsn.
+func eventDsn(event *Event, dsn *Dsn) *Dsn {
+	if event.sdkMetaData.dsn != nil {
+		return event.sdkMetaData.dsn
+	}
+	return dsn
+}
 
-			r.Header.Set("X-Sentry-Auth", auth)
-		}
-	}()
+func getRequestFromEvent(event *Event, dsn *Dsn) (*http.Request, error) {
+	dsn = eventDsn(event, dsn)
 	body := getRequestBodyFromEvent(event)
 	if body == nil {
 		return nil, errors.New("event could not be marshaled")
 	}
-	envelope, err := envelopeFromBody(event, dsn, time.Now(), body)
+	envelope, err := envelopeFromEvent(event, dsn, time.Now(), body)
 	if err != nil {
 		return nil, err
 	}
-	return http.NewRequest(
-		http.MethodPost,
-		dsn.GetAPIURL().String(),
-		envelope,
-	)
+	return getRequestFromEnvelope(envelope, dsn)
 }
 
-func categoryFor(eventType string) ratelimit.Category {
-	switch eventType {
-	case "":
+func getRequestFromEnvelope(envelope *Envelope, dsn *Dsn) (*http.Request, error) {
+	var r *http.Request
+	if envelope.streaming() {
+		// Stream the envelope to avoid loading large payloads in memory. The
+		// HTTP client closes the body, and with it the payload readers.
+		body, length, err := envelope.reader()
+		if err != nil {
+			return nil, err
+		}
+		r, err = http.NewRequest(http.MethodPost, dsn.GetAPIURL().String(), body)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		r.ContentLength = length
+	} else {
+		var b bytes.Buffer
+		if err := envelope.encode(&b); err != nil {
+			return nil, err
+		}
+		var err error
+		r, err = http.NewRequest(http.MethodPost, dsn.GetAPIURL().String(), &b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sdkName, sdkVersion := envelope.sdkInfo()
+	r.Header.Set("User-Agent", fmt.Sprintf("%s/%s", sdkName, sdkVersion))
+	r.Header.Set("Content-Type", "application/x-sentry-envelope")
+
+	auth := fmt.Sprintf("Sentry sentry_version=%s, "+
+		"sentry_client=%s/%s, sentry_key=%s", apiVersion, sdkName, sdkVersion, dsn.publicKey)
+
+	// The key sentry_secret is effectively deprecated and no longer needs to be set.
+	// However, since it was required in older self-hosted versions,
+	// it should still passed through to Sentry if set.
+	if dsn.secretKey != "" {
+		auth = fmt.Sprintf("%s, sentry_secret=%s", auth, dsn.secretKey)
+	}
+
+	r.Header.Set("X-Sentry-Auth", auth)
+
+	return r, nil
+}
+
+func categoryFor(itemType string) ratelimit.Category {
+	switch itemType {
+	case "", eventType:
 		return ratelimit.CategoryError
 	case transactionType:
 		return ratelimit.CategoryTransaction
+	case statsdType:
+		return ratelimit.CategoryMetricBucket
 	default:
-		return ratelimit.Category(eventType)
+		return ratelimit.Category(itemType)
 	}
 }
 
+// snapshotEvent returns a copy of event that can be serialized on another
+// goroutine. Top-level maps and slices are copied, so that the caller can keep
+// modifying them, while the values they hold are shared.
+func snapshotEvent(event *Event) *Event {
+	e := *event
+	e.Breadcrumbs = append([]*Breadcrumb(nil), event.Breadcrumbs...)
+	e.Fingerprint = append([]string(nil), event.Fingerprint...)
+	e.Threads = append([]Thread(nil), event.Threads...)
+	e.Exception = append([]Exception(nil), event.Exception...)
+	e.Spans = append([]*Span(nil), event.Spans...)
+	e.attachments = append([]*Attachment(nil), event.attachments...)
+	if event.Contexts != nil {
+		e.Contexts = make(map[string]Context, len(event.Contexts))
+		for k, v := range event.Contexts {
+			e.Contexts[k] = cloneContext(v)
+		}
+	}
+	if event.Extra != nil {
+		e.Extra = make(map[string]interface{}, len(event.Extra))
+		for k, v := range event.Extra {
+			e.Extra[k] = v
+		}
+	}
+	if event.Tags != nil {
+		e.Tags = make(map[string]string, len(event.Tags))
+		for k, v := range event.Tags {
+			e.Tags[k] = v
+		}
+	}
+	if event.Modules != nil {
+		e.Modules = make(map[string]string, len(event.Modules))
+		for k, v := range event.Modules {
+			e.Modules[k] = v
+		}
+	}
+	return &e
+}
+
+// requestHeaders holds the custom headers added to every request sent to
+// Sentry.
+type requestHeaders struct {
+	static  http.Header
+	dynamic func(request *http.Request) http.Header
+}
+
+func newRequestHeaders(options ClientOptions) requestHeaders {
+	return requestHeaders{
+		static:  options.HTTPHeaders.Clone(),
+		dynamic: options.HTTPHeadersFunc,
+	}
+}
+
+func (h requestHeaders) apply(request *http.Request) {
+	for k, v := range h.static {
+		request.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+	if h.dynamic == nil {
+		return
+	}
+	for k, v := range h.dynamic(request) {
+		request.Header[http.CanonicalHeaderKey(k)] = v
+	}
+}
+
+// doRequest sends the request and records its outcome in stats. A non-nil
+// response is returned for every completed request, even if Sentry responded
+// with an error status code.
+func doRequest(client *http.Client, request *http.Request, stats *transportStats) (*http.Response, error) {
+	stats.started()
+	response, err := client.Do(request)
+	if err != nil {
+		stats.finished(0)
+		stats.drop(DropReasonNetworkError, err)
+		debugf(LevelError, "There was an issue with sending an event: %v", err)
+		return nil, err
+	}
+	switch err := responseError(response); {
+	case errors.Is(err, ErrRateLimited):
+		stats.finished(0)
+		stats.drop(DropReasonRateLimit, nil)
+	case err != nil:
+		stats.finished(0)
+		stats.drop(DropReasonHTTPError, err)
+	default:
+		stats.finished(request.ContentLength)
+	}
+	return response, nil
+}
+
+// responseError returns the error corresponding to the status code of a
+// response from Sentry, if any.
+func responseError(response *http.Response) error {
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case response.StatusCode >= 400:
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	return nil
+}
+
 // ================================
 // HTTPTransport
 // ================================
@@ -262,8 +379,15 @@ type batch struct {
 	done    chan struct{} // closed to signal completion of all items
 }
 
+// A batchItem is either a ready to send request, or an event that the worker
+// serializes before sending.
 type batchItem struct {
-	request  *http.Request
+	request *http.Request
+	event   *Event
+	// envelope is set for envelopes with streamed payloads, which are only
+	// opened right before the request is sent, so that dropping the item
+	// never leaks them.
+	envelope *Envelope
 	category ratelimit.Category
 }
 
@@ -271,7 +395,8 @@ type batchItem struct {
 //
 // Clients using this transport will enqueue requests in a buffer and return to
 // the caller before any network communication has happened. Requests are sent
-// to Sentry sequentially from a background goroutine.
+// to Sentry from background goroutines, sequentially unless Workers is greater
+// than 1.
 type HTTPTransport struct {
 	dsn       *Dsn
 	client    *http.Client
@@ -287,9 +412,22 @@ type HTTPTransport struct {
 	BufferSize int
 	// HTTP Client request timeout. Defaults to 30 seconds.
 	Timeout time.Duration
+	// Number of goroutines sending requests to Sentry concurrently. Defaults
+	// to 1.
+	Workers int
+	// Timeout for sending a single request, enforced through the request
+	// context. Unlike Timeout, it also applies when a custom HTTPClient is
+	// configured. Zero means no additional timeout.
+	RequestTimeout time.Duration
 
 	mu     sync.RWMutex
 	limits ratelimit.Map
+
+	stats   transportStats
+	headers requestHeaders
+
+	// debugLogger is the DebugLogger of the client, if any.
+	debugLogger DebugLogger
 }
 
 // NewHTTPTransport returns a new pre-configured instance of HTTPTransport.
@@ -304,12 +442,14 @@ func NewHTTPTransport() *HTTPTransport {
 
 // Configure is called by the Client itself, providing it it's own ClientOptions.
 func (t *HTTPTransport) Configure(options ClientOptions) {
+	t.debugLogger = options.DebugLogger
 	dsn, err := NewDsn(options.Dsn)
 	if err != nil {
-		Logger.Printf("%v\n", err)
+		t.debugf(LevelError, "%v\n", err)
 		return
 	}
 	t.dsn = dsn
+	t.headers = newRequestHeaders(options)
 
 	// A buffered channel with capacity 1 works like a mutex, ensuring only one
 	// goroutine can access the current batch at a given time. Access is
@@ -327,6 +467,7 @@ func (t *HTTPTransport) Configure(options ClientOptions) {
 		t.transport = &http.Transport{
 			Proxy:           getProxyConfig(options),
 			TLSClientConfig: getTLSConfig(options),
+			DialContext:     getDialContext(options),
 		}
 	}
 
@@ -346,21 +487,83 @@ func (t *HTTPTransport) Configure(options ClientOptions) {
 
 // SendEvent assembles a new packet out of Event and sends it to remote server.
 func (t *HTTPTransport) SendEvent(event *Event) {
+	_ = t.sendEvent(event)
+}
+
+func (t *HTTPTransport) sendEvent(event *Event) error {
 	if t.dsn == nil {
-		return
+		return ErrSDKDisabled
 	}
 
 	category := categoryFor(event.Type)
 
 	if t.disabled(category) {
+		t.stats.drop(DropReasonRateLimit, nil)
+		return ErrRateLimited
+	}
+
+	// Serialization happens on the worker goroutine, so that capturing events
+	// doesn't pay for it. The snapshot protects against later changes to the
+	// event made by the caller.
+	if !t.enqueue(batchItem{event: snapshotEvent(event), category: category}) {
+		return ErrQueueFull
+	}
+
+	var eventType string
+	if event.Type == transactionType {
+		eventType = "transaction"
+	} else {
+		eventType = fmt.Sprintf("%s event", event.Level)
+	}
+	t.debugf(LevelDebug,
+		"Sending %s [%s] to %s project: %s",
+		eventType,
+		event.EventID,
+		eventDsn(event, t.dsn).host,
+		eventDsn(event, t.dsn).projectID,
+	)
+	return nil
+}
+
+// SendEnvelope sends a raw envelope to the remote server. Like SendEvent, it
+// enqueues the envelope and returns before any network communication has
+// happened.
+func (t *HTTPTransport) SendEnvelope(envelope *Envelope) {
+	if t.dsn == nil || envelope == nil {
 		return
 	}
 
-	request, err := getRequestFromEvent(event, t.dsn)
-	if err != nil {
+	category := envelope.category()
+
+	if t.disabled(category) {
+		t.stats.drop(DropReasonRateLimit, nil)
 		return
 	}
 
+	item := batchItem{envelope: envelope, category: category}
+	if !envelope.streaming() {
+		request, err := getRequestFromEnvelope(envelope, t.dsn)
+		if err != nil {
+			t.debugf(LevelError, "There was an issue with encoding an envelope: %v", err)
+			t.stats.drop(DropReasonEncodingError, err)
+			return
+		}
+		item = batchItem{request: request, category: category}
+	}
+
+	if t.enqueue(item) {
+		t.debugf(LevelDebug,
+			"Sending envelope with %d item(s) to %s project: %s",
+			len(envelope.Items),
+			t.dsn.host,
+			t.dsn.projectID,
+		)
+	}
+}
+
+// enqueue adds an item to the current batch. It returns false if the item was
+// dropped because the transport buffer is full.
+func (t *HTTPTransport) enqueue(item batchItem) bool {
 	// <-t.buffer is equivalent to acquiring a lock to access the current batch.
 	// A few lines below, t.buffer <- b releases the lock.
 	//
@@ -373,30 +576,23 @@ func (t *HTTPTransport) SendEvent(event *Event) {
 	// is, the event is dropped if it cannot be sent immediately to the b.items
 	// channel (used as a queue).
 	b := <-t.buffer
+	defer func() { t.buffer <- b }()
 
 	select {
-	case b.items <- batchItem{
-		request:  request,
-		category: category,
-	}:
-		var eventType string
-		if event.Type == transactionType {
-			eventType = "transaction"
-		} else {
-			eventType = fmt.Sprintf("%s event", event.Level)
-		}
-		Logger.Printf(
-			"Sending %s [%s] to %s project: %s",
-			eventType,
-			event.EventID,
-			t.dsn.host,
-			t.dsn.projectID,
-		)
+	case b.items <- item:
+		t.stats.enqueued()
+		return true
 	default:
-		Logger.Println("Event dropped due to transport buffer being full.")
+		t.debugf(LevelWarning, "Event dropped due to transport buffer being full.")
+		t.stats.drop(DropReasonQueueOverflow, nil)
+		return false
 	}
+}
 
-	t.buffer <- b
+// TransportStats returns a snapshot of the transport's queue and delivery
+// stats.
+func (t *HTTPTransport) TransportStats() TransportStats {
+	return t.stats.snapshot()
 }
 
 // Flush waits until any buffered events are sent to the Sentry server, blocking
@@ -447,14 +643,14 @@ started:
 	// Wait until the current batch is done or the timeout.
 	select {
 	case <-b.done:
-		Logger.Println("Buffer flushed successfully.")
+		t.debugf(LevelDebug, "Buffer flushed successfully.")
 		return true
 	case <-toolate:
 		goto fail
 	}
 
 fail:
-	Logger.Println("Buffer flushing reached the timeout.")
+	t.debugf(LevelWarning, "Buffer flushing reached the timeout.")
 	return false
 }
 
@@ -467,37 +663,75 @@ func (t *HTTPTransport) worker() {
 		// Equivalent to releasing a lock.
 		t.buffer <- b
 
-		// Process all batch items.
-		for item := range b.items {
-			if t.disabled(item.category) {
-				continue
-			}
-
-			response, err := t.client.Do(item.request)
-			if err != nil {
-				Logger.Printf("There was an issue with sending an event: %v", err)
-				continue
-			}
-			t.mu.Lock()
-			t.limits.Merge(ratelimit.FromResponse(response))
-			t.mu.Unlock()
-			// Drain body up to a limit and close it, allowing the
-			// transport to reuse TCP connections.
-			_, _ = io.CopyN(io.Discard, response.Body, maxDrainResponseBytes)
-			response.Body.Close()
+		// Process all batch items, using up to t.Workers goroutines.
+		workers := t.Workers
+		if workers < 1 {
+			workers = 1
 		}
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for item := range b.items {
+					t.sendItem(item)
+				}
+			}()
+		}
+		wg.Wait()
 
 		// Signal that processing of the batch is done.
 		close(b.done)
 	}
 }
 
+func (t *HTTPTransport) sendItem(item batchItem) {
+	t.stats.dequeued()
+
+	if t.disabled(item.category) {
+		t.stats.drop(DropReasonRateLimit, nil)
+		return
+	}
+
+	request := item.request
+	if request == nil {
+		var err error
+		if item.envelope != nil {
+			request, err = getRequestFromEnvelope(item.envelope, t.dsn)
+		} else {
+			request, err = getRequestFromEvent(item.event, t.dsn)
+		}
+		if err != nil {
+			t.stats.drop(DropReasonEncodingError, err)
+			return
+		}
+	}
+	t.headers.apply(request)
+	if t.RequestTimeout > 0 {
+		ctx, cancel := context.WithTimeout(request.Context(), t.RequestTimeout)
+		defer cancel()
+		request = request.WithContext(ctx)
+	}
+
+	response, err := doRequest(t.client, request, &t.stats)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	t.limits.Merge(ratelimit.FromResponse(response))
+	t.mu.Unlock()
+	// Drain body up to a limit and close it, allowing the
+	// transport to reuse TCP connections.
+	_, _ = io.CopyN(io.Discard, response.Body, maxDrainResponseBytes)
+	response.Body.Close()
+}
+
 func (t *HTTPTransport) disabled(c ratelimit.Category) bool {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	disabled := t.limits.IsRateLimited(c)
 	if disabled {
-		Logger.Printf("Too many requests for %q, backing off till: %v", c, t.limits.Deadline(c))
+		t.debugf(LevelWarning, "Too many requests for %q, backing off till: %v", c, t.limits.Deadline(c))
 	}
 	return disabled
 }
@@ -525,6 +759,12 @@ type HTTPSyncTransport struct {
 	mu     sync.Mutex
 	limits ratelimit.Map
 
+	stats   transportStats
+	headers requestHeaders
+
+	// debugLogger is the DebugLogger of the client, if any.
+	debugLogger DebugLogger
+
 	// HTTP Client request timeout. Defaults to 30 seconds.
 	Timeout time.Duration
 }
@@ -541,12 +781,14 @@ func NewHTTPSyncTransport() *HTTPSyncTransport {
 
 // Configure is called by the Client itself, providing it it's own ClientOptions.
 func (t *HTTPSyncTransport) Configure(options ClientOptions) {
+	t.debugLogger = options.DebugLogger
 	dsn, err := NewDsn(options.Dsn)
 	if err != nil {
-		Logger.Printf("%v\n", err)
+		t.debugf(LevelError, "%v\n", err)
 		return
 	}
 	t.dsn = dsn
+	t.headers = newRequestHeaders(options)
 
 	if options.HTTPTransport != nil {
 		t.transport = options.HTTPTransport
@@ -554,6 +796,7 @@ func (t *HTTPSyncTransport) Configure(options ClientOptions) {
 		t.transport = &http.Transport{
 			Proxy:           getProxyConfig(options),
 			TLSClientConfig: getTLSConfig(options),
+			DialContext:     getDialContext(options),
 		}
 	}
 
@@ -569,17 +812,23 @@ func (t *HTTPSyncTransport) Configure(options ClientOptions) {
 
 // SendEvent assembles a new packet out of Event and sends it to remote server.
 func (t *HTTPSyncTransport) SendEvent(event *Event) {
+	_ = t.sendEvent(event)
+}
+
+func (t *HTTPSyncTransport) sendEvent(event *Event) error {
 	if t.dsn == nil {
-		return
+		return ErrSDKDisabled
 	}
 
 	if t.disabled(categoryFor(event.Type)) {
-		return
+		t.stats.drop(DropReasonRateLimit, nil)
+		return ErrRateLimited
 	}
 
 	request, err := getRequestFromEvent(event, t.dsn)
 	if err != nil {
-		return
+		t.stats.drop(DropReasonEncodingError, err)
+		return err
 	}
 
 	var eventType string
@@ -588,18 +837,51 @@ func (t *HTTPSyncTransport) SendEvent(event *Event) {
 	} else {
 		eventType = fmt.Sprintf("%s event", event.Level)
 	}
-	Logger.Printf(
+	t.debugf(LevelDebug,
 		"Sending %s [%s] to %s project: %s",
 		eventType,
 		event.EventID,
+		eventDsn(event, t.dsn).host,
+		eventDsn(event, t.dsn).projectID,
+	)
+
+	return t.send(request)
+}
+
+// SendEnvelope sends a raw envelope to the remote server, blocking until a
+// response is returned.
+func (t *HTTPSyncTransport) SendEnvelope(envelope *Envelope) {
+	if t.dsn == nil || envelope == nil {
+		return
+	}
+
+	if t.disabled(envelope.category()) {
+		t.stats.drop(DropReasonRateLimit, nil)
+		return
+	}
+
+	request, err := getRequestFromEnvelope(envelope, t.dsn)
+	if err != nil {
+		t.debugf(LevelError, "There was an issue with encoding an envelope: %v", err)
+		t.stats.drop(DropReasonEncodingError, err)
+		return
+	}
+
+	t.debugf(LevelDebug,
+		"Sending envelope with %d item(s) to %s project: %s",
+		len(envelope.Items),
 		t.dsn.host,
 		t.dsn.projectID,
 	)
 
-	response, err := t.client.Do(request)
+	_ = t.send(request)
+}
+
+func (t *HTTPSyncTransport) send(request *http.Request) error {
+	t.headers.apply(request)
+	response, err := doRequest(t.client, request, &t.stats)
 	if err != nil {
-		Logger.Printf("There was an issue with sending an event: %v", err)
-		return
+		return err
 	}
 	t.mu.Lock()
 	t.limits.Merge(ratelimit.FromResponse(response))
@@ -609,6 +891,14 @@ func (t *HTTPSyncTransport) SendEvent(event *Event) {
 	// transport to reuse TCP connections.
 	_, _ = io.CopyN(io.Discard, response.Body, maxDrainResponseBytes)
 	response.Body.Close()
+
+	return responseError(response)
+}
+
+// TransportStats returns a snapshot of the transport's delivery stats.
+// QueueDepth is always zero, as requests are sent synchronously.
+func (t *HTTPSyncTransport) TransportStats() TransportStats {
+	return t.stats.snapshot()
 }
 
 // Flush is a no-op for HTTPSyncTransport. It always returns true immediately.
@@ -621,11 +911,249 @@ func (t *HTTPSyncTransport) disabled(c ratelimit.Category) bool {
 	defer t.mu.Unlock()
 	disabled := t.limits.IsRateLimited(c)
 	if disabled {
-		Logger.Printf("Too many requests for %q, backing off till: %v", c, t.limits.Deadline(c))
+		t.debugf(LevelWarning, "Too many requests for %q, backing off till: %v", c, t.limits.Deadline(c))
 	}
 	return disabled
 }
 
+// ================================
+// DryRunTransport
+// ================================
+
+// defaultDryRunBufferSize is the default number of events and envelopes kept in
+// memory by DryRunTransport.
+const defaultDryRunBufferSize = 100
+
+// DryRunTransport is an implementation of Transport that never sends data to
+// Sentry. Instead, it pretty-prints every event and envelope it would have sent
+// to Output and keeps the most recent ones in memory.
+//
+// It is meant for validating instrumentation, for example in staging
+// environments or in tests, without polluting a Sentry project. Setting the
+// DryRun client option makes the SDK use it by default.
+type DryRunTransport struct {
+	// Output is where events and envelopes are printed to. Nothing is printed
+	// if Output is nil.
+	Output io.Writer
+	// Number of events and envelopes kept in memory. Defaults to 100.
+	BufferSize int
+
+	mu        sync.Mutex
+	events    []*Event
+	envelopes []*Envelope
+
+	// debugLogger is the DebugLogger of the client, if any.
+	debugLogger DebugLogger
+}
+
+// NewDryRunTransport returns a new DryRunTransport that prints to w.
+func NewDryRunTransport(w io.Writer) *DryRunTransport {
+	return &DryRunTransport{
+		Output:     w,
+		BufferSize: defaultDryRunBufferSize,
+	}
+}
+
+// Configure is called by the Client itself, providing it it's own ClientOptions.
+func (t *DryRunTransport) Configure(options ClientOptions) {
+	t.debugLogger = options.DebugLogger
+	t.debugf(LevelDebug, "Sentry client initialized with DryRunTransport. No events will be delivered.")
+}
+
+// SendEvent prints the event and stores it in memory.
+func (t *DryRunTransport) SendEvent(event *Event) {
+	body := getRequestBodyFromEvent(event)
+	if body == nil {
+		return
+	}
+
+	var eventType string
+	if event.Type == transactionType {
+		eventType = "transaction"
+	} else {
+		eventType = fmt.Sprintf("%s event", event.Level)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append(t.events, event)
+	if n := t.bufferSize(); len(t.events) > n {
+		t.events = t.events[len(t.events)-n:]
+	}
+
+	if t.Output == nil {
+		return
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		pretty.Write(body)
+	}
+	fmt.Fprintf(t.Output, "[Sentry] Dry run: not sending %s [%s]\n%s\n", eventType, event.EventID, pretty.Bytes())
+}
+
+// SendEnvelope prints the envelope and stores it in memory.
+func (t *DryRunTransport) SendEnvelope(envelope *Envelope) {
+	if envelope == nil {
+		return
+	}
+	b, err := envelope.Serialize()
+	if err != nil {
+		t.debugf(LevelError, "There was an issue with encoding an envelope: %v", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.envelopes = append(t.envelopes, envelope)
+	if n := t.bufferSize(); len(t.envelopes) > n {
+		t.envelopes = t.envelopes[len(t.envelopes)-n:]
+	}
+
+	if t.Output == nil {
+		return
+	}
+	fmt.Fprintf(t.Output, "[Sentry] Dry run: not sending envelope with %d item(s)\n%s", len(envelope.Items), b)
+}
+
+// Flush is a no-op for DryRunTransport. It always returns true immediately.
+func (t *DryRunTransport) Flush(_ time.Duration) bool {
+	return true
+}
+
+// Events returns the most recent events passed to SendEvent, oldest first.
+func (t *DryRunTransport) Events() []*Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make([]*Event, len(t.events))
+	copy(events, t.events)
+	return events
+}
+
+// Envelopes returns the most recent envelopes passed to SendEnvelope, oldest
+// first.
+func (t *DryRunTransport) Envelopes() []*Envelope {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	envelopes := make([]*Envelope, len(t.envelopes))
+	copy(envelopes, t.envelopes)
+	return envelopes
+}
+
+func (t *DryRunTransport) bufferSize() int {
+	if t.BufferSize <= 0 {
+		return defaultDryRunBufferSize
+	}
+	return t.BufferSize
+}
+
+// ================================
+// WriterTransport
+// ================================
+
+// WriterTransport is an implementation of Transport that writes envelopes to an
+// io.Writer, such as os.Stdout, instead of sending them over the network. A
+// sidecar or log shipper is then responsible for forwarding them to Sentry,
+// which is useful in environments without direct network egress.
+//
+// Envelopes are written as newline delimited JSON, one object per line:
+//
+//	{"dsn":"https://public@o1.ingest.sentry.io/1","envelope":"eyJldmVudF9pZCI6..."}
+//
+// The envelope field holds the base64 encoded envelope, ready to be posted to
+// the envelope endpoint of the DSN. Writes are synchronous and serialized, so
+// lines are never interleaved.
+type WriterTransport struct {
+	dsn *Dsn
+
+	mu sync.Mutex
+	w  io.Writer
+
+	// debugLogger is the DebugLogger of the client, if any.
+	debugLogger DebugLogger
+}
+
+// NewWriterTransport returns a new WriterTransport writing to w. If w is nil,
+// envelopes are written to os.Stdout.
+func NewWriterTransport(w io.Writer) *WriterTransport {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &WriterTransport{w: w}
+}
+
+// Configure is called by the Client itself, providing it it's own ClientOptions.
+func (t *WriterTransport) Configure(options ClientOptions) {
+	t.debugLogger = options.DebugLogger
+	dsn, err := NewDsn(options.Dsn)
+	if err != nil {
+		t.debugf(LevelError, "%v\n", err)
+		return
+	}
+	t.dsn = dsn
+}
+
+// SendEvent writes the envelope of the event to the underlying writer.
+func (t *WriterTransport) SendEvent(event *Event) {
+	if t.dsn == nil {
+		return
+	}
+
+	body := getRequestBodyFromEvent(event)
+	if body == nil {
+		return
+	}
+	envelope, err := envelopeFromEvent(event, eventDsn(event, t.dsn), time.Now(), body)
+	if err != nil {
+		t.debugf(LevelError, "There was an issue with encoding an event: %v", err)
+		return
+	}
+	t.SendEnvelope(envelope)
+}
+
+// SendEnvelope writes the envelope to the underlying writer.
+func (t *WriterTransport) SendEnvelope(envelope *Envelope) {
+	if t.dsn == nil || envelope == nil {
+		return
+	}
+
+	b, err := envelope.Serialize()
+	if err != nil {
+		t.debugf(LevelError, "There was an issue with encoding an envelope: %v", err)
+		return
+	}
+	dsn := envelope.Header.Dsn
+	if dsn == "" {
+		dsn = t.dsn.String()
+	}
+	line, err := json.Marshal(struct {
+		Dsn      string `json:"dsn"`
+		Envelope []byte `json:"envelope"`
+	}{
+		Dsn:      dsn,
+		Envelope: b,
+	})
+	if err != nil {
+		t.debugf(LevelError, "There was an issue with encoding an envelope: %v", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.w.Write(append(line, '\n')); err != nil {
+		t.debugf(LevelError, "There was an issue with writing an envelope: %v", err)
+	}
+}
+
+// Flush is a no-op for WriterTransport, as envelopes are written synchronously.
+// It always returns true immediately.
+func (t *WriterTransport) Flush(_ time.Duration) bool {
+	return true
+}
+
 // ================================
 // noopTransport
 // ================================
@@ -637,11 +1165,20 @@ type noopTransport struct{}
 var _ Transport = noopTransport{}
 
 func (noopTransport) Configure(ClientOptions) {
-	Logger.Println("Sentry client initialized with an empty DSN. Using noopTransport. No events will be delivered.")
+	debugf(LevelDebug, "Sentry client initialized with an empty DSN. Using noopTransport. No events will be delivered.")
 }
 
 func (noopTransport) SendEvent(*Event) {
-	Logger.Println("Event dropped due to noopTransport usage.")
+	debugf(LevelWarning, "Event dropped due to noopTransport usage.")
+}
+
+func (t noopTransport) sendEvent(event *Event) error {
+	t.SendEvent(event)
+	return ErrSDKDisabled
+}
+
+func (noopTransport) SendEnvelope(*Envelope) {
+	debugf(LevelWarning, "Envelope dropped due to noopTransport usage.")
 }
 
 func (noopTransport) Flush(time.Duration) bool {
@@ -0,0 +1,127 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+)
+
+// DropReason describes why the SDK discarded an event or envelope instead of
+// delivering it to Sentry.
+type DropReason string
+
+const (
+	// DropReasonQueueOverflow means the transport buffer was full.
+	DropReasonQueueOverflow DropReason = "queue_overflow"
+	// DropReasonRateLimit means Sentry asked the SDK to back off, or that the
+	// ErrorRateLimit client options were exceeded.
+	DropReasonRateLimit DropReason = "ratelimit_backoff"
+	// DropReasonSampleRate means the event was not sampled.
+	DropReasonSampleRate DropReason = "sample_rate"
+	// DropReasonBeforeSend means BeforeSend or BeforeSendTransaction returned
+	// nil.
+	DropReasonBeforeSend DropReason = "before_send"
+	// DropReasonEventProcessor means an event processor or a level mapper
+	// discarded the event.
+	DropReasonEventProcessor DropReason = "event_processor"
+	// DropReasonEncodingError means the payload could not be serialized.
+	DropReasonEncodingError DropReason = "encoding_error"
+	// DropReasonNetworkError means the request could not be completed.
+	DropReasonNetworkError DropReason = "network_error"
+	// DropReasonHTTPError means Sentry responded with an error status code.
+	DropReasonHTTPError DropReason = "http_error"
+	// DropReasonBufferOverflow means a transaction had too many spans, see
+	// the MaxSpans client option.
+	DropReasonBufferOverflow DropReason = "buffer_overflow"
+)
+
+// TransportStats is a snapshot of the internal state of a transport.
+//
+// Stats are useful to monitor the SDK itself, for example to alert when events
+// are being dropped. They can be published with the expvar package:
+//
+//	expvar.Publish("sentry", expvar.Func(func() any {
+//		return transport.TransportStats()
+//	}))
+type TransportStats struct {
+	// QueueDepth is the number of requests waiting to be sent.
+	QueueDepth int `json:"queue_depth"`
+	// InFlight is the number of requests currently being sent.
+	InFlight int `json:"in_flight"`
+	// BytesSent is the total size of the request bodies delivered to Sentry.
+	BytesSent uint64 `json:"bytes_sent"`
+	// Dropped counts discarded events and envelopes by reason.
+	Dropped map[DropReason]uint64 `json:"dropped"`
+	// LastError is the message of the most recent delivery error, if any.
+	LastError string `json:"last_error,omitempty"`
+	// LastErrorTime is the time of the most recent delivery error.
+	LastErrorTime time.Time `json:"last_error_time"`
+}
+
+// transportStats accumulates the stats of a transport. It is safe for
+// concurrent use.
+type transportStats struct {
+	mu            sync.Mutex
+	queued        int
+	inFlight      int
+	bytesSent     uint64
+	dropped       map[DropReason]uint64
+	lastError     string
+	lastErrorTime time.Time
+}
+
+func (s *transportStats) enqueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued++
+}
+
+func (s *transportStats) dequeued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued--
+}
+
+func (s *transportStats) started() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight++
+}
+
+func (s *transportStats) finished(bytesSent int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if bytesSent > 0 {
+		s.bytesSent += uint64(bytesSent)
+	}
+}
+
+func (s *transportStats) drop(reason DropReason, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dropped == nil {
+		s.dropped = make(map[DropReason]uint64)
+	}
+	s.dropped[reason]++
+	if err != nil {
+		s.lastError = err.Error()
+		s.lastErrorTime = time.Now()
+	}
+}
+
+func (s *transportStats) snapshot() TransportStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dropped := make(map[DropReason]uint64, len(s.dropped))
+	for reason, n := range s.dropped {
+		dropped[reason] = n
+	}
+	return TransportStats{
+		QueueDepth:    s.queued,
+		InFlight:      s.inFlight,
+		BytesSent:     s.bytesSent,
+		Dropped:       dropped,
+		LastError:     s.lastError,
+		LastErrorTime: s.lastErrorTime,
+	}
+}
@@ -2,18 +2,23 @@ package sentry
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httptrace"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/getsentry/sentry-go/internal/ratelimit"
 	"github.com/getsentry/sentry-go/internal/testutils"
 	"github.com/google/go-cmp/cmp"
 )
@@ -680,3 +685,450 @@ func testRateLimiting(t *testing.T, tr Transport) {
 		t.Errorf("got transactionEvent = %d, want %d", n, 1)
 	}
 }
+
+func TestDryRunTransport(t *testing.T) {
+	var out bytes.Buffer
+	transport := NewDryRunTransport(&out)
+	transport.Configure(ClientOptions{})
+
+	event := &Event{
+		EventID: "b81c5be4d31e48959103a1f878a1efcb",
+		Level:   LevelError,
+		Message: "mkey",
+	}
+	transport.SendEvent(event)
+
+	envelope := NewEnvelope(EnvelopeHeader{SentAt: time.Unix(0, 0).UTC()})
+	envelope.AddItem(&EnvelopeItem{Type: "client_report", Payload: []byte(`{}`)})
+	transport.SendEnvelope(envelope)
+
+	if !transport.Flush(0) {
+		t.Error("Flush() = false, want true")
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"Dry run: not sending error event [b81c5be4d31e48959103a1f878a1efcb]",
+		"  \"message\": \"mkey\"",
+		"Dry run: not sending envelope with 1 item(s)",
+		`{"type":"client_report","length":2}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+
+	if events := transport.Events(); len(events) != 1 || events[0] != event {
+		t.Errorf("Events() = %v, want [%p]", events, event)
+	}
+	if envelopes := transport.Envelopes(); len(envelopes) != 1 || envelopes[0] != envelope {
+		t.Errorf("Envelopes() = %v, want [%p]", envelopes, envelope)
+	}
+}
+
+func TestDryRunTransportBufferSize(t *testing.T) {
+	transport := &DryRunTransport{BufferSize: 2}
+	for _, msg := range []string{"a", "b", "c"} {
+		transport.SendEvent(&Event{Message: msg})
+	}
+
+	var got []string
+	for _, event := range transport.Events() {
+		got = append(got, event.Message)
+	}
+	assertEqual(t, got, []string{"b", "c"})
+}
+
+func TestDryRunOption(t *testing.T) {
+	var out bytes.Buffer
+	client, err := NewClient(ClientOptions{
+		DryRun:      true,
+		DebugWriter: &out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := client.Transport.(*DryRunTransport)
+	if !ok {
+		t.Fatalf("client.Transport = %T, want *DryRunTransport", client.Transport)
+	}
+
+	client.CaptureMessage("dry run", nil, nil)
+
+	if n := len(transport.Events()); n != 1 {
+		t.Fatalf("len(Events()) = %d, want 1", n)
+	}
+	if !strings.Contains(out.String(), `"message": "dry run"`) {
+		t.Errorf("output does not contain the event:\n%s", out.String())
+	}
+}
+
+func TestHTTPTransportWorkers(t *testing.T) {
+	const workers = 3
+
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		if n == workers {
+			close(release)
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport()
+	transport.Workers = workers
+	transport.Configure(ClientOptions{
+		Dsn:        fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+		HTTPClient: server.Client(),
+	})
+
+	for i := 0; i < workers; i++ {
+		transport.SendEvent(NewEvent())
+	}
+	if !transport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out")
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got != workers {
+		t.Errorf("max concurrent requests = %d, want %d", got, workers)
+	}
+}
+
+func TestHTTPTransportRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	transport := NewHTTPTransport()
+	transport.RequestTimeout = 50 * time.Millisecond
+	transport.Configure(ClientOptions{
+		Dsn:        fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+		HTTPClient: server.Client(),
+	})
+
+	transport.SendEvent(NewEvent())
+	if !transport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out, the request timeout was not enforced")
+	}
+}
+
+func TestTransportUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sentry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "relay.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %v", err)
+	}
+	var requests uint64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(&requests, 1)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	options := ClientOptions{
+		Dsn:        "http://test@sentry.invalid/1",
+		UnixSocket: socket,
+	}
+
+	syncTransport := NewHTTPSyncTransport()
+	syncTransport.Configure(options)
+	syncTransport.SendEvent(NewEvent())
+
+	asyncTransport := NewHTTPTransport()
+	asyncTransport.Configure(options)
+	asyncTransport.SendEvent(NewEvent())
+	if !asyncTransport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out")
+	}
+
+	if got := atomic.LoadUint64(&requests); got != 2 {
+		t.Errorf("requests received through the unix socket = %d, want 2", got)
+	}
+}
+
+func TestTransportDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var dialed []string
+	transport := NewHTTPSyncTransport()
+	transport.Configure(ClientOptions{
+		Dsn: "http://test@sentry.invalid/1",
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			var d net.Dialer
+			return d.DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	})
+	transport.SendEvent(NewEvent())
+
+	assertEqual(t, dialed, []string{"sentry.invalid:80"})
+}
+
+func TestHTTPSyncTransportStats(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	transport := NewHTTPSyncTransport()
+	transport.Configure(ClientOptions{
+		Dsn: fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+	})
+
+	transport.SendEvent(NewEvent())
+	stats := transport.TransportStats()
+	if stats.BytesSent == 0 {
+		t.Error("BytesSent = 0, want > 0")
+	}
+
+	status = http.StatusInternalServerError
+	transport.SendEvent(NewEvent())
+	stats = transport.TransportStats()
+	assertEqual(t, stats.Dropped, map[DropReason]uint64{DropReasonHTTPError: 1})
+	assertEqual(t, stats.LastError, "unexpected response status: 500 Internal Server Error")
+	assertEqual(t, stats.InFlight, 0)
+	assertEqual(t, stats.QueueDepth, 0)
+}
+
+func TestHTTPTransportStats(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport()
+	transport.BufferSize = 1
+	transport.Configure(ClientOptions{
+		Dsn: fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+	})
+
+	// The first event is picked up by the worker, which blocks on the server.
+	transport.SendEvent(NewEvent())
+	deadline := time.Now().Add(testutils.FlushTimeout())
+	for transport.TransportStats().InFlight != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the request to be in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The second event waits in the queue, and the third one overflows it.
+	transport.SendEvent(NewEvent())
+	transport.SendEvent(NewEvent())
+
+	stats := transport.TransportStats()
+	assertEqual(t, stats.QueueDepth, 1)
+	assertEqual(t, stats.InFlight, 1)
+	assertEqual(t, stats.Dropped, map[DropReason]uint64{DropReasonQueueOverflow: 1})
+
+	close(unblock)
+	if !transport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out")
+	}
+
+	stats = transport.TransportStats()
+	assertEqual(t, stats.QueueDepth, 0)
+	assertEqual(t, stats.InFlight, 0)
+	if stats.BytesSent == 0 {
+		t.Error("BytesSent = 0, want > 0")
+	}
+}
+
+func TestHTTPTransportSerializesSnapshot(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport()
+	transport.Configure(ClientOptions{
+		Dsn: fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+	})
+
+	event := NewEvent()
+	event.Message = "original"
+	event.Tags = map[string]string{"key": "original"}
+	transport.SendEvent(event)
+
+	// Changes made after SendEvent returns must not be sent.
+	event.Message = "modified"
+	event.Tags["key"] = "modified"
+
+	if !transport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out")
+	}
+	if !bytes.Contains(body, []byte(`"message":"original"`)) || !bytes.Contains(body, []byte(`"tags":{"key":"original"}`)) {
+		t.Errorf("request body does not contain the original event:\n%s", body)
+	}
+}
+
+func TestTransportHTTPHeaders(t *testing.T) {
+	headers := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+
+	var calls int32
+	options := ClientOptions{
+		Dsn: fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+		HTTPHeaders: http.Header{
+			"X-Gateway-Token": {"static"},
+			"X-Signature":     {"overridden"},
+		},
+		HTTPHeadersFunc: func(r *http.Request) http.Header {
+			n := atomic.AddInt32(&calls, 1)
+			return http.Header{"X-Signature": {fmt.Sprintf("signature-%d", n)}}
+		},
+	}
+
+	syncTransport := NewHTTPSyncTransport()
+	syncTransport.Configure(options)
+	syncTransport.SendEvent(NewEvent())
+
+	asyncTransport := NewHTTPTransport()
+	asyncTransport.Configure(options)
+	asyncTransport.SendEvent(NewEvent())
+	if !asyncTransport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out")
+	}
+
+	for i := 1; i <= 2; i++ {
+		h := <-headers
+		assertEqual(t, h.Get("X-Gateway-Token"), "static")
+		assertEqual(t, h.Get("X-Signature"), fmt.Sprintf("signature-%d", i))
+		if h.Get("X-Sentry-Auth") == "" {
+			t.Error("X-Sentry-Auth header is missing")
+		}
+	}
+}
+
+func TestWriterTransport(t *testing.T) {
+	var out bytes.Buffer
+	transport := NewWriterTransport(&out)
+	transport.Configure(ClientOptions{Dsn: "https://public@example.com/1"})
+
+	event := NewEvent()
+	event.EventID = "b81c5be4d31e48959103a1f878a1efcb"
+	event.Message = "mkey"
+	transport.SendEvent(event)
+
+	envelope := NewEnvelope(EnvelopeHeader{SentAt: time.Unix(0, 0).UTC()})
+	envelope.AddItem(&EnvelopeItem{Type: "client_report", Payload: []byte(`{}`)})
+	transport.SendEnvelope(envelope)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out.String())
+	}
+
+	var got []string
+	for _, line := range lines {
+		var v struct {
+			Dsn      string `json:"dsn"`
+			Envelope []byte `json:"envelope"`
+		}
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, v.Dsn, "https://public@example.com/1")
+		got = append(got, string(v.Envelope))
+	}
+
+	if !strings.Contains(got[0], `"event_id":"b81c5be4d31e48959103a1f878a1efcb"`) || !strings.Contains(got[0], `"message":"mkey"`) {
+		t.Errorf("first envelope does not contain the event:\n%s", got[0])
+	}
+	want, _ := envelope.Serialize()
+	assertEqual(t, got[1], string(want))
+}
+
+func TestHTTPTransportDroppedEnvelopeClosesAttachments(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport()
+	transport.BufferSize = 1
+	transport.Configure(ClientOptions{
+		Dsn: fmt.Sprintf("http://test@%s/1", server.Listener.Addr()),
+	})
+
+	path := filepath.Join(t.TempDir(), "dump.bin")
+	if err := os.WriteFile(path, []byte("dump"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var files []*os.File
+	open := func() (io.ReadCloser, int64, error) {
+		rc, size, err := NewFileAttachment(path, "").Open()
+		if err == nil {
+			mu.Lock()
+			files = append(files, rc.(*os.File))
+			mu.Unlock()
+		}
+		return rc, size, err
+	}
+	envelope := func() *Envelope {
+		e := NewEnvelope(EnvelopeHeader{})
+		e.AddItem(&EnvelopeItem{Type: attachmentType, Filename: "dump.bin", open: open})
+		return e
+	}
+
+	// The first event is picked up by the worker, which blocks on the server.
+	transport.SendEvent(NewEvent())
+	deadline := time.Now().Add(testutils.FlushTimeout())
+	for transport.TransportStats().InFlight != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the request to be in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The first envelope waits in the queue, and is dropped by the worker
+	// because of a rate limit. The second one overflows the queue.
+	transport.SendEnvelope(envelope())
+	transport.SendEnvelope(envelope())
+	transport.mu.Lock()
+	transport.limits = ratelimit.Map{ratelimit.CategoryAll: ratelimit.Deadline(time.Now().Add(time.Minute))}
+	transport.mu.Unlock()
+
+	close(unblock)
+	if !transport.Flush(testutils.FlushTimeout()) {
+		t.Fatal("Flush() timed out")
+	}
+
+	stats := transport.TransportStats()
+	assertEqual(t, stats.Dropped, map[DropReason]uint64{DropReasonQueueOverflow: 1, DropReasonRateLimit: 1})
+	mu.Lock()
+	defer mu.Unlock()
+	for _, f := range files {
+		if err := f.Close(); err == nil {
+			t.Errorf("attachment file of a dropped envelope left open")
+		}
+	}
+}
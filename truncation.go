@@ -0,0 +1,329 @@
+package sentry
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// maxEventBytes is the maximum size of a serialized event. Sentry rejects
+// events that are larger than this limit as a whole.
+//
+// See https://develop.sentry.dev/sdk/envelopes/#size-limits.
+const maxEventBytes = 1000 * 1000
+
+// maxTruncatedStringLength is the length long strings are cut to when an event
+// exceeds maxEventBytes.
+const maxTruncatedStringLength = 1024
+
+// eventMeta holds the "_meta" annotations of an event, which tell Sentry which
+// parts of the event were modified by the SDK.
+//
+// See https://develop.sentry.dev/sdk/event-payloads/#meta-data.
+type eventMeta map[string]interface{}
+
+// markRemoved records that the value at the given path was removed because of
+// size limits. originalLength is omitted from the annotation if negative.
+func (m eventMeta) markRemoved(originalLength int, path ...string) {
+	node := m
+	for _, p := range path {
+		child, ok := node[p].(eventMeta)
+		if !ok {
+			child = eventMeta{}
+			node[p] = child
+		}
+		node = child
+	}
+	annotation := map[string]interface{}{
+		"rem": [][]interface{}{{"!limit", "x"}},
+	}
+	if originalLength >= 0 {
+		annotation["len"] = originalLength
+	}
+	node[""] = annotation
+}
+
+// setLength records the original length of the array at the given path, from
+// which elements were removed.
+func (m eventMeta) setLength(originalLength int, path ...string) {
+	node := m
+	for _, p := range path {
+		child, ok := node[p].(eventMeta)
+		if !ok {
+			child = eventMeta{}
+			node[p] = child
+		}
+		node = child
+	}
+	node[""] = map[string]interface{}{"len": originalLength}
+}
+
+// limitSpans enforces the limits on the descriptions and data of the spans of
+// a transaction, see the MaxSpanDescriptionLength and MaxSpanDataSize client
+// options. Non-positive limits are ignored.
+func limitSpans(event *Event, maxDescriptionLength, maxDataSize int) {
+	for i, span := range event.Spans {
+		span.mu.Lock()
+		if maxDescriptionLength > 0 && len(span.Description) > maxDescriptionLength {
+			event.meta.markRemoved(len(span.Description), "spans", strconv.Itoa(i), "description")
+			span.Description = truncateUTF8(span.Description, maxDescriptionLength) + "..."
+		}
+		if maxDataSize > 0 && len(span.Data) > 0 {
+			removeLargestSpanData(event, i, span, maxDataSize)
+		}
+		span.mu.Unlock()
+	}
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that doesn't
+// split a UTF-8 encoded character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// removeLargestSpanData removes the largest values from the data of the i-th
+// span of event until its serialized size is at most limit.
+func removeLargestSpanData(event *Event, i int, span *Span, limit int) {
+	b, err := json.Marshal(span.Data)
+	if err != nil || len(b) <= limit {
+		return
+	}
+	type entry struct {
+		key  string
+		size int
+	}
+	entries := make([]entry, 0, len(span.Data))
+	for k, v := range span.Data {
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{key: k, size: len(b)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].size > entries[j].size
+	})
+
+	data := make(map[string]interface{}, len(span.Data))
+	for k, v := range span.Data {
+		data[k] = v
+	}
+	size := len(b)
+	for _, e := range entries {
+		if size <= limit {
+			break
+		}
+		event.meta.markRemoved(e.size, "spans", strconv.Itoa(i), "data", e.key)
+		delete(data, e.key)
+		size -= e.size
+	}
+	span.Data = data
+}
+
+// truncateEvent trims the largest contributors to the size of an event until
+// its serialized form fits within limit, and returns the serialized event.
+//
+// Data is removed gradually, from the least to the most useful for debugging:
+// long strings are shortened first, then frame variables, breadcrumb data,
+// span data, extra data (largest values first) and, as a last resort, the
+// oldest breadcrumbs. Every removal is annotated in the "_meta" section of the
+// event, so that Sentry can show that the event was truncated.
+func truncateEvent(event *Event, body []byte, limit int) []byte {
+	if event.meta == nil {
+		event.meta = eventMeta{}
+	}
+
+	steps := []func(event *Event){
+		truncateLongStrings,
+		removeFrameVars,
+		removeBreadcrumbsData,
+		removeSpansData,
+		func(event *Event) { removeLargestExtra(event, len(body)-limit) },
+	}
+
+	for _, step := range steps {
+		step(event)
+		b, err := json.Marshal(event)
+		if err != nil {
+			debugf(LevelError, "Event couldn't be marshaled after truncation: %v", err)
+			return body
+		}
+		body = b
+		if len(body) <= limit {
+			debugf(LevelWarning, "Event [%s] truncated to %d bytes to fit within size limits.", event.EventID, len(body))
+			return body
+		}
+	}
+
+	originalBreadcrumbs := len(event.Breadcrumbs)
+	for len(event.Breadcrumbs) > 0 {
+		// Drop the oldest half of the breadcrumbs.
+		removeOldestBreadcrumbs(event, len(event.Breadcrumbs)/2+len(event.Breadcrumbs)%2)
+		event.meta.setLength(originalBreadcrumbs, "breadcrumbs")
+		b, err := json.Marshal(event)
+		if err != nil {
+			debugf(LevelError, "Event couldn't be marshaled after truncation: %v", err)
+			return body
+		}
+		body = b
+		if len(body) <= limit {
+			debugf(LevelWarning, "Event [%s] truncated to %d bytes to fit within size limits.", event.EventID, len(body))
+			return body
+		}
+	}
+
+	debugf(LevelWarning, "Event [%s] exceeds the size limit even after truncation: %d bytes.", event.EventID, len(body))
+	return body
+}
+
+func truncateString(s string) (string, bool) {
+	if len(s) <= maxTruncatedStringLength {
+		return s, false
+	}
+	return truncateUTF8(s, maxTruncatedStringLength) + "...", true
+}
+
+func truncateLongStrings(event *Event) {
+	if s, ok := truncateString(event.Message); ok {
+		event.meta.markRemoved(len(event.Message), "message")
+		event.Message = s
+	}
+	for i := range event.Exception {
+		value := event.Exception[i].Value
+		if s, ok := truncateString(value); ok {
+			event.meta.markRemoved(len(value), "exception", strconv.Itoa(i), "value")
+			event.Exception[i].Value = s
+		}
+	}
+	for i, b := range event.Breadcrumbs {
+		if s, ok := truncateString(b.Message); ok {
+			event.meta.markRemoved(len(b.Message), "breadcrumbs", strconv.Itoa(i), "message")
+			c := *b
+			c.Message = s
+			event.Breadcrumbs[i] = &c
+		}
+	}
+	for k, v := range event.Extra {
+		if v, ok := v.(string); ok {
+			if s, ok := truncateString(v); ok {
+				event.meta.markRemoved(len(v), "extra", k)
+				event.Extra[k] = s
+			}
+		}
+	}
+}
+
+func removeFrameVars(event *Event) {
+	for i := range event.Exception {
+		stacktrace := withoutFrameVars(event.Exception[i].Stacktrace, event.meta, "exception", strconv.Itoa(i))
+		event.Exception[i].Stacktrace = stacktrace
+	}
+	for i := range event.Threads {
+		stacktrace := withoutFrameVars(event.Threads[i].Stacktrace, event.meta, "threads", strconv.Itoa(i))
+		event.Threads[i].Stacktrace = stacktrace
+	}
+}
+
+// withoutFrameVars returns stacktrace, or a copy of it without the variables
+// of its frames if any frame has some.
+func withoutFrameVars(stacktrace *Stacktrace, meta eventMeta, path ...string) *Stacktrace {
+	if stacktrace == nil {
+		return nil
+	}
+	var frames []Frame
+	for j, frame := range stacktrace.Frames {
+		if frame.Vars == nil {
+			continue
+		}
+		if frames == nil {
+			frames = append([]Frame(nil), stacktrace.Frames...)
+		}
+		meta.markRemoved(-1, append(path, "stacktrace", "frames", strconv.Itoa(j), "vars")...)
+		frames[j].Vars = nil
+	}
+	if frames == nil {
+		return stacktrace
+	}
+	c := *stacktrace
+	c.Frames = frames
+	return &c
+}
+
+func removeBreadcrumbsData(event *Event) {
+	for i, b := range event.Breadcrumbs {
+		if b.Data != nil {
+			event.meta.markRemoved(-1, "breadcrumbs", strconv.Itoa(i), "data")
+			c := *b
+			c.Data = nil
+			event.Breadcrumbs[i] = &c
+		}
+	}
+}
+
+// removeOldestBreadcrumbs removes the n oldest breadcrumbs of event, and moves
+// the annotations of the remaining ones to their new index.
+func removeOldestBreadcrumbs(event *Event, n int) {
+	event.Breadcrumbs = event.Breadcrumbs[n:]
+	meta, ok := event.meta["breadcrumbs"].(eventMeta)
+	if !ok {
+		return
+	}
+	shifted := eventMeta{}
+	for k, v := range meta {
+		i, err := strconv.Atoi(k)
+		if err != nil {
+			shifted[k] = v
+		} else if i >= n {
+			shifted[strconv.Itoa(i-n)] = v
+		}
+	}
+	event.meta["breadcrumbs"] = shifted
+}
+
+func removeSpansData(event *Event) {
+	for i, span := range event.Spans {
+		if span.Data != nil {
+			event.meta.markRemoved(-1, "spans", strconv.Itoa(i), "data")
+			c := span.shallowCopy()
+			c.Data = nil
+			event.Spans[i] = c
+		}
+	}
+}
+
+// removeLargestExtra removes extra values, largest first, until at least excess
+// bytes were freed.
+func removeLargestExtra(event *Event, excess int) {
+	type entry struct {
+		key  string
+		size int
+	}
+	entries := make([]entry, 0, len(event.Extra))
+	for k, v := range event.Extra {
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{key: k, size: len(b)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].size > entries[j].size
+	})
+
+	freed := 0
+	for _, e := range entries {
+		if freed >= excess {
+			break
+		}
+		event.meta.markRemoved(e.size, "extra", e.key)
+		delete(event.Extra, e.key)
+		freed += e.size
+	}
+}
@@ -0,0 +1,254 @@
+package sentry
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func marshalTestEvent(t *testing.T, event *Event) []byte {
+	t.Helper()
+	b, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestTruncateEventLongStrings(t *testing.T) {
+	event := NewEvent()
+	event.Message = strings.Repeat("m", 5000)
+	event.Extra["long"] = strings.Repeat("x", 5000)
+
+	body := truncateEvent(event, marshalTestEvent(t, event), 4000)
+
+	if len(body) > 4000 {
+		t.Fatalf("body is %d bytes, want at most 4000", len(body))
+	}
+	assertEqual(t, len(event.Message), maxTruncatedStringLength+len("..."))
+	assertEqual(t, len(event.Extra["long"].(string)), maxTruncatedStringLength+len("..."))
+
+	var got struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"message": map[string]interface{}{
+			"": map[string]interface{}{"len": float64(5000), "rem": []interface{}{[]interface{}{"!limit", "x"}}},
+		},
+		"extra": map[string]interface{}{
+			"long": map[string]interface{}{
+				"": map[string]interface{}{"len": float64(5000), "rem": []interface{}{[]interface{}{"!limit", "x"}}},
+			},
+		},
+	}
+	assertEqual(t, got.Meta, want)
+}
+
+func TestTruncateEventBreadcrumbsDataDoesNotModifyScope(t *testing.T) {
+	breadcrumb := &Breadcrumb{
+		Message: "query",
+		Data:    map[string]interface{}{"payload": strings.Repeat("x", 500)},
+	}
+	scope := NewScope()
+	scope.AddBreadcrumb(breadcrumb, 10)
+
+	event := scope.ApplyToEvent(NewEvent(), nil)
+	body := truncateEvent(event, marshalTestEvent(t, event), 300)
+
+	if len(body) > 300 {
+		t.Fatalf("body is %d bytes, want at most 300", len(body))
+	}
+	if event.Breadcrumbs[0].Data != nil {
+		t.Error("breadcrumb data was not removed from the event")
+	}
+	if breadcrumb.Data == nil {
+		t.Error("breadcrumb data was removed from the scope")
+	}
+	if _, ok := event.meta["breadcrumbs"]; !ok {
+		t.Error("missing _meta annotation for breadcrumbs")
+	}
+}
+
+func TestTruncateEventDoesNotModifySharedValues(t *testing.T) {
+	stacktrace := &Stacktrace{Frames: []Frame{
+		{Function: "main"},
+		{Function: "handler", Vars: map[string]interface{}{"payload": strings.Repeat("x", 500)}},
+	}}
+	span := &Span{Op: "db", Data: map[string]interface{}{"query": strings.Repeat("y", 500)}}
+	event := NewEvent()
+	event.Exception = []Exception{{Type: "error", Stacktrace: stacktrace}}
+	event.Threads = []Thread{{ID: "1", Stacktrace: stacktrace}}
+	event.Type = transactionType
+	event.Spans = []*Span{span}
+
+	snapshot := snapshotEvent(event)
+	body := truncateEvent(snapshot, marshalTestEvent(t, snapshot), 900)
+
+	if len(body) > 900 {
+		t.Fatalf("body is %d bytes, want at most 900", len(body))
+	}
+	if snapshot.Exception[0].Stacktrace.Frames[1].Vars != nil || snapshot.Threads[0].Stacktrace.Frames[1].Vars != nil {
+		t.Error("frame vars were not removed from the event")
+	}
+	if snapshot.Spans[0].Data != nil {
+		t.Error("span data was not removed from the event")
+	}
+	if stacktrace.Frames[1].Vars == nil {
+		t.Error("frame vars were removed from the stacktrace of the caller")
+	}
+	if span.Data == nil {
+		t.Error("span data was removed from the span of the caller")
+	}
+}
+
+func TestTruncateEventLongStringsUTF8(t *testing.T) {
+	event := NewEvent()
+	event.meta = eventMeta{}
+	event.Message = "a" + strings.Repeat("é", 2000)
+
+	truncateLongStrings(event)
+
+	if !utf8.ValidString(event.Message) {
+		t.Errorf("truncated message %q isn't valid UTF-8", event.Message)
+	}
+	assertEqual(t, len(event.Message), maxTruncatedStringLength-1+len("..."))
+}
+
+func TestTruncateEventRemovesLargestExtraFirst(t *testing.T) {
+	event := NewEvent()
+	event.Extra["small"] = []int{1, 2, 3}
+	event.Extra["large"] = make([]int, 500)
+
+	body := truncateEvent(event, marshalTestEvent(t, event), 500)
+
+	if len(body) > 500 {
+		t.Fatalf("body is %d bytes, want at most 500", len(body))
+	}
+	if _, ok := event.Extra["large"]; ok {
+		t.Error("large extra was not removed")
+	}
+	if _, ok := event.Extra["small"]; !ok {
+		t.Error("small extra was removed")
+	}
+}
+
+func TestTruncateEventRemovesOldestBreadcrumbs(t *testing.T) {
+	event := NewEvent()
+	for i := 0; i < 100; i++ {
+		event.Breadcrumbs = append(event.Breadcrumbs, &Breadcrumb{Message: strings.Repeat("b", 50)})
+	}
+	last := event.Breadcrumbs[99]
+
+	body := truncateEvent(event, marshalTestEvent(t, event), 1000)
+
+	if len(body) > 1000 {
+		t.Fatalf("body is %d bytes, want at most 1000", len(body))
+	}
+	if len(event.Breadcrumbs) == 0 || event.Breadcrumbs[len(event.Breadcrumbs)-1] != last {
+		t.Error("the most recent breadcrumb should be kept")
+	}
+	assertEqual(t, event.meta["breadcrumbs"], eventMeta{"": map[string]interface{}{"len": 100}})
+}
+
+func TestTruncateEventRemovesOldestBreadcrumbsMeta(t *testing.T) {
+	event := NewEvent()
+	for i := 0; i < 100; i++ {
+		event.Breadcrumbs = append(event.Breadcrumbs, &Breadcrumb{Message: strings.Repeat("b", 50)})
+	}
+	event.Breadcrumbs[0].Message = strings.Repeat("x", 2000)
+	event.Breadcrumbs[99].Message = strings.Repeat("y", 2000)
+
+	body := truncateEvent(event, marshalTestEvent(t, event), 2000)
+
+	if len(body) > 2000 {
+		t.Fatalf("body is %d bytes, want at most 2000", len(body))
+	}
+	last := strconv.Itoa(len(event.Breadcrumbs) - 1)
+	assertEqual(t, event.meta["breadcrumbs"], eventMeta{
+		"": map[string]interface{}{"len": 100},
+		last: eventMeta{
+			"message": eventMeta{
+				"": map[string]interface{}{"len": 2000, "rem": [][]interface{}{{"!limit", "x"}}},
+			},
+		},
+	})
+}
+
+func TestGetRequestBodyFromEventTruncatesLargeEvents(t *testing.T) {
+	event := NewEvent()
+	event.Extra["huge"] = strings.Repeat("x", maxEventBytes)
+
+	body := getRequestBodyFromEvent(event)
+
+	if len(body) > maxEventBytes {
+		t.Fatalf("body is %d bytes, want at most %d", len(body), maxEventBytes)
+	}
+}
+
+func TestSpanLimits(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:            true,
+		TracesSampleRate:         1.0,
+		Transport:                transport,
+		MaxSpans:                 3,
+		MaxSpanDescriptionLength: 10,
+		MaxSpanDataSize:          50,
+	})
+	transaction := StartTransaction(ctx, "transaction")
+	described := transaction.StartChild("described")
+	described.Description = "SELECT * FROM thé_table"
+	withData := transaction.StartChild("data")
+	withData.SetData("small", "value")
+	withData.SetData("large", strings.Repeat("x", 100))
+	for i := 0; i < 2; i++ {
+		transaction.StartChild("dropped").Finish()
+	}
+	described.Finish()
+	withData.Finish()
+	transaction.Finish()
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("sent %d events, want 1", len(events))
+	}
+	event := events[0]
+	assertEqual(t, len(event.Spans), 2)
+	assertEqual(t, event.Spans[0].Description, "SELECT * F...")
+	assertEqual(t, event.Spans[1].Data, map[string]interface{}{"small": "value"})
+
+	var got struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if err := json.Unmarshal(marshalTestEvent(t, event), &got); err != nil {
+		t.Fatal(err)
+	}
+	removed := func(length int) map[string]interface{} {
+		return map[string]interface{}{
+			"": map[string]interface{}{"len": float64(length), "rem": []interface{}{[]interface{}{"!limit", "x"}}},
+		}
+	}
+	assertEqual(t, got.Meta, map[string]interface{}{
+		"spans": map[string]interface{}{
+			"":  map[string]interface{}{"len": float64(4)},
+			"0": map[string]interface{}{"description": removed(24)},
+			"1": map[string]interface{}{"data": map[string]interface{}{"large": removed(102)}},
+		},
+	})
+
+	client := hubFromContext(ctx).Client()
+	assertEqual(t, client.reports.take(true), []discardedEvent{
+		{Reason: DropReasonBufferOverflow, Category: "span", Quantity: 2},
+	})
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	assertEqual(t, truncateUTF8("thé", 3), "th")
+	assertEqual(t, truncateUTF8("thé", 4), "thé")
+	assertEqual(t, truncateUTF8("thé", 10), "thé")
+}
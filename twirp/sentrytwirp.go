@@ -0,0 +1,191 @@
+// Package sentrytwirp provides Sentry instrumentation for Twirp servers.
+//
+// Register the hooks with the server, and wrap it with Handler to continue
+// the traces propagated by clients and report the requests with errors:
+//
+//	server := haberdasher.NewHaberdasherServer(h, twirp.WithServerHooks(sentrytwirp.NewServerHooks(sentrytwirp.Options{})))
+//	http.Handle(server.PathPrefix(), sentrytwirp.Handler(server))
+//
+// Each request runs in an "rpc.server" transaction named after the package,
+// service and method of the RPC, which are also set as tags. Internal errors,
+// including panics of the service, are reported to Sentry.
+package sentrytwirp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/twitchtv/twirp"
+)
+
+// The identifier of the Twirp SDK.
+const sdkIdentifier = "sentry.go.twirp"
+
+// spanOperation is the operation of the transactions of requests.
+const spanOperation = "rpc.server"
+
+// spanOrigin is the origin of the transactions of requests.
+const spanOrigin = "auto.rpc.twirp"
+
+type contextKey int
+
+const (
+	headerKey contextKey = iota
+	transactionKey
+)
+
+// Options configure the hooks.
+type Options struct {
+	// CaptureErrorCodes are the codes of the errors reported to Sentry.
+	// Defaults to twirp.Internal, which is also the code of panics, and
+	// twirp.Unknown.
+	CaptureErrorCodes []twirp.ErrorCode
+}
+
+type hooks struct {
+	captureErrorCodes map[twirp.ErrorCode]bool
+}
+
+// Handler returns an http.Handler setting the request on the scope of a
+// clone of the hub of the request context, or of the current hub, and
+// recording the trace headers of the request for the hooks, before calling
+// handler.
+func Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+		hub = hub.Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+		ctx = context.WithValue(ctx, headerKey, r.Header)
+		r = r.WithContext(ctx)
+		hub.Scope().SetRequest(r)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// NewServerHooks returns Twirp server hooks starting a transaction for each
+// request and reporting errors to Sentry.
+func NewServerHooks(options Options) *twirp.ServerHooks {
+	codes := options.CaptureErrorCodes
+	if codes == nil {
+		codes = []twirp.ErrorCode{twirp.Internal, twirp.Unknown}
+	}
+	h := &hooks{captureErrorCodes: make(map[twirp.ErrorCode]bool, len(codes))}
+	for _, code := range codes {
+		h.captureErrorCodes[code] = true
+	}
+	return &twirp.ServerHooks{
+		RequestReceived: h.requestReceived,
+		RequestRouted:   h.requestRouted,
+		Error:           h.error,
+		ResponseSent:    h.responseSent,
+	}
+}
+
+// requestReceived starts the transaction of a request, named after its
+// service until it is routed.
+func (h *hooks) requestReceived(ctx context.Context) (context.Context, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	hub.Client().SetSDKIdentifier(sdkIdentifier)
+
+	options := []sentry.SpanOption{
+		sentry.WithOpName(spanOperation),
+		sentry.WithTransactionSource(sentry.SourceRoute),
+		sentry.WithSpanOrigin(spanOrigin),
+	}
+	if header, ok := ctx.Value(headerKey).(http.Header); ok {
+		options = append(options, sentry.ContinueFromRequest(&http.Request{Header: header}))
+	}
+
+	name := serviceName(ctx)
+	tags := map[string]string{"twirp.service": name}
+	if pkg, ok := twirp.PackageName(ctx); ok && pkg != "" {
+		tags["twirp.package"] = pkg
+		name = pkg + "." + name
+	}
+	hub.Scope().SetTags(tags)
+
+	transaction := sentry.StartTransaction(ctx, name, options...)
+	for key, value := range tags {
+		transaction.SetTag(key, value)
+	}
+	return context.WithValue(transaction.Context(), transactionKey, transaction), nil
+}
+
+// requestRouted names the transaction of a request after its method.
+func (h *hooks) requestRouted(ctx context.Context) (context.Context, error) {
+	transaction, ok := ctx.Value(transactionKey).(*sentry.Span)
+	if !ok {
+		return ctx, nil
+	}
+	if method, ok := twirp.MethodName(ctx); ok {
+		transaction.Name += "/" + method
+		transaction.SetTag("twirp.method", method)
+		if hub := sentry.GetHubFromContext(ctx); hub != nil {
+			hub.Scope().SetTag("twirp.method", method)
+		}
+	}
+	return ctx, nil
+}
+
+// error reports errors with one of the configured codes.
+func (h *hooks) error(ctx context.Context, err twirp.Error) context.Context {
+	if !h.captureErrorCodes[err.Code()] {
+		return ctx
+	}
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		twirpContext := sentry.Context{
+			"code":    string(err.Code()),
+			"message": err.Msg(),
+		}
+		if meta := err.MetaMap(); len(meta) > 0 {
+			twirpContext["meta"] = meta
+		}
+		scope.SetContext("twirp", twirpContext)
+
+		// Report the cause of wrapped errors, with its stack trace.
+		var reported error = err
+		if cause := errors.Unwrap(err); cause != nil {
+			reported = cause
+		}
+		hub.CaptureException(reported)
+	})
+	return ctx
+}
+
+// responseSent finishes the transaction of a request with the status code of
+// its response.
+func (h *hooks) responseSent(ctx context.Context) {
+	transaction, ok := ctx.Value(transactionKey).(*sentry.Span)
+	if !ok {
+		return
+	}
+	if status, ok := twirp.StatusCode(ctx); ok {
+		if code, err := strconv.Atoi(status); err == nil {
+			transaction.SetHTTPStatus(code)
+		}
+	}
+	transaction.Finish()
+}
+
+// serviceName returns the name of the service of a request.
+func serviceName(ctx context.Context) string {
+	if name, ok := twirp.ServiceName(ctx); ok {
+		return name
+	}
+	return "twirp"
+}
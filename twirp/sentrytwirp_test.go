@@ -0,0 +1,149 @@
+package sentrytwirp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	sentrytwirp "github.com/getsentry/sentry-go/twirp"
+	"github.com/google/go-cmp/cmp"
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/ctxsetters"
+)
+
+// server calls the hooks as a Twirp server does, with the error returned by
+// the method.
+type server struct {
+	hooks *twirp.ServerHooks
+	err   twirp.Error
+}
+
+func (s server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := ctxsetters.WithPackageName(r.Context(), "example.v1")
+	ctx = ctxsetters.WithServiceName(ctx, "Haberdasher")
+	ctx, _ = s.hooks.RequestReceived(ctx)
+	ctx = ctxsetters.WithMethodName(ctx, "MakeHat")
+	ctx, _ = s.hooks.RequestRouted(ctx)
+	status := http.StatusOK
+	if s.err != nil {
+		ctx = s.hooks.Error(ctx, s.err)
+		status = twirp.ServerHTTPStatusFromErrorCode(s.err.Code())
+	}
+	w.WriteHeader(status)
+	s.hooks.ResponseSent(ctxsetters.WithStatusCode(ctx, status))
+}
+
+func TestServerHooks(t *testing.T) {
+	var events, transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := sentrytwirp.NewServerHooks(sentrytwirp.Options{})
+
+	cause := errors.New("connection refused")
+	tests := []struct {
+		err        twirp.Error
+		wantStatus sentry.SpanStatus
+		wantEvents int
+	}{
+		{nil, sentry.SpanStatusOK, 0},
+		{twirp.NotFoundError("no hat"), sentry.SpanStatusNotFound, 0},
+		{twirp.InternalErrorWith(cause).WithMeta("size", "12"), sentry.SpanStatusInternalError, 1},
+	}
+	for _, tt := range tests {
+		events, transactions = nil, nil
+		req := httptest.NewRequest(http.MethodPost, "/twirp/example.v1.Haberdasher/MakeHat", nil)
+		req.Header.Set("sentry-trace", "d49d9bf66f13450b81f65bc51cf49c03-1cc4b26ab9094ef0-1")
+		sentrytwirp.Handler(server{hooks, tt.err}).ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(transactions) != 1 {
+			t.Fatalf("got %d transactions, want 1", len(transactions))
+		}
+		transaction := transactions[0]
+		if diff := cmp.Diff("example.v1.Haberdasher/MakeHat", transaction.Transaction); diff != "" {
+			t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tt.wantStatus, transaction.Contexts["trace"]["status"]); diff != "" {
+			t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(
+			"d49d9bf66f13450b81f65bc51cf49c03",
+			transaction.Contexts["trace"]["trace_id"].(sentry.TraceID).String(),
+		); diff != "" {
+			t.Errorf("trace ID mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(map[string]string{
+			"twirp.package": "example.v1",
+			"twirp.service": "Haberdasher",
+			"twirp.method":  "MakeHat",
+		}, transaction.Tags); diff != "" {
+			t.Errorf("transaction tags mismatch (-want +got):\n%s", diff)
+		}
+		if len(events) != tt.wantEvents {
+			t.Fatalf("got %d events, want %d", len(events), tt.wantEvents)
+		}
+	}
+
+	event := events[0]
+	if diff := cmp.Diff("connection refused", event.Exception[len(event.Exception)-1].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.Context{
+		"code":    "internal",
+		"message": "connection refused",
+		"meta":    map[string]string{"cause": "*errors.errorString", "size": "12"},
+	}, event.Contexts["twirp"]); diff != "" {
+		t.Errorf("twirp context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("MakeHat", event.Tags["twirp.method"]); diff != "" {
+		t.Errorf("method tag mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(http.MethodPost, event.Request.Method); diff != "" {
+		t.Errorf("request method mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerHooksWithoutHandler(t *testing.T) {
+	var transactions []*sentry.Event
+	err := sentry.Init(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := sentrytwirp.NewServerHooks(sentrytwirp.Options{})
+
+	ctx := ctxsetters.WithServiceName(context.Background(), "Haberdasher")
+	ctx, _ = hooks.RequestReceived(ctx)
+	hooks.ResponseSent(ctxsetters.WithStatusCode(ctx, http.StatusBadRequest))
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	if diff := cmp.Diff("Haberdasher", transactions[0].Transaction); diff != "" {
+		t.Errorf("transaction name mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInvalidArgument, transactions[0].Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
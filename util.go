@@ -62,16 +62,13 @@ func defaultRelease() (release string) {
 	}
 	for _, e := range envs {
 		if release = os.Getenv(e); release != "" {
-			Logger.Printf("Using release from environment variable %s: %s", e, release)
+			debugf(LevelDebug, "Using release from environment variable %s: %s", e, release)
 			return release
 		}
 	}
 
-	if info, ok := debug.ReadBuildInfo(); ok {
-		buildInfoVcsRevision := revisionFromBuildInfo(info)
-		if len(buildInfoVcsRevision) > 0 {
-			return buildInfoVcsRevision
-		}
+	if release = ReleaseFromBuildInfo(); release != "" {
+		return release
 	}
 
 	// Derive a version string from Git. Example outputs:
@@ -88,23 +85,93 @@ func defaultRelease() (release string) {
 		if err, ok := err.(*exec.ExitError); ok && len(err.Stderr) > 0 {
 			fmt.Fprintf(&s, ": %s", err.Stderr)
 		}
-		Logger.Print(s.String())
-		Logger.Print("Some Sentry features will not be available. See https://docs.sentry.io/product/releases/.")
-		Logger.Print("To stop seeing this message, pass a Release to sentry.Init or set the SENTRY_RELEASE environment variable.")
+		debugf(LevelDebug, "%s", s.String())
+		debugf(LevelDebug, "Some Sentry features will not be available. See https://docs.sentry.io/product/releases/.")
+		debugf(LevelDebug, "To stop seeing this message, pass a Release to sentry.Init or set the SENTRY_RELEASE environment variable.")
 		return ""
 	}
 	release = strings.TrimSpace(string(b))
-	Logger.Printf("Using release from Git: %s", release)
+	debugf(LevelDebug, "Using release from Git: %s", release)
 	return release
 }
 
 func revisionFromBuildInfo(info *debug.BuildInfo) string {
+	var revision, commitTime string
+	var modified bool
 	for _, setting := range info.Settings {
-		if setting.Key == "vcs.revision" && setting.Value != "" {
-			Logger.Printf("Using release from debug info: %s", setting.Value)
-			return setting.Value
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			commitTime = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
 		}
 	}
+	if revision == "" {
+		return ""
+	}
+
+	if modified {
+		revision += "-dirty"
+	}
+	if commitTime != "" {
+		debugf(LevelDebug, "Using release from debug info: %s (committed at %s)", revision, commitTime)
+	} else {
+		debugf(LevelDebug, "Using release from debug info: %s", revision)
+	}
+	return revision
+}
 
-	return ""
+// ReleaseFromBuildInfo returns a release name derived from the version control
+// information embedded by the Go toolchain into the running binary: the
+// revision, suffixed with "-dirty" if the working tree had uncommitted changes
+// at build time. It returns an empty string if the information isn't
+// available, for example in binaries built with -buildvcs=false.
+//
+// When neither the Release client option nor a release environment variable is
+// set, the release defaults to this value. Calling it explicitly allows
+// qualifying the release, for example with the name of the service:
+//
+//	sentry.Init(sentry.ClientOptions{
+//		Release: "my-service@" + sentry.ReleaseFromBuildInfo(),
+//	})
+func ReleaseFromBuildInfo() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return revisionFromBuildInfo(info)
+}
+
+// setScopeFromEnv sets the tags and contexts defined by the environment
+// variables of environ starting with tagsPrefix and contextsPrefix on scope.
+// See ClientOptions.EnvTagsPrefix and ClientOptions.EnvContextsPrefix.
+func setScopeFromEnv(scope *Scope, environ []string, tagsPrefix, contextsPrefix string) {
+	contexts := make(map[string]Context)
+	for _, kv := range environ {
+		name, value, found := strings.Cut(kv, "=")
+		if !found || value == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(name, tagsPrefix):
+			if key := strings.ToLower(strings.TrimPrefix(name, tagsPrefix)); key != "" {
+				scope.SetTag(key, value)
+			}
+		case strings.HasPrefix(name, contextsPrefix):
+			context, key, found := strings.Cut(strings.ToLower(strings.TrimPrefix(name, contextsPrefix)), "_")
+			if !found || context == "" || key == "" {
+				debugf(LevelWarning, "Ignoring environment variable %s: expected %s<CONTEXT>_<KEY>", name, contextsPrefix)
+				continue
+			}
+			if contexts[context] == nil {
+				contexts[context] = make(Context)
+			}
+			contexts[context][key] = value
+		}
+	}
+	if len(contexts) > 0 {
+		scope.SetContexts(contexts)
+	}
 }
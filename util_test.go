@@ -91,3 +91,42 @@ func TestRevisionFromBuildInfoNoVcsInformation(t *testing.T) {
 
 	assertEqual(t, revisionFromBuildInfo(info), "")
 }
+
+func TestRevisionFromBuildInfoModified(t *testing.T) {
+	info := &debug.BuildInfo{
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "4f72d7e3a1b2"},
+			{Key: "vcs.time", Value: "2023-09-05T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	assertEqual(t, revisionFromBuildInfo(info), "4f72d7e3a1b2-dirty")
+}
+
+func TestReleaseFromBuildInfo(t *testing.T) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("build info is not available")
+	}
+	assertEqual(t, ReleaseFromBuildInfo(), revisionFromBuildInfo(info))
+}
+
+func TestSetScopeFromEnv(t *testing.T) {
+	scope := NewScope()
+	setScopeFromEnv(scope, []string{
+		"SENTRY_TAGS_TEAM=payments",
+		"SENTRY_TAGS_K8S_CLUSTER=eu-1",
+		"SENTRY_TAGS_EMPTY=",
+		"SENTRY_CONTEXT_DEPLOY_PIPELINE_ID=42",
+		"SENTRY_CONTEXT_DEPLOY_REGION=eu",
+		"SENTRY_CONTEXT_INVALID=x",
+		"HOME=/root",
+	}, "SENTRY_TAGS_", "SENTRY_CONTEXT_")
+
+	assertEqual(t, scope.tags, map[string]string{"team": "payments", "k8s_cluster": "eu-1"})
+	assertEqual(t, scope.contexts, map[string]Context{
+		"deploy": {"pipeline_id": "42", "region": "eu"},
+	})
+}
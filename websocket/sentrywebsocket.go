@@ -0,0 +1,229 @@
+// Package sentrywebsocket provides Sentry instrumentation for long-lived
+// WebSocket connections, independently of the WebSocket library in use.
+//
+// Create a Connection once the request is upgraded, and handle each message
+// with Connection.HandleMessage:
+//
+//	conn, err := upgrader.Upgrade(w, r, nil)
+//	sc := sentrywebsocket.NewConnection(r, sentrywebsocket.Options{})
+//	defer sc.Close(websocket.CloseNormalClosure, "")
+//	for {
+//		_, data, err := conn.ReadMessage()
+//		if err != nil {
+//			return
+//		}
+//		_ = sc.HandleMessage(ctx, "chat.send", func(ctx context.Context) error {
+//			return handle(ctx, data)
+//		})
+//	}
+//
+// Events of a connection include its request and the "websocket" context,
+// with breadcrumbs for the lifecycle of the connection: connection, ping
+// timeouts and disconnection. Each message is handled with its own scope, so
+// that the events of a message don't include the data of other messages.
+package sentrywebsocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// spanOperation is the operation of the transactions of messages.
+const spanOperation = "websocket.message"
+
+// spanOrigin is the origin of the transactions of messages.
+const spanOrigin = "auto.websocket"
+
+// breadcrumbCategory is the category of the breadcrumbs of connections.
+const breadcrumbCategory = "websocket"
+
+// Options configure the instrumentation of a connection.
+type Options struct {
+	// Repanic configures whether to panic again after recovering from a
+	// panic in HandleMessage. Otherwise, the panic is returned as an error.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to block the
+	// current goroutine and wait until the panic event has been reported to
+	// Sentry before repanicking or resuming normal execution.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery is true.
+	Timeout time.Duration
+}
+
+// Connection instruments a WebSocket connection. It is safe for concurrent
+// use.
+type Connection struct {
+	hub             *sentry.Hub
+	id              string
+	protocol        string
+	start           time.Time
+	messages        uint64
+	repanic         bool
+	waitForDelivery bool
+	timeout         time.Duration
+}
+
+// NewConnection returns a Connection for the WebSocket connection upgraded
+// from r, with a clone of the hub of the request context, or of the current
+// hub, and adds a breadcrumb for the connection.
+func NewConnection(r *http.Request, options Options) *Connection {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	hub := sentry.GetHubFromContext(r.Context())
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	c := &Connection{
+		hub:             hub.Clone(),
+		id:              connectionID(),
+		protocol:        r.Header.Get("Sec-WebSocket-Protocol"),
+		start:           time.Now(),
+		repanic:         options.Repanic,
+		waitForDelivery: options.WaitForDelivery,
+		timeout:         timeout,
+	}
+
+	// The body of upgrade requests is empty.
+	scopeRequest := r.WithContext(r.Context())
+	scopeRequest.Body = nil
+	scope := c.hub.Scope()
+	scope.SetRequest(scopeRequest)
+	scope.SetTag("websocket.connection_id", c.id)
+	scope.SetContext("websocket", c.context())
+
+	c.addBreadcrumb(sentry.LevelInfo, "connected", nil)
+	return c
+}
+
+// ID returns the random identifier of the connection, set as the
+// "websocket.connection_id" tag of its events.
+func (c *Connection) ID() string {
+	return c.id
+}
+
+// Hub returns the hub of the connection.
+func (c *Connection) Hub() *sentry.Hub {
+	return c.hub
+}
+
+// Context returns a copy of ctx with the hub of the connection.
+func (c *Connection) Context(ctx context.Context) context.Context {
+	return sentry.SetHubOnContext(ctx, c.hub)
+}
+
+// HandleMessage calls handle with a context containing a clone of the hub of
+// the connection, and a "websocket.message" transaction named name. Errors
+// returned by handle and panics are reported to Sentry, with the name and
+// sequence number of the message in the "websocket" context.
+func (c *Connection) HandleMessage(ctx context.Context, name string, handle func(ctx context.Context) error) (err error) {
+	sequence := atomic.AddUint64(&c.messages, 1)
+	hub := c.hub.Clone()
+	websocket := c.context()
+	websocket["message"] = name
+	websocket["message_sequence"] = sequence
+	hub.Scope().SetContext("websocket", websocket)
+
+	transaction := sentry.StartTransaction(sentry.SetHubOnContext(ctx, hub), name,
+		sentry.WithOpName(spanOperation),
+		sentry.WithTransactionSource(sentry.SourceTask),
+		sentry.WithSpanOrigin(spanOrigin),
+	)
+	transaction.SetTag("websocket.connection_id", c.id)
+	defer func() {
+		if err != nil {
+			transaction.SetError(err)
+		} else {
+			transaction.Status = sentry.SpanStatusOK
+		}
+		transaction.Finish()
+	}()
+
+	ctx = transaction.Context()
+	defer c.recoverWithSentry(ctx, hub, &err)
+	if err = handle(ctx); err != nil {
+		hub.CaptureException(err)
+	}
+	return err
+}
+
+// recoverWithSentry reports panics of message handlers and sets *err to the
+// recovered panic, marking the transaction as failed, before repanicking if
+// configured.
+func (c *Connection) recoverWithSentry(ctx context.Context, hub *sentry.Hub, err *error) {
+	if r := recover(); r != nil {
+		eventID := hub.RecoverWithContext(ctx, r)
+		if eventID != nil && c.waitForDelivery {
+			hub.Flush(c.timeout)
+		}
+		if e, ok := r.(error); ok {
+			*err = fmt.Errorf("sentrywebsocket: panic: %w", e)
+		} else {
+			*err = fmt.Errorf("sentrywebsocket: panic: %v", r)
+		}
+		if c.repanic {
+			panic(r)
+		}
+	}
+}
+
+// PingTimeout adds a breadcrumb for a ping of the connection that wasn't
+// answered within timeout.
+func (c *Connection) PingTimeout(timeout time.Duration) {
+	c.addBreadcrumb(sentry.LevelWarning, "ping timeout", map[string]interface{}{
+		"timeout": timeout.String(),
+	})
+}
+
+// Close adds a breadcrumb for the disconnection of the connection, with the
+// close code and reason, if any, and the duration of the connection.
+func (c *Connection) Close(code int, reason string) {
+	data := map[string]interface{}{
+		"duration": time.Since(c.start).String(),
+		"messages": atomic.LoadUint64(&c.messages),
+	}
+	if code != 0 {
+		data["code"] = code
+	}
+	if reason != "" {
+		data["reason"] = reason
+	}
+	c.addBreadcrumb(sentry.LevelInfo, "disconnected", data)
+}
+
+// context returns the "websocket" context of the connection.
+func (c *Connection) context() sentry.Context {
+	websocket := sentry.Context{
+		"connection_id": c.id,
+	}
+	if c.protocol != "" {
+		websocket["protocol"] = c.protocol
+	}
+	return websocket
+}
+
+func (c *Connection) addBreadcrumb(level sentry.Level, message string, data map[string]interface{}) {
+	c.hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Type:     "default",
+		Category: breadcrumbCategory,
+		Message:  message,
+		Level:    level,
+		Data:     data,
+	}, nil)
+}
+
+// connectionID returns a random connection identifier.
+func connectionID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
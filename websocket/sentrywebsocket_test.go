@@ -0,0 +1,162 @@
+package sentrywebsocket_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentrywebsocket "github.com/getsentry/sentry-go/websocket"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConnection(t *testing.T) {
+	var events, transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			events = append(events, event)
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Set("Sec-WebSocket-Protocol", "chat")
+	r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+	conn := sentrywebsocket.NewConnection(r, sentrywebsocket.Options{})
+	ctx := context.Background()
+
+	if err := conn.HandleMessage(ctx, "chat.join", func(ctx context.Context) error {
+		sentry.GetHubFromContext(ctx).Scope().SetTag("room", "general")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.HandleMessage(ctx, "chat.send", func(ctx context.Context) error {
+		return errors.New("message too long")
+	}); err == nil {
+		t.Fatal("expected an error")
+	}
+	conn.PingTimeout(10 * time.Second)
+	err = conn.HandleMessage(ctx, "chat.leave", func(ctx context.Context) error {
+		panic("test")
+	})
+	if diff := cmp.Diff("sentrywebsocket: panic: test", err.Error()); diff != "" {
+		t.Errorf("panic error mismatch (-want +got):\n%s", diff)
+	}
+	conn.Close(1000, "bye")
+	conn.Hub().CaptureMessage("closed")
+
+	if len(transactions) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(transactions))
+	}
+	var statuses []sentry.SpanStatus
+	for _, transaction := range transactions {
+		statuses = append(statuses, transaction.Contexts["trace"]["status"].(sentry.SpanStatus))
+	}
+	if diff := cmp.Diff([]sentry.SpanStatus{
+		sentry.SpanStatusOK,
+		sentry.SpanStatusInternalError,
+		sentry.SpanStatusInternalError,
+	}, statuses); diff != "" {
+		t.Errorf("transaction statuses mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	sendError, panicEvent, closed := events[0], events[1], events[2]
+	if diff := cmp.Diff(sentry.Context{
+		"connection_id":    conn.ID(),
+		"protocol":         "chat",
+		"message":          "chat.send",
+		"message_sequence": uint64(2),
+	}, sendError.Contexts["websocket"]); diff != "" {
+		t.Errorf("websocket context mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := sendError.Tags["room"]; ok {
+		t.Error("tag of another message leaked")
+	}
+	if diff := cmp.Diff(conn.ID(), sendError.Tags["websocket.connection_id"]); diff != "" {
+		t.Errorf("connection tag mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.LevelFatal, panicEvent.Level); diff != "" {
+		t.Errorf("panic level mismatch (-want +got):\n%s", diff)
+	}
+
+	var breadcrumbs []string
+	for _, b := range closed.Breadcrumbs {
+		breadcrumbs = append(breadcrumbs, b.Message)
+	}
+	if diff := cmp.Diff([]string{"connected", "ping timeout", "disconnected"}, breadcrumbs); diff != "" {
+		t.Errorf("breadcrumbs mismatch (-want +got):\n%s", diff)
+	}
+	disconnected := closed.Breadcrumbs[2].Data
+	if diff := cmp.Diff(1000, disconnected["code"]); diff != "" {
+		t.Errorf("close code mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(uint64(3), disconnected["messages"]); diff != "" {
+		t.Errorf("message count mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sentry.Context{
+		"connection_id": conn.ID(),
+		"protocol":      "chat",
+	}, closed.Contexts["websocket"]); diff != "" {
+		t.Errorf("websocket context mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(http.MethodGet, closed.Request.Method); diff != "" {
+		t.Errorf("request method mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConnectionRepanic(t *testing.T) {
+	var transactions []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			return nil
+		},
+		BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			transactions = append(transactions, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+	conn := sentrywebsocket.NewConnection(r, sentrywebsocket.Options{Repanic: true})
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("got no panic, want a repanic")
+			}
+		}()
+		_ = conn.HandleMessage(context.Background(), "chat.leave", func(ctx context.Context) error {
+			panic("test")
+		})
+	}()
+
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+	if diff := cmp.Diff(sentry.SpanStatusInternalError, transactions[0].Contexts["trace"]["status"]); diff != "" {
+		t.Errorf("transaction status mismatch (-want +got):\n%s", diff)
+	}
+}
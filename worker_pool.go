@@ -0,0 +1,112 @@
+package sentry
+
+import (
+	"context"
+	"time"
+)
+
+// Operations of the spans of worker pool tasks.
+const (
+	workerTaskOperation  = "worker.task"
+	workerQueueOperation = "worker.queue"
+)
+
+// WorkerOptions configure a Worker.
+type WorkerOptions struct {
+	// Repanic configures whether to panic again after recovering from a panic
+	// in a task and reporting it. By default the task ends after the panic is
+	// reported, and the goroutine of the pool running it keeps running.
+	Repanic bool
+	// WaitForDelivery indicates, in case of a panic, whether to wait until the
+	// panic event has been reported to Sentry before repanicking or ending the
+	// task. It is implied by Repanic.
+	WaitForDelivery bool
+	// Timeout for the delivery of panic events. Defaults to 2s. Only relevant
+	// when WaitForDelivery or Repanic is true.
+	Timeout time.Duration
+}
+
+// Worker instruments the tasks run by a pool of goroutines. Create one with
+// InstrumentPool.
+type Worker struct {
+	name    string
+	options GoOptions
+}
+
+// InstrumentPool returns a Worker instrumenting the tasks of the pool of
+// goroutines named name.
+//
+// Wrap tasks when they are submitted to the pool, and run the returned
+// functions in the goroutines of the pool:
+//
+//	worker := sentry.InstrumentPool("thumbnails", sentry.WorkerOptions{})
+//	tasks <- worker.Wrap(ctx, func(ctx context.Context) error {
+//		return resize(ctx, image)
+//	})
+//
+//	// In the goroutines of the pool.
+//	for task := range tasks {
+//		task()
+//	}
+func InstrumentPool(name string, options WorkerOptions) *Worker {
+	return &Worker{
+		name: name,
+		options: GoOptions{
+			Repanic:         options.Repanic,
+			WaitForDelivery: options.WaitForDelivery,
+			Timeout:         options.Timeout,
+		},
+	}
+}
+
+// Wrap returns a function running task with a context carrying a clone of
+// the hub of ctx, or of the current hub if ctx has none, whose scope is tagged
+// with the name of the pool.
+//
+// The task runs in a "worker.task" span, a child of the span of ctx or a new
+// transaction named after the pool. The span starts when Wrap is called, and
+// has a "worker.queue" child span covering the time the task waited for a
+// goroutine of the pool, which is also set as the "queue_time" measurement of
+// transactions. The error returned by task sets the status of the span, and a
+// panic in task is reported to Sentry.
+func (w *Worker) Wrap(ctx context.Context, task func(ctx context.Context) error) func() {
+	hub := hubFromContext(ctx).Clone()
+	hub.Scope().SetTag("worker.pool", w.name)
+	ctx = SetHubOnContext(ctx, hub)
+	submitted := time.Now()
+
+	return func() {
+		span := StartSpan(ctx, workerTaskOperation,
+			WithTransactionName(w.name),
+			WithTransactionSource(SourceTask),
+		)
+		span.Description = w.name
+		started := time.Now()
+		span.StartTime = submitted
+
+		queue := span.StartChild(workerQueueOperation)
+		queue.Description = w.name
+		queue.StartTime = submitted
+		queue.EndTime = started
+		queue.Finish()
+		if span.IsTransaction() {
+			span.SetMeasurement("queue_time", milliseconds(started.Sub(submitted)), MeasurementUnitMillisecond)
+		}
+
+		defer func() {
+			if err := recover(); err != nil {
+				span.Status = SpanStatusInternalError
+				span.Finish()
+				reportGoroutinePanic(span.Context(), hub, err, w.options)
+				return
+			}
+			span.Finish()
+		}()
+
+		if err := task(span.Context()); err != nil {
+			span.SetError(err)
+		} else {
+			span.Status = SpanStatusOK
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkerWrap(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+	worker := InstrumentPool("thumbnails", WorkerOptions{})
+
+	var taskHub *Hub
+	task := worker.Wrap(ctx, func(ctx context.Context) error {
+		taskHub = GetHubFromContext(ctx)
+		return errors.New("invalid image")
+	})
+	time.Sleep(10 * time.Millisecond)
+	task()
+
+	if taskHub == nil || taskHub == GetHubFromContext(ctx) {
+		t.Error("the task should use a clone of the hub")
+	}
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	transaction := events[0]
+	assertEqual(t, transaction.Transaction, "thumbnails")
+	assertEqual(t, transaction.Contexts["trace"]["op"], workerTaskOperation)
+	assertEqual(t, transaction.Contexts["trace"]["status"], SpanStatusInternalError)
+	assertEqual(t, transaction.Tags["worker.pool"], "thumbnails")
+	if len(transaction.Spans) != 1 || transaction.Spans[0].Op != workerQueueOperation {
+		t.Fatalf("got spans %v, want a %q span", transaction.Spans, workerQueueOperation)
+	}
+	queue := transaction.Spans[0]
+	assertEqual(t, queue.StartTime, transaction.StartTime)
+	if wait := queue.EndTime.Sub(queue.StartTime); wait < 10*time.Millisecond {
+		t.Errorf("got queue wait %v, want at least 10ms", wait)
+	}
+	if queueTime := transaction.Measurements["queue_time"].Value; queueTime < 10 {
+		t.Errorf("got queue_time %vms, want at least 10ms", queueTime)
+	}
+}
+
+func TestWorkerWrapChildSpan(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+	transaction := StartTransaction(ctx, "batch")
+	InstrumentPool("thumbnails", WorkerOptions{}).Wrap(transaction.Context(), func(ctx context.Context) error {
+		return nil
+	})()
+	transaction.Finish()
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	spans := events[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	var task *Span
+	for _, span := range spans {
+		if span.Op == workerTaskOperation {
+			task = span
+		}
+	}
+	if task == nil {
+		t.Fatalf("no %q span", workerTaskOperation)
+	}
+	assertEqual(t, task.ParentSpanID, transaction.SpanID)
+	assertEqual(t, task.Status, SpanStatusOK)
+}
+
+func TestWorkerWrapPanic(t *testing.T) {
+	transport := &TransportMock{}
+	ctx := NewTestContext(ClientOptions{
+		EnableTracing:    true,
+		TracesSampleRate: 1.0,
+		Transport:        transport,
+	})
+	InstrumentPool("thumbnails", WorkerOptions{}).Wrap(ctx, func(ctx context.Context) error {
+		panic("boom")
+	})()
+
+	events := transport.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	transaction, event := events[0], events[1]
+	assertEqual(t, transaction.Contexts["trace"]["status"], SpanStatusInternalError)
+	assertEqual(t, event.Message, "boom")
+	assertEqual(t, event.Tags["worker.pool"], "thumbnails")
+}
@@ -0,0 +1,276 @@
+// Package sentryzerolog provides Sentry integration for github.com/rs/zerolog.
+//
+// The Writer captures the logs at the EventLevels as events, with the fields
+// of the logs as extra data. Write logs to both Sentry and another output
+// with zerolog.MultiLevelWriter:
+//
+//	logger := zerolog.New(zerolog.MultiLevelWriter(os.Stderr, sentryzerolog.NewWriter(sentryzerolog.Options{})))
+//
+// zerolog closes the writers of loggers before exiting on Fatal logs, and the
+// Writer flushes the hub when closed, so that fatal logs reach Sentry.
+//
+// The Hook adds the logs at the BreadcrumbLevels as breadcrumbs to the hub of
+// the context of the logs, set with Event.Ctx or Context.Ctx, or to the
+// current hub, so that they show up in the events captured later on:
+//
+//	logger = logger.Hook(sentryzerolog.NewHook(sentryzerolog.Options{}))
+//	logger.Info().Ctx(ctx).Str("order", order.ID).Msg("charging")
+//
+// As zerolog encodes fields as they are added, hooks can't read them, and
+// writers don't know the context of logs, which is why the two are split.
+package sentryzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+)
+
+// The identifier of the zerolog SDK.
+const sdkIdentifier = "sentry.go.zerolog"
+
+// loggerName is the logger of events and the category of breadcrumbs.
+const loggerName = "zerolog"
+
+// defaultFlushTimeout is the default FlushTimeout of the Writer.
+const defaultFlushTimeout = 2 * time.Second
+
+// Default levels of the logs captured as events and breadcrumbs.
+var (
+	defaultEventLevels      = []zerolog.Level{zerolog.ErrorLevel, zerolog.FatalLevel, zerolog.PanicLevel}
+	defaultBreadcrumbLevels = []zerolog.Level{zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel}
+)
+
+var levelMap = map[zerolog.Level]sentry.Level{
+	zerolog.TraceLevel: sentry.LevelDebug,
+	zerolog.DebugLevel: sentry.LevelDebug,
+	zerolog.InfoLevel:  sentry.LevelInfo,
+	zerolog.WarnLevel:  sentry.LevelWarning,
+	zerolog.ErrorLevel: sentry.LevelError,
+	zerolog.FatalLevel: sentry.LevelFatal,
+	zerolog.PanicLevel: sentry.LevelFatal,
+}
+
+// Options configure the Writer and the Hook.
+type Options struct {
+	// EventLevels are the levels of the logs captured as events by the
+	// Writer. Defaults to error, fatal and panic.
+	EventLevels []zerolog.Level
+	// BreadcrumbLevels are the levels of the logs added as breadcrumbs by the
+	// Hook. Defaults to debug, info and warn.
+	BreadcrumbLevels []zerolog.Level
+	// PromotionRules, if not nil, decide which logs are captured as events by
+	// the Writer, instead of EventLevels. The logger of logs is the value of
+	// their sentry.LogFieldLogger field.
+	PromotionRules *sentry.PromotionRules
+	// Sampler, if not nil, samples the events of the Writer, and drops the
+	// events repeating a recent event.
+	Sampler *sentry.LogSampler
+	// Hub is the hub capturing the events of the Writer. Defaults to the
+	// current hub.
+	Hub *sentry.Hub
+	// FlushTimeout is how long closing the Writer waits for the events of
+	// the hub to be sent. Defaults to 2 seconds.
+	FlushTimeout time.Duration
+}
+
+// Writer is a zerolog.LevelWriter capturing logs as events.
+type Writer struct {
+	hub            *sentry.Hub
+	levels         map[zerolog.Level]bool
+	promotionRules *sentry.PromotionRules
+	sampler        *sentry.LogSampler
+	flushTimeout   time.Duration
+}
+
+var (
+	_ zerolog.LevelWriter = (*Writer)(nil)
+	_ io.Closer           = (*Writer)(nil)
+)
+
+// NewWriter returns a new Writer.
+func NewWriter(options Options) *Writer {
+	levels := options.EventLevels
+	if levels == nil {
+		levels = defaultEventLevels
+	}
+	flushTimeout := options.FlushTimeout
+	if flushTimeout == 0 {
+		flushTimeout = defaultFlushTimeout
+	}
+	return &Writer{
+		hub:            options.Hub,
+		levels:         levelSet(levels),
+		promotionRules: options.PromotionRules,
+		sampler:        options.Sampler,
+		flushTimeout:   flushTimeout,
+	}
+}
+
+// Write implements io.Writer, for writers not calling WriteLevel.
+func (w *Writer) Write(p []byte) (int, error) {
+	var log struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(p, &log); err != nil {
+		return len(p), nil
+	}
+	level, err := zerolog.ParseLevel(log.Level)
+	if err != nil {
+		return len(p), nil
+	}
+	return w.WriteLevel(level, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter. It captures p, a log encoded in
+// JSON, if level is one of the EventLevels, or if the PromotionRules promote
+// it. Writing never fails, so that the other writers of a
+// zerolog.MultiLevelWriter are always written to.
+func (w *Writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if w.promotionRules == nil && !w.levels[level] {
+		return len(p), nil
+	}
+	fields := make(map[string]interface{})
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return len(p), nil
+	}
+	logger, _ := fields[sentry.LogFieldLogger].(string)
+	if w.promotionRules != nil && !w.promotionRules.Promote(logger, levelMap[level]) {
+		return len(p), nil
+	}
+	if w.sampler != nil {
+		message, _ := fields[zerolog.MessageFieldName].(string)
+		if !w.sampler.Sample(logger, message) {
+			return len(p), nil
+		}
+	}
+
+	hub := w.getHub()
+	client := hub.Client()
+	if client == nil {
+		return len(p), nil
+	}
+	client.SetSDKIdentifier(sdkIdentifier)
+
+	event := sentry.NewEvent()
+	event.Level = levelMap[level]
+	event.Logger = loggerName
+	event.Timestamp = time.Now()
+	if message, ok := fields[zerolog.MessageFieldName].(string); ok {
+		event.Message = message
+		delete(fields, zerolog.MessageFieldName)
+	}
+	if err, ok := fields[zerolog.ErrorFieldName].(string); ok {
+		event.Exception = []sentry.Exception{{Type: "error", Value: err}}
+		if event.Message == "" {
+			event.Message = err
+		}
+		delete(fields, zerolog.ErrorFieldName)
+	}
+	if t, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if ts, err := time.Parse(zerolog.TimeFieldFormat, t); err == nil {
+			event.Timestamp = ts
+		}
+	}
+	delete(fields, zerolog.TimestampFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	event.Extra = fields
+
+	hub.CaptureEvent(event)
+	return len(p), nil
+}
+
+// Close implements io.Closer. zerolog calls it before exiting on Fatal logs.
+// It waits for the events of the hub to be sent, for at most FlushTimeout.
+func (w *Writer) Close() error {
+	hub := w.getHub()
+	if hub.Client() == nil {
+		return nil
+	}
+	if !hub.Flush(w.flushTimeout) {
+		return errors.New("sentryzerolog: timed out flushing the events")
+	}
+	return nil
+}
+
+func (w *Writer) getHub() *sentry.Hub {
+	if w.hub != nil {
+		return w.hub
+	}
+	return sentry.CurrentHub()
+}
+
+// Hook is a zerolog.Hook adding logs as breadcrumbs.
+type Hook struct {
+	levels map[zerolog.Level]bool
+}
+
+var _ zerolog.Hook = (*Hook)(nil)
+
+// NewHook returns a new Hook.
+func NewHook(options Options) *Hook {
+	levels := options.BreadcrumbLevels
+	if levels == nil {
+		levels = defaultBreadcrumbLevels
+	}
+	return &Hook{levels: levelSet(levels)}
+}
+
+// Run implements zerolog.Hook. It adds a breadcrumb with message to the hub of
+// the context of e, or to the current hub, if level is one of the
+// BreadcrumbLevels.
+func (h *Hook) Run(e *zerolog.Event, level zerolog.Level, message string) {
+	if !h.levels[level] || !e.Enabled() {
+		return
+	}
+	hub := sentry.GetHubFromContext(e.GetCtx())
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Type:      "default",
+		Category:  loggerName,
+		Message:   message,
+		Level:     levelMap[level],
+		Timestamp: time.Now(),
+	}, nil)
+}
+
+// CorrelationHook is a zerolog.Hook adding the trace_id, span_id and event_id
+// fields of the context of logs, set with Event.Ctx or Context.Ctx, as
+// returned by sentry.LogCorrelationFromContext, so that logs can be matched
+// with the traces and events in Sentry:
+//
+//	logger = logger.Hook(sentryzerolog.CorrelationHook{})
+type CorrelationHook struct{}
+
+var _ zerolog.Hook = CorrelationHook{}
+
+// Run implements zerolog.Hook.
+func (CorrelationHook) Run(e *zerolog.Event, level zerolog.Level, message string) {
+	if !e.Enabled() {
+		return
+	}
+	c := sentry.LogCorrelationFromContext(e.GetCtx())
+	if c.TraceID != "" {
+		e.Str(sentry.LogFieldTraceID, c.TraceID).Str(sentry.LogFieldSpanID, c.SpanID)
+	}
+	if c.EventID != "" {
+		e.Str(sentry.LogFieldEventID, c.EventID)
+	}
+}
+
+func levelSet(levels []zerolog.Level) map[zerolog.Level]bool {
+	set := make(map[zerolog.Level]bool, len(levels))
+	for _, level := range levels {
+		set[level] = true
+	}
+	return set
+}
@@ -0,0 +1,231 @@
+package sentryzerolog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentryzerolog "github.com/getsentry/sentry-go/zerolog"
+	"github.com/google/go-cmp/cmp"
+	"github.com/rs/zerolog"
+)
+
+// record returns a BeforeSend callback adding the events to events and
+// dropping them.
+func record(events *[]*sentry.Event) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func TestWriterAndHook(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	var out bytes.Buffer
+	logger := zerolog.New(zerolog.MultiLevelWriter(&out, sentryzerolog.NewWriter(sentryzerolog.Options{Hub: hub}))).
+		Hook(sentryzerolog.NewHook(sentryzerolog.Options{})).
+		With().Str("service", "billing").Logger()
+
+	logger.Trace().Ctx(ctx).Msg("ignored")
+	logger.Info().Ctx(ctx).Int("amount", 42).Msg("charging")
+	logger.Error().Ctx(ctx).Err(errors.New("card declined")).Str("order", "o1").Msg("charge failed")
+
+	if got := bytes.Count(out.Bytes(), []byte("\n")); got != 3 {
+		t.Errorf("got %d lines written to the other writer, want 3", got)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff(sentry.LevelError, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("charge failed", event.Message); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("card declined", event.Exception[0].Value); diff != "" {
+		t.Errorf("exception mismatch (-want +got):\n%s", diff)
+	}
+	wantExtra := map[string]interface{}{"service": "billing", "order": "o1"}
+	if diff := cmp.Diff(wantExtra, event.Extra); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+	if len(event.Breadcrumbs) != 1 {
+		t.Fatalf("got %d breadcrumbs, want 1", len(event.Breadcrumbs))
+	}
+	if diff := cmp.Diff("charging", event.Breadcrumbs[0].Message); diff != "" {
+		t.Errorf("breadcrumb mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriterWrite(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	w := sentryzerolog.NewWriter(sentryzerolog.Options{Hub: hub, EventLevels: []zerolog.Level{zerolog.WarnLevel}})
+
+	for _, log := range []string{
+		`{"level":"error","message":"not captured"}`,
+		`not json`,
+		`{"level":"warn","message":"slow query","duration":1.5}`,
+	} {
+		if n, err := w.Write([]byte(log)); n != len(log) || err != nil {
+			t.Errorf("Write(%q) = %d, %v", log, n, err)
+		}
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if diff := cmp.Diff(sentry.LevelWarning, events[0].Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(json.Number("1.5"), events[0].Extra["duration"]); diff != "" {
+		t.Errorf("extra mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCorrelationHook(t *testing.T) {
+	client, err := sentry.NewClient(sentry.ClientOptions{EnableTracing: true, TracesSampleRate: 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	var out bytes.Buffer
+	logger := zerolog.New(&out).Hook(sentryzerolog.CorrelationHook{})
+
+	logger.Info().Ctx(ctx).Msg("no trace")
+	transaction := sentry.StartTransaction(ctx, "charge")
+	eventID := hub.CaptureException(errors.New("card declined"))
+	logger.Info().Ctx(transaction.Context()).Msg("charging")
+	transaction.Finish()
+
+	var got []map[string]interface{}
+	d := json.NewDecoder(&out)
+	for d.More() {
+		var line map[string]interface{}
+		if err := d.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, line)
+	}
+	want := []map[string]interface{}{{
+		"level":   "info",
+		"message": "no trace",
+	}, {
+		"level":    "info",
+		"message":  "charging",
+		"trace_id": transaction.TraceID.String(),
+		"span_id":  transaction.SpanID.String(),
+		"event_id": string(*eventID),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("logs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriterPromotionRules(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	rules, err := sentry.ParsePromotionRules("logger=payments AND level>=warn AND count>1/min")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(sentryzerolog.NewWriter(sentryzerolog.Options{Hub: hub, PromotionRules: rules}))
+
+	logger.Error().Str("logger", "cache").Msg("cache miss")
+	for i := 0; i < 3; i++ {
+		logger.Warn().Str("logger", "payments").Int("attempt", i).Msg("charge retried")
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if diff := cmp.Diff(sentry.LevelWarning, event.Level); diff != "" {
+		t.Errorf("level mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(json.Number("1"), event.Extra["attempt"]); diff != "" {
+		t.Errorf("attempt mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriterSampler(t *testing.T) {
+	var events []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: record(&events),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	logger := zerolog.New(sentryzerolog.NewWriter(sentryzerolog.Options{
+		Hub:     hub,
+		Sampler: sentry.NewLogSampler(sentry.LogSamplerOptions{DedupeWindow: time.Minute}),
+	}))
+
+	for i := 0; i < 3; i++ {
+		logger.Error().Str("logger", "payments").Int("attempt", i).Msg("charge failed")
+	}
+	logger.Error().Str("logger", "billing").Msg("charge failed")
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+}
+
+type flushTransport struct {
+	flushed bool
+}
+
+func (t *flushTransport) Configure(sentry.ClientOptions) {}
+func (t *flushTransport) SendEvent(*sentry.Event)        {}
+func (t *flushTransport) SendEnvelope(*sentry.Envelope)  {}
+func (t *flushTransport) Flush(time.Duration) bool       { t.flushed = true; return true }
+
+func TestWriterClose(t *testing.T) {
+	transport := &flushTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       "http://whatever@example.com/1337",
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	// zerolog closes the writers of loggers before exiting on Fatal logs.
+	writer := zerolog.MultiLevelWriter(&bytes.Buffer{}, sentryzerolog.NewWriter(sentryzerolog.Options{Hub: hub}))
+	if err := writer.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !transport.flushed {
+		t.Error("hub not flushed when the writer was closed")
+	}
+}